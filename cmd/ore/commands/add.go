@@ -3,14 +3,20 @@ package commands
 import (
 	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/contriboss/gemfile-go/gemfile"
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/errdefs"
+	"github.com/contriboss/ore-light/internal/logger"
 	"github.com/contriboss/ore-light/internal/resolver"
 )
 
-// RunAdd implements the ore add command
-func RunAdd(args []string) error {
+// RunAdd implements the ore add command. Like `bundle add`, it resolves and updates the
+// lockfile after editing the Gemfile unless --skip-lock is given; with --install it then
+// calls install (ore install) with --gemfile set.
+func RunAdd(args []string, install func(args []string) error) error {
 	fs := flag.NewFlagSet("add", flag.ContinueOnError)
 	version := fs.String("version", "", "Version constraint (e.g., ~> 8.0)")
 	group := fs.String("group", "", "Group to add gem to")
@@ -21,8 +27,10 @@ func RunAdd(args []string) error {
 	ref := fs.String("ref", "", "Git reference")
 	path := fs.String("path", "", "Local path to gem")
 	requireFlag := fs.Bool("require", true, "Whether to require the gem")
-	lock := fs.Bool("lock", false, "Automatically resolve and update Gemfile.lock")
-	verbose := fs.Bool("v", false, "Enable verbose output")
+	fs.Bool("lock", true, "Resolve and update Gemfile.lock (the default; kept for compatibility)")
+	skipLock := fs.Bool("skip-lock", false, "Only edit the Gemfile; don't resolve or update the lockfile")
+	installAfter := fs.Bool("install", false, "Run ore install after updating the lockfile")
+	verbose := fs.Bool("v", logger.Verbose(), "Enable verbose output")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -32,15 +40,33 @@ func RunAdd(args []string) error {
 	if len(gems) == 0 {
 		return fmt.Errorf("at least one gem name is required")
 	}
+	if *skipLock && *installAfter {
+		return fmt.Errorf("--install needs an updated lockfile and can't be used with --skip-lock")
+	}
 
-	// Find Gemfile
-	paths, err := lockfile.FindGemfiles()
-	if err != nil {
-		return fmt.Errorf("failed to find Gemfile: %w", err)
+	// Find Gemfile. It may not be locked yet: ore add creates the lockfile.
+	gemfilePath := defaultGemfilePath()
+	if paths, err := lockfile.FindGemfiles(); err == nil {
+		gemfilePath = paths.Gemfile
+	} else if _, statErr := os.Stat(gemfilePath); statErr != nil {
+		return errdefs.Newf(errdefs.ErrGemfileNotFound, "failed to find Gemfile: %w", err)
 	}
 
 	if *verbose {
-		fmt.Printf("📝 Adding gems to %s...\n", paths.GetGemfileName())
+		fmt.Printf("📝 Adding gems to %s...\n", filepath.Base(gemfilePath))
+	}
+
+	// Like `bundle add`, put the Gemfile back if the new gems can't be added or locked,
+	// so it never disagrees with the lockfile
+	original, err := os.ReadFile(gemfilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read Gemfile: %w", err)
+	}
+	restore := func(cause error) error {
+		if err := os.WriteFile(gemfilePath, original, 0o644); err != nil {
+			return fmt.Errorf("%w (and failed to restore %s: %v)", cause, gemfilePath, err)
+		}
+		return fmt.Errorf("%w; %s was left unchanged", cause, filepath.Base(gemfilePath))
 	}
 
 	// Process each gem
@@ -93,8 +119,8 @@ func RunAdd(args []string) error {
 		}
 
 		// Add gem to Gemfile using gemfile-go writer
-		if err := gemfile.AddGemToFile(paths.Gemfile, &dep); err != nil {
-			return fmt.Errorf("failed to add gem %s: %w", gemName, err)
+		if err := gemfile.AddGemToFile(gemfilePath, &dep); err != nil {
+			return restore(fmt.Errorf("failed to add gem %s: %w", gemName, err))
 		}
 
 		if *verbose {
@@ -102,20 +128,63 @@ func RunAdd(args []string) error {
 		}
 	}
 
+	if *skipLock {
+		fmt.Println("✨ Gems added successfully")
+		fmt.Println("💡 Run 'ore lock' to update the lockfile, then 'ore install'")
+		return nil
+	}
+
+	// Resolve and update the lockfile
+	if *verbose {
+		fmt.Println("🔒 Resolving dependencies and updating lockfile...")
+	}
+	if err := resolver.GenerateLockfile(gemfilePath); err != nil {
+		return restore(fmt.Errorf("failed to generate lockfile: %w", err))
+	}
 	fmt.Println("✨ Gems added successfully")
 
-	// Optionally resolve and update lockfile
-	if *lock {
-		if *verbose {
-			fmt.Println("🔒 Resolving dependencies and updating lockfile...")
-		}
-		if err := resolver.GenerateLockfile(paths.Gemfile); err != nil {
-			return fmt.Errorf("failed to generate lockfile: %w", err)
+	lockfilePath, err := findLockfilePath(gemfilePath)
+	if err != nil {
+		return fmt.Errorf("failed to find lockfile: %w", err)
+	}
+	lock, err := lockfile.ParseFile(lockfilePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse lockfile: %w", err)
+	}
+	for _, gemName := range gems {
+		if version := lockedVersion(lock, gemName); version != "" {
+			fmt.Printf("📌 %s locked at %s\n", gemName, version)
 		}
-		fmt.Println("💡 Run 'ore install' to fetch the new gems")
-	} else {
-		fmt.Println("💡 Run 'bundle lock' (or use --lock flag) to update Gemfile.lock, then 'ore install'")
 	}
 
+	if *installAfter {
+		return install([]string{"--gemfile", gemfilePath})
+	}
+	fmt.Println("💡 Run 'ore install' to fetch the new gems")
 	return nil
 }
+
+// lockedVersion describes the version a gem resolved to: its version, plus the
+// revision for git gems and the path for path gems. Returns "" if it isn't locked.
+func lockedVersion(lock *lockfile.Lockfile, name string) string {
+	for _, spec := range lock.GemSpecs {
+		if spec.Name == name {
+			return spec.Version
+		}
+	}
+	for _, spec := range lock.GitSpecs {
+		if spec.Name == name {
+			revision := spec.Revision
+			if len(revision) > 7 {
+				revision = revision[:7]
+			}
+			return fmt.Sprintf("%s (%s@%s)", spec.Version, spec.Remote, revision)
+		}
+	}
+	for _, spec := range lock.PathSpecs {
+		if spec.Name == name {
+			return fmt.Sprintf("%s (%s)", spec.Version, spec.Remote)
+		}
+	}
+	return ""
+}
@@ -1,56 +1,125 @@
 package commands
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/contriboss/gemfile-go/gemfile"
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/logger"
+	"github.com/contriboss/ore-light/internal/resolver"
+	"github.com/contriboss/ore-light/internal/ruby"
 )
 
+// checkReport is the result of ore check, also used for --json output
+type checkReport struct {
+	OK        bool          `json:"ok"`
+	Lockfile  string        `json:"lockfile"`
+	Installed int           `json:"installed"`
+	Platform  string        `json:"platform,omitempty"` // --platform
+	Missing   []checkGem    `json:"missing"`
+	Fallbacks []checkGem    `json:"ruby_fallbacks,omitempty"` // Native gems installed only as the pure-Ruby variant
+	Drifted   []checkDrift  `json:"drifted,omitempty"`        // --frozen: git and path gems whose source moved
+	Sync      *lockfileSync `json:"sync,omitempty"`           // nil when the Gemfile could not be read
+	Warnings  []string      `json:"warnings,omitempty"`
+}
+
+// checkGem is a locked gem that is not installed
+type checkGem struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Platform string `json:"platform,omitempty"` // For platform-specific gems
+	Source   string `json:"source"`             // "rubygems", "git", or "path"
+	Path     string `json:"path,omitempty"`
+}
+
+// checkDrift is a git or path gem whose source no longer matches the lockfile
+type checkDrift struct {
+	Name   string `json:"name"`
+	Source string `json:"source"` // "git" or "path"
+	Reason string `json:"reason"`
+}
+
+// lockfileSync compares the Gemfile's declared dependencies to the lockfile's DEPENDENCIES
+type lockfileSync struct {
+	InSync  bool     `json:"in_sync"`
+	Added   []string `json:"added"`   // In the Gemfile but not the lockfile
+	Removed []string `json:"removed"` // In the lockfile but no longer in the Gemfile
+	Changed []string `json:"changed"` // Requirement differs, e.g. "rails (~> 7.0 -> ~> 7.1)"
+}
+
 // RunCheck implements the ore check command
 func RunCheck(args []string) error {
 	fs := flag.NewFlagSet("check", flag.ContinueOnError)
 	gemfilePath := fs.String("gemfile", defaultGemfilePath(), "Path to Gemfile")
+	lockfileFlag := fs.String("lockfile", "", "Path to the lockfile (default: <gemfile>.lock)")
 	vendorDir := fs.String("vendor", defaultVendorDir(), "Vendor directory to check")
-	verbose := fs.Bool("v", false, "Enable verbose output")
+	verbose := fs.Bool("v", logger.Verbose(), "Enable verbose output")
+	jsonOutput := fs.Bool("json", false, "Output results as JSON")
+	deployment := fs.Bool("deployment", false, "Fail unless the Gemfile can be read and matches the lockfile")
+	platform := fs.String("platform", "", "Only check the gems locked for this platform (e.g. x86_64-linux), including its prebuilt native gems")
+	frozen := fs.Bool("frozen", false, "Also fail when git or path gem sources drifted from the lockfile (moved branch, missing revision, changed path gem)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
 	// Find the lockfile - supports both Gemfile.lock and gems.locked
-	lockfilePath, err := findLockfilePath(*gemfilePath)
-	if err != nil {
-		return fmt.Errorf("failed to find lockfile: %w - run 'ore lock' first", err)
+	lockfilePath := *lockfileFlag
+	if lockfilePath == "" {
+		var err error
+		if lockfilePath, err = findLockfilePath(*gemfilePath); err != nil {
+			return fmt.Errorf("failed to find lockfile: %w - run 'ore lock' first", err)
+		}
 	}
 
 	// Parse lockfile
-	lock, err := lockfile.ParseFile(lockfilePath)
+	lock, err := resolver.ParseLockfile(lockfilePath)
 	if err != nil {
 		return fmt.Errorf("failed to parse lockfile: %w", err)
 	}
 
-	if *verbose {
+	report := &checkReport{Lockfile: lockfilePath, Platform: *platform, Missing: []checkGem{}}
+	verboseText := *verbose && !*jsonOutput
+
+	// --platform checks the variant each gem installs as on that platform
+	specs := lock.GemSpecs
+	if *platform != "" {
+		if specs, err = platformGemSpecs(lock, *platform); err != nil {
+			return err
+		}
+	}
+
+	if verboseText {
 		fmt.Println("🔍 Checking installed gems...")
 	}
 
 	gemsDir := filepath.Join(*vendorDir, "gems")
-	missing := []string{}
-	installed := 0
 
 	// Check regular gems
-	for _, spec := range lock.GemSpecs {
+	for _, spec := range specs {
 		gemPath := filepath.Join(gemsDir, spec.FullName())
-		if _, err := os.Stat(gemPath); err != nil {
-			missing = append(missing, fmt.Sprintf("%s (%s)", spec.Name, spec.Version))
-			if *verbose {
-				fmt.Printf("  ✗ %s (%s) - not found\n", spec.Name, spec.Version)
+		_, err := os.Stat(gemPath)
+		// A pure-Ruby stand-in is only told apart from a missing gem for --platform
+		if err != nil && *platform != "" && isNativeVariant(spec) && rubyVariantInstalled(gemsDir, spec) {
+			report.Fallbacks = append(report.Fallbacks, checkGem{Name: spec.Name, Version: spec.Version, Platform: spec.Platform, Source: "rubygems"})
+			if verboseText {
+				fmt.Printf("  ✗ %s (%s-%s) - only the pure-Ruby %s-%s is installed\n", spec.Name, spec.Version, spec.Platform, spec.Name, spec.Version)
+			}
+		} else if err != nil {
+			report.Missing = append(report.Missing, checkGem{Name: spec.Name, Version: spec.Version, Platform: spec.Platform, Source: "rubygems"})
+			if verboseText {
+				fmt.Printf("  ✗ %s (%s) - not found\n", spec.Name, displayVersion(spec))
 			}
 		} else {
-			installed++
-			if *verbose {
-				fmt.Printf("  ✓ %s (%s)\n", spec.Name, spec.Version)
+			report.Installed++
+			if verboseText {
+				fmt.Printf("  ✓ %s (%s)\n", spec.Name, displayVersion(spec))
 			}
 		}
 	}
@@ -59,13 +128,13 @@ func RunCheck(args []string) error {
 	for _, spec := range lock.GitSpecs {
 		gemPath := filepath.Join(gemsDir, spec.FullName())
 		if _, err := os.Stat(gemPath); err != nil {
-			missing = append(missing, fmt.Sprintf("%s (%s) [git]", spec.Name, spec.Version))
-			if *verbose {
+			report.Missing = append(report.Missing, checkGem{Name: spec.Name, Version: spec.Version, Source: "git"})
+			if verboseText {
 				fmt.Printf("  ✗ %s (%s) [git] - not found\n", spec.Name, spec.Version)
 			}
 		} else {
-			installed++
-			if *verbose {
+			report.Installed++
+			if verboseText {
 				fmt.Printf("  ✓ %s (%s) [git]\n", spec.Name, spec.Version)
 			}
 		}
@@ -73,33 +142,308 @@ func RunCheck(args []string) error {
 
 	// Check path gems (these should always be available at their source)
 	for _, spec := range lock.PathSpecs {
-		if _, err := os.Stat(spec.Remote); err != nil {
-			missing = append(missing, fmt.Sprintf("%s (%s) [path: %s]", spec.Name, spec.Version, spec.Remote))
-			if *verbose {
+		if _, err := os.Stat(pathGemRemote(*gemfilePath, spec)); err != nil {
+			report.Missing = append(report.Missing, checkGem{Name: spec.Name, Version: spec.Version, Source: "path", Path: spec.Remote})
+			if verboseText {
 				fmt.Printf("  ✗ %s (%s) [path] - source not found at %s\n", spec.Name, spec.Version, spec.Remote)
 			}
 		} else {
-			installed++
-			if *verbose {
+			report.Installed++
+			if verboseText {
 				fmt.Printf("  ✓ %s (%s) [path]\n", spec.Name, spec.Version)
 			}
 		}
 	}
 
-	// Print summary
-	if len(missing) > 0 {
+	// --frozen: mutable git and path sources must still be what was locked
+	if *frozen {
+		if verboseText {
+			fmt.Println("🔍 Checking git and path sources for drift...")
+		}
+		report.Drifted = sourceDrift(*gemfilePath, lock, gemsDir)
+	}
+
+	// Check the lockfile still matches the Gemfile
+	sync, err := checkLockfileSync(*gemfilePath, lock)
+	if err != nil {
+		if *deployment {
+			return fmt.Errorf("failed to verify lockfile against Gemfile: %w", err)
+		}
+		report.Warnings = append(report.Warnings, fmt.Sprintf("could not compare lockfile to Gemfile: %v", err))
+	}
+	report.Sync = sync
+	report.OK = len(report.Missing) == 0 && len(report.Fallbacks) == 0 && len(report.Drifted) == 0 && (sync == nil || sync.InSync)
+
+	if *jsonOutput {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+		if !report.OK {
+			return fmt.Errorf("check failed")
+		}
+		return nil
+	}
+
+	return printCheckReport(report)
+}
+
+// printCheckReport renders a check report for humans and returns an error on any problem
+func printCheckReport(report *checkReport) error {
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	if sync := report.Sync; sync != nil && !sync.InSync {
+		fmt.Printf("\n❌ %s is out of sync with the Gemfile:\n", filepath.Base(report.Lockfile))
+		for _, name := range sync.Added {
+			fmt.Printf("  + %s\n", name)
+		}
+		for _, name := range sync.Removed {
+			fmt.Printf("  - %s\n", name)
+		}
+		for _, change := range sync.Changed {
+			fmt.Printf("  ~ %s\n", change)
+		}
+		fmt.Printf("\nRun `ore lock` to update the lockfile.\n")
+	}
+
+	if len(report.Missing) > 0 {
 		fmt.Printf("\n❌ The following gems are missing:\n")
-		for _, gem := range missing {
-			fmt.Printf("  * %s\n", gem)
+		for _, gem := range report.Missing {
+			switch gem.Source {
+			case "git":
+				fmt.Printf("  * %s (%s) [git]\n", gem.Name, gem.Version)
+			case "path":
+				fmt.Printf("  * %s (%s) [path: %s]\n", gem.Name, gem.Version, gem.Path)
+			default:
+				fmt.Printf("  * %s (%s)\n", gem.Name, displayVersion(lockfile.GemSpec{Version: gem.Version, Platform: gem.Platform}))
+			}
 		}
 		fmt.Printf("\nRun `ore install` to install missing gems.\n")
-		return fmt.Errorf("missing %d gem(s)", len(missing))
+		return fmt.Errorf("missing %d gem(s)", len(report.Missing))
+	}
+
+	if len(report.Fallbacks) > 0 {
+		fmt.Printf("\n❌ These gems are installed without their prebuilt %s binaries:\n", report.Platform)
+		for _, gem := range report.Fallbacks {
+			fmt.Printf("  * %s (%s-%s), installed as the pure-Ruby %s-%s\n", gem.Name, gem.Version, gem.Platform, gem.Name, gem.Version)
+		}
+		fmt.Printf("\nRun `ore install` on %s to install the platform gems.\n", report.Platform)
+		return fmt.Errorf("%d gem(s) missing their %s variant", len(report.Fallbacks), report.Platform)
+	}
+
+	if len(report.Drifted) > 0 {
+		fmt.Printf("\n❌ These sources drifted from %s:\n", filepath.Base(report.Lockfile))
+		for _, drift := range report.Drifted {
+			fmt.Printf("  * %s [%s]: %s\n", drift.Name, drift.Source, drift.Reason)
+		}
+		return fmt.Errorf("%d git/path gem(s) drifted from the lockfile", len(report.Drifted))
+	}
+
+	if !report.OK {
+		return fmt.Errorf("lockfile is out of sync with the Gemfile")
 	}
 
-	fmt.Printf("✅ All gems are installed (%d total)\n", installed)
+	if report.Platform != "" {
+		logger.Printf("✅ All gems for %s are installed (%d total)\n", report.Platform, report.Installed)
+		return nil
+	}
+	logger.Printf("✅ All gems are installed (%d total)\n", report.Installed)
 	return nil
 }
 
+// sourceDrift checks the lockfile's git and path gems for drift (see ore check --frozen).
+// Git gems follow the Gemfile's branch, tag or ref, or the lockfile's when the Gemfile
+// can't be read. Path gems whose source is gone are already reported missing.
+func sourceDrift(gemfilePath string, lock *lockfile.Lockfile, gemsDir string) []checkDrift {
+	gitSources := make(map[string]*gemfile.Source)
+	if parsed, err := gemfile.NewGemfileParser(gemfilePath).Parse(); err == nil {
+		for _, dep := range parsed.Dependencies {
+			if dep.Source != nil && dep.Source.Type == "git" {
+				gitSources[dep.Name] = dep.Source
+			}
+		}
+	}
+
+	var drifted []checkDrift
+	for _, spec := range lock.GitSpecs {
+		branch, tag, ref := spec.Branch, spec.Tag, ""
+		if source := gitSources[spec.Name]; source != nil {
+			branch, tag, ref = source.Branch, source.Tag, source.Ref
+		}
+		reason, err := resolver.GitGemDrift(context.Background(), spec, branch, tag, ref)
+		if err != nil {
+			reason = fmt.Sprintf("could not check the repository: %v", err)
+		}
+		if reason != "" {
+			drifted = append(drifted, checkDrift{Name: spec.Name, Source: "git", Reason: reason})
+		}
+	}
+
+	for _, spec := range lock.PathSpecs {
+		spec.Remote = pathGemRemote(gemfilePath, spec)
+		if _, err := os.Stat(spec.Remote); err != nil {
+			continue
+		}
+		reason, err := resolver.PathGemDrift(spec, filepath.Join(gemsDir, spec.FullName()))
+		if err != nil {
+			reason = fmt.Sprintf("could not check the source: %v", err)
+		}
+		if reason != "" {
+			drifted = append(drifted, checkDrift{Name: spec.Name, Source: "path", Reason: reason})
+		}
+	}
+	return drifted
+}
+
+// pathGemRemote returns where a path gem's source is: the lockfile's remote is
+// relative to the Gemfile, not to where ore runs.
+func pathGemRemote(gemfilePath string, spec lockfile.PathGemSpec) string {
+	if filepath.IsAbs(spec.Remote) {
+		return spec.Remote
+	}
+	return filepath.Join(filepath.Dir(gemfilePath), spec.Remote)
+}
+
+// platformGemSpecs returns the variant of each locked gem that installs on platform:
+// its prebuilt platform gem if the lockfile has one, otherwise the pure-Ruby gem. Gems
+// locked only for other platforms are left out. Fails if platform isn't in PLATFORMS.
+func platformGemSpecs(lock *lockfile.Lockfile, platform string) ([]lockfile.GemSpec, error) {
+	engine := ruby.Engine{Name: ruby.EngineMRI}
+	if strings.Contains(platform, "java") {
+		engine.Name = ruby.EngineJRuby
+	}
+	if !slices.ContainsFunc(lock.Platforms, func(locked string) bool {
+		return locked != "ruby" && lockPlatformMatches(locked, platform, engine)
+	}) && !slices.Contains(lock.Platforms, platform) {
+		return nil, fmt.Errorf("%s is not in the lockfile's PLATFORMS (%s); add it with `ore lock --add-platform %s`",
+			platform, strings.Join(lock.Platforms, ", "), platform)
+	}
+
+	type release struct{ name, version string }
+	chosen := make(map[release]lockfile.GemSpec)
+	var order []release
+	for _, spec := range lock.GemSpecs {
+		key := release{spec.Name, spec.Version}
+		current, seen := chosen[key]
+		switch {
+		case !isNativeVariant(spec):
+			if !seen {
+				chosen[key] = spec
+				order = append(order, key)
+			}
+		case lockPlatformMatches(spec.Platform, platform, engine):
+			if !seen {
+				order = append(order, key)
+			}
+			if !seen || !isNativeVariant(current) {
+				chosen[key] = spec
+			}
+		}
+	}
+
+	specs := make([]lockfile.GemSpec, 0, len(order))
+	for _, key := range order {
+		specs = append(specs, chosen[key])
+	}
+	return specs, nil
+}
+
+// isNativeVariant reports whether a locked gem is a platform-specific build
+func isNativeVariant(spec lockfile.GemSpec) bool {
+	return spec.Platform != "" && spec.Platform != "ruby"
+}
+
+// rubyVariantInstalled reports whether the pure-Ruby variant of a platform gem is installed
+func rubyVariantInstalled(gemsDir string, spec lockfile.GemSpec) bool {
+	_, err := os.Stat(filepath.Join(gemsDir, spec.Name+"-"+spec.Version))
+	return err == nil
+}
+
+// displayVersion is a gem's version with its platform, e.g. 1.16.0-x86_64-linux
+func displayVersion(spec lockfile.GemSpec) string {
+	if isNativeVariant(spec) {
+		return spec.Version + "-" + spec.Platform
+	}
+	return spec.Version
+}
+
+// checkLockfileSync compares the Gemfile's dependencies with the lockfile's DEPENDENCIES section
+//
+// Ruby developers: Like Bundler's "The gemspecs for path gems changed" /
+// "You are trying to install in deployment mode after changing your Gemfile" checks
+func checkLockfileSync(gemfilePath string, lock *lockfile.Lockfile) (*lockfileSync, error) {
+	parsed, err := gemfile.NewGemfileParser(gemfilePath).Parse()
+	if err != nil {
+		return nil, err
+	}
+	// ore lock writes the gemspec's runtime and development dependencies too
+	if err := resolver.ApplyGemspecDependencies(gemfilePath, parsed); err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]string, len(parsed.Dependencies))
+	for _, dep := range parsed.Dependencies {
+		declared[dep.Name] = normalizeRequirement(dep.Constraints)
+	}
+
+	locked := make(map[string]string, len(lock.Dependencies))
+	for _, dep := range lock.Dependencies {
+		// Git/path dependencies are written as "name!" in DEPENDENCIES
+		name := strings.TrimSuffix(dep.Name, "!")
+		// gemfile-go reads the RUBY VERSION section as a "ruby" dependency
+		if _, isGem := declared[name]; name == "ruby" && !isGem {
+			continue
+		}
+		locked[name] = normalizeRequirement(dep.Constraints)
+	}
+
+	sync := &lockfileSync{Added: []string{}, Removed: []string{}, Changed: []string{}}
+	for name, requirement := range declared {
+		lockedRequirement, found := locked[name]
+		switch {
+		case !found:
+			sync.Added = append(sync.Added, name)
+		case lockedRequirement != requirement:
+			sync.Changed = append(sync.Changed, fmt.Sprintf("%s (%s -> %s)", name, lockedRequirement, requirement))
+		}
+	}
+	for name := range locked {
+		if _, found := declared[name]; !found {
+			sync.Removed = append(sync.Removed, name)
+		}
+	}
+
+	sort.Strings(sync.Added)
+	sort.Strings(sync.Removed)
+	sort.Strings(sync.Changed)
+	sync.InSync = len(sync.Added) == 0 && len(sync.Removed) == 0 && len(sync.Changed) == 0
+
+	return sync, nil
+}
+
+// normalizeRequirement renders constraints the way Bundler writes them to the lockfile,
+// so `"~>7.0"` in a Gemfile compares equal to `(~> 7.0)` and `"1.0"` to `(= 1.0)`
+func normalizeRequirement(constraints []string) string {
+	if len(constraints) == 0 {
+		return ">= 0"
+	}
+
+	normalized := make([]string, 0, len(constraints))
+	for _, constraint := range constraints {
+		constraint = strings.TrimSpace(constraint)
+		version := strings.TrimLeft(constraint, "<>=~! ")
+		op := strings.TrimSpace(strings.TrimSuffix(constraint, version))
+		if op == "" {
+			op = "="
+		}
+		normalized = append(normalized, op+" "+strings.TrimSpace(version))
+	}
+	sort.Strings(normalized)
+
+	return strings.Join(normalized, ", ")
+}
+
 func defaultVendorDir() string {
 	if env := os.Getenv("ORE_VENDOR_DIR"); env != "" {
 		return env
@@ -3,19 +3,28 @@ package commands
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/logger"
 )
 
+// CleanOptions controls how RemoveUnusedGems reports and removes gems
+type CleanOptions struct {
+	DryRun  bool      // Only list the gems that would be removed
+	Verbose bool      // List every gem removed
+	Out     io.Writer // Progress output (stdout when nil)
+}
+
 // RunClean implements the ore clean command
 func RunClean(args []string) error {
 	fs := flag.NewFlagSet("clean", flag.ContinueOnError)
 	gemfilePath := fs.String("gemfile", defaultGemfilePath(), "Path to Gemfile")
 	vendorDir := fs.String("vendor", defaultVendorDir(), "Vendor directory")
 	dryRun := fs.Bool("dry-run", false, "Print what would be removed without actually removing")
-	verbose := fs.Bool("v", false, "Enable verbose output")
+	verbose := fs.Bool("v", logger.Verbose(), "Enable verbose output")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -44,23 +53,36 @@ func RunClean(args []string) error {
 		keepGems[spec.FullName()] = true
 	}
 
-	gemsDir := filepath.Join(*vendorDir, "gems")
+	_, err = RemoveUnusedGems(*vendorDir, keepGems, CleanOptions{DryRun: *dryRun, Verbose: *verbose})
+	return err
+}
+
+// RemoveUnusedGems deletes the gems under vendorDir/gems whose full names aren't in
+// keepGems, like `bundle clean`, and returns how many it removed (or would remove,
+// with opts.DryRun).
+func RemoveUnusedGems(vendorDir string, keepGems map[string]bool, opts CleanOptions) (int, error) {
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	gemsDir := filepath.Join(vendorDir, "gems")
 
 	// Check if vendor directory exists
 	if _, err := os.Stat(gemsDir); os.IsNotExist(err) {
-		fmt.Printf("Nothing to clean - %s does not exist\n", gemsDir)
-		return nil
+		fmt.Fprintf(out, "Nothing to clean - %s does not exist\n", gemsDir)
+		return 0, nil
 	}
 
 	// Find gems to remove
 	entries, err := os.ReadDir(gemsDir)
 	if err != nil {
-		return fmt.Errorf("failed to read gems directory: %w", err)
+		return 0, fmt.Errorf("failed to read gems directory: %w", err)
 	}
 
 	var toRemove []string
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		// --shared-store installs gems as symlinks into the store
+		if !entry.IsDir() && entry.Type()&os.ModeSymlink == 0 {
 			continue
 		}
 
@@ -71,21 +93,21 @@ func RunClean(args []string) error {
 	}
 
 	if len(toRemove) == 0 {
-		fmt.Println("✨ No unused gems to remove")
-		return nil
+		fmt.Fprintln(out, "✨ No unused gems to remove")
+		return 0, nil
 	}
 
 	// Show what will be removed
-	if *dryRun || *verbose {
-		fmt.Printf("Gems to remove:\n")
+	if opts.DryRun || opts.Verbose {
+		fmt.Fprintf(out, "Gems to remove:\n")
 		for _, gemName := range toRemove {
-			fmt.Printf("  * %s\n", gemName)
+			fmt.Fprintf(out, "  * %s\n", gemName)
 		}
 	}
 
-	if *dryRun {
-		fmt.Printf("\n[dry-run] Would remove %d gem(s)\n", len(toRemove))
-		return nil
+	if opts.DryRun {
+		fmt.Fprintf(out, "\n[dry-run] Would remove %d gem(s)\n", len(toRemove))
+		return len(toRemove), nil
 	}
 
 	// Remove unused gems
@@ -93,24 +115,25 @@ func RunClean(args []string) error {
 	failed := 0
 	for _, gemName := range toRemove {
 		gemPath := filepath.Join(gemsDir, gemName)
+		// For a symlinked gem this removes only the link; other bundles may use the store entry
 		if err := os.RemoveAll(gemPath); err != nil {
-			if *verbose {
+			if opts.Verbose {
 				fmt.Fprintf(os.Stderr, "Failed to remove %s: %v\n", gemName, err)
 			}
 			failed++
 		} else {
-			if *verbose {
-				fmt.Printf("Removed %s\n", gemName)
+			if opts.Verbose {
+				fmt.Fprintf(out, "Removed %s\n", gemName)
 			}
 			removed++
 		}
 	}
 
-	fmt.Printf("✨ Removed %d unused gem(s)", removed)
+	fmt.Fprintf(out, "✨ Removed %d unused gem(s)", removed)
 	if failed > 0 {
-		fmt.Printf(" (%d failed)", failed)
+		fmt.Fprintf(out, " (%d failed)", failed)
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
 
-	return nil
+	return removed, nil
 }
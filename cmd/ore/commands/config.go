@@ -7,11 +7,14 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/contriboss/ore-light/internal/config"
 	"gopkg.in/yaml.v3"
 )
 
-// RunConfig implements the ore config command
-func RunConfig(args []string) error {
+// RunConfig implements the ore config command. vendorDir resolves the install path the
+// way a bare ore install would (ore's config, env, .bundle/config, then the default),
+// for `ore config get path`.
+func RunConfig(args []string, vendorDir func() (dir, source, setting string)) error {
 	fs := flag.NewFlagSet("config", flag.ContinueOnError)
 	local := fs.Bool("local", false, "Set local config (project-level)")
 	global := fs.Bool("global", false, "Set global config (user-level)")
@@ -49,14 +52,35 @@ func RunConfig(args []string) error {
 		return unsetConfig(scope, configArgs[0])
 	}
 
+	// Explicit get/set, e.g. `ore config get path`
+	switch {
+	case len(configArgs) == 2 && configArgs[0] == "get":
+		if isPathKey(configArgs[1]) {
+			return getVendorDir(vendorDir)
+		}
+		return getConfig(scope, configArgs[1])
+	case len(configArgs) >= 3 && configArgs[0] == "set":
+		if err := setConfig(scope, configArgs[1], strings.Join(configArgs[2:], " ")); err != nil {
+			return err
+		}
+		if isPathKey(configArgs[1]) {
+			warnShadowedPath(scope, vendorDir)
+		}
+		return nil
+	case len(configArgs) >= 1 && configArgs[0] == "get":
+		return fmt.Errorf("usage: ore config [--local|--global] get <key>")
+	case len(configArgs) >= 1 && configArgs[0] == "set":
+		return fmt.Errorf("usage: ore config [--local|--global] set <key> <value>")
+	}
+
 	// Get a config value
 	if len(configArgs) == 1 {
 		return getConfig(scope, configArgs[0])
 	}
 
-	// Set a config value
-	if len(configArgs) == 2 {
-		return setConfig(scope, configArgs[0], configArgs[1])
+	// Set a config value (multi-word values like build flags are joined)
+	if len(configArgs) >= 2 {
+		return setConfig(scope, configArgs[0], strings.Join(configArgs[1:], " "))
 	}
 
 	return fmt.Errorf("usage: ore config [--local|--global] <key> [<value>]")
@@ -73,12 +97,19 @@ Options:
 
 Examples:
   ore config --local path vendor/bundle    # Set local install path
+  ore config set path vendor/bundle        # Same, as BUNDLE_PATH in .bundle/config
   ore config path                          # Get install path
+  ore config get path                      # Where ore install puts gems, and why
   ore config --list                        # List all settings
   ore config --unset --local path          # Remove local path setting
+  ore config build.nokogiri --use-system-libraries
+  ore config mirror.https://rubygems.org https://gems.example.com
 
 Supported keys:
-  path        Installation directory for gems
+  path                      Installation directory for gems
+  build.<gem>               Options passed to the gem's extconf.rb/configure
+  mirror.<url>              Download and resolve gems for <url> from a mirror (mirror.all for every source)
+  ignore_messages[.<gem>]   Hide post-install messages (for every gem, or just <gem>)
 `)
 	return nil
 }
@@ -217,6 +248,34 @@ func unsetConfig(scope, key string) error {
 	return nil
 }
 
+// isPathKey reports whether key is Bundler's install path setting
+func isPathKey(key string) bool {
+	return toBundleKey(key) == "BUNDLE_PATH"
+}
+
+// getVendorDir prints where a bare ore install puts gems and which setting chose it.
+// --vendor or --path on ore install still override it.
+func getVendorDir(vendorDir func() (dir, source, setting string)) error {
+	dir, source, setting := vendorDir()
+	if dir == "" {
+		return fmt.Errorf("could not determine the install path; set one with `ore config set path <dir>`")
+	}
+	fmt.Println(dir)
+	fmt.Printf("Source: %s (%s)\n", source, setting)
+	return nil
+}
+
+// warnShadowedPath warns after `ore config set path` when ore install won't use the new
+// BUNDLE_PATH: a higher-priority setting (ORE_VENDOR_DIR, the ore config file) wins, or
+// it went to ~/.bundle/config, which ore doesn't read for the path
+func warnShadowedPath(scope string, vendorDir func() (dir, source, setting string)) {
+	dir, source, setting := vendorDir()
+	if scope == "local" && source == config.VendorSourceBundle {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: ore install still uses %s from %s (%s)\n", dir, setting, source)
+}
+
 func getConfigPath(scope string) string {
 	if scope == "global" {
 		home, _ := os.UserHomeDir()
@@ -240,7 +299,10 @@ func toBundleKey(key string) string {
 		if strings.HasPrefix(strings.ToUpper(key), "BUNDLE_") {
 			return strings.ToUpper(key)
 		}
-		// Otherwise, prefix with BUNDLE_
+		// Otherwise, prefix with BUNDLE_ and encode like Bundler:
+		// "build.net-ssh" -> "BUNDLE_BUILD__NET___SSH"
+		key = strings.ReplaceAll(key, "-", "___")
+		key = strings.ReplaceAll(key, ".", "__")
 		return "BUNDLE_" + strings.ToUpper(key)
 	}
 }
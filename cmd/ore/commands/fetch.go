@@ -7,8 +7,10 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/contriboss/ore-light/internal/config"
+	"github.com/contriboss/ore-light/internal/geminstall"
 	"github.com/contriboss/ore-light/internal/logger"
 	"github.com/contriboss/ore-light/internal/registry"
 	"github.com/contriboss/ore-light/internal/sources"
@@ -21,6 +23,7 @@ func RunFetch(args []string) error {
 	version := fs.String("version", "", "Gem version to fetch (default: latest)")
 	platform := fs.String("platform", "", "Platform to fetch (e.g., x86_64-linux, java, ruby)")
 	source := fs.String("source", "https://rubygems.org", "Gem source URL")
+	trustPolicy := fs.String("trust-policy", "", "Verify gem signatures: NoSecurity, AlmostNoSecurity, LowSecurity, MediumSecurity or HighSecurity")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -31,6 +34,11 @@ func RunFetch(args []string) error {
 		return fmt.Errorf("at least one gem name is required")
 	}
 
+	policy, err := geminstall.ParseTrustPolicy(*trustPolicy)
+	if err != nil {
+		return err
+	}
+
 	// Get cache directory
 	cacheDir, err := config.DefaultCacheDir(nil)
 	if err != nil {
@@ -50,32 +58,43 @@ func RunFetch(args []string) error {
 
 	// Create source manager
 	sourceManager := sources.NewManager([]sources.SourceConfig{
-		{URL: *source, Fallback: ""},
+		{URL: config.ApplyMirror(config.ReadBundleMirrors(), *source), Fallback: ""},
 	}, nil)
 
 	ctx := context.Background()
 
+	var unverified []string
 	for _, gemName := range gems {
-		if err := fetchGem(ctx, client, sourceManager, gemName, *version, *platform, cacheDir); err != nil {
+		gemPath, err := fetchGem(ctx, client, sourceManager, gemName, *version, *platform, cacheDir)
+		if err != nil {
 			logger.Error("error fetching gem", "gem", gemName, "error", err)
 			continue
 		}
+		if err := geminstall.VerifyGem(gemPath, policy, geminstall.DefaultTrustDir()); err != nil {
+			// Don't leave a gem that failed verification in the cache
+			_ = os.Remove(gemPath)
+			logger.Error("gem failed signature verification", "gem", gemName, "policy", policy.Name, "error", err)
+			unverified = append(unverified, gemName)
+		}
 	}
 
+	if len(unverified) > 0 {
+		return fmt.Errorf("%d gem(s) failed signature verification under %s: %s", len(unverified), policy.Name, strings.Join(unverified, ", "))
+	}
 	return nil
 }
 
-func fetchGem(ctx context.Context, client *registry.Client, sourceManager *sources.Manager, gemName, version, platform, cacheDir string) error {
+func fetchGem(ctx context.Context, client *registry.Client, sourceManager *sources.Manager, gemName, version, platform, cacheDir string) (string, error) {
 	// Determine version to fetch
 	targetVersion := version
 	if targetVersion == "" {
 		logger.Debug("finding latest version", "gem", gemName)
 		versions, err := client.GetGemVersions(ctx, gemName)
 		if err != nil {
-			return fmt.Errorf("failed to get versions: %w", err)
+			return "", fmt.Errorf("failed to get versions: %w", err)
 		}
 		if len(versions) == 0 {
-			return fmt.Errorf("no versions found for gem %s", gemName)
+			return "", fmt.Errorf("no versions found for gem %s", gemName)
 		}
 		targetVersion = versions[0]
 	}
@@ -94,14 +113,14 @@ func fetchGem(ctx context.Context, client *registry.Client, sourceManager *sourc
 	// Check if already cached
 	cachedPath := filepath.Join(cacheDir, gemFileName)
 	if _, err := os.Stat(cachedPath); err == nil {
-		fmt.Printf("✓ %s already cached at %s\n", gemFileName, cachedPath)
-		return nil
+		logger.Printf("✓ %s already cached at %s\n", gemFileName, cachedPath)
+		return cachedPath, nil
 	}
 
 	// Create output file
 	outFile, err := os.Create(cachedPath)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return "", fmt.Errorf("failed to create output file: %w", err)
 	}
 	defer func() {
 		_ = outFile.Close()
@@ -117,30 +136,30 @@ func fetchGem(ctx context.Context, client *registry.Client, sourceManager *sourc
 
 			// Check cache again
 			if _, err := os.Stat(cachedPath); err == nil {
-				fmt.Printf("✓ %s already cached at %s\n", gemFileName, cachedPath)
-				return nil
+				logger.Printf("✓ %s already cached at %s\n", gemFileName, cachedPath)
+				return cachedPath, nil
 			}
 
 			// Close previous file and open new one
 			_ = outFile.Close()
 			outFile, err = os.Create(cachedPath)
 			if err != nil {
-				return fmt.Errorf("failed to create output file: %w", err)
+				return "", fmt.Errorf("failed to create output file: %w", err)
 			}
 			defer func() {
 				_ = outFile.Close()
 			}()
 
 			if err := sourceManager.DownloadGem(ctx, gemFileName, outFile); err != nil {
-				return fmt.Errorf("failed to download: %w", err)
+				return "", fmt.Errorf("failed to download: %w", err)
 			}
 		} else {
-			return fmt.Errorf("failed to download: %w", err)
+			return "", fmt.Errorf("failed to download: %w", err)
 		}
 	}
 
-	fmt.Printf("✓ Downloaded %s to %s\n", gemFileName, cachedPath)
-	return nil
+	logger.Printf("✓ Downloaded %s to %s\n", gemFileName, cachedPath)
+	return cachedPath, nil
 }
 
 // constructGemFilename constructs the gem filename with platform suffix
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/contriboss/ore-light/internal/ruby"
 )
 
 // GemInfo represents information about an installed gem
@@ -62,8 +63,8 @@ func RunGems(filter string) error {
 
 // getGemDirectory gets the system gem directory
 func getGemDirectory() (string, error) {
-	// Try to get from `gem environment gemdir`
-	cmd := exec.Command("gem", "environment", "gemdir")
+	// Ask the selected Ruby for Gem.dir
+	cmd := exec.Command(ruby.Binary(), "-e", "print Gem.dir")
 	output, err := cmd.Output()
 	if err == nil {
 		gemDir := strings.TrimSpace(string(output))
@@ -265,7 +266,7 @@ end
 `
 
 	args := append([]string{"-e", rubyScript}, specPaths...)
-	cmd := exec.Command("ruby", args...)
+	cmd := exec.Command(ruby.Binary(), args...)
 	output, err := cmd.Output()
 	if err != nil {
 		return nil // Skip on error
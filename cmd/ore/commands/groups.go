@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+	"github.com/contriboss/gemfile-go/lockfile"
+)
+
+// EnrichGemsWithGroups reads the Gemfile and enriches lockfile gems with group information.
+// Only gems the Gemfile names get groups; see inheritedGroups for their dependencies.
+func EnrichGemsWithGroups(gemfilePath string, parsed *lockfile.Lockfile) error {
+	parser := gemfile.NewGemfileParser(gemfilePath)
+	parsedGemfile, err := parser.Parse()
+	if err != nil {
+		return fmt.Errorf("failed to parse Gemfile: %w", err)
+	}
+
+	// Create a map of gem name -> groups from the Gemfile
+	gemGroups := make(map[string][]string)
+	for _, dep := range parsedGemfile.Dependencies {
+		if len(dep.Groups) > 0 {
+			gemGroups[dep.Name] = dep.Groups
+		} else {
+			gemGroups[dep.Name] = []string{"default"}
+		}
+	}
+
+	// Enrich GemSpecs with group information
+	for i := range parsed.GemSpecs {
+		if groups, found := gemGroups[parsed.GemSpecs[i].Name]; found {
+			parsed.GemSpecs[i].Groups = groups
+		}
+	}
+
+	// Enrich GitGemSpecs with group information
+	for i := range parsed.GitSpecs {
+		if groups, found := gemGroups[parsed.GitSpecs[i].Name]; found {
+			parsed.GitSpecs[i].Groups = groups
+		}
+	}
+
+	// Enrich PathGemSpecs with group information
+	for i := range parsed.PathSpecs {
+		if groups, found := gemGroups[parsed.PathSpecs[i].Name]; found {
+			parsed.PathSpecs[i].Groups = groups
+		}
+	}
+
+	return nil
+}
+
+// inheritedGroups maps every locked gem to its groups, given a lockfile enriched by
+// EnrichGemsWithGroups. Like ore install, a group's gems are its Gemfile gems plus
+// everything they depend on, so a transitive dependency gets the union of the groups
+// of every Gemfile gem that pulls it in.
+func inheritedGroups(lock *lockfile.Lockfile) map[string][]string {
+	deps := make(map[string][]string)
+	roots := make(map[string][]string)
+	for _, spec := range lock.GemSpecs {
+		for _, dep := range spec.Dependencies {
+			deps[spec.Name] = append(deps[spec.Name], dep.Name)
+		}
+		if len(spec.Groups) > 0 {
+			roots[spec.Name] = spec.Groups
+		}
+	}
+	for _, spec := range lock.GitSpecs {
+		for _, dep := range spec.Dependencies {
+			deps[spec.Name] = append(deps[spec.Name], dep.Name)
+		}
+		if len(spec.Groups) > 0 {
+			roots[spec.Name] = spec.Groups
+		}
+	}
+	for _, spec := range lock.PathSpecs {
+		for _, dep := range spec.Dependencies {
+			deps[spec.Name] = append(deps[spec.Name], dep.Name)
+		}
+		if len(spec.Groups) > 0 {
+			roots[spec.Name] = spec.Groups
+		}
+	}
+
+	groupSets := make(map[string]map[string]bool)
+	for root, groups := range roots {
+		for _, group := range groups {
+			// Walk the dependency graph, marking each reachable gem with this group
+			stack := []string{root}
+			for len(stack) > 0 {
+				name := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if groupSets[name] == nil {
+					groupSets[name] = make(map[string]bool)
+				}
+				if groupSets[name][group] {
+					continue
+				}
+				groupSets[name][group] = true
+				stack = append(stack, deps[name]...)
+			}
+		}
+	}
+
+	result := make(map[string][]string, len(groupSets))
+	for name, set := range groupSets {
+		groups := make([]string, 0, len(set))
+		for group := range set {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		result[name] = groups
+	}
+	return result
+}
@@ -1,9 +1,12 @@
 package commands
 
 import (
-	"fmt"
+	"encoding/json"
 	"os"
 	"path/filepath"
+
+	"github.com/contriboss/ore-light/internal/errdefs"
+	"github.com/mattn/go-isatty"
 )
 
 // defaultGemfilePath returns the path to the Gemfile to use.
@@ -67,5 +70,22 @@ func findLockfilePath(gemfilePath string) (string, error) {
 		}
 	}
 
-	return "", fmt.Errorf("no lockfile found for %s (looked for %s)", gemfilePath, lockfileName)
+	return "", errdefs.Newf(errdefs.ErrLockfileNotFound, "no lockfile found for %s (looked for %s)", gemfilePath, lockfileName)
+}
+
+// printJSON writes v to stdout as indented JSON.
+// Used by commands that offer a --json flag for scripting.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// hyperlink renders url as a clickable OSC 8 link when stdout is a terminal, showing the
+// URL itself so terminals without OSC 8 support still print something useful
+func hyperlink(url string) string {
+	if !isatty.IsTerminal(os.Stdout.Fd()) || os.Getenv("TERM") == "dumb" {
+		return url
+	}
+	return "\x1b]8;;" + url + "\x1b\\" + url + "\x1b]8;;\x1b\\"
 }
@@ -2,17 +2,59 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/geminstall"
+	"github.com/contriboss/ore-light/internal/httpcache"
+	"github.com/contriboss/ore-light/internal/httpclient"
+	"github.com/contriboss/ore-light/internal/logger"
 	"github.com/contriboss/ore-light/internal/registry"
+	"github.com/contriboss/ore-light/internal/resolver"
 )
 
+// gemDetails is the information ore info reports for a gem
+type gemDetails struct {
+	Name         string          `json:"name"`
+	Version      string          `json:"version"`
+	Summary      string          `json:"summary,omitempty"`
+	Homepage     string          `json:"homepage,omitempty"`
+	Licenses     []string        `json:"licenses"`
+	Dependencies []gemDependency `json:"dependencies"`
+	Source       string          `json:"source"` // "installed" or "remote"
+	Links        []gemLink       `json:"links,omitempty"`
+
+	versions []string              // All published versions (remote only)
+	devDeps  []registry.Dependency // Development dependencies (remote only)
+}
+
+// gemLink is one of the project URIs a gem publishes (changelog_uri, source_code_uri, ...)
+type gemLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// gemDependency is a runtime dependency in ore info output
+type gemDependency struct {
+	Name        string `json:"name"`
+	Requirement string `json:"requirement"`
+}
+
 // RunInfo implements the ore info command
 func RunInfo(args []string) error {
 	fs := flag.NewFlagSet("info", flag.ContinueOnError)
-	verbose := fs.Bool("v", false, "Enable verbose output")
+	verbose := fs.Bool("v", logger.Verbose(), "Enable verbose output")
+	jsonOutput := fs.Bool("json", false, "Output as JSON")
+	remote := fs.Bool("remote", false, "Fetch the latest published info instead of the installed version")
+	gemfilePath := fs.String("gemfile", defaultGemfilePath(), "Path to Gemfile")
+	vendorDir := fs.String("vendor", defaultVendorDir(), "Vendor directory")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -22,43 +64,78 @@ func RunInfo(args []string) error {
 		return fmt.Errorf("at least one gem name is required")
 	}
 
-	client, err := registry.NewClient("https://rubygems.org", registry.ProtocolRubygems)
-	if err != nil {
-		return fmt.Errorf("failed to create registry client: %w", err)
-	}
-
 	ctx := context.Background()
+	var results []*gemDetails
 
 	for _, gemName := range gems {
-		if *verbose {
-			fmt.Printf("🔍 Fetching info for %s...\n", gemName)
-		}
+		var details *gemDetails
 
-		// Get versions first
-		versions, err := client.GetGemVersions(ctx, gemName)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Could not fetch versions for %s: %v\n", gemName, err)
-			continue
+		// Prefer the installed copy so ore info works offline
+		if !*remote {
+			details = loadInstalledGemDetails(gemName, *gemfilePath, *vendorDir)
+			if details != nil && *verbose && !*jsonOutput {
+				fmt.Printf("📦 Using installed metadata for %s\n", gemName)
+			}
 		}
 
-		if len(versions) == 0 {
-			fmt.Printf("No versions found for gem: %s\n", gemName)
-			continue
+		if details == nil {
+			if *verbose && !*jsonOutput {
+				fmt.Printf("🔍 Fetching info for %s...\n", gemName)
+			}
+
+			// Bound the whole lookup, response bodies included, by the HTTP timeout
+			lookupCtx, cancel := context.WithTimeout(ctx, httpclient.Timeout())
+			var err error
+			details, err = fetchRemoteGemDetails(lookupCtx, gemName)
+			cancel()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				continue
+			}
 		}
 
-		// Get info for latest version
-		latestVersion := versions[0]
-		info, err := client.GetGemInfo(ctx, gemName, latestVersion)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Could not fetch info for %s: %v\n", gemName, err)
-			continue
+		results = append(results, details)
+	}
+
+	if *jsonOutput {
+		if results == nil {
+			results = []*gemDetails{}
 		}
+		return printJSON(results)
+	}
+
+	for _, details := range results {
+		printGemDetails(details, *verbose)
+	}
+
+	return nil
+}
 
-		// Print gem information
-		fmt.Printf("\n*** %s ***\n\n", gemName)
-		fmt.Printf("  Latest version: %s\n", latestVersion)
+// printGemDetails renders gem details for humans
+func printGemDetails(details *gemDetails, verbose bool) {
+	fmt.Printf("\n*** %s ***\n\n", details.Name)
 
-		// Show available versions (limit to 20)
+	if details.Source == "installed" {
+		fmt.Printf("  Installed version: %s\n", details.Version)
+	} else {
+		fmt.Printf("  Latest version: %s\n", details.Version)
+	}
+
+	if details.Summary != "" {
+		fmt.Printf("  Summary: %s\n", details.Summary)
+	}
+	if details.Homepage != "" {
+		fmt.Printf("  Homepage: %s\n", hyperlink(details.Homepage))
+	}
+	for _, link := range details.Links {
+		fmt.Printf("  %s: %s\n", link.Label, hyperlink(link.URL))
+	}
+	if len(details.Licenses) > 0 {
+		fmt.Printf("  Licenses: %s\n", strings.Join(details.Licenses, ", "))
+	}
+
+	// Show available versions (limit to 20)
+	if versions := details.versions; len(versions) > 0 {
 		fmt.Printf("  Versions: %s", versions[0])
 		limit := 20
 		if len(versions) > limit {
@@ -72,29 +149,218 @@ func RunInfo(args []string) error {
 			}
 			fmt.Println()
 		}
+	}
 
-		// Show dependencies
-		runtimeDeps := info.Dependencies.Runtime
-		devDeps := info.Dependencies.Development
+	// Show dependencies
+	if len(details.Dependencies) > 0 {
+		fmt.Printf("  Runtime dependencies:\n")
+		for _, dep := range details.Dependencies {
+			fmt.Printf("    - %s %s\n", dep.Name, dep.Requirement)
+		}
+	} else {
+		fmt.Printf("  Runtime dependencies: (none)\n")
+	}
 
-		if len(runtimeDeps) > 0 {
-			fmt.Printf("  Runtime dependencies:\n")
-			for _, dep := range runtimeDeps {
-				fmt.Printf("    - %s %s\n", dep.Name, dep.Requirements)
+	if len(details.devDeps) > 0 && verbose {
+		fmt.Printf("  Development dependencies:\n")
+		for _, dep := range details.devDeps {
+			fmt.Printf("    - %s %s\n", dep.Name, dep.Requirements)
+		}
+	}
+
+	fmt.Println()
+}
+
+// loadInstalledGemDetails reads metadata from the installed gem's cached .gem file.
+// Returns nil when the gem is not installed locally.
+//
+// Ruby developers: This is like `gem specification rack` - no network involved
+func loadInstalledGemDetails(gemName, gemfilePath, vendorDir string) *gemDetails {
+	gemPath := findInstalledGemArchive(gemName, gemfilePath, vendorDir)
+	if gemPath == "" {
+		return nil
+	}
+
+	metadataYAML, err := geminstall.ExtractMetadataOnly(gemPath)
+	if err != nil {
+		return nil
+	}
+
+	meta, err := geminstall.ParseGemMetadata(metadataYAML)
+	if err != nil || meta.Name == "" {
+		return nil
+	}
+
+	details := &gemDetails{
+		Name:         meta.Name,
+		Version:      meta.Version,
+		Summary:      meta.Summary,
+		Homepage:     meta.Homepage,
+		Licenses:     meta.Licenses,
+		Dependencies: []gemDependency{},
+		Source:       "installed",
+	}
+	if details.Licenses == nil {
+		details.Licenses = []string{}
+	}
+	for _, dep := range meta.Dependencies {
+		details.Dependencies = append(details.Dependencies, gemDependency{Name: dep.Name, Requirement: dep.Requirement})
+	}
+
+	return details
+}
+
+// findInstalledGemArchive locates the .gem file for an installed gem.
+// The locked version is preferred; otherwise the highest cached version wins.
+func findInstalledGemArchive(gemName, gemfilePath, vendorDir string) string {
+	cacheDirs := []string{filepath.Join(vendorDir, "cache")}
+	if systemDir, err := getGemDirectory(); err == nil && systemDir != "" {
+		cacheDirs = append(cacheDirs, filepath.Join(systemDir, "cache"))
+	}
+
+	// Use the lockfile version when available
+	if lockfilePath, err := findLockfilePath(gemfilePath); err == nil {
+		if lock, err := lockfile.ParseFile(lockfilePath); err == nil {
+			for _, spec := range lock.GemSpecs {
+				if spec.Name != gemName {
+					continue
+				}
+				if candidate := geminstall.FindCachedGem(cacheDirs, spec); candidate != "" {
+					return candidate
+				}
 			}
-		} else {
-			fmt.Printf("  Runtime dependencies: (none)\n")
 		}
+	}
 
-		if len(devDeps) > 0 && *verbose {
-			fmt.Printf("  Development dependencies:\n")
-			for _, dep := range devDeps {
-				fmt.Printf("    - %s %s\n", dep.Name, dep.Requirements)
+	// Fall back to any cached version of the gem
+	for _, dir := range cacheDirs {
+		plain, _ := filepath.Glob(filepath.Join(dir, gemName+"-*.gem"))
+		compressed, _ := filepath.Glob(filepath.Join(dir, gemName+"-*.gem"+geminstall.CompressedGemExt))
+		var best string
+		var bestVersion *resolver.SemverVersion
+		for _, match := range append(plain, compressed...) {
+			// Skip gems whose name merely starts with gemName (e.g. rack-test for rack)
+			rest := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), gemName+"-"), geminstall.CompressedGemExt)
+			rest = strings.TrimSuffix(rest, ".gem")
+			if rest == "" || rest[0] < '0' || rest[0] > '9' {
+				continue
 			}
+			// Compare versions numerically so 1.10.0 beats 1.9.0; a platform suffix follows the version
+			versionStr, _, _ := strings.Cut(rest, "-")
+			version, err := resolver.NewSemverVersion(versionStr)
+			if err != nil {
+				continue
+			}
+			if bestVersion == nil || version.Sort(bestVersion) > 0 {
+				best, bestVersion = match, version
+			}
+		}
+		if best != "" {
+			return best
 		}
+	}
+
+	return ""
+}
 
-		fmt.Println()
+// remoteGemResponse is the subset of /api/v1/gems/<name>.json used by ore info
+type remoteGemResponse struct {
+	Name             string   `json:"name"`
+	Version          string   `json:"version"`
+	Info             string   `json:"info"`
+	HomepageURI      string   `json:"homepage_uri"`
+	ChangelogURI     string   `json:"changelog_uri"`
+	SourceCodeURI    string   `json:"source_code_uri"`
+	BugTrackerURI    string   `json:"bug_tracker_uri"`
+	DocumentationURI string   `json:"documentation_uri"`
+	Licenses         []string `json:"licenses"`
+	Dependencies     struct {
+		Runtime     []registry.Dependency `json:"runtime"`
+		Development []registry.Dependency `json:"development"`
+	} `json:"dependencies"`
+}
+
+// links returns the gem's published project URIs other than the homepage, in display order.
+// A URI that only repeats the homepage is left out.
+func (r *remoteGemResponse) links() []gemLink {
+	var links []gemLink
+	for _, link := range []gemLink{
+		{"Changelog", r.ChangelogURI},
+		{"Source code", r.SourceCodeURI},
+		{"Bug tracker", r.BugTrackerURI},
+		{"Documentation", r.DocumentationURI},
+	} {
+		if link.URL != "" && link.URL != r.HomepageURI {
+			links = append(links, link)
+		}
 	}
+	return links
+}
 
-	return nil
+// fetchRemoteGem fetches /api/v1/gems/<name>.json from rubygems.org
+func fetchRemoteGem(ctx context.Context, gemName string) (*remoteGemResponse, error) {
+	apiURL := fmt.Sprintf("https://rubygems.org/api/v1/gems/%s.json", url.PathEscape(gemName))
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := httpcache.NewClient()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch info for %s: %w", gemName, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch info for %s: HTTP %d", gemName, resp.StatusCode)
+	}
+
+	var remoteGem remoteGemResponse
+	if err := json.NewDecoder(resp.Body).Decode(&remoteGem); err != nil {
+		return nil, fmt.Errorf("failed to decode info for %s: %w", gemName, err)
+	}
+	return &remoteGem, nil
+}
+
+// fetchRemoteGemDetails fetches the latest published info for a gem from rubygems.org
+func fetchRemoteGemDetails(ctx context.Context, gemName string) (*gemDetails, error) {
+	client, err := registry.NewClient("https://rubygems.org", registry.ProtocolRubygems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client: %w", err)
+	}
+
+	versions, err := client.GetGemVersions(ctx, gemName)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch versions for %s: %w", gemName, err)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no versions found for gem: %s", gemName)
+	}
+
+	remoteGem, err := fetchRemoteGem(ctx, gemName)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &gemDetails{
+		Name:         gemName,
+		Version:      versions[0],
+		Summary:      remoteGem.Info,
+		Homepage:     remoteGem.HomepageURI,
+		Licenses:     remoteGem.Licenses,
+		Dependencies: []gemDependency{},
+		Source:       "remote",
+		Links:        remoteGem.links(),
+		versions:     versions,
+		devDeps:      remoteGem.Dependencies.Development,
+	}
+	if details.Licenses == nil {
+		details.Licenses = []string{}
+	}
+	for _, dep := range remoteGem.Dependencies.Runtime {
+		details.Dependencies = append(details.Dependencies, gemDependency{Name: dep.Name, Requirement: dep.Requirements})
+	}
+
+	return details, nil
 }
@@ -5,48 +5,123 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// initOptions selects the Gemfile skeleton ore init writes
+type initOptions struct {
+	rubyVersion string // Adds a `ruby` line when set
+	gemspec     bool   // Gem project: `gemspec` plus development tools
+	rails       bool   // Typical Rails application skeleton
+}
+
 // RunInit implements the ore init command
 func RunInit(args []string) error {
 	fs := flag.NewFlagSet("init", flag.ContinueOnError)
 	gemfilePath := fs.String("gemfile", "Gemfile", "Path for new Gemfile")
+	gemspec := fs.Bool("gemspec", false, "Scaffold a gem project Gemfile (uses the gemspec directive)")
+	rails := fs.Bool("rails", false, "Scaffold a Rails application Gemfile")
+	rubyVersion := fs.String("ruby", "", "Ruby version for the `ruby` line (default: .ruby-version)")
+	force := fs.Bool("force", false, "Overwrite an existing Gemfile")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
-	// Check if Gemfile already exists
-	if _, err := os.Stat(*gemfilePath); err == nil {
-		return fmt.Errorf("%s already exists", *gemfilePath)
+	if *gemspec && *rails {
+		return fmt.Errorf("--gemspec and --rails cannot be combined")
 	}
 
-	// Get Ruby version if available
-	rubyVersion := detectRubyVersion()
-
-	// Create Gemfile content
-	content := `# frozen_string_literal: true
-
-source "https://rubygems.org"
-
-`
-	if rubyVersion != "" {
-		content += fmt.Sprintf("ruby \"%s\"\n\n", rubyVersion)
+	// Check if Gemfile already exists
+	if _, err := os.Stat(*gemfilePath); err == nil && !*force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", *gemfilePath)
 	}
 
-	content += `# gem "rails"
-`
+	opts := initOptions{
+		rubyVersion: *rubyVersion,
+		gemspec:     *gemspec,
+		rails:       *rails,
+	}
+	if opts.rubyVersion == "" {
+		opts.rubyVersion = detectRubyVersion()
+	}
 
 	// Write Gemfile
-	if err := os.WriteFile(*gemfilePath, []byte(content), 0644); err != nil {
+	if err := os.WriteFile(*gemfilePath, []byte(initGemfileContent(opts)), 0644); err != nil {
 		return fmt.Errorf("failed to write Gemfile: %w", err)
 	}
 
 	absPath, _ := filepath.Abs(*gemfilePath)
 	fmt.Printf("Writing new %s to %s\n", filepath.Base(*gemfilePath), absPath)
 
+	if opts.gemspec {
+		if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(*gemfilePath), "*.gemspec")); len(matches) == 0 {
+			fmt.Fprintln(os.Stderr, "Note: no .gemspec found next to the Gemfile yet; `ore lock` needs one for the gemspec directive")
+		}
+	}
+
 	return nil
 }
 
+// initGemfileContent renders the Gemfile for ore init.
+// Ruby developers: the --gemspec and --rails skeletons follow `bundle gem` and `rails new`.
+func initGemfileContent(opts initOptions) string {
+	var b strings.Builder
+
+	b.WriteString(`# frozen_string_literal: true
+
+source "https://rubygems.org"
+
+`)
+	if opts.rubyVersion != "" {
+		fmt.Fprintf(&b, "ruby \"%s\"\n\n", strings.TrimPrefix(opts.rubyVersion, "ruby-"))
+	}
+
+	switch {
+	case opts.gemspec:
+		b.WriteString(`# Specify your gem's dependencies in the .gemspec file
+gemspec
+
+group :development, :test do
+  gem "rake", "~> 13.0"
+  gem "minitest", "~> 5.16"
+  gem "rubocop", "~> 1.21"
+end
+`)
+	case opts.rails:
+		b.WriteString(`gem "rails", "~> 8.0"
+gem "propshaft"
+gem "puma", ">= 5.0"
+gem "sqlite3", ">= 2.1"
+gem "importmap-rails"
+gem "turbo-rails"
+gem "stimulus-rails"
+gem "jbuilder"
+gem "bootsnap", require: false
+gem "tzinfo-data", platforms: [:windows, :jruby]
+
+group :development, :test do
+  gem "debug", platforms: [:mri, :windows]
+  gem "brakeman", require: false
+  gem "rubocop-rails-omakase", require: false
+end
+
+group :development do
+  gem "web-console"
+end
+
+group :test do
+  gem "capybara"
+  gem "selenium-webdriver"
+end
+`)
+	default:
+		b.WriteString(`# gem "rails"
+`)
+	}
+
+	return b.String()
+}
+
 func detectRubyVersion() string {
 	// Try to detect Ruby version from .ruby-version file first
 	if data, err := os.ReadFile(".ruby-version"); err == nil {
@@ -3,11 +3,14 @@ package commands
 import (
 	"flag"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/logger"
 )
 
 type gemEntry struct {
@@ -15,14 +18,29 @@ type gemEntry struct {
 	version string
 	source  string
 	typ     string // "gem", "git", "path"
+	groups  []string
+	latest  string // Latest published version (only set with --outdated)
+}
+
+// listJSONEntry is the --json representation of a bundled gem
+type listJSONEntry struct {
+	Name          string   `json:"name"`
+	Version       string   `json:"version"`
+	Groups        []string `json:"groups"`
+	Source        string   `json:"source"`
+	LatestVersion string   `json:"latest_version,omitempty"`
 }
 
 // RunList implements the ore list command
 func RunList(args []string) error {
 	fs := flag.NewFlagSet("list", flag.ContinueOnError)
 	gemfilePath := fs.String("gemfile", defaultGemfilePath(), "Path to Gemfile")
-	verbose := fs.Bool("v", false, "Show gem sources")
+	verbose := fs.Bool("v", logger.Verbose(), "Show gem sources")
 	useTable := fs.Bool("table", false, "Display as table")
+	jsonOutput := fs.Bool("json", false, "Output as JSON")
+	nameOnly := fs.Bool("name-only", false, "Print only gem names, one per line")
+	outdatedOnly := fs.Bool("outdated", false, "Only list gems with newer versions available")
+	group := fs.String("group", "", "Only list gems in these groups (comma-separated), with the dependencies they pull in")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -89,13 +107,62 @@ func RunList(args []string) error {
 		return allGems[i].name < allGems[j].name
 	})
 
+	// Attach Gemfile groups (transitive gems inherit from the gems that pull them in)
+	err = EnrichGemsWithGroups(*gemfilePath, lock)
+	if err == nil {
+		groups := inheritedGroups(lock)
+		for i := range allGems {
+			allGems[i].groups = groups[allGems[i].name]
+		}
+	} else if *group != "" {
+		return fmt.Errorf("--group needs the Gemfile's groups: %w", err)
+	}
+
+	if *group != "" {
+		allGems = filterEntriesByGroup(allGems, strings.Split(*group, ","))
+	}
+
+	if *outdatedOnly {
+		allGems, err = filterOutdatedEntries(*gemfilePath, allGems)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *jsonOutput {
+		entries := make([]listJSONEntry, 0, len(allGems))
+		for _, gem := range allGems {
+			groups := gem.groups
+			if groups == nil {
+				groups = []string{}
+			}
+			entries = append(entries, listJSONEntry{
+				Name:          gem.name,
+				Version:       gem.version,
+				Groups:        groups,
+				Source:        gem.source,
+				LatestVersion: gem.latest,
+			})
+		}
+		return printJSON(entries)
+	}
+
+	if *nameOnly {
+		for _, gem := range allGems {
+			fmt.Println(gem.name)
+		}
+		return nil
+	}
+
 	// Print gems
 	if *useTable {
 		printGemsTable(allGems, *verbose)
 	} else {
 		fmt.Printf("Gems included in the bundle:\n")
 		for _, gem := range allGems {
-			if *verbose {
+			if gem.latest != "" {
+				fmt.Printf("  * %s (%s -> %s)\n", gem.name, gem.version, gem.latest)
+			} else if *verbose {
 				fmt.Printf("  * %s (%s) [%s]\n", gem.name, gem.version, gem.source)
 			} else {
 				fmt.Printf("  * %s (%s)\n", gem.name, gem.version)
@@ -108,6 +175,45 @@ func RunList(args []string) error {
 	return nil
 }
 
+// filterEntriesByGroup keeps gems in any of groups. Entries carry inherited groups,
+// so a group's transitive dependencies stay in the listing.
+func filterEntriesByGroup(gems []gemEntry, groups []string) []gemEntry {
+	var filtered []gemEntry
+	for _, gem := range gems {
+		for _, group := range groups {
+			if slices.Contains(gem.groups, strings.TrimSpace(group)) {
+				filtered = append(filtered, gem)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// filterOutdatedEntries keeps only gems with a newer published version,
+// using the same bulk version lookup as ore outdated
+func filterOutdatedEntries(gemfilePath string, gems []gemEntry) ([]gemEntry, error) {
+	outdated, err := LoadOutdatedGems(gemfilePath, OutdatedOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]string, len(outdated))
+	for _, gem := range outdated {
+		latest[gem.Name] = gem.LatestVersion
+	}
+
+	var filtered []gemEntry
+	for _, gem := range gems {
+		if version, ok := latest[gem.name]; ok && gem.typ == "gem" {
+			gem.latest = version
+			filtered = append(filtered, gem)
+		}
+	}
+
+	return filtered, nil
+}
+
 func printGemsTable(gems []gemEntry, verbose bool) {
 	// Define styles
 	headerStyle := lipgloss.NewStyle().
@@ -5,13 +5,22 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
-// Open opens a gem's source directory in the user's editor
-func Open(gemName, vendorDir string) error {
+// OpenOptions controls what ore open does once the gem is found
+type OpenOptions struct {
+	PrintPath bool // Only print the path (like `bundle info --path`), don't launch an editor
+}
+
+// Open opens a gem's source directory, or a file inside it, in the user's editor.
+// target is "<gem>", "<gem>/<file>" or "<gem>/<file>:<line>", e.g. "rack/lib/rack.rb:12".
+// Without an editor (or with PrintPath) it prints the path instead, so it works headless.
+func Open(target, vendorDir string, opts OpenOptions) error {
+	gemName, file, line := parseOpenTarget(target)
 	if gemName == "" {
 		return fmt.Errorf("gem name is required")
 	}
@@ -22,10 +31,29 @@ func Open(gemName, vendorDir string) error {
 		return err
 	}
 
+	path := gemPath
+	if file != "" {
+		// Only files inside the gem: no "..", no absolute paths
+		if !filepath.IsLocal(filepath.FromSlash(file)) {
+			return fmt.Errorf("%s is outside %s", file, filepath.Base(gemPath))
+		}
+		path = filepath.Join(gemPath, filepath.FromSlash(file))
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("%s not found in %s", file, filepath.Base(gemPath))
+		}
+	}
+
+	if opts.PrintPath {
+		fmt.Println(path)
+		return nil
+	}
+
 	// Get the editor
 	editor := getEditor()
 	if editor == "" {
-		return fmt.Errorf("no editor found. Set $EDITOR, $VISUAL, or $BUNDLER_EDITOR")
+		fmt.Println(path)
+		fmt.Fprintln(os.Stderr, "No editor configured; set $EDITOR, $VISUAL, or $BUNDLER_EDITOR to open it directly")
+		return nil
 	}
 
 	// Display what we're doing
@@ -33,11 +61,12 @@ func Open(gemName, vendorDir string) error {
 		Foreground(lipgloss.Color("86"))
 	fmt.Printf("%s %s in %s\n",
 		infoStyle.Render("Opening"),
-		gemName,
+		strings.TrimPrefix(path, filepath.Dir(gemPath)+string(filepath.Separator)),
 		editor)
 
-	// Execute the editor
-	cmd := exec.Command(editor, gemPath)
+	// Execute the editor; $EDITOR may carry arguments, e.g. "code --wait"
+	command := strings.Fields(editor)
+	cmd := exec.Command(command[0], append(command[1:], editorArgs(command[0], path, line)...)...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -45,6 +74,39 @@ func Open(gemName, vendorDir string) error {
 	return cmd.Run()
 }
 
+// parseOpenTarget splits "rack/lib/rack.rb:12" into gem, file inside the gem and line.
+// Gem names can't contain "/", so the first slash starts the file.
+func parseOpenTarget(target string) (gemName, file string, line int) {
+	gemName, file, _ = strings.Cut(target, "/")
+	if i := strings.LastIndex(file, ":"); i >= 0 {
+		if n, err := strconv.Atoi(file[i+1:]); err == nil && n > 0 {
+			file, line = file[:i], n
+		}
+	}
+	return gemName, file, line
+}
+
+// editorArgs returns the arguments that open path at line in editor.
+// Editors that don't understand a line number just get the path.
+func editorArgs(editor, path string, line int) []string {
+	if line <= 0 {
+		return []string{path}
+	}
+
+	switch strings.TrimSuffix(filepath.Base(editor), ".exe") {
+	case "vi", "vim", "nvim", "gvim", "mvim", "nano", "emacs", "emacsclient", "micro", "kak", "joe", "ne", "mg":
+		return []string{fmt.Sprintf("+%d", line), path}
+	case "code", "code-insiders", "codium", "cursor", "windsurf":
+		return []string{"--goto", fmt.Sprintf("%s:%d", path, line)}
+	case "subl", "zed", "hx", "helix":
+		return []string{fmt.Sprintf("%s:%d", path, line)}
+	case "mate":
+		return []string{"-l", strconv.Itoa(line), path}
+	default:
+		return []string{path}
+	}
+}
+
 // findGemPath locates the installation directory for a gem
 func findGemPath(gemName, vendorDir string) (string, error) {
 	// Walk the vendor directory to find matching gems
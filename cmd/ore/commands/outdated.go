@@ -5,11 +5,24 @@ import (
 	"fmt"
 	"os"
 	"runtime/pprof"
+	"strings"
 
 	"github.com/contriboss/ore-light/internal/logger"
 	"github.com/mattn/go-isatty"
 )
 
+// outdatedJSON is one gem in `ore outdated --json` output
+type outdatedJSON struct {
+	Name       string   `json:"name"`
+	Current    string   `json:"current"`
+	Latest     string   `json:"latest"`
+	Constraint string   `json:"constraint,omitempty"`
+	Reachable  bool     `json:"reachable"`
+	UpdateType string   `json:"update_type"`
+	Groups     []string `json:"groups"`
+	Changelog  string   `json:"changelog,omitempty"`
+}
+
 // RunOutdated implements the ore outdated command
 // Auto-detects TTY: shows TUI if interactive terminal, plain text if piped
 func RunOutdated(args []string) error {
@@ -17,6 +30,11 @@ func RunOutdated(args []string) error {
 	gemfilePath := fs.String("gemfile", defaultGemfilePath(), "Path to Gemfile")
 	plainText := fs.Bool("plain", false, "Force plain text output (no TUI)")
 	cpuProfile := fs.String("cpuprofile", "", "Write CPU profile to file")
+	strict := fs.Bool("strict", false, "Only report versions the Gemfile constraints allow")
+	offline := fs.Bool("offline", false, "Use the cached gem index only, without network access")
+	jsonOutput := fs.Bool("json", false, "Output as JSON")
+	group := fs.String("group", "", "Only report gems in this Gemfile group (e.g. production), in every output mode")
+	groupBy := fs.Bool("group-by", false, "Section the plain text output by Gemfile group (implies --plain)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -34,42 +52,70 @@ func RunOutdated(args []string) error {
 		defer pprof.StopCPUProfile()
 	}
 
+	opts := OutdatedOptions{Strict: *strict, Offline: *offline, Group: *group}
+	if *groupBy {
+		*plainText = true
+	}
+
 	// Auto-detect TTY: require both stdin and stdout to be terminals for the TUI
 	stdoutTTY := isatty.IsTerminal(os.Stdout.Fd())
 	stdinTTY := isatty.IsTerminal(os.Stdin.Fd())
 
-	if !*plainText && stdoutTTY && stdinTTY {
-		if err := RunOutdatedTUI(*gemfilePath); err == nil {
+	if !*plainText && !*jsonOutput && stdoutTTY && stdinTTY {
+		if err := RunOutdatedTUI(*gemfilePath, opts); err == nil {
 			return nil
 		} else {
 			logger.Warn("could not start interactive TUI, falling back to plain text output", "error", err)
 		}
-	} else if !*plainText && (!stdoutTTY || !stdinTTY) {
+	} else if !*plainText && !*jsonOutput && (!stdoutTTY || !stdinTTY) {
 		logger.Debug("interactive mode requires a TTY; falling back to plain text output")
 	}
 
 	// Plain text output (for pipes, scripts, or --plain flag)
 	logger.Debug("checking for outdated gems...")
 
-	gems, err := LoadOutdatedGems(*gemfilePath)
+	gems, err := LoadOutdatedGems(*gemfilePath, opts)
 	if err != nil {
 		return err
 	}
 
+	if *jsonOutput {
+		results := make([]outdatedJSON, len(gems))
+		for i, gem := range gems {
+			results[i] = outdatedJSON{
+				Name:       gem.Name,
+				Current:    gem.CurrentVersion,
+				Latest:     gem.LatestVersion,
+				Constraint: gem.Constraint,
+				Reachable:  gem.Reachable,
+				UpdateType: strings.ToLower(gem.UpdateType.String()),
+				Groups:     gem.Groups,
+				Changelog:  gem.Changelog,
+			}
+		}
+		return printJSON(results)
+	}
+
 	if len(gems) == 0 {
-		fmt.Println("✨ All gems are up to date!")
+		if *group != "" {
+			logger.Printf("✨ All gems in the %s group are up to date!\n", *group)
+			return nil
+		}
+		logger.Println("✨ All gems are up to date!")
 		return nil
 	}
 
-	// Display outdated gems in plain text
-	for _, gem := range gems {
-		constraint := gem.Constraint
-		if constraint == "" {
-			constraint = "(no constraint)"
+	// Display outdated gems in plain text, sectioned by group with --group-by
+	if *groupBy {
+		for i, name := range collectAvailableGroups(gems) {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("===== Group \"%s\" =====\n", name)
+			printOutdatedGems(filterOutdatedByGroup(gems, name))
 		}
-
-		fmt.Printf("  * %s (newest %s, installed %s, requested %s)\n",
-			gem.Name, gem.LatestVersion, gem.CurrentVersion, constraint)
+	} else {
+		printOutdatedGems(gems)
 	}
 
 	fmt.Printf("\n%d gem(s) can be updated.\n", len(gems))
@@ -77,3 +123,25 @@ func RunOutdated(args []string) error {
 
 	return nil
 }
+
+// printOutdatedGems lists gems in the plain text format
+func printOutdatedGems(gems []OutdatedGem) {
+	for _, gem := range gems {
+		constraint := gem.Constraint
+		if constraint == "" {
+			constraint = "(no constraint)"
+		}
+
+		// Without --strict, flag versions the Gemfile constraint keeps out of reach
+		blocked := ""
+		if !gem.Reachable {
+			blocked = ", not allowed by requested"
+		}
+
+		fmt.Printf("  * %s (newest %s, installed %s, requested %s%s)\n",
+			gem.Name, gem.LatestVersion, gem.CurrentVersion, constraint, blocked)
+		if gem.Changelog != "" {
+			fmt.Printf("    changelog: %s\n", hyperlink(gem.Changelog))
+		}
+	}
+}
@@ -2,13 +2,22 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/contriboss/gemfile-go/gemfile"
 	"github.com/contriboss/gemfile-go/lockfile"
 	"github.com/contriboss/ore-light/internal/compactindex"
+	"github.com/contriboss/ore-light/internal/httpclient"
+	"github.com/contriboss/ore-light/internal/logger"
+	"github.com/contriboss/ore-light/internal/resolver"
 )
 
 // UpdateType represents the severity of a gem update
@@ -40,54 +49,63 @@ type OutdatedGem struct {
 	CurrentVersion string
 	LatestVersion  string
 	Constraint     string
+	Reachable      bool // LatestVersion satisfies Constraint, so `ore update` could install it
 	UpdateType     UpdateType
 	Groups         []string // Gem groups (default, development, test, etc.)
+	Changelog      string   // changelog_uri from the gem's metadata, if it publishes one
 	Selected       bool     // Selection state for multi-select update
 }
 
+// OutdatedOptions controls which versions ore outdated reports
+type OutdatedOptions struct {
+	Strict  bool   // Report the newest version the Gemfile constraint allows, not the absolute newest
+	Offline bool   // Use only the cached compact index versions file, never the network
+	Group   string // Only report gems in this Gemfile group; "" for all
+}
+
+// changelogWorkers caps concurrent rubygems.org metadata requests
+const changelogWorkers = 8
+
 // versionCheckResult holds the result of checking a gem's latest version
 type versionCheckResult struct {
 	gemName       string
 	latestVersion string
+	versions      []string // Every non-yanked version, platform suffixes stripped
 	err           error
 }
 
 // checkVersionsParallel fetches latest versions using the bulk versions file
 // This is MUCH faster than individual gem info files - one HTTP call instead of N
 func checkVersionsParallel(ctx context.Context, client *compactindex.Client, gemNames []string) map[string]versionCheckResult {
-	results := make(map[string]versionCheckResult)
-
 	// Fetch the versions file once (contains ALL gems)
 	// This uses cache and only makes HTTP call if stale (>1 hour)
 	allVersions, err := client.GetVersions(ctx)
 	if err != nil {
 		// If we can't get versions file, return error for all gems
+		results := make(map[string]versionCheckResult)
 		for _, name := range gemNames {
 			results[name] = versionCheckResult{gemName: name, err: err}
 		}
 		return results
 	}
 
-	// Build a map for quick lookup
-	versionMap := make(map[string]string)
+	return versionResults(allVersions, gemNames)
+}
+
+// versionResults looks up gemNames in the parsed versions file
+func versionResults(allVersions []compactindex.VersionsEntry, gemNames []string) map[string]versionCheckResult {
+	results := make(map[string]versionCheckResult)
+
+	releasedMap := make(map[string][]string)
 	for _, entry := range allVersions {
-		if len(entry.Versions) > 0 {
-			// Versions are sorted oldest first (compact-index is append-only)
-			// Iterate from the END to get latest non-yanked version
-			for i := len(entry.Versions) - 1; i >= 0; i-- {
-				v := entry.Versions[i]
-				if !strings.HasPrefix(v, "-") {
-					versionMap[entry.Name] = v
-					break
-				}
-			}
-		}
+		releasedMap[entry.Name] = append(releasedMap[entry.Name], entry.Versions...)
 	}
 
 	// Look up each gem we need
 	for _, name := range gemNames {
-		if latestVersion, ok := versionMap[name]; ok {
-			results[name] = versionCheckResult{gemName: name, latestVersion: latestVersion}
+		if entries, ok := releasedMap[name]; ok {
+			versions := releasedVersions(entries)
+			results[name] = versionCheckResult{gemName: name, latestVersion: newestVersion(versions), versions: versions}
 		} else {
 			results[name] = versionCheckResult{gemName: name, err: fmt.Errorf("gem not found in registry")}
 		}
@@ -96,6 +114,121 @@ func checkVersionsParallel(ctx context.Context, client *compactindex.Client, gem
 	return results
 }
 
+// releasedVersions turns versions file entries (oldest first, "-1.0.0" marks a yank)
+// into the versions still available, without platform suffixes or duplicates
+func releasedVersions(entries []string) []string {
+	yanked := make(map[string]bool)
+	for _, v := range entries {
+		if rest, ok := strings.CutPrefix(v, "-"); ok {
+			yanked[rest] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var versions []string
+	for _, v := range entries {
+		if strings.HasPrefix(v, "-") || yanked[v] {
+			continue
+		}
+		// "1.16.0-x86_64-linux" → "1.16.0"; Ruby versions never contain "-"
+		v, _, _ = strings.Cut(v, "-")
+		if !seen[v] {
+			seen[v] = true
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+// newestVersion returns the highest released version, or "" if there is none.
+// The versions file is in publish order, so a backport (6.1.8 after 7.0.0) can come last.
+// Prereleases only count when no final release exists.
+func newestVersion(versions []string) string {
+	var best, bestPrerelease *resolver.SemverVersion
+	for _, v := range versions {
+		version, err := resolver.NewSemverVersion(v)
+		if err != nil {
+			continue
+		}
+		if isPrerelease(v) {
+			if bestPrerelease == nil || version.Sort(bestPrerelease) > 0 {
+				bestPrerelease = version
+			}
+		} else if best == nil || version.Sort(best) > 0 {
+			best = version
+		}
+	}
+	if best == nil {
+		best = bestPrerelease
+	}
+	if best == nil {
+		return ""
+	}
+	return best.String()
+}
+
+// newestSatisfying returns the highest version meeting condition, or "".
+// Prereleases only count when the installed version is one too, like Bundler.
+func newestSatisfying(versions []string, condition *resolver.SemverCondition, current string) string {
+	allowPrerelease := isPrerelease(current)
+
+	var best *resolver.SemverVersion
+	for _, v := range versions {
+		if !allowPrerelease && isPrerelease(v) {
+			continue
+		}
+		version, err := resolver.NewSemverVersion(v)
+		if err != nil || !condition.Satisfies(version) {
+			continue
+		}
+		if best == nil || version.Sort(best) > 0 {
+			best = version
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.String()
+}
+
+// describeAge renders how old a cache is, e.g. "3 hours ago"
+func describeAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return pluralAge(int(age/time.Minute), "minute")
+	case age < 48*time.Hour:
+		return pluralAge(int(age/time.Hour), "hour")
+	default:
+		return pluralAge(int(age/(24*time.Hour)), "day")
+	}
+}
+
+func pluralAge(n int, unit string) string {
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s ago", n, unit)
+}
+
+// isNewer reports whether version a sorts after b
+func isNewer(a, b string) bool {
+	va, errA := resolver.NewSemverVersion(a)
+	vb, errB := resolver.NewSemverVersion(b)
+	if errA != nil || errB != nil {
+		return a != b
+	}
+	return va.Sort(vb) > 0
+}
+
+// isPrerelease reports whether a RubyGems version has a letter segment ("7.0.0.rc1")
+func isPrerelease(version string) bool {
+	return strings.ContainsFunc(version, func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+	})
+}
+
 // detectUpdateType determines if an update is major, minor, or patch
 func detectUpdateType(current, latest string) UpdateType {
 	// Parse semver: major.minor.patch
@@ -134,7 +267,7 @@ func detectUpdateType(current, latest string) UpdateType {
 }
 
 // LoadOutdatedGems loads all outdated gems from Gemfile and lockfile
-func LoadOutdatedGems(gemfilePath string) ([]OutdatedGem, error) {
+func LoadOutdatedGems(gemfilePath string, opts OutdatedOptions) ([]OutdatedGem, error) {
 	// Find the lockfile
 	lockfilePath, err := findLockfilePath(gemfilePath)
 	if err != nil {
@@ -154,21 +287,24 @@ func LoadOutdatedGems(gemfilePath string) ([]OutdatedGem, error) {
 		return nil, fmt.Errorf("failed to parse lockfile: %w", err)
 	}
 
-	// Build map of gem name -> constraint and groups
+	// Build map of gem name -> constraint
 	constraints := make(map[string]string)
-	gemGroups := make(map[string][]string)
 	for _, dep := range parsed.Dependencies {
 		if len(dep.Constraints) > 0 {
-			constraints[dep.Name] = dep.Constraints[0]
-		}
-		// Store groups, default to ["default"] if empty
-		if len(dep.Groups) > 0 {
-			gemGroups[dep.Name] = dep.Groups
-		} else {
-			gemGroups[dep.Name] = []string{"default"}
+			constraints[dep.Name] = strings.Join(dep.Constraints, ", ")
 		}
 	}
 
+	// Dependencies belong to the groups of the Gemfile gems that pull them in, as for ore install
+	if err := EnrichGemsWithGroups(gemfilePath, lock); err != nil {
+		return nil, err
+	}
+	gemGroups := inheritedGroups(lock)
+
+	if opts.Group != "" && !hasGroup(gemGroups, opts.Group) {
+		return nil, fmt.Errorf("the Gemfile has no gems in group %q", opts.Group)
+	}
+
 	// Determine source URL from Gemfile, fallback to rubygems.org
 	sourceURL := "https://rubygems.org"
 	for _, src := range parsed.Sources {
@@ -193,7 +329,20 @@ func LoadOutdatedGems(gemfilePath string) ([]OutdatedGem, error) {
 	}
 
 	// Check all versions
-	results := checkVersionsParallel(ctx, client, gemNames)
+	var results map[string]versionCheckResult
+	if opts.Offline {
+		allVersions, fetchedAt, err := client.CachedVersions()
+		if errors.Is(err, compactindex.ErrNotCached) {
+			return nil, fmt.Errorf("no cached gem index for %s; run `ore outdated` once while online to use --offline", httpclient.RedactURL(sourceURL))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cached gem index: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "📴 Offline: using the gem index for %s cached %s\n", httpclient.RedactURL(sourceURL), describeAge(time.Since(fetchedAt)))
+		results = versionResults(allVersions, gemNames)
+	} else {
+		results = checkVersionsParallel(ctx, client, gemNames)
+	}
 
 	// Check if all results have errors (likely network issue)
 	errorCount := 0
@@ -222,14 +371,26 @@ func LoadOutdatedGems(gemfilePath string) ([]OutdatedGem, error) {
 			continue
 		}
 
-		// Compare versions
-		if result.latestVersion != spec.Version {
-			constraint := constraints[spec.Name]
-			if constraint == "" {
-				constraint = ""
+		constraint := constraints[spec.Name]
+		latest := result.latestVersion
+		reachable := true
+		if constraint != "" {
+			if condition, err := resolver.NewSemverCondition(constraint); err == nil {
+				allowed := newestSatisfying(result.versions, condition, spec.Version)
+				reachable = allowed == latest
+				// --strict: the newest version `ore update` could actually install
+				if opts.Strict {
+					if allowed == "" || !isNewer(allowed, spec.Version) {
+						continue
+					}
+					latest, reachable = allowed, true
+				}
 			}
+		}
 
-			// Get groups, default to ["default"] if not found
+		// Compare versions
+		if latest != spec.Version {
+			// Get groups, default to ["default"] for gems no Gemfile gem depends on
 			groups := gemGroups[spec.Name]
 			if len(groups) == 0 {
 				groups = []string{"default"}
@@ -238,14 +399,76 @@ func LoadOutdatedGems(gemfilePath string) ([]OutdatedGem, error) {
 			outdated = append(outdated, OutdatedGem{
 				Name:           spec.Name,
 				CurrentVersion: spec.Version,
-				LatestVersion:  result.latestVersion,
+				LatestVersion:  latest,
 				Constraint:     constraint,
-				UpdateType:     detectUpdateType(spec.Version, result.latestVersion),
+				Reachable:      reachable,
+				UpdateType:     detectUpdateType(spec.Version, latest),
 				Groups:         groups,
 				Selected:       false, // Initially not selected
 			})
 		}
 	}
 
+	if opts.Group != "" {
+		outdated = filterOutdatedByGroup(outdated, opts.Group)
+	}
+
+	// Release notes links come from the rubygems.org API, so private sources are skipped
+	if !opts.Offline && isRubygemsOrg(sourceURL) {
+		fetchChangelogs(ctx, outdated)
+	}
+
 	return outdated, nil
 }
+
+// hasGroup reports whether any gem in gemGroups belongs to group
+func hasGroup(gemGroups map[string][]string, group string) bool {
+	for _, groups := range gemGroups {
+		if slices.Contains(groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterOutdatedByGroup keeps the gems in group, like the TUI's group filter
+func filterOutdatedByGroup(gems []OutdatedGem, group string) []OutdatedGem {
+	var filtered []OutdatedGem
+	for _, gem := range gems {
+		if slices.Contains(gem.Groups, group) {
+			filtered = append(filtered, gem)
+		}
+	}
+	return filtered
+}
+
+// fetchChangelogs fills in Changelog for each gem, fetching metadata concurrently.
+// Gems without a changelog_uri, or whose metadata can't be fetched, are left blank.
+func fetchChangelogs(ctx context.Context, gems []OutdatedGem) {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, changelogWorkers)
+	for i := range gems {
+		wg.Go(func() {
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			remoteGem, err := fetchRemoteGem(ctx, gems[i].Name)
+			if err != nil {
+				logger.Debug("could not fetch gem metadata", "gem", gems[i].Name, "error", err)
+				return
+			}
+			gems[i].Changelog = remoteGem.ChangelogURI
+		})
+	}
+	wg.Wait()
+}
+
+// isRubygemsOrg reports whether sourceURL points at rubygems.org
+func isRubygemsOrg(sourceURL string) bool {
+	parsed, err := url.Parse(sourceURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	return host == "rubygems.org" || host == "www.rubygems.org"
+}
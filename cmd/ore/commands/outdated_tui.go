@@ -47,6 +47,10 @@ var (
 	constraintStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("240")).
 			Italic(true)
+
+	blockedConstraintStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("208")). // Orange
+				Italic(true)
 )
 
 // tableRow represents a row in the table
@@ -150,7 +154,10 @@ func buildTableRows(gems []OutdatedGem) ([]table.Row, []tableRow) {
 
 		// Constraint
 		constraint := ""
-		if gem.Constraint != "" {
+		if gem.Constraint != "" && !gem.Reachable {
+			// The latest version is outside the Gemfile constraint
+			constraint = blockedConstraintStyle.Render(gem.Constraint + " ✗")
+		} else if gem.Constraint != "" {
 			constraint = constraintStyle.Render(gem.Constraint)
 		}
 
@@ -163,6 +170,9 @@ func buildTableRows(gems []OutdatedGem) ([]table.Row, []tableRow) {
 			Foreground(lipgloss.Color("243")).
 			Render(groupsStr)
 
+		// Changelog, without the scheme so more of it fits in the column
+		changelog := strings.TrimPrefix(strings.TrimPrefix(gem.Changelog, "https://"), "http://")
+
 		rows = append(rows, table.Row{
 			checkbox,
 			gem.Name,
@@ -170,6 +180,7 @@ func buildTableRows(gems []OutdatedGem) ([]table.Row, []tableRow) {
 			updateTypeStr,
 			constraint,
 			groupsDisplay,
+			changelog,
 		})
 		rowMapping = append(rowMapping, tableRow{gemIndex: i, isHeader: false})
 	}
@@ -214,6 +225,7 @@ func initialOutdatedModel(gems []OutdatedGem) outdatedModel {
 		{Title: "Type", Width: 8},        // MAJOR/MINOR/PATCH
 		{Title: "Constraint", Width: 12}, // Version constraint
 		{Title: "Groups", Width: 15},     // Gem groups
+		{Title: "Changelog", Width: 40},  // changelog_uri
 	}
 
 	t := table.New(
@@ -534,6 +546,9 @@ func (m outdatedModel) renderPreviewModal() string {
 	var majors, minors, patches []string
 	for _, gem := range selected {
 		line := fmt.Sprintf("  • %s: %s → %s", gem.Name, gem.CurrentVersion, gem.LatestVersion)
+		if gem.Changelog != "" {
+			line += "\n    " + gem.Changelog
+		}
 		switch gem.UpdateType {
 		case UpdateMajor:
 			majors = append(majors, majorUpdateStyle.Render(line))
@@ -573,9 +588,9 @@ func (m outdatedModel) renderPreviewModal() string {
 }
 
 // RunOutdatedTUI starts the interactive TUI for viewing outdated gems
-func RunOutdatedTUI(gemfilePath string) error {
+func RunOutdatedTUI(gemfilePath string, opts OutdatedOptions) error {
 	// Load outdated gems
-	gems, err := LoadOutdatedGems(gemfilePath)
+	gems, err := LoadOutdatedGems(gemfilePath, opts)
 	if err != nil {
 		return err
 	}
@@ -7,16 +7,30 @@ import (
 	"os/exec"
 	"regexp"
 	"runtime"
+	"strings"
 
 	"github.com/contriboss/gemfile-go/gemfile"
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/ruby"
 )
 
+// platformInfo is the `ore platform --json` output
+type platformInfo struct {
+	Platform          string   `json:"platform"`
+	RubyEngine        string   `json:"ruby_engine"`
+	RubyVersion       string   `json:"ruby_version"`
+	RubyRequirement   string   `json:"ruby_requirement,omitempty"`
+	LockfilePlatforms []string `json:"lockfile_platforms"`
+	MatchingPlatforms []string `json:"matching_platforms"`
+	PlatformMatches   bool     `json:"platform_matches"`
+}
+
 // RunPlatform implements the ore platform command
 func RunPlatform(args []string) error {
 	fs := flag.NewFlagSet("platform", flag.ContinueOnError)
 	gemfilePath := fs.String("gemfile", defaultGemfilePath(), "Path to Gemfile")
-	rubyOnly := fs.Bool("ruby", false, "Display only Ruby version requirement")
+	rubyOnly := fs.Bool("ruby", false, "Display only the Ruby in use: engine, version and RubyGems platform")
+	jsonOutput := fs.Bool("json", false, "Output platform information as JSON")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -27,62 +41,79 @@ func RunPlatform(args []string) error {
 		return fmt.Errorf("failed to find lockfile: %w", err)
 	}
 
-	// Get current platform
-	currentPlatform := detectCurrentPlatform()
-
-	if *rubyOnly {
-		// Just show Ruby requirement from Gemfile
-		parser := gemfile.NewGemfileParser(*gemfilePath)
-		parsed, err := parser.Parse()
-		if err != nil {
-			return fmt.Errorf("failed to parse Gemfile: %w", err)
-		}
-
-		if parsed.RubyVersion != "" {
-			fmt.Println(parsed.RubyVersion)
-		}
-		return nil
+	engine := ruby.DetectEngine()
+	info := platformInfo{
+		Platform:          detectCurrentPlatform(),
+		RubyEngine:        engine.Name,
+		RubyVersion:       engine.Version,
+		LockfilePlatforms: []string{},
+		MatchingPlatforms: []string{},
 	}
 
 	// Parse Gemfile for Ruby requirement
-	var rubyRequirement string
 	parser := gemfile.NewGemfileParser(*gemfilePath)
 	parsed, err := parser.Parse()
 	if err == nil && parsed.RubyVersion != "" {
-		rubyRequirement = parsed.RubyVersion
+		info.RubyRequirement = parsed.RubyVersion
 	}
 
 	// Parse lockfile for platforms
-	var platforms []string
 	if _, err := os.Stat(lockfilePath); err == nil {
 		lock, err := lockfile.ParseFile(lockfilePath)
 		if err == nil {
-			platforms = lock.Platforms
+			info.LockfilePlatforms = lock.Platforms
+		}
+	}
+	for _, platform := range info.LockfilePlatforms {
+		if lockPlatformMatches(platform, info.Platform, engine) {
+			info.MatchingPlatforms = append(info.MatchingPlatforms, platform)
+		}
+	}
+	info.PlatformMatches = len(info.MatchingPlatforms) > 0
+
+	if *jsonOutput {
+		return printJSON(info)
+	}
+
+	if *rubyOnly {
+		fmt.Printf("Engine: %s\n", info.RubyEngine)
+		fmt.Printf("Version: %s\n", info.RubyVersion)
+		fmt.Printf("Platform: %s\n", info.Platform)
+		if info.RubyRequirement != "" {
+			fmt.Printf("Gemfile requires: ruby %s\n", info.RubyRequirement)
 		}
+		return nil
 	}
 
 	// Display information
-	fmt.Printf("Your platform is: %s\n", currentPlatform)
+	fmt.Printf("Your platform is: %s\n", info.Platform)
 
-	if len(platforms) > 0 {
+	if len(info.LockfilePlatforms) > 0 {
 		fmt.Println("\nYour app has gems that work on these platforms:")
-		for _, platform := range platforms {
+		for _, platform := range info.LockfilePlatforms {
 			fmt.Printf("* %s\n", platform)
 		}
+
+		if info.PlatformMatches {
+			fmt.Printf("\nYour platform matches: %s\n", strings.Join(info.MatchingPlatforms, ", "))
+		} else {
+			fmt.Printf("\nNone of the lockfile's platforms match %s, so platform-specific gems won't be installed.\n", info.Platform)
+			fmt.Printf("Add it with: ore lock --add-platform %s\n", info.Platform)
+		}
 	}
 
-	if rubyRequirement != "" {
+	if info.RubyRequirement != "" {
 		fmt.Println("\nYour Gemfile specifies a Ruby version requirement:")
-		fmt.Printf("* ruby %s\n", rubyRequirement)
+		fmt.Printf("* ruby %s\n", info.RubyRequirement)
 
 		// Check if current Ruby matches
 		currentRubyVersion := detectCurrentRubyVersion()
 		if currentRubyVersion != "" {
-			if currentRubyVersion == rubyRequirement {
+			if currentRubyVersion == info.RubyRequirement {
 				fmt.Println("\nYour current platform satisfies the Ruby version requirement.")
 			} else {
 				fmt.Printf("\nYour Ruby version is %s, but your Gemfile specified %s\n",
-					currentRubyVersion, rubyRequirement)
+					currentRubyVersion, info.RubyRequirement)
 			}
 		}
 	} else {
@@ -92,9 +123,30 @@ func RunPlatform(args []string) error {
 	return nil
 }
 
+// lockPlatformMatches reports whether gems locked for platform install on current.
+// "ruby" is pure Ruby and works everywhere, "java" needs JRuby, and variants match
+// on arch and OS (x86_64-linux-gnu matches x86_64-linux, arm64-darwin-24 matches arm64-darwin24).
+func lockPlatformMatches(platform, current string, engine ruby.Engine) bool {
+	switch platform {
+	case "ruby":
+		return true
+	case "java":
+		return engine.Name == ruby.EngineJRuby
+	case current:
+		return true
+	}
+
+	lockParts := strings.Split(platform, "-")
+	currentParts := strings.Split(current, "-")
+	if len(lockParts) < 2 || len(currentParts) < 2 {
+		return false
+	}
+	return lockParts[0] == currentParts[0] && strings.TrimRight(lockParts[1], "0123456789") == strings.TrimRight(currentParts[1], "0123456789")
+}
+
 func detectCurrentPlatform() string {
 	// Try to get Ruby platform first
-	cmd := exec.Command("ruby", "-e", "puts RUBY_PLATFORM")
+	cmd := exec.Command(ruby.Binary(), "-e", "puts RUBY_PLATFORM")
 	output, err := cmd.Output()
 	if err == nil {
 		platform := regexp.MustCompile(`\s+`).ReplaceAllString(string(output), "")
@@ -121,7 +173,7 @@ func detectCurrentPlatform() string {
 }
 
 func detectCurrentRubyVersion() string {
-	cmd := exec.Command("ruby", "-e", "puts RUBY_VERSION")
+	cmd := exec.Command(ruby.Binary(), "-e", "puts RUBY_VERSION")
 	output, err := cmd.Output()
 	if err == nil {
 		version := regexp.MustCompile(`\s+`).ReplaceAllString(string(output), "")
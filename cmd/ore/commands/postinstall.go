@@ -4,17 +4,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/contriboss/gemfile-go/gemfile"
+	"github.com/contriboss/ore-light/internal/config"
 )
 
 // PostInstallMessage represents a gem's post-install message
 type PostInstallMessage struct {
-	GemName string
-	Version string
-	Message string
+	GemName string `json:"gem"`
+	Version string `json:"version"`
+	Message string `json:"message"`
 }
 
 // ReadPostInstallMessages reads post-install messages from installed gemspecs
@@ -86,6 +88,58 @@ func readMessagesFromSpecDir(specDir string) ([]PostInstallMessage, error) {
 	return messages, nil
 }
 
+// FilterPostInstallMessages keeps messages from gems installed in this run
+// (full names like "rails-7.1.0"; nil keeps every gem), drops gems silenced with
+// `bundle config ignore_messages`, and shows each distinct message only once
+func FilterPostInstallMessages(messages []PostInstallMessage, installedGems []string, ignore config.IgnoreMessages) []PostInstallMessage {
+	installed := make(map[string]bool, len(installedGems))
+	for _, fullName := range installedGems {
+		installed[fullName] = true
+	}
+
+	sorted := append([]PostInstallMessage(nil), messages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].GemName != sorted[j].GemName {
+			return sorted[i].GemName < sorted[j].GemName
+		}
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	seen := make(map[string]bool)
+	filtered := []PostInstallMessage{}
+	for _, msg := range sorted {
+		if installedGems != nil && !wasInstalled(installed, msg) {
+			continue
+		}
+		if ignore.Ignores(msg.GemName) {
+			continue
+		}
+
+		text := strings.TrimSpace(msg.Message)
+		if seen[text] {
+			continue
+		}
+		seen[text] = true
+		filtered = append(filtered, msg)
+	}
+
+	return filtered
+}
+
+// wasInstalled matches a message's gem against installed full names, with or without a platform suffix
+func wasInstalled(installed map[string]bool, msg PostInstallMessage) bool {
+	fullName := msg.GemName + "-" + msg.Version
+	if installed[fullName] {
+		return true
+	}
+	for name := range installed {
+		if strings.HasPrefix(name, fullName+"-") {
+			return true
+		}
+	}
+	return false
+}
+
 // DisplayPostInstallMessages displays post-install messages with nice formatting
 func DisplayPostInstallMessages(messages []PostInstallMessage) {
 	if len(messages) == 0 {
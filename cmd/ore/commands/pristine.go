@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,26 +9,67 @@ import (
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/errdefs"
+	"github.com/contriboss/ore-light/internal/extensions"
 	"github.com/contriboss/ore-light/internal/geminstall"
+	"github.com/contriboss/ore-light/internal/resolver"
+	"github.com/contriboss/ore-light/internal/ruby"
 )
 
+// PristineOptions configures ore pristine
+type PristineOptions struct {
+	All       bool                    // Restore every gem in the lockfile
+	CacheDirs []string                // Gem caches to search, in order (ore cache first)
+	VendorDir string                  // Installed gems
+	ExtConfig *extensions.BuildConfig // nil skips rebuilding native extensions
+}
+
+// pristineTarget is a locked gem that can be restored, regardless of its source
+type pristineTarget struct {
+	name     string
+	fullName string
+	version  string
+	source   string                 // "rubygems", "git", or "path"
+	restore  func() (string, error) // Restores the gem and returns its install dir
+}
+
+// installed reports whether the target is in vendorDir, i.e. ore install put it there
+// for this platform and the groups it installed
+func (t pristineTarget) installed(vendorDir string) bool {
+	info, err := os.Stat(filepath.Join(vendorDir, "gems", t.fullName))
+	return err == nil && info.IsDir()
+}
+
 // Pristine restores gems to their pristine condition using pure Go
-func Pristine(gemNames []string, lockfilePath, cacheDir, vendorDir string) error {
+//
+// Ruby developers: Like `bundle pristine` - regular gems are re-extracted from
+// the cache, git gems re-exported at the locked revision, path gems re-copied
+func Pristine(gemNames []string, lockfilePath string, opts PristineOptions) error {
 	// Parse lockfile to get gem info
 	lock, err := lockfile.ParseFile(lockfilePath)
 	if err != nil {
 		return fmt.Errorf("failed to parse Gemfile.lock: %w", err)
 	}
 
-	// If no gems specified, require explicit gem names (like Bundler does)
-	if len(gemNames) == 0 {
-		return fmt.Errorf("usage: ore pristine <gem> [<gem>...]\n\nRestores specified gems to pristine condition")
+	// Without --all, require explicit gem names
+	if !opts.All && len(gemNames) == 0 {
+		return fmt.Errorf("usage: ore pristine [--all] <gem> [<gem>...]\n\nRestores specified gems to pristine condition")
 	}
 
-	// Build map of available gems
-	gemMap := make(map[string]*lockfile.GemSpec)
-	for i := range lock.GemSpecs {
-		gemMap[lock.GemSpecs[i].Name] = &lock.GemSpecs[i]
+	// Gems copied by `ore cache`/vendor installs are also valid sources
+	cacheDirs := append(append([]string{}, opts.CacheDirs...), filepath.Join(opts.VendorDir, "cache"))
+	targets := collectPristineTargets(lock, cacheDirs, opts.VendorDir)
+	targetMap := make(map[string]pristineTarget, len(targets))
+	var installedTargets []pristineTarget
+	for _, target := range targets {
+		// Of a gem's platform variants, the installed one is the one to restore
+		installed := target.installed(opts.VendorDir)
+		if existing, ok := targetMap[target.name]; !ok || (installed && !existing.installed(opts.VendorDir)) {
+			targetMap[target.name] = target
+		}
+		if installed {
+			installedTargets = append(installedTargets, target)
+		}
 	}
 
 	// Styles
@@ -40,23 +82,44 @@ func Pristine(gemNames []string, lockfilePath, cacheDir, vendorDir string) error
 
 	var restored, failed int
 
-	// Process each gem
-	for _, gemName := range gemNames {
-		gemSpec, found := gemMap[gemName]
-		if !found {
-			fmt.Fprintf(os.Stderr, "%s Gem %q not found in Gemfile.lock\n",
-				errorStyle.Render("✗"),
-				gemName)
-			failed++
-			continue
+	// --all restores what install put in vendorDir, skipping gems for other platforms
+	// and excluded groups that the lockfile also lists
+	selected := installedTargets
+	if !opts.All {
+		selected = nil
+		for _, gemName := range gemNames {
+			target, found := targetMap[gemName]
+			if !found {
+				fmt.Fprintf(os.Stderr, "%s Gem %q not found in Gemfile.lock\n",
+					errorStyle.Render("✗"),
+					gemName)
+				failed++
+				continue
+			}
+			selected = append(selected, target)
 		}
+	}
 
-		fmt.Printf("Restoring %s (%s)...\n",
-			gemStyle.Render(gemSpec.Name),
-			gemSpec.Version)
+	var extBuilder *extensions.Builder
+	if opts.ExtConfig != nil && !opts.ExtConfig.SkipExtensions {
+		extBuilder = extensions.NewBuilder(opts.ExtConfig)
+	}
+	engine := ruby.DetectEngine()
+	ctx := context.Background()
 
-		// Restore the gem using pure Go
-		if err := restoreGemPureGo(*gemSpec, cacheDir, vendorDir); err != nil {
+	// Process each gem
+	for _, target := range selected {
+		source := ""
+		if target.source != "rubygems" {
+			source = fmt.Sprintf(" [%s]", target.source)
+		}
+		fmt.Printf("Restoring %s (%s)%s...\n",
+			gemStyle.Render(target.name),
+			target.version,
+			source)
+
+		destDir, err := target.restore()
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "  %s Failed: %v\n",
 				errorStyle.Render("✗"),
 				err)
@@ -64,6 +127,23 @@ func Pristine(gemNames []string, lockfilePath, cacheDir, vendorDir string) error
 			continue
 		}
 
+		// Rebuild native extensions removed along with the old install
+		if extBuilder != nil {
+			extResult, err := extBuilder.BuildExtensions(ctx, destDir, target.fullName, engine)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  %s Extension build failed: %v\n",
+					errorStyle.Render("✗"),
+					err)
+				failed++
+				continue
+			}
+			if !extResult.Skipped && len(extResult.Extensions) > 0 {
+				fmt.Printf("  %s Rebuilt %d native extension(s)\n",
+					successStyle.Render("✓"),
+					len(extResult.Extensions))
+			}
+		}
+
 		fmt.Printf("  %s Restored successfully\n",
 			successStyle.Render("✓"))
 		restored++
@@ -81,16 +161,82 @@ func Pristine(gemNames []string, lockfilePath, cacheDir, vendorDir string) error
 	return nil
 }
 
+// collectPristineTargets lists every gem in the lockfile with a restore action for its source
+func collectPristineTargets(lock *lockfile.Lockfile, cacheDirs []string, vendorDir string) []pristineTarget {
+	var targets []pristineTarget
+
+	for _, spec := range lock.GemSpecs {
+		targets = append(targets, pristineTarget{
+			name:     spec.Name,
+			fullName: spec.FullName(),
+			version:  spec.Version,
+			source:   "rubygems",
+			restore: func() (string, error) {
+				if err := restoreGemPureGo(spec, cacheDirs, vendorDir); err != nil {
+					return "", err
+				}
+				return filepath.Join(vendorDir, "gems", spec.FullName()), nil
+			},
+		})
+	}
+
+	for _, spec := range lock.GitSpecs {
+		targets = append(targets, pristineTarget{
+			name:     spec.Name,
+			fullName: spec.FullName(),
+			version:  spec.Version,
+			source:   "git",
+			restore: func() (string, error) {
+				return reinstallGemDir(spec.FullName(), vendorDir, func(destDir string) error {
+					return resolver.CloneGitGem(context.Background(), spec, destDir)
+				})
+			},
+		})
+	}
+
+	for _, spec := range lock.PathSpecs {
+		targets = append(targets, pristineTarget{
+			name:     spec.Name,
+			fullName: spec.FullName(),
+			version:  spec.Version,
+			source:   "path",
+			restore: func() (string, error) {
+				return reinstallGemDir(spec.FullName(), vendorDir, func(destDir string) error {
+					return resolver.CopyPathGem(spec, destDir)
+				})
+			},
+		})
+	}
+
+	return targets
+}
+
+// reinstallGemDir replaces vendor/gems/<fullName> with a fresh copy produced by install
+func reinstallGemDir(fullName, vendorDir string, install func(destDir string) error) (string, error) {
+	destDir := filepath.Join(vendorDir, "gems", fullName)
+	if err := removeGemDirectory(destDir); err != nil {
+		return "", fmt.Errorf("failed to remove gem directory: %w", err)
+	}
+
+	if err := install(destDir); err != nil {
+		return "", err
+	}
+
+	// Link binaries
+	if err := geminstall.LinkGemBinaries(destDir, filepath.Join(vendorDir, "bin")); err != nil {
+		return "", fmt.Errorf("failed to link binaries: %w", err)
+	}
+
+	return destDir, nil
+}
+
 // restoreGemPureGo restores a gem to pristine condition
 // It removes the installed gem and reinstalls it from cache
-func restoreGemPureGo(gemSpec lockfile.GemSpec, cacheDir, vendorDir string) error {
-	// 1. Verify gem exists in cache
-	exists, err := verifyGemInCache(cacheDir, gemSpec.Name, gemSpec.Version)
-	if err != nil {
-		return fmt.Errorf("failed to verify cache: %w", err)
-	}
-	if !exists {
-		return fmt.Errorf("gem not found in cache; run `ore fetch` first")
+func restoreGemPureGo(gemSpec lockfile.GemSpec, cacheDirs []string, vendorDir string) error {
+	// 1. Verify gem exists in cache (same layout and search order as ore fetch)
+	cachePath := geminstall.FindCachedGem(cacheDirs, gemSpec)
+	if cachePath == "" {
+		return errdefs.Newf(errdefs.ErrGemNotCached, "gem not found in cache; run `ore fetch` first")
 	}
 
 	// 2. Find and remove installed gem directory
@@ -111,7 +257,6 @@ func restoreGemPureGo(gemSpec lockfile.GemSpec, cacheDir, vendorDir string) erro
 	}
 
 	// 4. Reinstall from cache using geminstall package
-	cachePath := getGemCachePath(cacheDir, gemSpec.Name, gemSpec.Version)
 	destDir := filepath.Join(vendorDir, "gems", gemSpec.FullName())
 
 	// Extract gem contents
@@ -178,26 +323,6 @@ func removeGemDirectory(path string) error {
 	return os.RemoveAll(path)
 }
 
-// getGemCachePath returns the path to a gem's cached .gem file
-func getGemCachePath(cacheDir, gemName, version string) string {
-	filename := fmt.Sprintf("%s-%s.gem", gemName, version)
-	// Cache structure: cache/gems/*.gem
-	return filepath.Join(cacheDir, "gems", filename)
-}
-
-// verifyGemInCache checks if a gem exists in the cache
-func verifyGemInCache(cacheDir, gemName, version string) (bool, error) {
-	gemPath := getGemCachePath(cacheDir, gemName, version)
-	stat, err := os.Stat(gemPath)
-	if os.IsNotExist(err) {
-		return false, nil
-	}
-	if err != nil {
-		return false, err
-	}
-	return !stat.IsDir(), nil
-}
-
 // removeGemspec removes a gem's specification file
 func removeGemspec(gemName, version, vendorDir string) error {
 	// Find and remove gemspec
@@ -6,12 +6,14 @@ import (
 
 	"github.com/contriboss/gemfile-go/gemfile"
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/errdefs"
+	"github.com/contriboss/ore-light/internal/logger"
 )
 
 // RunRemove implements the ore remove command
 func RunRemove(args []string) error {
 	fs := flag.NewFlagSet("remove", flag.ContinueOnError)
-	verbose := fs.Bool("v", false, "Enable verbose output")
+	verbose := fs.Bool("v", logger.Verbose(), "Enable verbose output")
 
 	if err := fs.Parse(args); err != nil {
 		return err
@@ -25,7 +27,7 @@ func RunRemove(args []string) error {
 	// Find Gemfile
 	paths, err := lockfile.FindGemfiles()
 	if err != nil {
-		return fmt.Errorf("failed to find Gemfile: %w", err)
+		return errdefs.Newf(errdefs.ErrGemfileNotFound, "failed to find Gemfile: %w", err)
 	}
 
 	if *verbose {
@@ -1,15 +1,18 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/contriboss/ore-light/internal/httpcache"
+	"github.com/contriboss/ore-light/internal/httpclient"
 )
 
 // SearchResult represents a gem search result from RubyGems API
@@ -23,54 +26,182 @@ type SearchResult struct {
 	Licenses         []string `json:"licenses"`
 	HomepageURI      string   `json:"homepage_uri"`
 	ProjectURI       string   `json:"project_uri"`
-	Source           string   `json:"-"` // Not from API, we add this
+	Source           string   `json:"source,omitempty"` // Not from API, we add this
+}
+
+// SearchOptions controls how search results are filtered and rendered
+type SearchOptions struct {
+	Limit int    // Maximum number of results to display
+	Exact bool   // Only return a gem whose name equals the query
+	JSON  bool   // Emit results as JSON instead of styled text
+	Sort  string // relevance (the registry's order, the default), downloads or name
+}
+
+// Search sort orders
+const (
+	SortRelevance = "relevance"
+	SortDownloads = "downloads"
+	SortName      = "name"
+)
+
+// searchPageSize is how many results the RubyGems search API returns per page
+const searchPageSize = 30
+
+// maxSearchPages bounds how many pages are fetched from one source for a large --limit
+const maxSearchPages = 20
+
+// ValidSearchSort reports whether sort is a supported --sort value ("" means relevance)
+func ValidSearchSort(sort string) bool {
+	switch sort {
+	case "", SortRelevance, SortDownloads, SortName:
+		return true
+	}
+	return false
 }
 
 // Search searches for gems across all configured gem sources
-func Search(query string, limit int, sources []string) error {
+func Search(query string, opts SearchOptions, sources []string) error {
 	if len(sources) == 0 {
 		// Default to rubygems.org if no sources configured
 		sources = []string{"https://rubygems.org"}
 	}
+	if !ValidSearchSort(opts.Sort) {
+		return fmt.Errorf("unknown sort %q (expected relevance, downloads or name)", opts.Sort)
+	}
+
+	// An exact match is on the first page; otherwise fetch enough pages for --limit
+	pages := 1
+	if !opts.Exact {
+		pages = searchPages(opts.Limit)
+	}
 
 	allResults := make([]SearchResult, 0)
 	seen := make(map[string]bool) // Deduplicate by gem name
 
 	// Search each source
 	for _, source := range sources {
-		results, err := searchSource(source, query)
+		results, err := searchSource(source, query, pages)
 		if err != nil {
 			// Don't fail completely if one source fails, just warn
-			fmt.Fprintf(os.Stderr, "Warning: failed to search %s: %v\n", source, err)
+			fmt.Fprintf(os.Stderr, "Warning: failed to search %s: %v\n", httpclient.RedactURL(source), httpclient.RedactError(err))
 			continue
 		}
 
 		// Add results, deduplicating by name
-		for _, result := range results {
-			if !seen[result.Name] {
-				result.Source = source
-				allResults = append(allResults, result)
-				seen[result.Name] = true
-			}
+		allResults = mergeSearchResults(allResults, results, source, seen)
+	}
+
+	if opts.Exact {
+		allResults = filterExactMatch(allResults, query)
+	}
+	sortSearchResults(allResults, opts.Sort)
+
+	if opts.JSON {
+		if opts.Limit > 0 && len(allResults) > opts.Limit {
+			allResults = allResults[:opts.Limit]
 		}
+		return printJSON(allResults)
 	}
 
 	// Display results
-	displaySearchResults(allResults, query, limit)
+	displaySearchResults(allResults, query, opts.Limit, opts.Sort)
 
 	return nil
 }
 
-// searchSource searches a single gem source
-func searchSource(sourceURL, query string) ([]SearchResult, error) {
+// mergeSearchResults appends results from source, skipping gem names already seen
+func mergeSearchResults(allResults, results []SearchResult, source string, seen map[string]bool) []SearchResult {
+	for _, result := range results {
+		if !seen[result.Name] {
+			result.Source = source
+			allResults = append(allResults, result)
+			seen[result.Name] = true
+		}
+	}
+	return allResults
+}
+
+// filterExactMatch keeps only the result whose name equals the query
+func filterExactMatch(results []SearchResult, query string) []SearchResult {
+	exact := make([]SearchResult, 0, 1)
+	for _, result := range results {
+		if result.Name == query {
+			exact = append(exact, result)
+		}
+	}
+	return exact
+}
+
+// searchPages returns how many API pages cover limit results
+func searchPages(limit int) int {
+	if limit <= searchPageSize {
+		return 1
+	}
+	pages := (limit + searchPageSize - 1) / searchPageSize
+	if pages > maxSearchPages {
+		pages = maxSearchPages
+	}
+	return pages
+}
+
+// sortSearchResults orders results in place. Relevance keeps the registry's order
+// (sources in configured order); downloads and name sort what was fetched.
+func sortSearchResults(results []SearchResult, order string) {
+	switch order {
+	case SortDownloads:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Downloads > results[j].Downloads
+		})
+	case SortName:
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Name < results[j].Name
+		})
+	}
+}
+
+// searchSource searches a single gem source, fetching up to pages pages of results.
+// It stops early at a short (last) page. The whole search, response bodies included,
+// must finish within the HTTP timeout, so a server that stalls mid-response can't hang it.
+func searchSource(sourceURL, query string, pages int) ([]SearchResult, error) {
+	client := httpcache.NewClient()
+	ctx, cancel := context.WithTimeout(context.Background(), httpclient.Timeout())
+	defer cancel()
+
+	var results []SearchResult
+	for page := 1; page <= pages; page++ {
+		pageResults, err := searchSourcePage(ctx, client, sourceURL, query, page)
+		if err != nil {
+			if page > 1 {
+				// Keep what the earlier pages returned
+				fmt.Fprintf(os.Stderr, "Warning: failed to fetch page %d from %s: %v\n", page, httpclient.RedactURL(sourceURL), httpclient.RedactError(err))
+				break
+			}
+			return nil, err
+		}
+		results = append(results, pageResults...)
+		if len(pageResults) < searchPageSize {
+			break
+		}
+	}
+	return results, nil
+}
+
+// searchSourcePage fetches one page of search results from a gem source
+func searchSourcePage(ctx context.Context, client *http.Client, sourceURL, query string, page int) ([]SearchResult, error) {
 	// Build API URL
 	apiURL := fmt.Sprintf("%s/api/v1/search.json?query=%s",
 		strings.TrimSuffix(sourceURL, "/"),
 		url.QueryEscape(query))
+	if page > 1 {
+		apiURL += fmt.Sprintf("&page=%d", page)
+	}
 
 	// Make HTTP request
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(apiURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search: %w", err)
 	}
@@ -92,7 +223,7 @@ func searchSource(sourceURL, query string) ([]SearchResult, error) {
 }
 
 // displaySearchResults displays the search results with formatted output
-func displaySearchResults(results []SearchResult, query string, limit int) {
+func displaySearchResults(results []SearchResult, query string, limit int, order string) {
 	if len(results) == 0 {
 		fmt.Printf("No gems found matching %q\n", query)
 		return
@@ -121,7 +252,11 @@ func displaySearchResults(results []SearchResult, query string, limit int) {
 		Italic(true)
 
 	// Header
-	fmt.Printf("%s %q\n\n", headerStyle.Render("Gems matching"), query)
+	if order != "" && order != SortRelevance {
+		fmt.Printf("%s %q %s\n\n", headerStyle.Render("Gems matching"), query, versionStyle.Render("by "+order))
+	} else {
+		fmt.Printf("%s %q\n\n", headerStyle.Render("Gems matching"), query)
+	}
 
 	// Limit results
 	displayCount := limit
@@ -1,20 +1,86 @@
 package commands
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/httpclient"
+	"github.com/contriboss/ore-light/internal/logger"
+	"github.com/contriboss/ore-light/internal/resolver"
 )
 
+// showEntry describes where a locked gem lives on disk, and with --verbose where it came from
+type showEntry struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Path     string `json:"path"`
+	Source   string `json:"source,omitempty"` // rubygems, git or path
+	Remote   string `json:"remote,omitempty"`
+	Revision string `json:"revision,omitempty"` // Locked commit, for git gems
+	Branch   string `json:"branch,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+}
+
+func gemShowEntry(spec lockfile.GemSpec, path string) showEntry {
+	remote := spec.SourceURL
+	if remote == "" {
+		remote = "https://rubygems.org/"
+	}
+	return showEntry{Name: spec.Name, Version: spec.Version, Path: path, Source: "rubygems", Remote: httpclient.RedactURL(remote)}
+}
+
+func gitShowEntry(spec lockfile.GitGemSpec, path string) showEntry {
+	return showEntry{
+		Name:     spec.Name,
+		Version:  spec.Version,
+		Path:     path,
+		Source:   "git",
+		Remote:   httpclient.RedactURL(spec.Remote),
+		Revision: spec.Revision,
+		Branch:   spec.Branch,
+		Tag:      spec.Tag,
+	}
+}
+
+func pathShowEntry(spec lockfile.PathGemSpec) showEntry {
+	return showEntry{Name: spec.Name, Version: spec.Version, Path: absPath(spec.Remote), Source: "path", Remote: spec.Remote}
+}
+
+// location drops the source details, for output without --verbose
+func (e showEntry) location() showEntry {
+	return showEntry{Name: e.Name, Version: e.Version, Path: e.Path}
+}
+
+// printVerbose prints the entry with its source, remote and locked revision
+func (e showEntry) printVerbose() {
+	fmt.Printf("%s %s\n", e.Name, e.Version)
+	fmt.Printf("  path:     %s\n", e.Path)
+	fmt.Printf("  source:   %s\n", e.Source)
+	fmt.Printf("  remote:   %s\n", e.Remote)
+	if e.Revision != "" {
+		fmt.Printf("  revision: %s\n", e.Revision)
+	}
+	if e.Branch != "" {
+		fmt.Printf("  branch:   %s\n", e.Branch)
+	}
+	if e.Tag != "" {
+		fmt.Printf("  tag:      %s\n", e.Tag)
+	}
+}
+
 // RunShow implements the ore show command
 func RunShow(args []string) error {
 	fs := flag.NewFlagSet("show", flag.ContinueOnError)
 	gemfilePath := fs.String("gemfile", defaultGemfilePath(), "Path to Gemfile")
 	vendorDir := fs.String("vendor", defaultVendorDir(), "Vendor directory")
 	paths := fs.Bool("paths", false, "List all gem paths")
+	jsonOutput := fs.Bool("json", false, "Output as JSON")
+	verbose := fs.Bool("verbose", logger.Verbose(), "Also show each gem's source, remote and locked git revision")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -26,40 +92,39 @@ func RunShow(args []string) error {
 	}
 
 	// Parse lockfile
-	lock, err := lockfile.ParseFile(lockfilePath)
+	content, err := os.ReadFile(lockfilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	lock, err := lockfile.Parse(bytes.NewReader(content))
 	if err != nil {
 		return fmt.Errorf("failed to parse lockfile: %w", err)
 	}
+	resolver.ApplyGemRemotes(lock, content)
+
+	gemsDirs := showGemsDirs(*vendorDir)
 
 	// If --paths flag, list all gem paths
 	if *paths {
-		gemsDir := filepath.Join(*vendorDir, "gems")
-
-		// Regular gems
-		for _, spec := range lock.GemSpecs {
-			gemPath := filepath.Join(gemsDir, spec.FullName())
-			if _, err := os.Stat(gemPath); err == nil {
-				fmt.Println(gemPath)
+		entries := collectShowEntries(lock, gemsDirs)
+		if !*verbose {
+			for i := range entries {
+				entries[i] = entries[i].location()
 			}
 		}
-
-		// Git gems
-		for _, spec := range lock.GitSpecs {
-			gemPath := filepath.Join(gemsDir, spec.FullName())
-			if _, err := os.Stat(gemPath); err == nil {
-				fmt.Println(gemPath)
-			}
+		if *jsonOutput {
+			return printJSON(entries)
 		}
-
-		// Path gems (show source path, not vendor copy)
-		for _, spec := range lock.PathSpecs {
-			if absPath, err := filepath.Abs(spec.Remote); err == nil {
-				fmt.Println(absPath)
-			} else {
-				fmt.Println(spec.Remote)
+		for i, entry := range entries {
+			if !*verbose {
+				fmt.Println(entry.Path)
+				continue
 			}
+			if i > 0 {
+				fmt.Println()
+			}
+			entry.printVerbose()
 		}
-
 		return nil
 	}
 
@@ -69,48 +134,114 @@ func RunShow(args []string) error {
 		return fmt.Errorf("gem name required (or use --paths to list all)")
 	}
 
-	gemName := gems[0]
-	gemsDir := filepath.Join(*vendorDir, "gems")
+	entry, err := findShowEntry(lock, gemsDirs, gems[0])
+	if err != nil {
+		return err
+	}
+
+	if !*verbose {
+		entry = entry.location()
+	}
+	if *jsonOutput {
+		return printJSON(entry)
+	}
+	if *verbose {
+		entry.printVerbose()
+		return nil
+	}
+	fmt.Println(entry.Path)
+	return nil
+}
+
+// showGemsDirs returns the gems directories to search, vendor first, then the system gem dir
+func showGemsDirs(vendorDir string) []string {
+	dirs := []string{filepath.Join(vendorDir, "gems")}
+	if systemDir, err := getGemDirectory(); err == nil && systemDir != "" {
+		systemGems := filepath.Join(systemDir, "gems")
+		if systemGems != dirs[0] {
+			dirs = append(dirs, systemGems)
+		}
+	}
+	return dirs
+}
+
+// locateInstalledGem returns the absolute path of fullName in the first gems dir that has it
+func locateInstalledGem(gemsDirs []string, fullName string) (string, bool) {
+	for _, dir := range gemsDirs {
+		gemPath := filepath.Join(dir, fullName)
+		if _, err := os.Stat(gemPath); err == nil {
+			return absPath(gemPath), true
+		}
+	}
+	return "", false
+}
+
+// absPath resolves path to an absolute path, returning it unchanged on failure
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// collectShowEntries gathers installed paths for every gem in the lockfile, sorted by path
+func collectShowEntries(lock *lockfile.Lockfile, gemsDirs []string) []showEntry {
+	entries := []showEntry{}
 
+	// Regular gems
+	for _, spec := range lock.GemSpecs {
+		if gemPath, ok := locateInstalledGem(gemsDirs, spec.FullName()); ok {
+			entries = append(entries, gemShowEntry(spec, gemPath))
+		}
+	}
+
+	// Git gems
+	for _, spec := range lock.GitSpecs {
+		if gemPath, ok := locateInstalledGem(gemsDirs, spec.FullName()); ok {
+			entries = append(entries, gitShowEntry(spec, gemPath))
+		}
+	}
+
+	// Path gems (show source path, not vendor copy)
+	for _, spec := range lock.PathSpecs {
+		entries = append(entries, pathShowEntry(spec))
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	return entries
+}
+
+// findShowEntry resolves the installed path for a single gem
+func findShowEntry(lock *lockfile.Lockfile, gemsDirs []string, gemName string) (showEntry, error) {
 	// Search in regular gems
 	for _, spec := range lock.GemSpecs {
 		if spec.Name == gemName {
-			gemPath := filepath.Join(gemsDir, spec.FullName())
-			if _, err := os.Stat(gemPath); err == nil {
-				if absPath, err := filepath.Abs(gemPath); err == nil {
-					fmt.Println(absPath)
-				} else {
-					fmt.Println(gemPath)
-				}
-				return nil
+			if gemPath, ok := locateInstalledGem(gemsDirs, spec.FullName()); ok {
+				return gemShowEntry(spec, gemPath), nil
 			}
-			return fmt.Errorf("gem %s is in lockfile but not installed", gemName)
+			return showEntry{}, fmt.Errorf("gem %s is in lockfile but not installed", gemName)
 		}
 	}
 
 	// Search in git gems
 	for _, spec := range lock.GitSpecs {
 		if spec.Name == gemName {
-			gemPath := filepath.Join(gemsDir, spec.FullName())
-			if _, err := os.Stat(gemPath); err == nil {
-				fmt.Println(gemPath)
-				return nil
+			if gemPath, ok := locateInstalledGem(gemsDirs, spec.FullName()); ok {
+				return gitShowEntry(spec, gemPath), nil
 			}
-			return fmt.Errorf("gem %s is in lockfile but not installed", gemName)
+			return showEntry{}, fmt.Errorf("gem %s is in lockfile but not installed", gemName)
 		}
 	}
 
 	// Search in path gems
 	for _, spec := range lock.PathSpecs {
 		if spec.Name == gemName {
-			if absPath, err := filepath.Abs(spec.Remote); err == nil {
-				fmt.Println(absPath)
-			} else {
-				fmt.Println(spec.Remote)
-			}
-			return nil
+			return pathShowEntry(spec), nil
 		}
 	}
 
-	return fmt.Errorf("gem %s not found in bundle", gemName)
+	return showEntry{}, fmt.Errorf("gem %s not found in bundle", gemName)
 }
@@ -12,27 +12,46 @@ import (
 	"sync"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/contriboss/ore-light/internal/cache"
+	"github.com/contriboss/ore-light/internal/ruby"
 )
 
 // RubyVersion represents a Ruby installation with gem count and size
 type RubyVersion struct {
-	Version  string
-	GemCount int
-	GemSize  int64
-	GemDir   string
-	IsActive bool
+	Version  string `json:"version"`
+	GemCount int    `json:"gem_count"`
+	GemSize  int64  `json:"gem_size"`
+	GemDir   string `json:"gem_dir"`
+	IsActive bool   `json:"is_active"`
 }
 
 // VersionManager represents a detected Ruby version manager
 type VersionManager struct {
-	Name     string
-	Detected bool
-	Path     string
+	Name     string `json:"name"`
+	Detected bool   `json:"detected"`
+	Path     string `json:"path,omitempty"`
 }
 
-// RunStats implements the ore stats command
-func RunStats(args []string) error {
+// CacheUsage describes ore's own download cache
+type CacheUsage struct {
+	Dir       string `json:"dir"`
+	Files     int    `json:"files"`
+	TotalSize int64  `json:"total_size"`
+}
+
+// statsReport is the --json output of ore stats
+type statsReport struct {
+	Manager  *VersionManager `json:"manager"`
+	Active   string          `json:"active"`
+	Versions []RubyVersion   `json:"versions"`
+	Cache    *CacheUsage     `json:"cache"`
+}
+
+// RunStats implements the ore stats command. cacheDir is ore's configured gem cache,
+// "" when it couldn't be determined.
+func RunStats(args []string, cacheDir string) error {
 	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	jsonOutput := fs.Bool("json", false, "Output as JSON")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -49,12 +68,67 @@ func RunStats(args []string) error {
 		return err
 	}
 
+	// Measure ore's download cache (nil when the cache dir can't be determined)
+	cacheUsage := collectCacheUsage(cacheDir)
+
+	if *jsonOutput {
+		if versions == nil {
+			versions = []RubyVersion{}
+		}
+		return printJSON(statsReport{Manager: manager, Active: activeVersion, Versions: versions, Cache: cacheUsage})
+	}
+
 	// Render stats
 	renderStats(manager, activeVersion, versions)
+	renderCacheUsage(cacheUsage)
 
 	return nil
 }
 
+// collectCacheUsage returns the size of ore's gem cache, or nil if it can't be read
+func collectCacheUsage(cacheDir string) *CacheUsage {
+	if cacheDir == "" {
+		return nil
+	}
+
+	stats, err := cache.CollectStats(cacheDir)
+	if err != nil {
+		return nil
+	}
+
+	return &CacheUsage{Dir: cacheDir, Files: stats.Files, TotalSize: stats.TotalSize}
+}
+
+// renderCacheUsage renders the ore cache section of ore stats
+func renderCacheUsage(usage *CacheUsage) {
+	if usage == nil {
+		return
+	}
+
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("15"))
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("245")) // Light gray
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("8")).
+		Italic(true)
+
+	fmt.Println()
+	fmt.Println(headerStyle.Render("ore Cache"))
+	fmt.Println()
+	fmt.Printf("%s %s\n", labelStyle.Render("Path: "), usage.Dir)
+	fmt.Printf("%s %d\n", labelStyle.Render("Files:"), usage.Files)
+	fmt.Printf("%s %s\n", labelStyle.Render("Size: "), cache.HumanBytes(usage.TotalSize))
+
+	if usage.Files > 0 {
+		fmt.Println()
+		fmt.Println(hintStyle.Render("Run `ore cache prune` to free this space."))
+	}
+}
+
 // detectVersionManager detects which Ruby version manager is installed
 func detectVersionManager() *VersionManager {
 	// Try mise
@@ -104,7 +178,7 @@ func detectVersionManager() *VersionManager {
 
 // detectActiveRuby returns the currently active Ruby version
 func detectActiveRuby() string {
-	cmd := exec.Command("ruby", "-v")
+	cmd := exec.Command(ruby.Binary(), "-v")
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -136,7 +210,7 @@ func listRubyVersions(manager *VersionManager, activeVersion string) ([]RubyVers
 		}
 
 		// Try to get gem directory for system Ruby
-		cmd := exec.Command("ruby", "-e", "puts Gem.dir")
+		cmd := exec.Command(ruby.Binary(), "-e", "puts Gem.dir")
 		output, err := cmd.Output()
 		if err != nil {
 			return []RubyVersion{{
@@ -529,20 +603,6 @@ func detectRubyType(version string) rubyImplementationType {
 	return rubyTypeCRuby
 }
 
-// humanBytes formats bytes as human-readable string
-func humanBytes(size int64) string {
-	const unit = 1024
-	if size < unit {
-		return fmt.Sprintf("%d B", size)
-	}
-	div, exp := int64(unit), 0
-	for n := size / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
-}
-
 // renderStats renders the statistics using bubbles table
 func renderStats(manager *VersionManager, activeVersion string, versions []RubyVersion) {
 	// Define styles
@@ -650,7 +710,7 @@ func renderStats(manager *VersionManager, activeVersion string, versions []RubyV
 		// Format and pad plain text first, then apply styling
 		version := fmt.Sprintf("%-25s", v.Version)
 		gems := fmt.Sprintf("%-12s", fmt.Sprintf("%d", v.GemCount))
-		size := fmt.Sprintf("%-15s", humanBytes(v.GemSize))
+		size := fmt.Sprintf("%-15s", cache.HumanBytes(v.GemSize))
 
 		// Apply styling after padding
 		versionText := style.Render(version)
@@ -1,10 +1,18 @@
 package commands
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/contriboss/gemfile-go/gemfile"
+	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/errdefs"
+	"github.com/contriboss/ore-light/internal/logger"
 	"github.com/contriboss/ore-light/internal/resolver"
 )
 
@@ -12,7 +20,12 @@ import (
 func RunUpdate(args []string) error {
 	fs := flag.NewFlagSet("update", flag.ContinueOnError)
 	gemfilePath := fs.String("gemfile", defaultGemfilePath(), "Path to Gemfile")
-	verbose := fs.Bool("v", false, "Enable verbose output")
+	verbose := fs.Bool("v", logger.Verbose(), "Enable verbose output")
+	conservative := fs.Bool("conservative", false, "Keep gems not named on the command line (and shared dependencies) at their locked versions")
+	group := fs.String("group", "", "Only update the gems in this Gemfile group (e.g. test); everything else stays locked")
+	refresh := fs.Bool("refresh", false, "Fetch every gem's versions and dependencies from its source instead of reusing cached ones")
+	var bundler bundlerFlag
+	fs.Var(&bundler, "bundler", "Only update BUNDLED WITH, to the given version or the installed Bundler (--bundler[=<version>])")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -25,35 +38,341 @@ func RunUpdate(args []string) error {
 		return fmt.Errorf("failed to find lockfile: %w", err)
 	}
 
+	// Like `bundle update --bundler`: no relock, gem versions stay as they are
+	if bundler.set {
+		if len(gems) > 0 || *conservative || *group != "" {
+			return fmt.Errorf("--bundler can't be combined with gem names, --conservative or --group")
+		}
+		version := bundler.version
+		if version == "" {
+			version = resolver.InstalledBundlerVersion()
+		}
+		if err := updateBundledWith(lockfilePath, version); err != nil {
+			return err
+		}
+		logger.Printf("✨ Updated BUNDLED WITH to %s in %s\n", version, lockfilePath)
+		return nil
+	}
+
 	// Parse Gemfile to ensure it exists and is valid
 	parser := gemfile.NewGemfileParser(*gemfilePath)
-	_, parseErr := parser.Parse()
+	parsedGemfile, parseErr := parser.Parse()
 	if parseErr != nil {
 		return fmt.Errorf("failed to parse Gemfile: %w", parseErr)
 	}
 
 	// Determine which gems to update
 	var versionPins map[string]string
-	if len(gems) == 0 {
-		// Update all gems - no pins
+	var locked *lockfile.Lockfile
+	var members []string
+	if *conservative || *group != "" {
+		locked, err = lockfile.ParseFile(lockfilePath)
+		if err != nil {
+			return fmt.Errorf("failed to parse lockfile: %w", err)
+		}
+	}
+	if *group != "" {
+		if len(gems) > 0 {
+			return fmt.Errorf("--group can't be combined with gem names")
+		}
+		// --conservative moves only the group's own gems, not dependencies they pull in
+		members, err = groupGems(*gemfilePath, locked, parsedGemfile, *group, !*conservative)
+		if err != nil {
+			return err
+		}
+		versionPins = conservativePins(locked, parsedGemfile, members)
+	} else if *conservative {
+		versionPins = conservativePins(locked, parsedGemfile, gems)
+	}
+
+	if *group != "" {
 		if *verbose {
-			fmt.Println("🔄 Updating all gems...")
+			fmt.Printf("🔄 Updating the %s group: %v (other gems stay at their locked versions)\n", *group, members)
 		}
-	} else {
-		// Selective update for specific gems
-		// For now, just re-resolve without any pins
-		// TODO: Implement conservative update strategy
+	} else if len(gems) == 0 {
+		// Update all gems - no pins, unless --conservative keeps the current versions
 		if *verbose {
+			if *conservative {
+				fmt.Println("🔄 Updating only gems whose Gemfile requirements changed...")
+			} else {
+				fmt.Println("🔄 Updating all gems...")
+			}
+		}
+	} else if *verbose {
+		if *conservative {
+			fmt.Printf("🔄 Updating gems: %v (other gems stay at their locked versions)\n", gems)
+		} else {
+			// Selective update for specific gems
+			// For now, just re-resolve without any pins
 			fmt.Printf("🔄 Updating gems: %v (re-resolving all dependencies)\n", gems)
 		}
 	}
 
 	// Regenerate lockfile with version pins for selective update
-	if err := resolver.GenerateLockfileWithPins(*gemfilePath, versionPins); err != nil {
+	lockOpts := resolver.LockOptions{VersionPins: versionPins, Verbose: *verbose, Refresh: *refresh}
+	if versionPins != nil {
+		err = lockWithPins(*gemfilePath, locked, parsedGemfile, lockOpts)
+	} else {
+		err = resolver.GenerateLockfileWithOptions(*gemfilePath, lockOpts)
+	}
+	if err != nil && *group != "" {
+		return fmt.Errorf("failed to update the %s group with other gems locked (try ore update without --group): %w", *group, err)
+	}
+	if err != nil && *conservative {
+		return fmt.Errorf("failed to update lockfile conservatively (try without --conservative): %w", err)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to update lockfile: %w", err)
 	}
 
-	fmt.Printf("✨ Updated %s\n", lockfilePath)
-	fmt.Println("💡 Run `ore install` to fetch the updated gems.")
+	logger.Printf("✨ Updated %s\n", lockfilePath)
+	logger.Println("💡 Run `ore install` to fetch the updated gems.")
+	return nil
+}
+
+// bundlerFlag is --bundler[=<version>]; bare, it uses the installed Bundler's version
+type bundlerFlag struct {
+	set     bool
+	version string
+}
+
+func (f *bundlerFlag) String() string   { return f.version }
+func (f *bundlerFlag) IsBoolFlag() bool { return true }
+
+func (f *bundlerFlag) Set(value string) error {
+	switch value {
+	case "true":
+		f.set, f.version = true, ""
+	case "false":
+		f.set, f.version = false, ""
+	default:
+		f.set, f.version = true, value
+	}
+	return nil
+}
+
+// bundlerVersionFormat is a RubyGems-style release or prerelease version ("2.5.23", "2.6.0.pre.1")
+var bundlerVersionFormat = regexp.MustCompile(`^\d+\.\d+\.\d+(\.[0-9A-Za-z]+)*$`)
+
+// updateBundledWith rewrites the version under BUNDLED WITH, leaving every other byte
+// of the lockfile as it was. A lockfile without the section gets one appended.
+func updateBundledWith(lockfilePath, version string) error {
+	if !bundlerVersionFormat.MatchString(version) {
+		return fmt.Errorf("invalid Bundler version %q (expected e.g. 2.5.23)", version)
+	}
+
+	data, err := os.ReadFile(lockfilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	content := string(data)
+
+	updated, ok := replaceBundledWith(content, version)
+	if !ok {
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		updated = content + "\nBUNDLED WITH\n   " + version + "\n"
+	}
+	if updated == content {
+		return nil
+	}
+
+	info, err := os.Stat(lockfilePath)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(lockfilePath, []byte(updated), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
 	return nil
 }
+
+// replaceBundledWith swaps the version line after "BUNDLED WITH", keeping its indentation
+// and line ending. Reports false when the lockfile has no such section.
+func replaceBundledWith(content, version string) (string, bool) {
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		if strings.TrimRight(line, "\r\n") != "BUNDLED WITH" || i+1 >= len(lines) {
+			continue
+		}
+		next := lines[i+1]
+		old := strings.TrimSpace(next)
+		if old == "" {
+			lines[i+1] = "   " + version + "\n" + next
+			return strings.Join(lines, ""), true
+		}
+		start := strings.Index(next, old)
+		lines[i+1] = next[:start] + version + next[start+len(old):]
+		return strings.Join(lines, ""), true
+	}
+	return content, false
+}
+
+// LockConservatively relocks gemfilePath like `ore lock`, but keeps every gem at its
+// version in the existing lockfile unless the Gemfile's requirements for it changed, so a
+// relock after an unrelated Gemfile edit only moves the gems that edit affects.
+// Without a lockfile it's a plain lock.
+func LockConservatively(gemfilePath string, opts resolver.LockOptions) error {
+	lockfilePath := opts.LockfilePath
+	var err error
+	if lockfilePath == "" {
+		lockfilePath, err = findLockfilePath(gemfilePath)
+	} else {
+		_, err = os.Stat(lockfilePath)
+	}
+	if err != nil {
+		return resolver.GenerateLockfileWithOptions(gemfilePath, opts)
+	}
+	locked, err := lockfile.ParseFile(lockfilePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse lockfile: %w", err)
+	}
+	parsed, err := gemfile.NewGemfileParser(gemfilePath).Parse()
+	if err != nil {
+		return fmt.Errorf("failed to parse Gemfile: %w", err)
+	}
+
+	opts.VersionPins = conservativePins(locked, parsed, nil)
+	if err := lockWithPins(gemfilePath, locked, parsed, opts); err != nil {
+		return fmt.Errorf("failed to keep the locked versions (try without --conservative): %w", err)
+	}
+	return nil
+}
+
+// lockWithPins resolves with opts.VersionPins. An unpinned gem may need a newer shared
+// dependency, so when the pins conflict it retries with the locked dependencies of the
+// unpinned gems freed too before giving up. Any other failure (network, parse) is
+// returned as is: freeing shared dependencies wouldn't fix it.
+func lockWithPins(gemfilePath string, locked *lockfile.Lockfile, parsed *gemfile.ParsedGemfile, opts resolver.LockOptions) error {
+	err := resolver.GenerateLockfileWithOptions(gemfilePath, opts)
+	if err == nil || !errors.Is(err, errdefs.ErrResolutionFailed) {
+		return err
+	}
+
+	unlocked := unlockedGems(locked, opts.VersionPins)
+	relaxed := conservativePins(locked, parsed, lockedDependencyClosure(locked, unlocked))
+	if len(relaxed) >= len(opts.VersionPins) {
+		return err
+	}
+	if opts.Verbose {
+		// Keep stdout clean when the lockfile is printed there
+		status := os.Stdout
+		if opts.Output != nil {
+			status = os.Stderr
+		}
+		fmt.Fprintf(status, "🔄 Retrying with the dependencies of %v unlocked\n", unlocked)
+	}
+	opts.VersionPins = relaxed
+	return resolver.GenerateLockfileWithOptions(gemfilePath, opts)
+}
+
+// conservativePins pins every locked gem to its current version, like Bundler's
+// `update --conservative`, except gems named in unlock and gems whose locked version
+// no longer satisfies the Gemfile (their constraint forces a change)
+func conservativePins(locked *lockfile.Lockfile, parsed *gemfile.ParsedGemfile, unlock []string) map[string]string {
+	free := make(map[string]bool, len(unlock))
+	for _, name := range unlock {
+		free[name] = true
+	}
+
+	requirements := make(map[string]*resolver.SemverCondition)
+	for _, dep := range parsed.Dependencies {
+		if len(dep.Constraints) == 0 {
+			continue
+		}
+		if condition, err := resolver.NewSemverCondition(strings.Join(dep.Constraints, ", ")); err == nil {
+			requirements[dep.Name] = condition
+		}
+	}
+
+	pins := make(map[string]string)
+	for _, spec := range locked.GemSpecs {
+		if free[spec.Name] {
+			continue
+		}
+		if condition, ok := requirements[spec.Name]; ok {
+			version, err := resolver.NewSemverVersion(spec.Version)
+			if err != nil || !condition.Satisfies(version) {
+				continue
+			}
+		}
+		pins[spec.Name] = spec.Version
+	}
+	return pins
+}
+
+// groupGems returns the locked gems in a Gemfile group: the gems declared in it and,
+// with dependencies set, the dependencies only that group needs. A dependency another
+// group also reaches stays out so it keeps its locked version; lockWithPins frees it if
+// the update can't resolve otherwise. Gems outside a group block are in "default".
+// Returns an error if no gem in the Gemfile is in the group.
+func groupGems(gemfilePath string, locked *lockfile.Lockfile, parsed *gemfile.ParsedGemfile, group string, dependencies bool) ([]string, error) {
+	var declared []string
+	for _, dep := range parsed.Dependencies {
+		groups := dep.Groups
+		if len(groups) == 0 {
+			groups = []string{"default"}
+		}
+		if slices.Contains(groups, group) {
+			declared = append(declared, dep.Name)
+		}
+	}
+	if len(declared) == 0 {
+		return nil, fmt.Errorf("group %q not found in %s", group, gemfilePath)
+	}
+	if !dependencies {
+		return declared, nil
+	}
+
+	if err := EnrichGemsWithGroups(gemfilePath, locked); err != nil {
+		return nil, err
+	}
+	gemGroups := inheritedGroups(locked)
+	var members []string
+	for _, spec := range locked.GemSpecs {
+		groups := gemGroups[spec.Name]
+		exclusive := len(groups) > 0 && !slices.ContainsFunc(groups, func(g string) bool { return g != group })
+		if slices.Contains(declared, spec.Name) || exclusive {
+			members = append(members, spec.Name)
+		}
+	}
+	return members, nil
+}
+
+// unlockedGems lists locked gems that pins leaves free to move
+func unlockedGems(locked *lockfile.Lockfile, pins map[string]string) []string {
+	var names []string
+	for _, spec := range locked.GemSpecs {
+		if _, pinned := pins[spec.Name]; !pinned {
+			names = append(names, spec.Name)
+		}
+	}
+	return names
+}
+
+// lockedDependencyClosure returns names plus everything they depend on in the lockfile
+func lockedDependencyClosure(locked *lockfile.Lockfile, names []string) []string {
+	deps := make(map[string][]lockfile.Dependency)
+	for _, spec := range locked.GemSpecs {
+		deps[spec.Name] = append(deps[spec.Name], spec.Dependencies...)
+	}
+
+	seen := make(map[string]bool)
+	var closure []string
+	var visit func(name string)
+	visit = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		closure = append(closure, name)
+		for _, dep := range deps[name] {
+			visit(dep.Name)
+		}
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return closure
+}
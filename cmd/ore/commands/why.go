@@ -2,6 +2,7 @@ package commands
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
@@ -9,39 +10,103 @@ import (
 	"github.com/contriboss/gemfile-go/lockfile"
 )
 
-// Why shows why a gem is in the bundle by displaying dependency chains
-func Why(gemName string) error {
+// Why shows why gems are in the bundle by displaying their dependency chains.
+// Gems missing from the lockfile get a note; it fails only when none were found.
+func Why(gemNames []string) error {
 	// Parse lockfile
 	lock, err := lockfile.ParseFile("Gemfile.lock")
 	if err != nil {
 		return fmt.Errorf("failed to parse Gemfile.lock: %w", err)
 	}
 
-	// Find the target gem
-	var targetGem *lockfile.GemSpec
-	for _, spec := range lock.GemSpecs {
-		if spec.Name == gemName {
-			targetGem = &spec
-			break
+	// Build reverse dependency map (gem -> gems that depend on it)
+	reverseDeps := buildReverseDeps(lock.GemSpecs)
+
+	found := 0
+	for i, gemName := range gemNames {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		// Find the target gem
+		var targetGem *lockfile.GemSpec
+		for _, spec := range lock.GemSpecs {
+			if spec.Name == gemName {
+				targetGem = &spec
+				break
+			}
+		}
+		if targetGem == nil {
+			fmt.Printf("%s is not in Gemfile.lock\n", gemName)
+			continue
 		}
+		found++
+
+		// Find all paths from root gems to target
+		paths := findDependencyPaths(lock, reverseDeps, gemName)
+
+		// Display results
+		displayWhyResults(gemName, targetGem, paths)
 	}
 
-	if targetGem == nil {
-		return fmt.Errorf("gem %q not found in Gemfile.lock", gemName)
+	if found == 0 {
+		return fmt.Errorf("none of %s found in Gemfile.lock", strings.Join(gemNames, ", "))
 	}
+	return nil
+}
 
-	// Build reverse dependency map (gem -> gems that depend on it)
-	reverseDeps := buildReverseDeps(lock.GemSpecs)
+// GemDependents is a gem with the number of locked gems that depend on it directly
+type GemDependents struct {
+	Name       string
+	Dependents int
+}
 
-	// Find all paths from root gems to target
-	paths := findDependencyPaths(lock, reverseDeps, gemName)
+// WhyTop prints the limit gems with the most direct dependents: the transitive
+// gems the most of the bundle rests on
+func WhyTop(limit int) error {
+	lock, err := lockfile.ParseFile("Gemfile.lock")
+	if err != nil {
+		return fmt.Errorf("failed to parse Gemfile.lock: %w", err)
+	}
 
-	// Display results
-	displayWhyResults(gemName, targetGem, paths)
+	ranked := rankByDependents(buildReverseDeps(lock.GemSpecs))
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+
+	gemStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("86"))
+	countStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("242"))
 
+	width := 0
+	for _, gem := range ranked {
+		width = max(width, len(gem.Name))
+	}
+	for _, gem := range ranked {
+		noun := "dependents"
+		if gem.Dependents == 1 {
+			noun = "dependent"
+		}
+		fmt.Printf("  %s  %s\n", gemStyle.Render(fmt.Sprintf("%-*s", width, gem.Name)), countStyle.Render(fmt.Sprintf("%d %s", gem.Dependents, noun)))
+	}
 	return nil
 }
 
+// rankByDependents orders gems by direct dependent count, most first, then by name
+func rankByDependents(reverseDeps map[string][]string) []GemDependents {
+	ranked := make([]GemDependents, 0, len(reverseDeps))
+	for name, dependents := range reverseDeps {
+		// Platform variants of one gem (nokogiri and nokogiri-x86_64-linux) count once
+		ranked = append(ranked, GemDependents{Name: name, Dependents: len(slices.Compact(slices.Sorted(slices.Values(dependents))))})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Dependents != ranked[j].Dependents {
+			return ranked[i].Dependents > ranked[j].Dependents
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	return ranked
+}
+
 // buildReverseDeps builds a map of gem -> list of gems that depend on it
 func buildReverseDeps(specs []lockfile.GemSpec) map[string][]string {
 	reverseDeps := make(map[string][]string)
@@ -70,7 +135,7 @@ func findDependencyPaths(lock *lockfile.Lockfile, reverseDeps map[string][]strin
 	// Find root gems from lockfile Dependencies section
 	rootGems := make(map[string]bool)
 	for _, dep := range lock.Dependencies {
-		rootGems[dep.Name] = true
+		rootGems[strings.TrimSuffix(dep.Name, "!")] = true // "name!" has its own source
 	}
 
 	// Check if target is a root gem
@@ -12,7 +12,7 @@ _ore_completions() {
     cur="${COMP_WORDS[COMP_CWORD]}"
     prev="${COMP_WORDS[COMP_CWORD-1]}"
 
-    commands="init add remove update outdated lock fetch install check list show info search why exec clean cache pristine config platform stats help version"
+    commands="init add remove update outdated lock fetch install check verify list show info search why exec clean cache pristine config platform stats help version"
 
     # Complete commands
     if [ $COMP_CWORD -eq 1 ]; then
@@ -20,6 +20,32 @@ _ore_completions() {
         return 0
     fi
 
+    # ore exec: options until the command (or "--"), then complete the command and
+    # leave its arguments to the command's own completion
+    if [ "${COMP_WORDS[1]}" = "exec" ]; then
+        local i=2 dashdash=0
+        while [ $i -lt $COMP_CWORD ]; do
+            case "${COMP_WORDS[i]}" in
+                --) dashdash=1; i=$((i+1)); break ;;
+                --lockfile|--gemfile|--vendor|--path|--keep-env) i=$((i+2)) ;;
+                -*) i=$((i+1)) ;;
+                *) break ;;
+            esac
+        done
+        if [ $i -le $COMP_CWORD ]; then
+            if [ $i -lt $COMP_CWORD ] && type _command_offset &>/dev/null; then
+                _command_offset $i
+            elif [ $i -lt $COMP_CWORD ]; then
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+            elif [ $dashdash -eq 0 ] && [[ ${cur} == -* ]]; then
+                COMPREPLY=( $(compgen -W "--lockfile --gemfile --vendor --path --strict-ruby --keep-env --" -- ${cur}) )
+            else
+                COMPREPLY=( $(compgen -c -- ${cur}) )
+            fi
+            return 0
+        fi
+    fi
+
     # Complete flags
     case "${prev}" in
         --lockfile|-l)
@@ -54,6 +80,7 @@ _ore() {
         'fetch:Download gems into cache (no Ruby required)'
         'install:Install gems from Gemfile.lock'
         'check:Verify all gems are installed'
+        'verify:Check installed gems are complete'
         'list:List all gems in the current bundle'
         'show:Show the source location of a gem'
         'info:Show detailed information about a gem'
@@ -82,7 +109,7 @@ _ore() {
             ;;
         args)
             case $words[1] in
-                install|fetch|check|list|lock)
+                install|fetch|check|verify|list|lock)
                     _arguments \
                         '--lockfile[Path to Gemfile.lock]:file:_files -g "*.lock"' \
                         '--vendor[Destination directory]:directory:_directories' \
@@ -93,6 +120,18 @@ _ore() {
                     _arguments \
                         '*:gem name:'
                     ;;
+                exec)
+                    # -S: "--" ends ore's options; the command's arguments use its own completion
+                    _arguments -S \
+                        '--lockfile[Path to Gemfile.lock]:file:_files -g "*.lock"' \
+                        '--gemfile[Path to the Gemfile]:file:_files' \
+                        '--vendor[Path to installed gems]:directory:_directories' \
+                        '--path[Bundler-style install path]:directory:_directories' \
+                        '--strict-ruby[Fail when the active Ruby differs from the lockfile]' \
+                        '*--keep-env[Pass this variable through unchanged]:variable:_parameters' \
+                        '(-)1:command:_command_names -e' \
+                        '*::arguments:_normal'
+                    ;;
                 *)
                     _arguments \
                         '--help[Print command help]'
@@ -119,6 +158,7 @@ complete -c ore -f -n '__fish_use_subcommand' -a 'lock' -d 'Regenerate Gemfile.l
 complete -c ore -f -n '__fish_use_subcommand' -a 'fetch' -d 'Download gems into cache (no Ruby required)'
 complete -c ore -f -n '__fish_use_subcommand' -a 'install' -d 'Install gems from Gemfile.lock'
 complete -c ore -f -n '__fish_use_subcommand' -a 'check' -d 'Verify all gems are installed'
+complete -c ore -f -n '__fish_use_subcommand' -a 'verify' -d 'Check installed gems are complete'
 complete -c ore -f -n '__fish_use_subcommand' -a 'list' -d 'List all gems in the current bundle'
 complete -c ore -f -n '__fish_use_subcommand' -a 'show' -d 'Show the source location of a gem'
 complete -c ore -f -n '__fish_use_subcommand' -a 'info' -d 'Show detailed information about a gem'
@@ -139,10 +179,16 @@ complete -c ore -f -s h -l help -d 'Print help'
 complete -c ore -f -s V -l version -d 'Print version'
 
 # Common options for install/fetch/check commands
-complete -c ore -f -n '__fish_seen_subcommand_from install fetch check list lock' -l lockfile -d 'Path to Gemfile.lock' -r -F
-complete -c ore -f -n '__fish_seen_subcommand_from install fetch check list' -l vendor -d 'Destination directory' -r -a '(__fish_complete_directories)'
+complete -c ore -f -n '__fish_seen_subcommand_from install fetch check verify list lock' -l lockfile -d 'Path to Gemfile.lock' -r -F
+complete -c ore -f -n '__fish_seen_subcommand_from install fetch check verify list' -l vendor -d 'Destination directory' -r -a '(__fish_complete_directories)'
 complete -c ore -f -n '__fish_seen_subcommand_from install' -l force -d 'Force reinstall'
 complete -c ore -f -n '__fish_seen_subcommand_from install fetch' -l verbose -d 'Enable verbose output'
+
+# ore exec: complete the command to run, then that command's own arguments
+complete -c ore -n '__fish_seen_subcommand_from exec' -l gemfile -d 'Path to the Gemfile' -r -F
+complete -c ore -n '__fish_seen_subcommand_from exec' -l strict-ruby -d 'Fail when the active Ruby differs from the lockfile'
+complete -c ore -n '__fish_seen_subcommand_from exec' -l keep-env -d 'Pass this variable through unchanged' -x
+complete -c ore -n '__fish_seen_subcommand_from exec' -xa '(__fish_complete_subcommand --fcs-skip=2)'
 `)
 }
 
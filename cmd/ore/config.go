@@ -6,20 +6,55 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	toml "github.com/pelletier/go-toml/v2"
 )
 
 type SourceConfig struct {
-	URL      string `toml:"url"`
-	Fallback string `toml:"fallback,omitempty"`
+	URL        string `toml:"url"`
+	Fallback   string `toml:"fallback,omitempty"`
+	HealthPath string `toml:"health_path,omitempty"` // Probe path for the pre-flight health check
+
+	// ResponseTimeout bounds the wait for response headers from url before trying
+	// fallback ("10s", or seconds); defaults to the global HTTP timeout
+	ResponseTimeout string `toml:"response_timeout,omitempty"`
+}
+
+// CredentialConfig is a [[credentials]] entry: auth for a private source host, kept out of
+// Gemfile URLs. Token is sent as the basic auth user with an empty password, as Bundler does; otherwise Username/Password as basic auth.
+type CredentialConfig struct {
+	Host     string `toml:"host"`
+	Token    string `toml:"token,omitempty"`
+	Username string `toml:"username,omitempty"`
+	Password string `toml:"password,omitempty"`
+}
+
+// InstallConfig is the [install] section: defaults for ore install's flags. An explicit
+// flag overrides it, and it overrides the environment and .bundle/config.
+type InstallConfig struct {
+	Without        string `toml:"without,omitempty"`         // Comma-separated groups to skip (--without)
+	With           string `toml:"with,omitempty"`            // Comma-separated optional groups to install (--with)
+	Vendor         string `toml:"vendor,omitempty"`          // Install directory (--vendor)
+	Workers        int    `toml:"workers,omitempty"`         // Concurrent downloads (--workers)
+	SkipExtensions bool   `toml:"skip_extensions,omitempty"` // Don't build native extensions (--skip-extensions)
 }
 
 type Config struct {
-	VendorDir  string         `toml:"vendor_dir"`
-	CacheDir   string         `toml:"cache_dir"`
-	GemSources []SourceConfig `toml:"gem_sources"`
-	Gemfile    string         `toml:"gemfile"`
+	VendorDir        string             `toml:"vendor_dir"`
+	CacheDir         string             `toml:"cache_dir"`
+	GemSources       []SourceConfig     `toml:"gem_sources"`
+	Gemfile          string             `toml:"gemfile"`
+	MaxDownloadRate  int64              `toml:"max_download_rate"` // Bytes/sec across all downloads (0 = unlimited)
+	LinkCache        bool               `toml:"link_cache"`        // Link vendor/cache/*.gem to the ore cache instead of copying
+	SharedStore      bool               `toml:"shared_store"`      // Link vendor/gems/* to one extracted copy in <cache_dir>/store
+	HTTPTimeout      string             `toml:"http_timeout"`      // Per-request HTTP timeout ("90s", "2m", or seconds)
+	CacheCompression string             `toml:"cache_compression"` // "zstd" stores cached gems compressed (default "none")
+	Credentials      []CredentialConfig `toml:"credentials"`
+	GitSSHCommand    string             `toml:"git_ssh_command"` // GIT_SSH_COMMAND for SSH git remotes
+	GitSSHKey        string             `toml:"git_ssh_key"`     // Identity file for SSH git remotes (e.g. a CI deploy key)
+	Install          InstallConfig      `toml:"install"`
 }
 
 var appConfig = loadConfig()
@@ -27,7 +62,7 @@ var appConfig = loadConfig()
 func loadConfig() *Config {
 	cfg := &Config{}
 
-	merge := func(path string) {
+	merge := func(path string, project bool) {
 		if path == "" {
 			return
 		}
@@ -46,11 +81,24 @@ func loadConfig() *Config {
 			return
 		}
 
+		// A project .ore.toml ships with the checkout, so it must not pick the command
+		// git runs over SSH: locking an untrusted repo would execute it
+		if project {
+			ignore := func(key string, value *string) {
+				if *value != "" {
+					fmt.Fprintf(os.Stderr, "warning: ignoring %s in project config %s; set it in the user config or ORE_%s\n", key, path, strings.ToUpper(key))
+					*value = ""
+				}
+			}
+			ignore("git_ssh_command", &fileCfg.GitSSHCommand)
+			ignore("git_ssh_key", &fileCfg.GitSSHKey)
+		}
+
 		cfg.merge(fileCfg)
 	}
 
-	merge(userConfigPath())
-	merge(projectConfigPath())
+	merge(userConfigPath(), false)
+	merge(projectConfigPath(), true)
 
 	return cfg
 }
@@ -68,6 +116,77 @@ func (c *Config) merge(other Config) {
 	if other.Gemfile != "" {
 		c.Gemfile = other.Gemfile
 	}
+	if other.MaxDownloadRate != 0 {
+		c.MaxDownloadRate = other.MaxDownloadRate
+	}
+	if other.LinkCache {
+		c.LinkCache = true
+	}
+	if other.SharedStore {
+		c.SharedStore = true
+	}
+	if other.HTTPTimeout != "" {
+		c.HTTPTimeout = other.HTTPTimeout
+	}
+	if other.CacheCompression != "" {
+		c.CacheCompression = other.CacheCompression
+	}
+	if other.GitSSHCommand != "" {
+		c.GitSSHCommand = other.GitSSHCommand
+	}
+	if other.GitSSHKey != "" {
+		c.GitSSHKey = other.GitSSHKey
+	}
+	c.Install.merge(other.Install)
+	// Credentials are per host: a project entry replaces the user entry for the same host
+	for _, cred := range other.Credentials {
+		c.Credentials = slices.DeleteFunc(c.Credentials, func(existing CredentialConfig) bool {
+			return strings.EqualFold(existing.Host, cred.Host)
+		})
+		c.Credentials = append(c.Credentials, cred)
+	}
+}
+
+func (c *InstallConfig) merge(other InstallConfig) {
+	if other.Without != "" {
+		c.Without = other.Without
+	}
+	if other.With != "" {
+		c.With = other.With
+	}
+	if other.Vendor != "" {
+		c.Vendor = other.Vendor
+	}
+	if other.Workers > 0 {
+		c.Workers = other.Workers
+	}
+	if other.SkipExtensions {
+		c.SkipExtensions = true
+	}
+}
+
+// installDefaults returns the [install] config section
+func installDefaults() InstallConfig {
+	if appConfig == nil {
+		return InstallConfig{}
+	}
+	return appConfig.Install
+}
+
+// credentialsFor returns the [[credentials]] entry for host as "user:pass" or a token
+func (c *Config) credentialsFor(host string) string {
+	for _, cred := range c.Credentials {
+		if !strings.EqualFold(cred.Host, host) {
+			continue
+		}
+		if cred.Token != "" {
+			return cred.Token
+		}
+		if cred.Username != "" {
+			return cred.Username + ":" + cred.Password
+		}
+	}
+	return ""
 }
 
 func userConfigPath() string {
@@ -2,16 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/config"
+	"github.com/contriboss/ore-light/internal/geminstall"
+	"github.com/contriboss/ore-light/internal/httpclient"
+	"github.com/contriboss/ore-light/internal/logger"
+	"github.com/contriboss/ore-light/internal/ruby"
 	"github.com/contriboss/ore-light/internal/sources"
 	"golang.org/x/sync/errgroup"
 )
@@ -22,6 +29,13 @@ type downloadManager struct {
 	cacheDir      string
 	sourceManager *sources.Manager
 	workers       int
+	quiet         bool                    // Suppress progress output (ore install --quiet/--json)
+	compress      bool                    // Store downloaded gems compressed (cache_compression = "zstd")
+	trustPolicy   *geminstall.TrustPolicy // Signature policy checked before a gem is used (--trust-policy)
+	trustDir      string                  // Trusted root certificates for trustPolicy
+	mirrors       map[string]string       // Bundler mirrors, applied to gems locked to their own remote
+	retryMissing  bool                    // Keep going after a failed download and report every failure at the end (--retry-missing)
+	localSource   string                  // Directory of .gem files used instead of downloading (--local-source)
 }
 
 // This is like a thread-safe Ruby object with attr_accessor methods
@@ -30,9 +44,16 @@ type downloadReport struct {
 	Total      int
 	Downloaded int
 	Skipped    int
+	Failed     []downloadFailure // Gems that couldn't be fetched, with retryMissing
 	mu         sync.Mutex
 }
 
+// downloadFailure is a gem DownloadAll couldn't fetch from any source
+type downloadFailure struct {
+	Gem string
+	Err error
+}
+
 func newDownloadManager(cacheDir string, sourceConfigs []SourceConfig, client *http.Client, workers int) (*downloadManager, error) {
 	if cacheDir == "" {
 		return nil, fmt.Errorf("cache directory must be provided")
@@ -55,8 +76,16 @@ func newDownloadManager(cacheDir string, sourceConfigs []SourceConfig, client *h
 	managerConfigs := make([]sources.SourceConfig, len(sourceConfigs))
 	for i, config := range sourceConfigs {
 		managerConfigs[i] = sources.SourceConfig{
-			URL:      config.URL,
-			Fallback: config.Fallback,
+			URL:        config.URL,
+			Fallback:   config.Fallback,
+			HealthPath: config.HealthPath,
+		}
+		if config.ResponseTimeout != "" {
+			timeout, err := httpclient.ParseTimeout(config.ResponseTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid response_timeout %q for %s: %w", config.ResponseTimeout, httpclient.RedactURL(config.URL), err)
+			}
+			managerConfigs[i].ResponseTimeout = timeout
 		}
 	}
 
@@ -64,6 +93,7 @@ func newDownloadManager(cacheDir string, sourceConfigs []SourceConfig, client *h
 		cacheDir:      cacheDir,
 		sourceManager: sources.NewManager(managerConfigs, client),
 		workers:       workers,
+		mirrors:       config.ReadBundleMirrors(),
 	}, nil
 }
 
@@ -96,7 +126,13 @@ func (m *downloadManager) DownloadAll(ctx context.Context, gems []lockfile.GemSp
 
 			downloaded, err := m.downloadGem(ctx, gem, force)
 			if err != nil {
-				return err
+				if !m.retryMissing || ctx.Err() != nil {
+					return err
+				}
+				report.mu.Lock()
+				report.Failed = append(report.Failed, downloadFailure{Gem: gem.FullName(), Err: err})
+				report.mu.Unlock()
+				return nil
 			}
 
 			// Mutex.Lock/Unlock is like Ruby's synchronize { } block
@@ -113,26 +149,67 @@ func (m *downloadManager) DownloadAll(ctx context.Context, gems []lockfile.GemSp
 
 	// Wait for all goroutines - like Thread.join in Ruby
 	err := g.Wait()
+	if err == nil && len(report.Failed) > 0 {
+		err = missingGemsError(report.Failed)
+	}
 	return report, err
 }
 
+// missingGemsError lists every gem --retry-missing couldn't fetch, so mirror gaps show
+// up in one run instead of one failure at a time
+func missingGemsError(failed []downloadFailure) error {
+	sort.Slice(failed, func(i, j int) bool { return failed[i].Gem < failed[j].Gem })
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d gem(s) couldn't be fetched from any source:", len(failed))
+	for _, failure := range failed {
+		fmt.Fprintf(&b, "\n  - %s: %v", failure.Gem, failure.Err)
+	}
+	return errors.New(b.String())
+}
+
 func (m *downloadManager) downloadGem(ctx context.Context, gem lockfile.GemSpec, force bool) (bool, error) {
 	cachePath := m.cachePathFor(gem)
-	if !force {
-		// Check all cache locations (ore cache + system RubyGems cache)
-		if foundPath := m.findInCaches(gem); foundPath != "" {
+	if !force || m.localSource != "" {
+		// Check all cache locations (ore cache + system RubyGems cache); with --force, only
+		// the local source, which stands in for downloading. A copy that no longer
+		// matches the lockfile's checksum (e.g. after ore update) is stale.
+		foundPath := m.findInCaches(gem)
+		if force {
+			foundPath = geminstall.FindCachedGem([]string{m.localSource}, gem)
+		}
+		if foundPath != "" && !cachedGemMatchesChecksum(gem, foundPath) {
+			// A local source stands in for downloading, so there's no other copy to try
+			if m.localSource != "" {
+				return false, fmt.Errorf("%s: %w (a local source can't download it again)", foundPath, verifyGemChecksum(gem, foundPath))
+			}
+			if !m.quiet {
+				fmt.Fprintf(os.Stderr, "Cached %s doesn't match the lockfile's checksum; downloading it again\n", gem.FullName())
+			}
+			foundPath = ""
+		}
+		if foundPath != "" {
 			// Gem found in cache, copy to primary cache if not there already
-			if foundPath != cachePath {
-				if err := copyFile(foundPath, cachePath); err != nil {
+			if filepath.Dir(foundPath) != m.cacheDir {
+				if err := m.storeInCache(foundPath, cachePath); err != nil {
 					// Non-fatal: we can still use the gem from system cache
 					// but log the copy failure for visibility
 					fmt.Fprintf(os.Stderr, "Note: Using %s from system cache (copy failed: %v)\n", gem.FullName(), err)
 				}
 			}
+			if err := m.verifyGem(gem, foundPath); err != nil {
+				return false, err
+			}
+			if logger.Verbose() && !m.quiet {
+				fmt.Printf("Using cached %s (%s)\n", gem.FullName(), foundPath)
+			}
 			return false, nil
 		}
 	}
 
+	if m.localSource != "" {
+		return false, fmt.Errorf("%s isn't in the local source %s or the gem cache", gemFileName(gem), m.localSource)
+	}
+
 	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
 		return false, fmt.Errorf("failed to prepare cache dir: %w", err)
 	}
@@ -148,7 +225,7 @@ func (m *downloadManager) downloadGem(ctx context.Context, gem lockfile.GemSpec,
 
 	// Use SourceManager to download with fallback support
 	gemName := gemFileName(gem)
-	if err := m.sourceManager.DownloadGem(ctx, gemName, tempFile); err != nil {
+	if err := m.sourceManager.DownloadGemFrom(ctx, m.remoteFor(gem), gemName, tempFile); err != nil {
 		return false, fmt.Errorf("failed to download %s: %w", gem.FullName(), err)
 	}
 
@@ -156,20 +233,108 @@ func (m *downloadManager) downloadGem(ctx context.Context, gem lockfile.GemSpec,
 		return false, fmt.Errorf("failed to close temp file for %s: %w", gem.FullName(), err)
 	}
 
-	if err := os.Rename(tempFile.Name(), cachePath); err != nil {
+	// Check the lockfile's checksum and signatures before the gem enters the cache
+	if err := verifyGemChecksum(gem, tempFile.Name()); err != nil {
+		return false, err
+	}
+	if err := m.verifyGem(gem, tempFile.Name()); err != nil {
+		return false, err
+	}
+
+	if m.compress {
+		if err := geminstall.CompressGem(tempFile.Name(), cachePath); err != nil {
+			return false, fmt.Errorf("failed to finalize download for %s: %w", gem.FullName(), err)
+		}
+	} else if err := os.Rename(tempFile.Name(), cachePath); err != nil {
 		return false, fmt.Errorf("failed to finalize download for %s: %w", gem.FullName(), err)
 	}
 
-	fmt.Printf("Fetched %s\n", gem.FullName())
+	if !m.quiet {
+		fmt.Printf("Fetched %s\n", gem.FullName())
+	}
 	return true, nil
 }
 
+// remoteFor returns the remote a gem must be downloaded from: its GEM section's remote
+// when the lockfile has several (Gemfile source blocks), or "" for the configured sources
+func (m *downloadManager) remoteFor(gem lockfile.GemSpec) string {
+	if gem.SourceURL == "" || strings.EqualFold(strings.TrimSuffix(gem.SourceURL, "/"), defaultGemRemote) {
+		return ""
+	}
+	return config.ApplyMirror(m.mirrors, gem.SourceURL)
+}
+
+// setTrustPolicy enables signature verification under the named RubyGems policy,
+// trusting the root certificates in geminstall.DefaultTrustDir
+func (m *downloadManager) setTrustPolicy(name string) error {
+	policy, err := geminstall.ParseTrustPolicy(name)
+	if err != nil {
+		return err
+	}
+	m.trustPolicy = policy
+	m.trustDir = geminstall.DefaultTrustDir()
+	return nil
+}
+
+// verifyGem checks a gem file against the --trust-policy, if any
+func (m *downloadManager) verifyGem(gem lockfile.GemSpec, path string) error {
+	if err := geminstall.VerifyGem(path, m.trustPolicy, m.trustDir); err != nil {
+		return fmt.Errorf("%s failed %s verification: %w", gem.FullName(), m.trustPolicy.Name, err)
+	}
+	return nil
+}
+
+// cachedGemMatchesChecksum reports whether a cached gem has the SHA-256 the lockfile's
+// CHECKSUMS section records for it; gems without a recorded checksum always match
+func cachedGemMatchesChecksum(gem lockfile.GemSpec, path string) bool {
+	return gem.Checksum == "" || verifyGemChecksum(gem, path) == nil
+}
+
+// verifyGemChecksum checks a gem file against the lockfile's CHECKSUMS entry, if any
+func verifyGemChecksum(gem lockfile.GemSpec, path string) error {
+	if gem.Checksum == "" {
+		return nil
+	}
+	sum, err := geminstall.GemSHA256(path)
+	if err != nil {
+		return fmt.Errorf("failed to checksum %s: %w", gem.FullName(), err)
+	}
+	if !strings.EqualFold(sum, gem.Checksum) {
+		return fmt.Errorf("%s doesn't match the lockfile's checksum (expected sha256=%s, got sha256=%s)", gem.FullName(), gem.Checksum, sum)
+	}
+	return nil
+}
+
 func (m *downloadManager) cachePathFor(gem lockfile.GemSpec) string {
-	return filepath.Join(m.cacheDir, gemFileName(gem))
+	path := filepath.Join(m.cacheDir, gemFileName(gem))
+	if m.compress {
+		path += geminstall.CompressedGemExt
+	}
+	return path
+}
+
+// storeInCache copies a gem found in another cache (e.g. the system RubyGems cache)
+// into the ore cache, compressing it when cache compression is enabled
+func (m *downloadManager) storeInCache(src, cachePath string) error {
+	if m.compress && !geminstall.IsCompressedGem(src) {
+		return geminstall.CompressGem(src, cachePath)
+	}
+	return copyFile(src, cachePath)
 }
 
 // CheckSourceHealth performs pre-flight health checks on all configured sources
 func (m *downloadManager) CheckSourceHealth(ctx context.Context) {
+	if m.quiet {
+		m.sourceManager.CheckHealth(ctx)
+		// Only report problems
+		for _, source := range m.sourceManager.GetSources() {
+			if !source.Healthy {
+				fmt.Fprintf(os.Stderr, "Warning: gem source %s is unavailable\n", httpclient.RedactURL(source.URL))
+			}
+		}
+		return
+	}
+
 	fmt.Println("Checking gem source availability...")
 	m.sourceManager.CheckHealth(ctx)
 
@@ -177,29 +342,37 @@ func (m *downloadManager) CheckSourceHealth(ctx context.Context) {
 	sources := m.sourceManager.GetSources()
 	for _, source := range sources {
 		if source.Healthy {
-			fmt.Printf("  ✓ %s (healthy)\n", source.URL)
+			fmt.Printf("  ✓ %s (healthy)\n", httpclient.RedactURL(source.URL))
 		} else {
-			fmt.Printf("  ✗ %s (unavailable)\n", source.URL)
+			fmt.Printf("  ✗ %s (unavailable)\n", httpclient.RedactURL(source.URL))
 		}
 		if source.Fallback != "" {
 			if source.FallbackHealthy {
-				fmt.Printf("    └─ fallback: %s (healthy)\n", source.Fallback)
+				fmt.Printf("    └─ fallback: %s (healthy)\n", httpclient.RedactURL(source.Fallback))
 			} else {
-				fmt.Printf("    └─ fallback: %s (unavailable)\n", source.Fallback)
+				fmt.Printf("    └─ fallback: %s (unavailable)\n", httpclient.RedactURL(source.Fallback))
 			}
 		}
 	}
 }
 
-// cacheLocations returns all cache directories to check for gems
+// cacheLocations returns all cache directories to check for gems, starting with the
+// local source
 func (m *downloadManager) cacheLocations() []string {
-	locations := []string{m.cacheDir} // Ore cache first
+	if m.localSource != "" {
+		return append([]string{m.localSource}, gemCacheLocations(m.cacheDir)...)
+	}
+	return gemCacheLocations(m.cacheDir)
+}
+
+// gemCacheLocations returns the ore cache followed by any system RubyGems caches
+func gemCacheLocations(cacheDir string) []string {
+	locations := []string{cacheDir} // Ore cache first
 
 	// Try to get system RubyGems caches (only if Ruby is available)
 	if gemPaths := tryGetGemPaths(); len(gemPaths) > 0 {
 		for _, gemPath := range gemPaths {
-			cacheDir := filepath.Join(gemPath, "cache")
-			locations = append(locations, cacheDir)
+			locations = append(locations, filepath.Join(gemPath, "cache"))
 		}
 	}
 
@@ -209,7 +382,7 @@ func (m *downloadManager) cacheLocations() []string {
 // tryGetGemPaths attempts to get gem paths, returns empty if Ruby not available
 func tryGetGemPaths() []string {
 	// Try using `gem environment gempath` if Ruby is available
-	cmd := exec.Command("gem", "environment", "gempath")
+	cmd := exec.Command(ruby.Binary(), "-e", "print Gem.path.join(File::PATH_SEPARATOR)")
 	output, err := cmd.Output()
 	if err == nil {
 		pathsStr := strings.TrimSpace(string(output))
@@ -260,14 +433,7 @@ func tryGetGemPaths() []string {
 
 // findInCaches searches all cache locations for a gem
 func (m *downloadManager) findInCaches(gem lockfile.GemSpec) string {
-	fileName := gemFileName(gem)
-	for _, cacheDir := range m.cacheLocations() {
-		path := filepath.Join(cacheDir, fileName)
-		if _, err := os.Stat(path); err == nil {
-			return path
-		}
-	}
-	return ""
+	return geminstall.FindCachedGem(m.cacheLocations(), gem)
 }
 
 // copyFile copies a file from src to dst
@@ -305,5 +471,5 @@ func (m *downloadManager) CacheDir() string {
 }
 
 func gemFileName(gem lockfile.GemSpec) string {
-	return fmt.Sprintf("%s.gem", gem.FullName())
+	return geminstall.GemFileName(gem)
 }
@@ -9,12 +9,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/contriboss/gemfile-go/lockfile"
 	"github.com/contriboss/ore-light/internal/config"
+	"github.com/contriboss/ore-light/internal/errdefs"
 	"github.com/contriboss/ore-light/internal/extensions"
 	"github.com/contriboss/ore-light/internal/geminstall"
+	"github.com/contriboss/ore-light/internal/logger"
 	"github.com/contriboss/ore-light/internal/registry"
 	"github.com/contriboss/ore-light/internal/resolver"
 	"github.com/contriboss/ore-light/internal/ruby"
@@ -26,10 +29,138 @@ import (
 type installReport struct {
 	Total             int
 	Installed         int
+	InstalledGems     []string // Full names of the gems installed in this run
+	Shared            int      // Installed gems linked to the shared store (--shared-store)
 	Skipped           int
 	ExtensionsBuilt   int
 	ExtensionsSkipped int
 	ExtensionsFailed  int
+	Extensions        []extensionResult // Each gem's extension build outcome
+	Engine            ruby.Engine       // Ruby engine the gems were checked against
+	Incompatible      []incompatibleGem // Gems skipped because the engine can't build their C extensions
+	RubyVersion       string            // Ruby the gems' required_ruby_version was checked against
+	RubyMismatches    []incompatibleGem // Gems installed although their required_ruby_version excludes it
+}
+
+// incompatibleGem is a gem installFromCache skipped for the Ruby engine, with the reason
+type incompatibleGem struct {
+	Name   string `json:"gem"`
+	Reason string `json:"reason"`
+}
+
+// formatIncompatibleReport summarizes the gems skipped because the Ruby engine (e.g.
+// JRuby) can't build their C extensions, one line per gem with the reason.
+// Gems skipped for being another platform's variant aren't worth reporting.
+func formatIncompatibleReport(engine ruby.Engine, skipped []incompatibleGem) string {
+	if len(skipped) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Skipped %d gem(s) with C extensions %s can't build:\n", len(skipped), engine)
+	for _, gem := range skipped {
+		fmt.Fprintf(&b, "  %s: %s\n", gem.Name, gem.Reason)
+	}
+	if engine.Name == ruby.EngineJRuby {
+		b.WriteString("Run `ore lock --add-platform java` to lock their java variants, if they have one.\n")
+	}
+	return b.String()
+}
+
+// formatRubyRequirementReport warns about installed gems whose required_ruby_version
+// the target Ruby doesn't satisfy; they'd fail with a confusing error when loaded
+func formatRubyRequirementReport(rubyVersion string, mismatches []incompatibleGem) string {
+	if len(mismatches) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Warning: %d gem(s) don't support Ruby %s and may fail to load:\n", len(mismatches), rubyVersion)
+	for _, gem := range mismatches {
+		fmt.Fprintf(&b, "  %s: %s\n", gem.Name, gem.Reason)
+	}
+	b.WriteString("Switch Ruby, or run `ore update <gem>` to lock versions that support it.\n")
+	return b.String()
+}
+
+// targetRubyVersion is the Ruby gems are installed for: the active interpreter's
+// RUBY_VERSION, or the RUBY VERSION of the lockfile being installed when no Ruby can be run
+func targetRubyVersion(lockfilePath string) string {
+	if version := ruby.ActiveVersion(); version != "" {
+		return version
+	}
+	return ruby.DetectRubyVersionFromLockfile(lockfilePath, func(version string) string { return version })
+}
+
+// unsupportedRubyReason returns why a gem's required_ruby_version (from its metadata)
+// excludes rubyVersion, or "" when it's satisfied or either one can't be read
+func unsupportedRubyReason(metadata []byte, rubyVersion string) string {
+	if rubyVersion == "" {
+		return ""
+	}
+	meta, err := geminstall.ParseGemMetadata(metadata)
+	if err != nil || meta.RequiredRubyVersion == ">= 0" {
+		return ""
+	}
+	condition, err := resolver.NewSemverCondition(meta.RequiredRubyVersion)
+	if err != nil {
+		return ""
+	}
+	version, err := resolver.NewSemverVersion(rubyVersion)
+	if err != nil || condition.Satisfies(version) {
+		return ""
+	}
+	return "requires ruby " + meta.RequiredRubyVersion
+}
+
+// Extension build outcomes, as reported in extensionResult.Status
+const (
+	extensionBuilt   = "built"
+	extensionSkipped = "skipped"
+	extensionFailed  = "failed"
+)
+
+// extensionResult is one gem's extension build outcome, also used for --json output
+type extensionResult struct {
+	Gem    string `json:"gem"`
+	Status string `json:"status"` // extensionBuilt, extensionSkipped or extensionFailed
+	Error  string `json:"error,omitempty"`
+	Log    string `json:"log,omitempty"` // Build output, for failures
+}
+
+// recordExtension counts a gem's extension build outcome and keeps it for the summary
+func (r *installReport) recordExtension(gemName, status string, err error, logPath string) {
+	result := extensionResult{Gem: gemName, Status: status, Log: logPath}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	switch status {
+	case extensionBuilt:
+		r.ExtensionsBuilt++
+	case extensionSkipped:
+		r.ExtensionsSkipped++
+	case extensionFailed:
+		r.ExtensionsFailed++
+	}
+	r.Extensions = append(r.Extensions, result)
+}
+
+// formatExtensionFailures lists the gems whose extensions failed to build, each with
+// the error and where to find its build log
+func formatExtensionFailures(results []extensionResult) string {
+	var b strings.Builder
+	for _, result := range results {
+		if result.Status != extensionFailed {
+			continue
+		}
+		fmt.Fprintf(&b, "  %s", result.Gem)
+		if result.Error != "" {
+			fmt.Fprintf(&b, ": %s", result.Error)
+		}
+		if result.Log != "" {
+			fmt.Fprintf(&b, " (log: %s)", result.Log)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
 }
 
 // extensionTarget tracks a gem that needs extensions built
@@ -65,12 +196,12 @@ func installBuildDependency(ctx context.Context, gemName, cacheDir, vendorDir st
 	if _, err := os.Stat(cachedPath); err != nil {
 		// Need to download
 		if verbose {
-			fmt.Printf("📦 Fetching build dependency %s-%s...\n", gemName, targetVersion)
+			logger.Printf("📦 Fetching build dependency %s-%s...\n", gemName, targetVersion)
 		}
 
 		// Create source manager for download
 		sourceManager := sources.NewManager([]sources.SourceConfig{
-			{URL: "https://rubygems.org", Fallback: ""},
+			{URL: config.ApplyMirror(config.ReadBundleMirrors(), defaultGemRemote), Fallback: ""},
 		}, nil)
 
 		outFile, err := os.Create(cachedPath)
@@ -112,23 +243,22 @@ func installBuildDependency(ctx context.Context, gemName, cacheDir, vendorDir st
 	}
 
 	if verbose {
-		fmt.Printf("✓ Installed build dependency %s-%s\n", gemName, targetVersion)
+		logger.Printf("✓ Installed build dependency %s-%s\n", gemName, targetVersion)
 	}
 
 	return nil
 }
 
-func installFromCache(ctx context.Context, cacheDir, vendorDir string, gems []lockfile.GemSpec, force bool, buildExtensions bool, extConfig *extensions.BuildConfig) (installReport, error) {
-	report := installReport{Total: len(gems)}
-
+// installFromCache installs cached gems into vendorDir. With a storeDir (--shared-store),
+// gems without native extensions are linked to a copy extracted once into the shared store.
+// Gems whose required_ruby_version excludes rubyVersion are reported; "" skips that check.
+func installFromCache(ctx context.Context, cacheDir, vendorDir string, gems []lockfile.GemSpec, force bool, buildExtensions bool, linkCache bool, storeDir string, rubyVersion string, extConfig *extensions.BuildConfig) (installReport, error) {
 	// Detect Ruby engine for compatibility filtering
 	engine := ruby.DetectEngine()
+	report := installReport{Total: len(gems), Engine: engine, RubyVersion: rubyVersion}
 	engineChecker := resolver.NewEngineCompatibility(engine)
 
-	if err := geminstall.EnsureDir(filepath.Join(vendorDir, "gems")); err != nil {
-		return report, err
-	}
-	if err := geminstall.EnsureDir(filepath.Join(vendorDir, "cache")); err != nil {
+	if err := geminstall.EnsureGemSubdirectories(vendorDir); err != nil {
 		return report, err
 	}
 	if err := geminstall.EnsureDir(filepath.Join(vendorDir, "bin")); err != nil {
@@ -147,7 +277,7 @@ func installFromCache(ctx context.Context, cacheDir, vendorDir string, gems []lo
 	for _, gem := range gems {
 		gemPath := findGemInCaches(cacheDir, gem)
 		if gemPath == "" {
-			return report, fmt.Errorf("gem %s is not cached; run `ore download` first", gem.FullName())
+			return report, errdefs.Newf(errdefs.ErrGemNotCached, "gem %s is not cached; run `ore download` first", gem.FullName())
 		}
 
 		destDir := filepath.Join(vendorDir, "gems", gem.FullName())
@@ -168,6 +298,14 @@ func installFromCache(ctx context.Context, cacheDir, vendorDir string, gems []lo
 					})
 				}
 			}
+			// Installed under another Ruby, it may not load under this one
+			if report.RubyVersion != "" {
+				if metadata, err := geminstall.ExtractMetadataOnly(gemPath); err == nil {
+					if reason := unsupportedRubyReason(metadata, report.RubyVersion); reason != "" {
+						report.RubyMismatches = append(report.RubyMismatches, incompatibleGem{Name: gem.FullName(), Reason: reason})
+					}
+				}
+			}
 			report.Skipped++
 			continue
 		}
@@ -181,6 +319,7 @@ func installFromCache(ctx context.Context, cacheDir, vendorDir string, gems []lo
 
 		// Check engine compatibility BEFORE full extraction
 		// Parse metadata to populate gem.Extensions for compatibility check
+		var gemExtensions []string
 		if len(metadata) > 0 {
 			// Parse extensions from metadata YAML
 			gemWithExtensions := gem
@@ -200,24 +339,46 @@ func installFromCache(ctx context.Context, cacheDir, vendorDir string, gems []lo
 			if !engineChecker.IsCompatible(gemWithExtensions) {
 				reason := engineChecker.GetIncompatibilityReason(gemWithExtensions)
 				if extConfig != nil && extConfig.Verbose {
-					fmt.Printf("⚠️  Skipping %s: %s\n", gem.FullName(), reason)
+					logger.Printf("⚠️  Skipping %s: %s\n", gem.FullName(), reason)
+				}
+				if engineChecker.LacksExtensionSupport(gemWithExtensions) {
+					report.Incompatible = append(report.Incompatible, incompatibleGem{Name: gem.FullName(), Reason: reason})
 				}
 				report.Skipped++
 				continue
 			}
-		}
+			gemExtensions = gemWithExtensions.Extensions
 
-		// Gem is compatible - proceed with full extraction
-		if err := os.RemoveAll(destDir); err != nil {
-			return report, fmt.Errorf("failed to clean install dir for %s: %w", gem.FullName(), err)
+			// Install anyway, like the lockfile asks, but say why it may not load
+			if reason := unsupportedRubyReason(metadata, report.RubyVersion); reason != "" {
+				report.RubyMismatches = append(report.RubyMismatches, incompatibleGem{Name: gem.FullName(), Reason: reason})
+			}
 		}
 
-		_, err = geminstall.ExtractGemContents(gemPath, destDir)
-		if err != nil {
-			return report, fmt.Errorf("failed to extract %s: %w", gem.FullName(), err)
+		// Gem is compatible - proceed with full extraction. Gems with native extensions are
+		// never shared: their builds depend on the Ruby each bundle uses.
+		shared := storeDir != "" && len(gemExtensions) == 0
+		if shared {
+			if _, err := geminstall.InstallFromStore(storeDir, gemPath, gem.FullName(), destDir); err != nil {
+				return report, fmt.Errorf("failed to install %s from the shared store: %w", gem.FullName(), err)
+			}
+		} else {
+			if err := os.RemoveAll(destDir); err != nil {
+				return report, fmt.Errorf("failed to clean install dir for %s: %w", gem.FullName(), err)
+			}
+			if _, err := geminstall.ExtractGemContents(gemPath, destDir); err != nil {
+				return report, fmt.Errorf("failed to extract %s: %w", gem.FullName(), err)
+			}
 		}
 
-		if err := geminstall.CopyGemToVendorCache(gemPath, filepath.Join(vendorDir, "cache", gemFileName(gem))); err != nil {
+		// --link avoids storing every .gem twice (ore cache + vendor/cache)
+		vendorCachePath := filepath.Join(vendorDir, "cache", gemFileName(gem))
+		if linkCache {
+			err = geminstall.LinkGemToVendorCache(gemPath, vendorCachePath)
+		} else {
+			err = geminstall.CopyGemToVendorCache(gemPath, vendorCachePath)
+		}
+		if err != nil {
 			return report, err
 		}
 
@@ -232,19 +393,24 @@ func installFromCache(ctx context.Context, cacheDir, vendorDir string, gems []lo
 		}
 
 		// Collect this gem for extension building (defer until all gems installed)
-		extensionTargets = append(extensionTargets, extensionTarget{
-			gemName: gem.FullName(),
-			destDir: destDir,
-		})
+		if shared {
+			report.Shared++
+		} else {
+			extensionTargets = append(extensionTargets, extensionTarget{
+				gemName: gem.FullName(),
+				destDir: destDir,
+			})
+		}
 
 		report.Installed++
+		report.InstalledGems = append(report.InstalledGems, gem.FullName())
 	}
 
 	// Build extensions for all installed gems (two-phase: install all, then build all)
 	// This ensures all gem specifications are written before any extensions build,
 	// allowing gems like nokogiri to find build dependencies like mini_portile2
 	if extConfig != nil && extConfig.Verbose {
-		fmt.Printf("Building extensions for %d gems after all installations complete...\n", len(extensionTargets))
+		logger.Printf("Building extensions for %d gems after all installations complete...\n", len(extensionTargets))
 	}
 	buildPendingExtensions(ctx, extBuilder, engine, extensionTargets, &report, extConfig, cacheDir, vendorDir)
 
@@ -267,7 +433,7 @@ func buildPendingExtensions(ctx context.Context, extBuilder *extensions.Builder,
 		if (err != nil || !extResult.Success) && extResult != nil && len(extResult.MissingDependencies) > 0 {
 			// Try to install missing build dependencies
 			if extConfig.Verbose {
-				fmt.Printf("Extension build for %s requires: %v\n", target.gemName, extResult.MissingDependencies)
+				logger.Printf("Extension build for %s requires: %v\n", target.gemName, extResult.MissingDependencies)
 			}
 
 			// Determine cacheDir if not provided
@@ -278,26 +444,26 @@ func buildPendingExtensions(ctx context.Context, extBuilder *extensions.Builder,
 				actualCacheDir, configErr = config.DefaultCacheDir(nil)
 				if configErr != nil {
 					fmt.Fprintf(os.Stderr, "Warning: Failed to determine cache directory: %v\n", configErr)
-					report.ExtensionsFailed++
+					report.recordExtension(target.gemName, extensionFailed, configErr, "")
 					continue
 				}
 			}
 
 			// Install each missing dependency
-			allInstalled := true
+			var depErr error
 			for _, dep := range extResult.MissingDependencies {
 				if extConfig.Verbose {
-					fmt.Printf("Installing build dependency: %s\n", dep)
+					logger.Printf("Installing build dependency: %s\n", dep)
 				}
 				if err := installBuildDependency(ctx, dep, actualCacheDir, vendorDir, extConfig.Verbose); err != nil {
 					fmt.Fprintf(os.Stderr, "Warning: Failed to install build dependency %s: %v\n", dep, err)
-					allInstalled = false
+					depErr = fmt.Errorf("failed to install build dependency %s: %w", dep, err)
 					break
 				}
 			}
 
-			if !allInstalled {
-				report.ExtensionsFailed++
+			if depErr != nil {
+				report.recordExtension(target.gemName, extensionFailed, depErr, extResult.LogPath)
 				continue
 			}
 
@@ -312,13 +478,13 @@ func buildPendingExtensions(ctx context.Context, extBuilder *extensions.Builder,
 			}
 			if pathErr != nil {
 				fmt.Fprintf(os.Stderr, "Warning: Failed to update PATH for build dependencies: %v\n", pathErr)
-				report.ExtensionsFailed++
+				report.recordExtension(target.gemName, extensionFailed, pathErr, extResult.LogPath)
 				continue
 			}
 
 			// Retry building extensions after installing dependencies
 			if extConfig.Verbose {
-				fmt.Printf("Retrying extension build for %s...\n", target.gemName)
+				logger.Printf("Retrying extension build for %s...\n", target.gemName)
 			}
 			extResult, err = extBuilder.BuildExtensions(ctx, target.destDir, target.gemName, engine)
 		}
@@ -327,25 +493,49 @@ func buildPendingExtensions(ctx context.Context, extBuilder *extensions.Builder,
 		if err != nil || (extResult != nil && !extResult.Success) {
 			// Extension build failure - warn but continue
 			fmt.Fprintf(os.Stderr, "Warning: Failed to build extensions for %s: %v\n", target.gemName, err)
-			report.ExtensionsFailed++
+			logPath := ""
+			if extResult != nil && extResult.LogPath != "" {
+				logPath = extResult.LogPath
+				fmt.Fprintf(os.Stderr, "  Build log: %s\n", logPath)
+			}
+			if err == nil && extResult != nil {
+				err = extResult.Error
+			}
+			report.recordExtension(target.gemName, extensionFailed, err, logPath)
 		} else if extResult.Skipped {
-			report.ExtensionsSkipped++
+			report.recordExtension(target.gemName, extensionSkipped, nil, "")
 		} else if extResult.Success && len(extResult.Extensions) > 0 {
 			if extConfig.Verbose {
-				fmt.Printf("Built %d extension(s) for %s: %v\n", len(extResult.Extensions), target.gemName, extResult.Extensions)
+				logger.Printf("Built %d extension(s) for %s: %v\n", len(extResult.Extensions), target.gemName, extResult.Extensions)
 			}
-			report.ExtensionsBuilt++
+			report.recordExtension(target.gemName, extensionBuilt, nil, "")
 		}
 	}
 }
 
+// installLockedBundler installs the bundler gem at the lockfile's BUNDLED WITH version
+// into vendorDir and links its `bundle` binstub into vendorDir/bin (which ore exec puts
+// on PATH). An already-installed copy of that version is skipped unless force is set.
+func installLockedBundler(ctx context.Context, dm *downloadManager, bundledWith, vendorDir string, force bool, extConfig *extensions.BuildConfig) (installReport, error) {
+	version := strings.TrimSpace(bundledWith)
+	if version == "" {
+		return installReport{}, fmt.Errorf("lockfile has no BUNDLED WITH section; cannot tell which Bundler to install")
+	}
+
+	bundler := []lockfile.GemSpec{{Name: "bundler", Version: version}}
+	if _, err := dm.DownloadAll(ctx, bundler, force); err != nil {
+		return installReport{}, fmt.Errorf("failed to fetch bundler %s: %w", version, err)
+	}
+
+	return installFromCache(ctx, dm.CacheDir(), vendorDir, bundler, force, false, false, "", "", extConfig)
+}
+
 // findGemInCaches searches for a gem in cache directories (ore cache + system cache)
 func findGemInCaches(primaryCache string, gem lockfile.GemSpec) string {
 	fileName := gemFileName(gem)
 
-	// Check primary ore cache first
-	path := filepath.Join(primaryCache, fileName)
-	if _, err := os.Stat(path); err == nil {
+	// Check primary ore cache first (it may hold a compressed copy)
+	if path := geminstall.FindCachedGem([]string{primaryCache}, gem); path != "" {
 		return path
 	}
 
@@ -364,7 +554,7 @@ func findGemInCaches(primaryCache string, gem lockfile.GemSpec) string {
 
 // tryGetGemPathsForInstall uses same logic as download.go
 func tryGetGemPathsForInstall() []string {
-	cmd := exec.Command("gem", "environment", "gempath")
+	cmd := exec.Command(ruby.Binary(), "-e", "print Gem.path.join(File::PATH_SEPARATOR)")
 	output, err := cmd.Output()
 	if err == nil {
 		pathsStr := strings.TrimSpace(string(output))
@@ -413,7 +603,18 @@ func tryGetGemPathsForInstall() []string {
 
 // Functions moved to internal/geminstall package
 
-func buildExecutionEnv(vendorDir string, specs []lockfile.GemSpec) ([]string, error) {
+// execEnvOptions tune the environment `ore exec` builds
+type execEnvOptions struct {
+	// Bundler is set when the command runs under Bundler (`bundle ...`, or RUBYOPT
+	// requiring bundler/setup), so its BUNDLE_* settings must survive
+	Bundler bool
+	// KeepEnv names variables whose caller value wins over ore's rewrite (--keep-env)
+	KeepEnv []string
+	// Gemfile is exported as BUNDLE_GEMFILE for commands run under Bundler (--gemfile)
+	Gemfile string
+}
+
+func buildExecutionEnv(vendorDir string, specs []lockfile.GemSpec, opts execEnvOptions) ([]string, error) {
 	if err := geminstall.EnsureDir(vendorDir); err != nil {
 		return nil, err
 	}
@@ -431,16 +632,51 @@ func buildExecutionEnv(vendorDir string, specs []lockfile.GemSpec) ([]string, er
 	if vendorDir != systemGemDir {
 		env = setEnv(env, "GEM_HOME", vendorDir)
 		env = setEnv(env, "GEM_PATH", vendorDir)
-		// Disable Bundler's auto-setup to avoid conflicts
-		env = setEnv(env, "BUNDLE_GEMFILE", "")
+		// Without Bundler in the picture, drop its settings so a stray Gemfile
+		// elsewhere can't take over gem activation
+		if !opts.Bundler {
+			env = unsetEnv(env, "BUNDLE_GEMFILE")
+			env = unsetEnv(env, "BUNDLE_BIN_PATH")
+		}
 	}
 
+	// Put the selected Ruby's bin/ (ruby, gem, irb) ahead of other Rubies on PATH
+	if rubyBinary := ruby.Binary(); filepath.IsAbs(rubyBinary) {
+		env = prependPath(env, filepath.Dir(rubyBinary))
+	}
 	env = prependPath(env, filepath.Join(vendorDir, "bin"))
 	env = prependRubyLib(env, libPaths)
 
+	if opts.Bundler && opts.Gemfile != "" {
+		gemfilePath, err := filepath.Abs(opts.Gemfile)
+		if err != nil {
+			return nil, err
+		}
+		env = setEnv(env, "BUNDLE_GEMFILE", gemfilePath)
+	}
+
+	for _, key := range opts.KeepEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			env = setEnv(env, key, value)
+		} else {
+			env = unsetEnv(env, key)
+		}
+	}
+
 	return env, nil
 }
 
+// runsUnderBundler reports whether a command will load Bundler itself: it is
+// `bundle`/`bundler`, or RUBYOPT requires bundler/setup (as inside `bundle exec`)
+func runsUnderBundler(command string) bool {
+	switch filepath.Base(command) {
+	case "bundle", "bundler":
+		return true
+	}
+	rubyOpt := os.Getenv("RUBYOPT")
+	return strings.Contains(rubyOpt, "-rbundler/setup") || strings.Contains(rubyOpt, "-r bundler/setup")
+}
+
 func collectLibraryPaths(vendorDir string, specs []lockfile.GemSpec) []string {
 	seen := make(map[string]struct{})
 	var libs []string
@@ -471,6 +707,13 @@ func setEnv(env []string, key, value string) []string {
 	return append(env, prefix+value)
 }
 
+func unsetEnv(env []string, key string) []string {
+	prefix := key + "="
+	return slices.DeleteFunc(env, func(kv string) bool {
+		return strings.HasPrefix(kv, prefix)
+	})
+}
+
 func prependPath(env []string, path string) []string {
 	if path == "" {
 		return env
@@ -526,8 +769,19 @@ func installGitGems(ctx context.Context, vendorDir string, gitSpecs []lockfile.G
 		gemName := fmt.Sprintf("%s-%s", spec.Name, spec.Version)
 		destDir := filepath.Join(vendorDir, "gems", gemName)
 
+		// A git gem re-locked to another commit keeps its name-version directory, so
+		// an installed copy only counts when it's the locked revision
+		installed := false
+		if _, err := os.Stat(destDir); err == nil {
+			installed = true
+			if err := resolver.VerifyGitGem(spec, destDir); err != nil {
+				fmt.Fprintf(os.Stderr, "%v; reinstalling\n", err)
+				installed = false
+			}
+		}
+
 		// Smart skip logic
-		if _, err := os.Stat(destDir); err == nil && !force {
+		if installed && !force {
 			// If buildExtensions mode is enabled, check if this gem needs extension building
 			if buildExtensions {
 				needsBuild, err := extensions.NeedsBuild(destDir, engine)
@@ -551,9 +805,12 @@ func installGitGems(ctx context.Context, vendorDir string, gitSpecs []lockfile.G
 		}
 
 		// Clone the git repo at the locked revision
-		if err := cloneGitGem(spec, destDir); err != nil {
+		if err := resolver.CloneGitGem(ctx, spec, destDir); err != nil {
 			return report, fmt.Errorf("failed to clone git gem %s: %w", spec.Name, err)
 		}
+		if err := resolver.VerifyGitGem(spec, destDir); err != nil {
+			return report, err
+		}
 
 		// Link binaries if any
 		if err := geminstall.LinkGemBinaries(destDir, filepath.Join(vendorDir, "bin")); err != nil {
@@ -567,6 +824,7 @@ func installGitGems(ctx context.Context, vendorDir string, gitSpecs []lockfile.G
 		})
 
 		report.Installed++
+		report.InstalledGems = append(report.InstalledGems, gemName)
 	}
 
 	// Build extensions for all installed gems (two-phase: install all, then build all)
@@ -575,22 +833,6 @@ func installGitGems(ctx context.Context, vendorDir string, gitSpecs []lockfile.G
 	return report, nil
 }
 
-// cloneGitGem clones a git gem at the specified revision
-func cloneGitGem(spec lockfile.GitGemSpec, destDir string) error {
-	// Import the resolver package to use GitSource
-	gitSource, err := resolver.NewGitSource(spec.Remote, spec.Branch, spec.Tag, spec.Revision)
-	if err != nil {
-		return fmt.Errorf("failed to create git source: %w", err)
-	}
-
-	// Clone at the locked revision
-	if err := gitSource.CloneAtRevision(spec.Revision, destDir); err != nil {
-		return fmt.Errorf("failed to clone at revision %s: %w", spec.Revision, err)
-	}
-
-	return nil
-}
-
 // installPathGems installs gems from local paths
 func installPathGems(ctx context.Context, vendorDir string, pathSpecs []lockfile.PathGemSpec, force bool, buildExtensions bool, extConfig *extensions.BuildConfig) (installReport, error) {
 	report := installReport{Total: len(pathSpecs)}
@@ -611,8 +853,20 @@ func installPathGems(ctx context.Context, vendorDir string, pathSpecs []lockfile
 		gemName := fmt.Sprintf("%s-%s", spec.Name, spec.Version)
 		destDir := filepath.Join(vendorDir, "gems", gemName)
 
+		// Path gems are copied, so edits to the source need a fresh copy
+		installed := false
+		if _, err := os.Stat(destDir); err == nil {
+			installed = true
+			if changed, err := resolver.PathGemChanged(spec, destDir); err != nil {
+				return report, fmt.Errorf("failed to check path gem %s: %w", spec.Name, err)
+			} else if changed {
+				fmt.Fprintf(os.Stderr, "Path gem %s changed since it was installed; reinstalling\n", spec.Name)
+				installed = false
+			}
+		}
+
 		// Smart skip logic
-		if _, err := os.Stat(destDir); err == nil && !force {
+		if installed && !force {
 			// If buildExtensions mode is enabled, check if this gem needs extension building
 			if buildExtensions {
 				needsBuild, err := extensions.NeedsBuild(destDir, engine)
@@ -636,7 +890,7 @@ func installPathGems(ctx context.Context, vendorDir string, pathSpecs []lockfile
 		}
 
 		// Copy the path gem to vendor
-		if err := copyPathGem(spec, destDir); err != nil {
+		if err := resolver.CopyPathGem(spec, destDir); err != nil {
 			return report, fmt.Errorf("failed to copy path gem %s: %w", spec.Name, err)
 		}
 
@@ -652,6 +906,7 @@ func installPathGems(ctx context.Context, vendorDir string, pathSpecs []lockfile
 		})
 
 		report.Installed++
+		report.InstalledGems = append(report.InstalledGems, gemName)
 	}
 
 	// Build extensions for all installed gems (two-phase: install all, then build all)
@@ -660,20 +915,6 @@ func installPathGems(ctx context.Context, vendorDir string, pathSpecs []lockfile
 	return report, nil
 }
 
-// copyPathGem copies a path gem to the vendor directory
-func copyPathGem(spec lockfile.PathGemSpec, destDir string) error {
-	pathSource, err := resolver.NewPathSource(spec.Remote)
-	if err != nil {
-		return fmt.Errorf("failed to create path source: %w", err)
-	}
-
-	if err := pathSource.CopyToVendor(destDir); err != nil {
-		return fmt.Errorf("failed to copy to vendor: %w", err)
-	}
-
-	return nil
-}
-
 // Helper for tests: create a minimal .gem archive.
 func createFakeGemArchive(dest string, files map[string][]byte, marshalData []byte) error {
 	var metadataBuf bytes.Buffer
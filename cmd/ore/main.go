@@ -1,15 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"runtime/pprof"
+	"slices"
 	"strings"
 	"time"
 
@@ -20,10 +27,15 @@ import (
 	"github.com/contriboss/ore-light/internal/audit"
 	"github.com/contriboss/ore-light/internal/cache"
 	"github.com/contriboss/ore-light/internal/config"
+	"github.com/contriboss/ore-light/internal/errdefs"
 	"github.com/contriboss/ore-light/internal/extensions"
+	"github.com/contriboss/ore-light/internal/geminstall"
+	"github.com/contriboss/ore-light/internal/httpcache"
+	"github.com/contriboss/ore-light/internal/httpclient"
 	"github.com/contriboss/ore-light/internal/logger"
 	"github.com/contriboss/ore-light/internal/resolver"
 	"github.com/contriboss/ore-light/internal/ruby"
+	"github.com/contriboss/ore-light/internal/sources"
 )
 
 var (
@@ -37,8 +49,8 @@ const (
 	DEFAULT_RUBY_VERSION = "3.4.7"
 
 	// DEFAULT_BUNDLER_VERSION is the Bundler version to write in Gemfile.lock
-	// Update this to match the current stable Bundler release
-	DEFAULT_BUNDLER_VERSION = "2.7.2"
+	// Defined by the resolver, which writes BUNDLED WITH
+	DEFAULT_BUNDLER_VERSION = resolver.DEFAULT_BUNDLER_VERSION
 
 	// DEFAULT_RUBYGEMS_VERSION is the RubyGems version to write in gemspec files
 	// Update this to match the current stable RubyGems release
@@ -53,23 +65,60 @@ func main() {
 		return
 	}
 
-	// Check for global --verbose flag anywhere in args and extract command
-	verbose := false
+	// Global verbosity flags (--quiet, --verbose/-v, --debug) work anywhere, except after
+	// `ore exec` where the arguments belong to the command being run. --ruby and --timeout
+	// are only global before the command (platform has its own --ruby).
+	level := logger.LevelNormal
+	rubyOverride := os.Getenv("ORE_RUBY")
+	timeoutValue := ""
 	cmd := ""
 	args := []string{}
 
-	for _, arg := range os.Args[1:] {
-		if arg == "--verbose" {
-			verbose = true
-		} else if cmd == "" {
+	rawArgs := os.Args[1:]
+	for i := 0; i < len(rawArgs); i++ {
+		arg := rawArgs[i]
+		switch {
+		case cmd == "exec":
+			args = append(args, arg)
+		case arg == "--verbose" || arg == "-v" && len(rawArgs) > 1: // A lone -v prints the version
+			level = logger.LevelVerbose
+		case arg == "--debug":
+			level = logger.LevelDebug
+		case arg == "--quiet":
+			level = logger.LevelQuiet
+		case cmd == "" && arg == "--ruby" && i+1 < len(rawArgs):
+			i++
+			rubyOverride = rawArgs[i]
+		case cmd == "" && strings.HasPrefix(arg, "--ruby="):
+			rubyOverride = strings.TrimPrefix(arg, "--ruby=")
+		case cmd == "" && arg == "--timeout" && i+1 < len(rawArgs):
+			i++
+			timeoutValue = rawArgs[i]
+		case cmd == "" && strings.HasPrefix(arg, "--timeout="):
+			timeoutValue = strings.TrimPrefix(arg, "--timeout=")
+		case cmd == "":
 			cmd = arg
-		} else {
+		default:
 			args = append(args, arg)
 		}
 	}
 
 	// Setup logger with verbosity level
-	logger.SetupLogger(verbose)
+	logger.Setup(logger.EnvLevel(level))
+
+	if err := configureHTTPTimeout(timeoutValue); err != nil {
+		exitWithError(err)
+	}
+	sources.SetCredentialLookup(sourceCredentials)
+	resolver.SetGitSSH(gitSSHConfig())
+	if cacheDir, err := defaultCacheDir(); err == nil {
+		resolver.SetGraphCacheDir(filepath.Join(cacheDir, "resolve"))
+		httpcache.SetDir(filepath.Join(cacheDir, "http"))
+	}
+
+	// Use --ruby/ORE_RUBY when given, otherwise the Ruby the project asks for
+	// (.ruby-version, mise, ...) rather than whatever `ruby` is first on PATH
+	ruby.SetBinary(ruby.ResolveBinary(defaultLockfilePath(), defaultGemfilePath(), rubyOverride))
 
 	// This is like Ruby's case/when, but switch in Go doesn't fall through by default!
 	// In Ruby you need 'when' to match multiple conditions; Go evaluates once and exits.
@@ -80,7 +129,7 @@ func main() {
 	case "--version", "-V", "-v", "version":
 		printVersion()
 	case "add":
-		if err := commands.RunAdd(args); err != nil {
+		if err := commands.RunAdd(args, runInstallCommand); err != nil {
 			exitWithError(err)
 		}
 	case "remove":
@@ -107,6 +156,10 @@ func main() {
 		if err := commands.RunCheck(args); err != nil {
 			exitWithError(err)
 		}
+	case "verify":
+		if err := runVerifyCommand(args); err != nil {
+			exitWithError(err)
+		}
 	case "init":
 		if err := commands.RunInit(args); err != nil {
 			exitWithError(err)
@@ -132,7 +185,7 @@ func main() {
 			exitWithError(err)
 		}
 	case "config":
-		if err := commands.RunConfig(args); err != nil {
+		if err := commands.RunConfig(args, resolveVendorDir); err != nil {
 			exitWithError(err)
 		}
 	case "lock":
@@ -144,7 +197,7 @@ func main() {
 			exitWithError(err)
 		}
 	case "fetch":
-		if err := commands.RunFetch(args); err != nil {
+		if err := runFetchCommand(args); err != nil {
 			exitWithError(err)
 		}
 	case "install":
@@ -172,7 +225,9 @@ func main() {
 			exitWithError(err)
 		}
 	case "stats":
-		if err := commands.RunStats(args); err != nil {
+		// cache_dir from the config counts too; without one stats leaves the cache out
+		cacheDir, _ := defaultCacheDir()
+		if err := commands.RunStats(args, cacheDir); err != nil {
 			exitWithError(err)
 		}
 	case "why":
@@ -201,8 +256,14 @@ func main() {
 func runLockCommand(args []string) error {
 	fs := flag.NewFlagSet("lock", flag.ContinueOnError)
 	gemfilePath := fs.String("gemfile", defaultGemfilePath(), "Path to Gemfile")
-	verbose := fs.Bool("v", false, "Enable verbose output")
+	lockfileFlag := fs.String("lockfile", "", "Lockfile to write (default: <gemfile>.lock, or gems.locked for gems.rb)")
+	verbose := fs.Bool("v", logger.Verbose(), "Enable verbose output")
 	cpuProfile := fs.String("cpuprofile", "", "Write CPU profile to file")
+	printOnly := fs.Bool("print", false, "Print the resolved lockfile to stdout instead of writing it")
+	normalizePlatforms := fs.Bool("normalize-platforms", false, "Collapse versioned platforms (arm64-darwin-23 -> arm64-darwin, x86_64-linux-gnu -> x86_64-linux) and drop covered ones")
+	conservative := fs.Bool("conservative", false, "Keep gems at their currently locked versions unless the Gemfile change requires moving them")
+	refresh := fs.Bool("refresh", false, "Fetch every gem's versions and dependencies from its source instead of reusing cached ones")
+	localSource := fs.String("local-source", "", "Resolve gems from this directory of .gem files instead of the network")
 
 	// Multi-value flag for platforms (like bundle lock --add-platform)
 	var platforms []string
@@ -233,31 +294,56 @@ func runLockCommand(args []string) error {
 	}
 
 	if _, err := os.Stat(*gemfilePath); err != nil {
-		return fmt.Errorf("gemfile not found at %s", *gemfilePath)
+		return errdefs.Newf(errdefs.ErrGemfileNotFound, "gemfile not found at %s", *gemfilePath)
+	}
+
+	lockfilePath := *lockfileFlag
+	if lockfilePath == "" {
+		lockfilePath = lockfileForGemfile(*gemfilePath)
+	}
+
+	// With --print, stdout carries only the lockfile
+	status := os.Stdout
+	lockOpts := resolver.LockOptions{Platforms: platforms, Verbose: *verbose, NormalizePlatforms: *normalizePlatforms, LockfilePath: lockfilePath, Refresh: *refresh, LocalSource: *localSource}
+	if *printOnly {
+		status = os.Stderr
+		lockOpts.Output = os.Stdout
 	}
 
 	if *verbose {
-		fmt.Printf("🔒 Resolving dependencies from %s…\n", *gemfilePath)
+		fmt.Fprintf(status, "🔒 Resolving dependencies from %s…\n", *gemfilePath)
 	}
 
 	startTime := time.Now()
-	if err := resolver.GenerateLockfileWithPlatforms(*gemfilePath, nil, platforms); err != nil {
+	if *conservative {
+		if err := commands.LockConservatively(*gemfilePath, lockOpts); err != nil {
+			return err
+		}
+	} else if err := resolver.GenerateLockfileWithOptions(*gemfilePath, lockOpts); err != nil {
 		return fmt.Errorf("failed to generate lockfile: %w", err)
 	}
 	elapsed := time.Since(startTime)
 
 	if *cpuProfile != "" {
-		fmt.Printf("⏱️  Resolution took: %v\n", elapsed)
+		fmt.Fprintf(status, "⏱️  Resolution took: %v\n", elapsed)
+	}
+
+	if *printOnly {
+		return nil
 	}
 
-	lockfilePath := *gemfilePath + ".lock"
 	if *verbose {
-		fmt.Printf("✅ Updated %s\n", lockfilePath)
+		logger.Printf("✅ Updated %s\n", lockfilePath)
 	} else {
-		fmt.Printf("✨ Wrote %s\n", lockfilePath)
+		logger.Printf("✨ Wrote %s\n", lockfilePath)
 	}
 
-	fmt.Println("💡 Run `ore install` to fetch the resolved gems.")
+	// ore install only finds the lockfile by itself when it's the Gemfile's own
+	if lockfilePath != lockfileForGemfile(*gemfilePath) {
+		logger.Printf("💡 Run `ore install --gemfile %s --lockfile %s` to fetch the resolved gems.\n", *gemfilePath, lockfilePath)
+	} else {
+		logger.Println("💡 Run `ore install` to fetch the resolved gems.")
+	}
 	return nil
 }
 
@@ -269,10 +355,15 @@ Usage: ore [OPTIONS] [COMMAND]
 Options:
   -V, --version    Print version info and exit
   -h, --help       Print help
+  -v, --verbose    Show per-gem resolution, download and extension detail
+  --debug          Also dump gem metadata (or ORE_DEBUG=1)
+  --quiet          Skip progress and success messages
+  --ruby <path>    Ruby interpreter to use (or ORE_RUBY); skips version-manager detection
+  --timeout <dur>  Per-request HTTP timeout, e.g. 90s or 2m (or ORE_HTTP_TIMEOUT; default 60s)
 
 Commands:
     init          Create a new Gemfile
-    add           Add gems to Gemfile
+    add           Add gems to Gemfile and update the lockfile
     remove        Remove gems from Gemfile
     update        Update gems to their latest versions within constraints
     outdated      List gems with newer versions available
@@ -281,6 +372,7 @@ Commands:
     fetch         Download gems into cache (no Ruby required)
     install       Install gems from Gemfile.lock
     check         Verify all gems are installed
+    verify        Check installed gems are complete (gemspecs, binstubs, extensions)
     list          List all gems in the current bundle
     show          Show the source location of a gem
     info          Show detailed information about a gem
@@ -328,27 +420,105 @@ func runInstallCommand(args []string) error {
 
 	fs := flag.NewFlagSet("install", flag.ContinueOnError)
 	lockfilePath := fs.String("lockfile", defaultLockfilePath(), "Path to Gemfile.lock")
-	workers := fs.Int("workers", runtime.NumCPU(), "Number of concurrent downloads")
+	gemfileFlag := fs.String("gemfile", "", "Path to the Gemfile; the lockfile defaults to <gemfile>.lock")
+	workers := fs.Int("workers", defaultWorkers(), "Number of concurrent downloads (default: [install] workers, bundle config jobs, else CPU count)")
+	maxRate := fs.Int64("max-rate", defaultMaxDownloadRate(), "Limit total download speed in bytes/sec (0 = unlimited)")
+	linkCache := fs.Bool("link", appConfig != nil && appConfig.LinkCache, "Reflink/hardlink gems into vendor/cache instead of copying")
+	sharedStore := fs.Bool("shared-store", appConfig != nil && appConfig.SharedStore, "Link gems without native extensions to one copy in a store under the cache dir, shared by every bundle")
 	force := fs.Bool("force", false, "Re-download or reinstall even if artifacts exist")
 	vendorDir := fs.String("vendor", defaultVendorDir(), "Destination directory for installed gems")
-	skipExtensions := fs.Bool("skip-extensions", false, "Skip building native extensions")
+	bundlePath := fs.String("path", "", "Bundler-style install path (gems go in <path>/ruby/<version>); saved to .bundle/config")
+	skipExtensions := fs.Bool("skip-extensions", installDefaults().SkipExtensions, "Skip building native extensions")
 	buildExtensions := fs.Bool("build-extensions", false, "Force building native extensions even for already-installed gems")
-	verbose := fs.Bool("verbose", false, "Enable verbose output including extension build logs")
+	verbose := fs.Bool("verbose", logger.Verbose(), "Enable verbose output including extension build logs")
 	without := fs.String("without", "", "Comma-separated list of groups to exclude (e.g., development,test)")
+	with := fs.String("with", "", "Comma-separated list of groups to include, e.g. optional groups or ones in BUNDLE_WITHOUT")
+	extTimeout := fs.Duration("ext-timeout", extensions.DefaultBuildTimeout, "Per-gem native extension build timeout (0 disables)")
+	cflags := fs.String("cflags", os.Getenv("CFLAGS"), "Extra CFLAGS for native extension builds")
+	ldflags := fs.String("ldflags", os.Getenv("LDFLAGS"), "Extra LDFLAGS for native extension builds")
+	rubyPath := fs.String("ruby", "", "Path to the Ruby interpreter (default: detected from .ruby-version, mise, rbenv, asdf)")
+	quiet := fs.Bool("quiet", logger.Quiet(), "Only print errors")
+	jsonOutput := fs.Bool("json", false, "Print a JSON summary instead of progress output")
+	noPostInstallMessages := fs.Bool("no-post-install-messages", false, "Don't show gems' post-install messages")
+	noExtensionsSummary := fs.Bool("no-extensions-summary", false, "Only count failed native extension builds instead of listing each gem with its build log")
+	installBundler := fs.Bool("install-bundler", false, "Also install the Bundler version recorded in BUNDLED WITH")
+	trustPolicy := fs.String("trust-policy", "", "Verify gem signatures: NoSecurity, AlmostNoSecurity, LowSecurity, MediumSecurity or HighSecurity")
+	standalone := fs.Bool("standalone", false, "Write bundler/setup.rb so the bundle loads without Bundler")
+	var binstubs binstubsFlag
+	fs.Var(&binstubs, "binstubs", "Write binstubs for the bundle's executables into bin/, or the given directory (--binstubs[=<dir>])")
+	strictRuby := fs.Bool("strict-ruby", false, "Fail when the active Ruby differs from the lockfile's RUBY VERSION")
+	dryRun := fs.Bool("dry-run", false, "List what would be downloaded, reused from cache or skipped, without changing anything")
+	clean := fs.Bool("clean", false, "After installing, remove gems no longer in the lockfile from the vendor directory (never the system gem dir)")
+	localSource := fs.String("local-source", "", "Install gems from this directory of .gem files (and the caches) instead of downloading them")
+	retryMissing := fs.Bool("retry-missing", false, "Keep downloading when a gem can't be fetched, then list every gem no source had instead of stopping at the first")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	gemfilePath := applyGemfileFlag(fs, *gemfileFlag, lockfilePath)
+	if gemfilePath == "" {
+		gemfilePath = "Gemfile"
+	}
 
-	dm, err := newDefaultDownloadManager(*workers)
+	// --json implies --quiet so stdout holds only the summary; both override --verbose
+	silent := *quiet || *jsonOutput
+	if silent {
+		*verbose = false
+		// Status printed below the command (extension builds, build dependencies) checks the logger
+		defer logger.Setup(logger.CurrentLevel())
+		logger.Setup(logger.LevelQuiet)
+	}
+	say := func(format string, a ...any) {
+		if !silent {
+			fmt.Printf(format, a...)
+		}
+	}
+	summary := &installSummary{Extensions: []extensionResult{}, RubyMismatches: []incompatibleGem{}, PostInstallMessages: []commands.PostInstallMessage{}}
+
+	if *rubyPath != "" {
+		ruby.SetBinary(*rubyPath)
+	}
+
+	// Like `bundle install --path`, remember the path so a bare `ore install` reuses it
+	if *bundlePath != "" {
+		if hasFlagArg(args, "vendor") {
+			return fmt.Errorf("--path and --vendor cannot be combined")
+		}
+		*vendorDir = bundlePathVendorDir(*bundlePath)
+		if !*dryRun {
+			if err := config.WriteBundleConfig(*bundlePath); err != nil {
+				return err
+			}
+			say("Saved BUNDLE_PATH=%s to .bundle/config\n", *bundlePath)
+		}
+	}
+
+	var dm *downloadManager
+	var err error
+	if *dryRun {
+		dm, err = newPlanningDownloadManager()
+	} else {
+		dm, err = newDefaultDownloadManager(*workers)
+	}
 	if err != nil {
 		return err
 	}
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Perform pre-flight health checks on gem sources
-	dm.CheckSourceHealth(ctx)
+	if !*dryRun {
+		dm.sourceManager.SetMaxRate(*maxRate)
+		dm.quiet = silent
+		dm.retryMissing = *retryMissing
+		dm.localSource = *localSource
+		if err := dm.setTrustPolicy(*trustPolicy); err != nil {
+			return err
+		}
+
+		// Perform pre-flight health checks on gem sources; a local source needs none
+		if dm.localSource == "" {
+			dm.CheckSourceHealth(ctx)
+		}
+	}
 
 	// Load both regular gems and git gems from lockfile
 	parsed, err := loadLockfile(*lockfilePath)
@@ -356,26 +526,57 @@ func runInstallCommand(args []string) error {
 		return err
 	}
 
-	if len(parsed.GemSpecs) == 0 && len(parsed.GitSpecs) == 0 {
-		fmt.Println("No gems found in lockfile.")
+	if len(parsed.GemSpecs) == 0 && len(parsed.GitSpecs) == 0 && !*dryRun {
+		if *jsonOutput {
+			summary.ElapsedMs = time.Since(startTime).Milliseconds()
+			return printInstallSummary(summary)
+		}
+		say("No gems found in lockfile.\n")
 		return nil
 	}
 
-	// Parse excluded groups from --without flag
-	var excludeGroups []string
-	if *without != "" {
-		excludeGroups = parseGroupList(*without)
+	// --clean keeps everything the lockfile has for this platform, even when installing
+	// only some gems
+	locked := parsed
+
+	// `ore install rack nokogiri` installs just those gems and their dependencies
+	if names := fs.Args(); len(names) > 0 {
+		parsed, err = selectLockedGems(parsed, names)
+		if err != nil {
+			return err
+		}
+		say("Installing %s and dependencies only.\n", strings.Join(names, ", "))
+	}
+
+	if err := checkLockfileRuby(*lockfilePath, *strictRuby); err != nil {
+		return err
+	}
+
+	// Refuse to install for a different Ruby engine than the Gemfile requires
+	if err := checkGemfileRubyEngine(gemfilePath); err != nil {
+		return err
+	}
+
+	// Excluded groups: --without (or a persisted BUNDLE_WITHOUT) plus the Gemfile's
+	// optional groups, minus any requested with --with (or BUNDLE_WITH)
+	excludeGroups, includeGroups := installGroups(gemfilePath, *with, *without)
+
+	// Leave out gems whose install_if condition is false here, or scoped to other platforms
+	parsed, err = applyGemfileExclusions(parsed, gemfilePath)
+	if err != nil {
+		return err
+	}
+
+	if len(excludeGroups) > 0 {
 		if *verbose {
 			fmt.Printf("Excluding groups: %v\n", excludeGroups)
+			if len(includeGroups) > 0 {
+				fmt.Printf("Including groups: %v\n", includeGroups)
+			}
 		}
 
 		// If filtering by groups, we need to load the Gemfile to get group information
-		gemfilePath := detectGemfileFromLock(*lockfilePath)
-		if gemfilePath == "" {
-			gemfilePath = "Gemfile"
-		}
-
-		if err := enrichGemsWithGroups(gemfilePath, parsed); err != nil {
+		if err := commands.EnrichGemsWithGroups(gemfilePath, parsed); err != nil {
 			if *verbose {
 				fmt.Fprintf(os.Stderr, "Warning: could not load Gemfile for group filtering: %v\n", err)
 				fmt.Fprintf(os.Stderr, "Proceeding without group filtering.\n")
@@ -385,15 +586,38 @@ func runInstallCommand(args []string) error {
 	}
 
 	// Filter and deduplicate GemSpecs
-	gems := deduplicateGemSpecs(parsed.GemSpecs)
+	allGems := deduplicateGemSpecs(parsed.GemSpecs)
+	gems := allGems
 	if len(excludeGroups) > 0 {
 		// Filter by groups - only keep direct dependencies with allowed groups
-		gems = filterGemsByGroupsAndDependencies(gems, parsed.GemSpecs, excludeGroups)
+		gems = filterGemsByGroupsAndDependencies(gems, parsed.GemSpecs, excludeGroups, includeGroups)
 	}
 
+	// Swap precompiled gems for pure-Ruby ones where force_ruby_platform asks for it
+	gems = applyForceRubyPlatform(gems, gemfilePath)
+	groupedGems := gems
+
 	// Filter by current platform
 	gems = filterGemsByPlatform(gems)
 
+	if *dryRun {
+		gitSpecs, pathSpecs := parsed.GitSpecs, parsed.PathSpecs
+		if len(excludeGroups) > 0 {
+			gitSpecs = filterGitGemsByGroups(gitSpecs, excludeGroups, includeGroups)
+			pathSpecs = filterPathGemsByGroups(pathSpecs, excludeGroups, includeGroups)
+		}
+		plan := newInstallPlan(dm, allGems, groupedGems, gems, *force)
+		plan.addSourceGems(parsed.GitSpecs, gitSpecs, parsed.PathSpecs, pathSpecs)
+		if err := printInstallPlan(plan, *jsonOutput); err != nil {
+			return err
+		}
+		if *clean && !*jsonOutput {
+			fmt.Println()
+			return cleanAfterInstall(*vendorDir, locked, gemfilePath, commands.CleanOptions{DryRun: true, Verbose: *verbose})
+		}
+		return nil
+	}
+
 	// Download regular gems from rubygems.org
 	// Note: Engine compatibility filtering happens during installation
 	// after extracting metadata (which contains extension info)
@@ -402,61 +626,156 @@ func runInstallCommand(args []string) error {
 		if err != nil {
 			return err
 		}
-		fmt.Printf("Cache ready. %d fetched, %d reused.\n", downloadReport.Downloaded, downloadReport.Skipped)
+		summary.Downloaded = downloadReport.Downloaded
+		say("Cache ready. %d fetched, %d reused.\n", downloadReport.Downloaded, downloadReport.Skipped)
 	}
 
 	// Import the extensions package for config
 	extConfig := buildExtensionConfig(*skipExtensions, *verbose, *vendorDir)
+	extConfig.Timeout = *extTimeout
+	extConfig.CFlags = *cflags
+	extConfig.LDFlags = *ldflags
 
 	// Install regular gems
 	var totalInstalled, totalSkipped, totalExtBuilt, totalExtFailed int
+	var installedGems []string
+	extResults := []extensionResult{}
 	if len(gems) > 0 {
-		installReport, err := installFromCache(ctx, dm.CacheDir(), *vendorDir, gems, *force, *buildExtensions, extConfig)
+		storeDir := ""
+		if *sharedStore {
+			storeDir = geminstall.SharedStoreDir(dm.CacheDir())
+		}
+		installReport, err := installFromCache(ctx, dm.CacheDir(), *vendorDir, gems, *force, *buildExtensions, *linkCache, storeDir, targetRubyVersion(*lockfilePath), extConfig)
 		if err != nil {
 			return err
 		}
+		if installReport.Shared > 0 {
+			say("Linked %d gem(s) from the shared store %s\n", installReport.Shared, storeDir)
+		}
+		if skipped := formatIncompatibleReport(installReport.Engine, installReport.Incompatible); skipped != "" {
+			say("%s", skipped)
+		}
+		if warning := formatRubyRequirementReport(installReport.RubyVersion, installReport.RubyMismatches); warning != "" && !silent {
+			fmt.Fprint(os.Stderr, warning)
+		}
+		summary.RubyVersion = installReport.RubyVersion
+		summary.RubyMismatches = append(summary.RubyMismatches, installReport.RubyMismatches...)
 		totalInstalled += installReport.Installed
+		installedGems = append(installedGems, installReport.InstalledGems...)
 		totalSkipped += installReport.Skipped
 		totalExtBuilt += installReport.ExtensionsBuilt
 		totalExtFailed += installReport.ExtensionsFailed
+		extResults = append(extResults, installReport.Extensions...)
 	}
 
 	// Filter and install git gems
 	gitSpecs := parsed.GitSpecs
 	if len(excludeGroups) > 0 {
-		gitSpecs = filterGitGemsByGroups(gitSpecs, excludeGroups)
+		gitSpecs = filterGitGemsByGroups(gitSpecs, excludeGroups, includeGroups)
 	}
 	if len(gitSpecs) > 0 {
-		fmt.Printf("Installing %d git gem(s)...\n", len(gitSpecs))
+		say("Installing %d git gem(s)...\n", len(gitSpecs))
 		gitReport, err := installGitGems(ctx, *vendorDir, gitSpecs, *force, *buildExtensions, extConfig)
 		if err != nil {
 			return err
 		}
 		totalInstalled += gitReport.Installed
+		installedGems = append(installedGems, gitReport.InstalledGems...)
 		totalSkipped += gitReport.Skipped
 		totalExtBuilt += gitReport.ExtensionsBuilt
 		totalExtFailed += gitReport.ExtensionsFailed
+		extResults = append(extResults, gitReport.Extensions...)
 	}
 
 	// Filter and install path gems
 	pathSpecs := parsed.PathSpecs
 	if len(excludeGroups) > 0 {
-		pathSpecs = filterPathGemsByGroups(pathSpecs, excludeGroups)
+		pathSpecs = filterPathGemsByGroups(pathSpecs, excludeGroups, includeGroups)
 	}
 	if len(pathSpecs) > 0 {
-		fmt.Printf("Installing %d path gem(s)...\n", len(pathSpecs))
+		say("Installing %d path gem(s)...\n", len(pathSpecs))
 		pathReport, err := installPathGems(ctx, *vendorDir, pathSpecs, *force, *buildExtensions, extConfig)
 		if err != nil {
 			return err
 		}
 		totalInstalled += pathReport.Installed
+		installedGems = append(installedGems, pathReport.InstalledGems...)
 		totalSkipped += pathReport.Skipped
 		totalExtBuilt += pathReport.ExtensionsBuilt
 		totalExtFailed += pathReport.ExtensionsFailed
+		extResults = append(extResults, pathReport.Extensions...)
+	}
+
+	// Bundler itself, so `ore exec` works in containers without a preinstalled Bundler
+	if *installBundler {
+		bundlerReport, err := installLockedBundler(ctx, dm, parsed.BundledWith, *vendorDir, *force, extConfig)
+		if err != nil {
+			return err
+		}
+		if bundlerReport.Installed > 0 {
+			say("Installed bundler %s\n", strings.TrimSpace(parsed.BundledWith))
+		}
+		totalInstalled += bundlerReport.Installed
+		installedGems = append(installedGems, bundlerReport.InstalledGems...)
+		totalSkipped += bundlerReport.Skipped
+	}
+
+	if *standalone {
+		setupPath := geminstall.StandaloneSetupPath(*vendorDir)
+		if err := writeStandaloneSetup(setupPath, *vendorDir, gems, gitSpecs, pathSpecs, gemfilePath); err != nil {
+			return err
+		}
+		say("Wrote standalone setup to %s\n", setupPath)
+	}
+
+	if binstubs.dir != "" {
+		count, err := writeProjectBinstubs(binstubs.dir, *vendorDir, installedGemDirs(gems, gitSpecs, pathSpecs))
+		if err != nil {
+			return err
+		}
+		say("Wrote %d binstub(s) to %s\n", count, binstubs.dir)
+	}
+
+	if *clean {
+		cleanOpts := commands.CleanOptions{Verbose: *verbose}
+		if silent {
+			cleanOpts.Out = io.Discard
+		}
+		if err := cleanAfterInstall(*vendorDir, locked, gemfilePath, cleanOpts); err != nil {
+			return err
+		}
 	}
 
 	elapsed := time.Since(startTime)
 
+	if totalExtFailed > 0 && *noExtensionsSummary {
+		fmt.Fprintf(os.Stderr, "Warning: %d extension(s) failed to build.\n", totalExtFailed)
+	} else if totalExtFailed > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d extension(s) failed to build:\n%s", totalExtFailed, formatExtensionFailures(extResults))
+	}
+
+	// Post-install messages from the gems installed in this run
+	postInstallMessages := []commands.PostInstallMessage{}
+	if totalInstalled > 0 && !*noPostInstallMessages {
+		if messages, err := commands.ReadPostInstallMessages(*vendorDir); err == nil {
+			postInstallMessages = commands.FilterPostInstallMessages(messages, installedGems, config.ReadBundleIgnoreMessages())
+		}
+	}
+
+	if *jsonOutput {
+		summary.Skipped = totalSkipped
+		summary.Installed = totalInstalled
+		summary.ExtensionsBuilt = totalExtBuilt
+		summary.ExtensionsFailed = totalExtFailed
+		summary.Extensions = extResults
+		summary.ElapsedMs = elapsed.Milliseconds()
+		summary.PostInstallMessages = postInstallMessages
+		return printInstallSummary(summary)
+	}
+	if *quiet {
+		return nil
+	}
+
 	// Simplify vendor dir display for common paths
 	vendorDisplay := *vendorDir
 	if home, err := os.UserHomeDir(); err == nil {
@@ -473,16 +792,9 @@ func runInstallCommand(args []string) error {
 	if totalExtBuilt > 0 {
 		fmt.Printf("Built %d native extension(s).\n", totalExtBuilt)
 	}
-	if totalExtFailed > 0 {
-		fmt.Fprintf(os.Stderr, "Warning: %d extension(s) failed to build.\n", totalExtFailed)
-	}
 
 	// Display post-install messages
-	if totalInstalled > 0 {
-		if messages, err := commands.ReadPostInstallMessages(*vendorDir); err == nil {
-			commands.DisplayPostInstallMessages(messages)
-		}
-	}
+	commands.DisplayPostInstallMessages(postInstallMessages)
 
 	// Build simplified exec command suggestion
 	execCmd := "ore exec"
@@ -512,6 +824,187 @@ func runInstallCommand(args []string) error {
 	return nil
 }
 
+// installSummary is the --json output of ore install
+type installSummary struct {
+	Downloaded       int               `json:"downloaded"`
+	Skipped          int               `json:"skipped"`
+	Installed        int               `json:"installed"`
+	ExtensionsBuilt  int               `json:"extensions_built"`
+	ExtensionsFailed int               `json:"extensions_failed"`
+	Extensions       []extensionResult `json:"extensions"` // Per-gem build outcomes
+	ElapsedMs        int64             `json:"elapsed_ms"`
+
+	RubyVersion    string            `json:"ruby_version,omitempty"` // Ruby required_ruby_version was checked against
+	RubyMismatches []incompatibleGem `json:"ruby_mismatches"`        // Gems installed although they don't support it
+
+	PostInstallMessages []commands.PostInstallMessage `json:"post_install_messages"`
+}
+
+// printInstallSummary writes the install summary to stdout as JSON
+func printInstallSummary(summary *installSummary) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(summary)
+}
+
+// runFetchCommand implements ore fetch.
+// With --only it downloads locked gems into the cache; otherwise it fetches gems by name like `gem fetch`.
+func runFetchCommand(args []string) error {
+	fs := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	only := fs.String("only", "", "Comma-separated gems from the lockfile to fetch, with their dependencies")
+	lockfilePath := fs.String("lockfile", defaultLockfilePath(), "Path to Gemfile.lock")
+	gemfileFlag := fs.String("gemfile", "", "Path to the Gemfile; the lockfile defaults to <gemfile>.lock")
+	workers := fs.Int("workers", defaultWorkers(), "Number of concurrent downloads (default: [install] workers, bundle config jobs, else CPU count)")
+	maxRate := fs.Int64("max-rate", defaultMaxDownloadRate(), "Limit total download speed in bytes/sec (0 = unlimited)")
+	force := fs.Bool("force", false, "Re-download even if cached")
+	trustPolicy := fs.String("trust-policy", "", "Verify gem signatures: NoSecurity, AlmostNoSecurity, LowSecurity, MediumSecurity or HighSecurity")
+	dryRun := fs.Bool("dry-run", false, "List what would be downloaded or reused from cache, without downloading")
+	jsonOutput := fs.Bool("json", false, "Print the --dry-run plan as JSON")
+
+	// Without --only, --gemfile or --dry-run this is `gem fetch`, whose flags (--version,
+	// --platform, ...) are commands.RunFetch's to parse and report
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return commands.RunFetch(args)
+	}
+	fs.SetOutput(os.Stderr)
+	lockfileMode := *dryRun
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "only" || f.Name == "gemfile" {
+			lockfileMode = true
+		}
+	})
+	if !lockfileMode {
+		return commands.RunFetch(args)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("gem names can't be combined with --only, --gemfile or --dry-run; use --only %s", strings.Join(fs.Args(), ","))
+	}
+	gemfilePath := applyGemfileFlag(fs, *gemfileFlag, lockfilePath)
+
+	// --dry-run without --only plans the whole lockfile
+	names := parseGroupList(*only)
+	if len(names) == 0 && !*dryRun {
+		return fmt.Errorf("--only requires at least one gem name")
+	}
+
+	parsed, err := loadLockfile(*lockfilePath)
+	if err != nil {
+		return err
+	}
+
+	selected := parsed
+	if len(names) > 0 {
+		selected, err = selectLockedGems(parsed, names)
+		if err != nil {
+			return err
+		}
+	}
+	selectedGems := applyForceRubyPlatform(deduplicateGemSpecs(selected.GemSpecs), gemfilePath)
+	gems := filterGemsByPlatform(selectedGems)
+
+	if *dryRun {
+		dm, err := newPlanningDownloadManager()
+		if err != nil {
+			return err
+		}
+		return printInstallPlan(newInstallPlan(dm, selectedGems, selectedGems, gems, *force), *jsonOutput)
+	}
+
+	dm, err := newDefaultDownloadManager(*workers)
+	if err != nil {
+		return err
+	}
+	dm.sourceManager.SetMaxRate(*maxRate)
+	if err := dm.setTrustPolicy(*trustPolicy); err != nil {
+		return err
+	}
+
+	report, err := dm.DownloadAll(context.Background(), gems, *force)
+	if err != nil {
+		return err
+	}
+
+	logger.Printf("Cache ready. %d fetched, %d reused.\n", report.Downloaded, report.Skipped)
+	return nil
+}
+
+// hasFlagArg reports whether args contain -name or --name (with or without =value)
+func hasFlagArg(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		trimmed := strings.TrimLeft(arg, "-")
+		if trimmed == arg {
+			continue
+		}
+		if trimmed == name || strings.HasPrefix(trimmed, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// selectLockedGems narrows a lockfile to the named gems plus their transitive dependencies.
+// Errors if a named gem is not in the lockfile.
+func selectLockedGems(parsed *lockfile.Lockfile, names []string) (*lockfile.Lockfile, error) {
+	// Dependencies of every locked gem, regardless of source
+	deps := make(map[string][]lockfile.Dependency)
+	for _, spec := range parsed.GemSpecs {
+		deps[spec.Name] = append(deps[spec.Name], spec.Dependencies...)
+	}
+	for _, spec := range parsed.GitSpecs {
+		deps[spec.Name] = append(deps[spec.Name], spec.Dependencies...)
+	}
+	for _, spec := range parsed.PathSpecs {
+		deps[spec.Name] = append(deps[spec.Name], spec.Dependencies...)
+	}
+
+	for _, name := range names {
+		if _, found := deps[name]; !found {
+			return nil, fmt.Errorf("gem %s is not in the lockfile", name)
+		}
+	}
+
+	needed := make(map[string]bool)
+	var collect func(name string)
+	collect = func(name string) {
+		if needed[name] {
+			return
+		}
+		needed[name] = true
+		for _, dep := range deps[name] {
+			collect(dep.Name)
+		}
+	}
+	for _, name := range names {
+		collect(name)
+	}
+
+	selected := *parsed
+	selected.GemSpecs = nil
+	selected.GitSpecs = nil
+	selected.PathSpecs = nil
+	for _, spec := range parsed.GemSpecs {
+		if needed[spec.Name] {
+			selected.GemSpecs = append(selected.GemSpecs, spec)
+		}
+	}
+	for _, spec := range parsed.GitSpecs {
+		if needed[spec.Name] {
+			selected.GitSpecs = append(selected.GitSpecs, spec)
+		}
+	}
+	for _, spec := range parsed.PathSpecs {
+		if needed[spec.Name] {
+			selected.PathSpecs = append(selected.PathSpecs, spec)
+		}
+	}
+
+	return &selected, nil
+}
+
 func runCacheCommand(args []string) error {
 	if len(args) == 0 {
 		printCacheHelp()
@@ -535,7 +1028,10 @@ func printCacheHelp() {
 
 Subcommands:
   info         Show cache location, size, and gem count
-  prune        Remove all cached gems
+  prune        Remove all cached gems, or only the named ones:
+               ore cache prune nokogiri        (every version)
+               ore cache prune rack-3.0.8      (one version, any platform)
+               ore cache prune --store         (also the --shared-store gems bundles link to)
 `)
 }
 
@@ -559,13 +1055,27 @@ func runCacheInfo(args []string) error {
 
 	fmt.Printf("Cache directory: %s\n", cacheDir)
 	fmt.Printf("Cached gems:    %d\n", stats.Files)
-	fmt.Printf("Total size:     %s\n", humanBytes(stats.TotalSize))
+	fmt.Printf("Total size:     %s\n", cache.HumanBytes(stats.TotalSize))
+
+	compressed, err := cache.CollectCompressionStats(cacheDir)
+	if err != nil {
+		return err
+	}
+	if compressed.Files > 0 {
+		saved := 0.0
+		if compressed.OriginalSize > 0 {
+			saved = float64(compressed.Saved()) / float64(compressed.OriginalSize) * 100
+		}
+		fmt.Printf("Compressed:     %d gems, %s on disk (%s uncompressed, %.1f%% saved)\n",
+			compressed.Files, cache.HumanBytes(compressed.StoredSize), cache.HumanBytes(compressed.OriginalSize), saved)
+	}
 	return nil
 }
 
 func runCachePrune(args []string) error {
 	fs := flag.NewFlagSet("cache prune", flag.ContinueOnError)
 	dryRun := fs.Bool("dry-run", false, "Show what would be removed without deleting files")
+	store := fs.Bool("store", false, "Also remove the shared store that --shared-store bundles link to")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -575,49 +1085,152 @@ func runCachePrune(args []string) error {
 		return err
 	}
 
+	if fs.NArg() > 0 {
+		if *store {
+			return fmt.Errorf("--store can't be combined with gem names")
+		}
+		return pruneCachedGems(cacheDir, fs.Args(), *dryRun)
+	}
+
 	if *dryRun {
 		stats, err := collectCacheStats(cacheDir)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("[dry-run] Would remove %d files (%s) from %s\n", stats.Files, humanBytes(stats.TotalSize), cacheDir)
+		if *store {
+			storeStats, err := collectCacheStats(geminstall.SharedStoreDir(cacheDir))
+			if err != nil {
+				return err
+			}
+			stats.Files += storeStats.Files
+			stats.TotalSize += storeStats.TotalSize
+		}
+		fmt.Printf("[dry-run] Would remove %d files (%s) from %s\n", stats.Files, cache.HumanBytes(stats.TotalSize), cacheDir)
 		return nil
 	}
 
-	if err := os.RemoveAll(cacheDir); err != nil {
-		return fmt.Errorf("failed to prune cache: %w", err)
+	if *store {
+		fmt.Fprintf(os.Stderr, "Warning: bundles installed with --shared-store link into %s; run `ore install` in each to restore them\n", geminstall.SharedStoreDir(cacheDir))
 	}
-	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
-		return fmt.Errorf("failed to recreate cache dir: %w", err)
+	if err := cache.Clear(cacheDir, *store); err != nil {
+		return fmt.Errorf("failed to prune cache: %w", err)
 	}
 
 	fmt.Printf("Cache cleared: %s\n", cacheDir)
 	return nil
 }
 
-func runExecCommand(args []string) error {
+// pruneCachedGems removes the cached gems matching patterns (see cache.MatchGem)
+func pruneCachedGems(cacheDir string, patterns []string, dryRun bool) error {
+	matches, err := cache.FindGems(cacheDir, patterns)
+	if err != nil {
+		return fmt.Errorf("failed to read cache: %w", err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no cached gems match %s in %s", strings.Join(patterns, ", "), cacheDir)
+	}
+
+	var freed int64
+	for _, gem := range matches {
+		if dryRun {
+			fmt.Printf("[dry-run] Would remove %s (%s)\n", filepath.Base(gem.Path), cache.HumanBytes(gem.Size))
+		} else {
+			if err := os.Remove(gem.Path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", gem.Path, err)
+			}
+			fmt.Printf("Removed %s (%s)\n", filepath.Base(gem.Path), cache.HumanBytes(gem.Size))
+		}
+		freed += gem.Size
+	}
+
+	if dryRun {
+		fmt.Printf("[dry-run] Would free %s from %s\n", cache.HumanBytes(freed), cacheDir)
+	} else {
+		fmt.Printf("Removed %d cached gems, freed %s\n", len(matches), cache.HumanBytes(freed))
+	}
+	return nil
+}
+
+// execOptions are ore exec's own options and the command it runs
+type execOptions struct {
+	lockfilePath string
+	gemfile      string // --gemfile, exported as BUNDLE_GEMFILE to bundle commands
+	vendorDir    string
+	strictRuby   bool
+	keepEnv      []string
+	command      []string // The command and its arguments, verbatim
+}
+
+// parseExecArgs splits ore exec's arguments into its own options and the command.
+// Options are ore's only up to the command: the first argument that isn't an option,
+// or everything after "--", starts the command, and what follows is passed through
+// untouched, so `ore exec rspec --format doc` never parses --format.
+func parseExecArgs(args []string) (*execOptions, error) {
 	fs := flag.NewFlagSet("exec", flag.ContinueOnError)
 	lockfilePath := fs.String("lockfile", defaultLockfilePath(), "Path to Gemfile.lock")
+	gemfileFlag := fs.String("gemfile", "", "Path to the Gemfile; the lockfile defaults to <gemfile>.lock")
 	vendorDir := fs.String("vendor", defaultVendorDir(), "Path to installed gems (created by ore install)")
+	bundlePath := fs.String("path", "", "Bundler-style install path (gems in <path>/ruby/<version>)")
+	strictRuby := fs.Bool("strict-ruby", false, "Fail when the active Ruby differs from the lockfile's RUBY VERSION")
+	opts := &execOptions{}
+	fs.Func("keep-env", "Pass this variable through unchanged, e.g. GEM_PATH or BUNDLE_GEMFILE (can be repeated)", func(s string) error {
+		opts.keepEnv = append(opts.keepEnv, strings.Split(s, ",")...)
+		return nil
+	})
+	// flag.Parse stops at the first non-option argument and drops a leading "--"
 	if err := fs.Parse(args); err != nil {
-		return err
+		if strings.HasPrefix(err.Error(), "flag provided but not defined") {
+			return nil, fmt.Errorf("%w (options for the command go after it, or after --: ore exec [options] -- <command> [args...])", err)
+		}
+		return nil, err
+	}
+	if *bundlePath != "" {
+		*vendorDir = bundlePathVendorDir(*bundlePath)
 	}
+	applyGemfileFlag(fs, *gemfileFlag, lockfilePath)
 
-	cmdArgs := fs.Args()
-	if len(cmdArgs) == 0 {
-		return fmt.Errorf("no command provided; usage: ore exec [options] -- <command> [args...]")
+	opts.lockfilePath = *lockfilePath
+	opts.gemfile = *gemfileFlag
+	opts.vendorDir = *vendorDir
+	opts.strictRuby = *strictRuby
+	opts.command = fs.Args()
+	if len(opts.command) == 0 {
+		return nil, fmt.Errorf("no command provided; usage: ore exec [options] -- <command> [args...]")
 	}
+	return opts, nil
+}
+
+func runExecCommand(args []string) error {
+	opts, err := parseExecArgs(args)
+	if err != nil {
+		return err
+	}
+	cmdArgs := opts.command
 
-	gems, err := loadGemSpecs(*lockfilePath)
+	gems, err := loadGemSpecs(opts.lockfilePath)
 	if err != nil {
 		return err
 	}
 
-	env, err := buildExecutionEnv(*vendorDir, gems)
+	if err := checkLockfileRuby(opts.lockfilePath, opts.strictRuby); err != nil {
+		return err
+	}
+
+	env, err := buildExecutionEnv(opts.vendorDir, gems, execEnvOptions{
+		Bundler: runsUnderBundler(cmdArgs[0]),
+		KeepEnv: opts.keepEnv,
+		Gemfile: opts.gemfile,
+	})
 	if err != nil {
 		return err
 	}
 
+	// Run `ruby` as the selected interpreter; exec.Command resolves names against
+	// ore's own PATH, not the one in env
+	if cmdArgs[0] == "ruby" {
+		cmdArgs[0] = ruby.Binary()
+	}
+
 	// When using system gems, run command directly (not via bundle exec)
 	// Bundler's auto-load in Ruby 3.4+ handles gem activation automatically
 	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
@@ -633,9 +1246,30 @@ func defaultLockfilePath() string {
 	return config.DefaultLockfilePath()
 }
 
+// defaultVendorDir returns the install directory used when --vendor isn't given: the
+// [install] vendor config key, then the locations config.DefaultVendorDir checks
 func defaultVendorDir() string {
+	dir, _, _ := resolveVendorDir()
+	return dir
+}
+
+// resolveVendorDir returns the default vendor directory with where it came from, for
+// `ore config get path`: the [install] section, then internal/config's priority order
+func resolveVendorDir() (dir, source, setting string) {
+	if vendor := installDefaults().Vendor; vendor != "" {
+		return vendor, config.VendorSourceConfig, "[install] vendor"
+	}
 	cfg := configAdapter(appConfig)
-	return config.DefaultVendorDir(cfg, detectRubyVersion, getSystemGemDir)
+	return config.ResolveVendorDir(cfg, detectRubyVersion, getSystemGemDir)
+}
+
+// bundlePathVendorDir returns where gems live for a Bundler-style --path / BUNDLE_PATH:
+// <path>/ruby/<version>, e.g. vendor/bundle/ruby/3.4.0
+func bundlePathVendorDir(bundlePath string) string {
+	if rubyVersion := detectRubyVersion(); rubyVersion != "" {
+		return filepath.Join(bundlePath, "ruby", rubyVersion)
+	}
+	return bundlePath
 }
 
 // configAdapter converts main.Config to internal/config.Config
@@ -675,18 +1309,20 @@ func defaultGemfilePath() string {
 func loadLockfile(lockfilePath string) (*lockfile.Lockfile, error) {
 	// Ruby developers: This is like File.open with explicit error handling
 	// defer is like Ruby's ensure block but scoped to the current function
-	file, err := os.Open(lockfilePath)
+	content, err := os.ReadFile(lockfilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errdefs.Newf(errdefs.ErrLockfileNotFound, "failed to open lockfile: %w", err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to open lockfile: %w", err)
 	}
-	defer func() {
-		_ = file.Close()
-	}()
 
-	parsed, err := lockfile.Parse(file)
+	parsed, err := lockfile.Parse(bytes.NewReader(content))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse lockfile: %w", err)
 	}
+	resolver.ApplyGemRemotes(parsed, content)
+	resolver.ApplyGemChecksums(parsed, content)
 
 	return parsed, nil
 }
@@ -721,10 +1357,6 @@ func deduplicateGemSpecs(specs []lockfile.GemSpec) []lockfile.GemSpec {
 	return result
 }
 
-func humanBytes(size int64) string {
-	return cache.HumanBytes(size)
-}
-
 func defaultCacheDir() (string, error) {
 	return config.DefaultCacheDir(configAdapter(appConfig))
 }
@@ -741,29 +1373,194 @@ func newDefaultDownloadManager(workers int) (*downloadManager, error) {
 		return nil, err
 	}
 
+	compress, err := cacheCompressionEnabled()
+	if err != nil {
+		return nil, err
+	}
+
 	sourceConfigs := getGemSources()
 	client := defaultHTTPClient()
 
-	return newDownloadManager(cacheDir, sourceConfigs, client, workers)
-}
-
-func defaultHTTPClient() *http.Client {
-	return &http.Client{Timeout: 60 * time.Second}
+	dm, err := newDownloadManager(cacheDir, sourceConfigs, client, workers)
+	if err != nil {
+		return nil, err
+	}
+	dm.compress = compress
+	dm.quiet = logger.Quiet()
+	return dm, nil
 }
 
-func getGemSources() []SourceConfig {
-	// Check if user has configured sources in TOML
-	if appConfig != nil && len(appConfig.GemSources) > 0 {
-		return appConfig.GemSources
+// newPlanningDownloadManager returns a download manager for --dry-run that only looks
+// up caches: unlike newDefaultDownloadManager it doesn't create the cache directory
+// and has no sources to download from
+func newPlanningDownloadManager() (*downloadManager, error) {
+	cacheDir, err := defaultCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	compress, err := cacheCompressionEnabled()
+	if err != nil {
+		return nil, err
+	}
+	return &downloadManager{cacheDir: cacheDir, compress: compress}, nil
+}
+
+// cacheCompressionEnabled reports whether cache_compression asks for compressed gems.
+// The default stays uncompressed so the cache layout matches RubyGems' own cache.
+func cacheCompressionEnabled() (bool, error) {
+	if appConfig == nil {
+		return false, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(appConfig.CacheCompression)) {
+	case "", "none":
+		return false, nil
+	case "zstd":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported cache_compression %q (supported: none, zstd)", appConfig.CacheCompression)
+	}
+}
+
+// defaultWorkers returns the download concurrency used when --workers isn't given:
+// the [install] workers config key, then Bundler's `jobs` setting (BUNDLE_JOBS), then the CPU count
+func defaultWorkers() int {
+	if workers := installDefaults().Workers; workers > 0 {
+		return workers
+	}
+	if jobs := config.ReadBundleJobs(); jobs > 0 {
+		return jobs
+	}
+	return runtime.NumCPU()
+}
+
+// defaultMaxDownloadRate returns the configured download bandwidth cap (max_download_rate)
+func defaultMaxDownloadRate() int64 {
+	if appConfig != nil {
+		return appConfig.MaxDownloadRate
+	}
+	return 0
+}
+
+// configureHTTPTimeout sets the HTTP timeout from --timeout, ORE_HTTP_TIMEOUT or the
+// http_timeout config key, in that order. The timeout bounds connecting and waiting for
+// headers, not the whole transfer, so slow-but-steady downloads still finish.
+func configureHTTPTimeout(flagValue string) error {
+	value := flagValue
+	if value == "" {
+		value = os.Getenv("ORE_HTTP_TIMEOUT")
+	}
+	if value == "" && appConfig != nil {
+		value = appConfig.HTTPTimeout
+	}
+	if value == "" {
+		return nil
+	}
+
+	timeout, err := httpclient.ParseTimeout(value)
+	if err != nil {
+		return fmt.Errorf("invalid HTTP timeout %q: %w", value, err)
+	}
+	httpclient.SetTimeout(timeout)
+	return nil
+}
+
+// gitSSHConfig returns the SSH settings for git remotes from ORE_GIT_SSH_COMMAND and
+// ORE_GIT_SSH_KEY, falling back to the git_ssh_command and git_ssh_key keys of the user
+// config (loadConfig drops them from a project .ore.toml)
+func gitSSHConfig() resolver.GitSSHConfig {
+	cfg := resolver.GitSSHConfig{
+		Command:      os.Getenv("ORE_GIT_SSH_COMMAND"),
+		IdentityFile: os.Getenv("ORE_GIT_SSH_KEY"),
+	}
+	if appConfig != nil {
+		if cfg.Command == "" {
+			cfg.Command = appConfig.GitSSHCommand
+		}
+		if cfg.IdentityFile == "" {
+			cfg.IdentityFile = appConfig.GitSSHKey
+		}
 	}
+	return cfg
+}
 
+// sourceCredentials returns the credentials for a private source host, from
+// ORE_CREDENTIALS_<HOST>, a [[credentials]] config entry or `bundle config <host>`, in that order
+func sourceCredentials(host string) string {
+	if credentials := config.ReadOreCredentials(host); credentials != "" {
+		return credentials
+	}
+	if appConfig != nil {
+		if credentials := appConfig.credentialsFor(host); credentials != "" {
+			return credentials
+		}
+	}
+	return config.ReadBundleCredentials(host)
+}
+
+// defaultHTTPClient returns a client honoring --timeout / ORE_HTTP_TIMEOUT / http_timeout
+func defaultHTTPClient() *http.Client {
+	return httpclient.New()
+}
+
+// defaultGemRemote is the gem server used when nothing else is configured
+const defaultGemRemote = "https://rubygems.org"
+
+func getGemSources() []SourceConfig {
 	// Default to rubygems.org if no sources configured
-	return []SourceConfig{
+	configured := []SourceConfig{
 		{
-			URL:      "https://rubygems.org",
+			URL:      defaultGemRemote,
 			Fallback: "",
 		},
 	}
+
+	// Check if user has configured sources in TOML
+	if appConfig != nil && len(appConfig.GemSources) > 0 {
+		configured = appConfig.GemSources
+	}
+
+	// Swap in mirrors from `bundle config mirror.<url>`
+	mirrors := config.ReadBundleMirrors()
+	result := make([]SourceConfig, 0, len(configured))
+	for _, src := range configured {
+		result = append(result, SourceConfig{
+			URL:             config.ApplyMirror(mirrors, src.URL),
+			Fallback:        config.ApplyMirror(mirrors, src.Fallback),
+			HealthPath:      src.HealthPath,
+			ResponseTimeout: src.ResponseTimeout,
+		})
+	}
+	return result
+}
+
+// applyGemfileFlag handles --gemfile: the given Gemfile is used instead of the one
+// next to the lockfile, and the lockfile becomes <gemfile>.lock (gems.locked for gems.rb)
+// unless --lockfile was also given. Returns the Gemfile to use ("" if none was found).
+//
+// Ruby developers: like BUNDLE_GEMFILE=gemfiles/rails_7.gemfile for Appraisal
+func applyGemfileFlag(fs *flag.FlagSet, gemfilePath string, lockfilePath *string) string {
+	if gemfilePath == "" {
+		return detectGemfileFromLock(*lockfilePath)
+	}
+
+	lockfileSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "lockfile" {
+			lockfileSet = true
+		}
+	})
+	if !lockfileSet {
+		*lockfilePath = lockfileForGemfile(gemfilePath)
+	}
+	return gemfilePath
+}
+
+// lockfileForGemfile returns the lockfile Bundler pairs with a Gemfile
+func lockfileForGemfile(gemfilePath string) string {
+	if filepath.Base(gemfilePath) == "gems.rb" {
+		return filepath.Join(filepath.Dir(gemfilePath), "gems.locked")
+	}
+	return gemfilePath + ".lock"
 }
 
 func detectGemfileFromLock(lockfilePath string) string {
@@ -797,16 +1594,21 @@ func buildExtensionConfig(skipExtensions, verbose bool, vendorDir string) *exten
 		skipExtensions = true
 	}
 
+	// Per-gem build options from `bundle config build.<gem>`
+	buildOptions := config.ReadBundleBuildOptions()
+
 	config := &extensions.BuildConfig{
 		SkipExtensions: skipExtensions,
 		Verbose:        verbose,
 		Parallel:       runtime.NumCPU(),
 		VendorDir:      vendorDir,
+		BuildOptions:   buildOptions,
+		RubyPath:       ruby.Binary(),
 	}
 
 	// Check if Ruby is available
 	if !skipExtensions && !extensions.IsRubyAvailable() {
-		fmt.Fprintf(os.Stderr, "Warning: Ruby not found in PATH. Native extensions will be skipped.\n")
+		fmt.Fprintf(os.Stderr, "Warning: Ruby (%s) not found. Native extensions will be skipped.\n", config.RubyPath)
 		fmt.Fprintf(os.Stderr, "Install Ruby or use --skip-extensions to suppress this warning.\n")
 		config.SkipExtensions = true
 	}
@@ -814,6 +1616,88 @@ func buildExtensionConfig(skipExtensions, verbose bool, vendorDir string) *exten
 	return config
 }
 
+// installGroups returns the groups ore install leaves out and the ones --with keeps:
+// --without (or [install] without, or a persisted BUNDLE_WITHOUT) plus the Gemfile's
+// optional groups, minus any requested with --with (or [install] with, or BUNDLE_WITH)
+func installGroups(gemfilePath, with, without string) (excludeGroups, includeGroups []string) {
+	// --with/--without override the [install] config, which overrides Bundler's settings
+	if with == "" {
+		with = installDefaults().With
+	}
+	if without == "" {
+		without = installDefaults().Without
+	}
+
+	// The flags are comma-separated; Bundler's settings use its own separators
+	bundleGroups := config.ReadBundleGroups()
+	includeGroups = parseGroupList(with)
+	if with == "" {
+		includeGroups = config.SplitBundleGroups(bundleGroups.With)
+	}
+	excludeGroups = parseGroupList(without)
+	if without == "" {
+		excludeGroups = config.SplitBundleGroups(bundleGroups.Without)
+	}
+	if optional, err := optionalGemfileGroups(gemfilePath); err == nil {
+		excludeGroups = append(excludeGroups, optional...)
+	}
+	excludeGroups = slices.DeleteFunc(excludeGroups, func(group string) bool {
+		return slices.Contains(includeGroups, group)
+	})
+	return excludeGroups, includeGroups
+}
+
+// cleanAfterInstall implements ore install --clean: like `bundle install --clean`, it
+// removes vendored gems the lockfile no longer has for this platform. The system gem
+// dir is never cleaned, since it holds gems installed for other projects.
+func cleanAfterInstall(vendorDir string, lock *lockfile.Lockfile, gemfilePath string, opts commands.CleanOptions) error {
+	if isSystemGemDir(vendorDir) {
+		fmt.Fprintf(os.Stderr, "Warning: not cleaning %s: it's the system gem directory (install with --path to use --clean)\n", vendorDir)
+		return nil
+	}
+	_, err := commands.RemoveUnusedGems(vendorDir, cleanKeepGems(lock, gemfilePath), opts)
+	return err
+}
+
+// cleanKeepGems returns the full names of the gems ore install --clean keeps: the
+// lockfile's gems for this platform, its git and path gems, and the locked Bundler
+func cleanKeepGems(lock *lockfile.Lockfile, gemfilePath string) map[string]bool {
+	keep := make(map[string]bool)
+	gems := filterGemsByPlatform(applyForceRubyPlatform(deduplicateGemSpecs(lock.GemSpecs), gemfilePath))
+	for _, spec := range gems {
+		keep[spec.FullName()] = true
+	}
+	for _, spec := range lock.GitSpecs {
+		keep[spec.FullName()] = true
+	}
+	for _, spec := range lock.PathSpecs {
+		keep[spec.FullName()] = true
+	}
+	if version := strings.TrimSpace(lock.BundledWith); version != "" {
+		keep["bundler-"+version] = true
+	}
+	return keep
+}
+
+// isSystemGemDir reports whether dir is the system gem directory (or GEM_HOME) or
+// another directory on Ruby's gem path
+func isSystemGemDir(dir string) bool {
+	target, err := filepath.Abs(dir)
+	if err != nil {
+		return true // Can't tell; err on the side of not deleting
+	}
+	candidates := append([]string{getSystemGemDir()}, tryGetGemPathsForInstall()...)
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(candidate); err == nil && abs == target {
+			return true
+		}
+	}
+	return false
+}
+
 // parseGroupList parses a comma-separated list of groups
 func parseGroupList(groupsStr string) []string {
 	if groupsStr == "" {
@@ -831,8 +1715,9 @@ func parseGroupList(groupsStr string) []string {
 	return result
 }
 
-// filterGemsByGroupsAndDependencies filters gems by groups and includes transitive dependencies
-func filterGemsByGroupsAndDependencies(gems []lockfile.GemSpec, allGems []lockfile.GemSpec, excludeGroups []string) []lockfile.GemSpec {
+// filterGemsByGroupsAndDependencies filters gems by groups and includes transitive dependencies.
+// Gems in any of includeGroups (--with) are kept even when another of their groups is excluded.
+func filterGemsByGroupsAndDependencies(gems []lockfile.GemSpec, allGems []lockfile.GemSpec, excludeGroups, includeGroups []string) []lockfile.GemSpec {
 	// Create a map of all gems for lookup
 	gemMap := make(map[string]lockfile.GemSpec)
 	for _, gem := range allGems {
@@ -845,19 +1730,7 @@ func filterGemsByGroupsAndDependencies(gems []lockfile.GemSpec, allGems []lockfi
 	for _, gem := range gems {
 		if len(gem.Groups) > 0 {
 			// This is a direct dependency from Gemfile
-			excluded := false
-			for _, gemGroup := range gem.Groups {
-				for _, excludeGroup := range excludeGroups {
-					if gemGroup == excludeGroup {
-						excluded = true
-						break
-					}
-				}
-				if excluded {
-					break
-				}
-			}
-			if !excluded {
+			if !isGroupExcluded(gem.Groups, excludeGroups, includeGroups) {
 				rootGems[gem.Name] = true
 			}
 		}
@@ -918,7 +1791,7 @@ func filterGemsByPlatform(gems []lockfile.GemSpec) []lockfile.GemSpec {
 // detectCurrentPlatform returns the current platform string compatible with RubyGems
 func detectCurrentPlatform() string {
 	// Try using Ruby to get the exact platform if available
-	cmd := exec.Command("ruby", "-e", "require 'rbconfig'; puts RbConfig::CONFIG['arch']")
+	cmd := exec.Command(ruby.Binary(), "-e", "require 'rbconfig'; puts RbConfig::CONFIG['arch']")
 	if output, err := cmd.Output(); err == nil {
 		platform := strings.TrimSpace(string(output))
 		if platform != "" {
@@ -979,7 +1852,7 @@ func platformMatches(gemPlatform, currentPlatform string) bool {
 }
 
 // filterGitGemsByGroups filters git gems by excluding specified groups
-func filterGitGemsByGroups(gitSpecs []lockfile.GitGemSpec, excludeGroups []string) []lockfile.GitGemSpec {
+func filterGitGemsByGroups(gitSpecs []lockfile.GitGemSpec, excludeGroups, includeGroups []string) []lockfile.GitGemSpec {
 	var result []lockfile.GitGemSpec
 	for _, gem := range gitSpecs {
 		if len(gem.Groups) == 0 {
@@ -987,20 +1860,7 @@ func filterGitGemsByGroups(gitSpecs []lockfile.GitGemSpec, excludeGroups []strin
 			continue
 		}
 
-		excluded := false
-		for _, gemGroup := range gem.Groups {
-			for _, excludeGroup := range excludeGroups {
-				if gemGroup == excludeGroup {
-					excluded = true
-					break
-				}
-			}
-			if excluded {
-				break
-			}
-		}
-
-		if !excluded {
+		if !isGroupExcluded(gem.Groups, excludeGroups, includeGroups) {
 			result = append(result, gem)
 		}
 	}
@@ -1008,7 +1868,7 @@ func filterGitGemsByGroups(gitSpecs []lockfile.GitGemSpec, excludeGroups []strin
 }
 
 // filterPathGemsByGroups filters path gems by excluding specified groups
-func filterPathGemsByGroups(pathSpecs []lockfile.PathGemSpec, excludeGroups []string) []lockfile.PathGemSpec {
+func filterPathGemsByGroups(pathSpecs []lockfile.PathGemSpec, excludeGroups, includeGroups []string) []lockfile.PathGemSpec {
 	var result []lockfile.PathGemSpec
 	for _, gem := range pathSpecs {
 		if len(gem.Groups) == 0 {
@@ -1016,74 +1876,308 @@ func filterPathGemsByGroups(pathSpecs []lockfile.PathGemSpec, excludeGroups []st
 			continue
 		}
 
-		excluded := false
-		for _, gemGroup := range gem.Groups {
-			for _, excludeGroup := range excludeGroups {
-				if gemGroup == excludeGroup {
-					excluded = true
-					break
-				}
-			}
-			if excluded {
-				break
+		if !isGroupExcluded(gem.Groups, excludeGroups, includeGroups) {
+			result = append(result, gem)
+		}
+	}
+	return result
+}
+
+// isGroupExcluded reports whether a Gemfile gem in gemGroups should be skipped:
+// any --with group keeps it, otherwise any excluded group drops it
+func isGroupExcluded(gemGroups, excludeGroups, includeGroups []string) bool {
+	for _, group := range gemGroups {
+		if slices.Contains(includeGroups, group) {
+			return false
+		}
+	}
+	for _, group := range gemGroups {
+		if slices.Contains(excludeGroups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// optionalGemfileGroups returns the groups declared with `optional: true` in a Gemfile.
+// Bundler only installs them when requested with --with (or BUNDLE_WITH).
+func optionalGemfileGroups(gemfilePath string) ([]string, error) {
+	data, err := os.ReadFile(gemfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []string
+	for _, match := range optionalGroupPattern.FindAllStringSubmatch(string(data), -1) {
+		for _, name := range strings.Split(match[1], ",") {
+			name = strings.Trim(strings.TrimSpace(name), `:"'`)
+			if name != "" && !slices.Contains(groups, name) {
+				groups = append(groups, name)
 			}
 		}
+	}
+	return groups, nil
+}
 
-		if !excluded {
-			result = append(result, gem)
+// optionalGroupPattern matches `group :docs, :ci, optional: true do` (and the :optional => true form)
+var optionalGroupPattern = regexp.MustCompile(`(?m)^\s*group\s*\(?\s*((?:[:"'][\w-]+["']?\s*,\s*)+)(?:optional:|:optional\s*=>)\s*true\s*\)?\s*do\b`)
+
+// applyGemfileExclusions drops the gems the Gemfile leaves out on this machine (a false
+// install_if condition, or platforms: for another Ruby engine or OS), along with
+// dependencies nothing else needs
+func applyGemfileExclusions(parsed *lockfile.Lockfile, gemfilePath string) (*lockfile.Lockfile, error) {
+	excluded, err := ruby.InstallIfExclusions(gemfilePath, detectCurrentPlatform, os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read install_if blocks: %w", err)
+	}
+	otherPlatforms, err := platformExclusions(gemfilePath)
+	if err != nil {
+		return nil, err
+	}
+	excluded = append(excluded, otherPlatforms...)
+	if len(excluded) == 0 {
+		return parsed, nil
+	}
+
+	locked := make(map[string]bool)
+	for _, spec := range parsed.GemSpecs {
+		locked[spec.Name] = true
+	}
+	for _, spec := range parsed.GitSpecs {
+		locked[spec.Name] = true
+	}
+	for _, spec := range parsed.PathSpecs {
+		locked[spec.Name] = true
+	}
+
+	if len(parsed.Dependencies) == 0 {
+		// No DEPENDENCIES section to start from; drop just the excluded gems
+		parsed.GemSpecs = slices.DeleteFunc(parsed.GemSpecs, func(spec lockfile.GemSpec) bool { return slices.Contains(excluded, spec.Name) })
+		parsed.GitSpecs = slices.DeleteFunc(parsed.GitSpecs, func(spec lockfile.GitGemSpec) bool { return slices.Contains(excluded, spec.Name) })
+		parsed.PathSpecs = slices.DeleteFunc(parsed.PathSpecs, func(spec lockfile.PathGemSpec) bool { return slices.Contains(excluded, spec.Name) })
+		return parsed, nil
+	}
+
+	// Keep what the remaining Gemfile dependencies need
+	var roots []string
+	for _, dep := range parsed.Dependencies {
+		name := strings.TrimSuffix(dep.Name, "!")
+		if locked[name] && !slices.Contains(excluded, name) && !slices.Contains(roots, name) {
+			roots = append(roots, name)
 		}
 	}
-	return result
+	return selectLockedGems(parsed, roots)
 }
 
-// enrichGemsWithGroups reads the Gemfile and enriches lockfile gems with group information
-func enrichGemsWithGroups(gemfilePath string, parsed *lockfile.Lockfile) error {
-	parser := gemfile.NewGemfileParser(gemfilePath)
-	parsedGemfile, err := parser.Parse()
+// platformExclusions returns the Gemfile gems scoped with platforms: to a different Ruby
+// engine or OS than this one, e.g. `gem "jruby-openssl", platforms: :jruby` on MRI
+func platformExclusions(gemfilePath string) ([]string, error) {
+	if gemfilePath == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(gemfilePath); os.IsNotExist(err) {
+		return nil, nil
+	}
+	parsedGemfile, err := gemfile.NewGemfileParser(gemfilePath).Parse()
 	if err != nil {
-		return fmt.Errorf("failed to parse Gemfile: %w", err)
+		return nil, fmt.Errorf("failed to parse Gemfile: %w", err)
 	}
 
-	// Create a map of gem name -> groups from the Gemfile
-	gemGroups := make(map[string][]string)
+	var excluded []string
+	var engine ruby.Engine
+	var platform string
 	for _, dep := range parsedGemfile.Dependencies {
-		if len(dep.Groups) > 0 {
-			gemGroups[dep.Name] = dep.Groups
-		} else {
-			gemGroups[dep.Name] = []string{"default"}
+		if len(dep.Platforms) == 0 {
+			continue
+		}
+		if engine.Name == "" {
+			engine, platform = ruby.DetectEngine(), detectCurrentPlatform()
+		}
+		if !ruby.MatchesPlatformSymbols(dep.Platforms, engine, platform) {
+			excluded = append(excluded, dep.Name)
 		}
 	}
+	return excluded, nil
+}
 
-	// Enrich GemSpecs with group information
-	for i := range parsed.GemSpecs {
-		if groups, found := gemGroups[parsed.GemSpecs[i].Name]; found {
-			parsed.GemSpecs[i].Groups = groups
+// applyForceRubyPlatform replaces platform-specific gems with their pure-Ruby ("ruby"
+// platform) variant when `bundle config set force_ruby_platform true` is set, or for gems
+// declared with `gem "nokogiri", force_ruby_platform: true`. The ruby variant is built
+// from source, so install compiles its extension instead of using the precompiled binary.
+func applyForceRubyPlatform(gems []lockfile.GemSpec, gemfilePath string) []lockfile.GemSpec {
+	forceAll := config.ReadForceRubyPlatform()
+	forced, _ := forceRubyPlatformGems(gemfilePath)
+	if !forceAll && len(forced) == 0 {
+		return gems
+	}
+
+	// The ruby variants' CHECKSUMS entries, for the platform variants swapped for them
+	rubyChecksums := make(map[string]string)
+	for _, gem := range gems {
+		if gem.Platform == "" || gem.Platform == "ruby" {
+			rubyChecksums[gem.Name+"-"+gem.Version] = gem.Checksum
 		}
 	}
 
-	// Enrich GitGemSpecs with group information
-	for i := range parsed.GitSpecs {
-		if groups, found := gemGroups[parsed.GitSpecs[i].Name]; found {
-			parsed.GitSpecs[i].Groups = groups
+	seen := make(map[string]bool)
+	var result []lockfile.GemSpec
+	for _, gem := range gems {
+		if gem.Platform != "" && gem.Platform != "ruby" && (forceAll || slices.Contains(forced, gem.Name)) {
+			// Same name, version and dependencies; the lockfile may not list the ruby variant,
+			// and the platform variant's checksum doesn't describe the ruby gem
+			gem.Platform = ""
+			gem.Checksum = rubyChecksums[gem.FullName()]
 		}
+		if seen[gem.FullName()] {
+			continue
+		}
+		seen[gem.FullName()] = true
+		result = append(result, gem)
+	}
+	return result
+}
+
+// forceRubyPlatformGems returns the gems the Gemfile declares with force_ruby_platform: true
+func forceRubyPlatformGems(gemfilePath string) ([]string, error) {
+	data, err := os.ReadFile(gemfilePath)
+	if err != nil {
+		return nil, err
 	}
 
-	// Enrich PathGemSpecs with group information
-	for i := range parsed.PathSpecs {
-		if groups, found := gemGroups[parsed.PathSpecs[i].Name]; found {
-			parsed.PathSpecs[i].Groups = groups
+	var names []string
+	for _, match := range forceRubyPlatformPattern.FindAllStringSubmatch(string(data), -1) {
+		if !slices.Contains(names, match[1]) {
+			names = append(names, match[1])
 		}
 	}
+	return names, nil
+}
+
+// forceRubyPlatformPattern matches `gem "nokogiri", force_ruby_platform: true` (and the :force_ruby_platform => true form)
+var forceRubyPlatformPattern = regexp.MustCompile(`(?m)^\s*gem\s*\(?\s*["']([\w.-]+)["'][^#\n]*?(?:force_ruby_platform:|:force_ruby_platform\s*=>)\s*true\b`)
+
+// writeStandaloneSetup writes the `--standalone` setup.rb for the installed gems, with
+// the require: options of the Gemfile's dependencies
+func writeStandaloneSetup(setupPath, vendorDir string, gems []lockfile.GemSpec, gitSpecs []lockfile.GitGemSpec, pathSpecs []lockfile.PathGemSpec, gemfilePath string) error {
+	gemDirs := installedGemDirs(gems, gitSpecs, pathSpecs)
+
+	parsedGemfile, err := gemfile.NewGemfileParser(gemfilePath).Parse()
+	if err != nil {
+		return fmt.Errorf("failed to parse Gemfile: %w", err)
+	}
+	requires := make([]geminstall.GemRequire, 0, len(parsedGemfile.Dependencies))
+	for _, dep := range parsedGemfile.Dependencies {
+		requires = append(requires, geminstall.NewGemRequire(dep.Name, dep.Groups, dep.Require))
+	}
+
+	return geminstall.WriteStandaloneSetup(setupPath, vendorDir, gemDirs, requires)
+}
+
+// installedGemDirs returns the <vendor>/gems directory names of the installed gems
+func installedGemDirs(gems []lockfile.GemSpec, gitSpecs []lockfile.GitGemSpec, pathSpecs []lockfile.PathGemSpec) []string {
+	gemDirs := make([]string, 0, len(gems)+len(gitSpecs)+len(pathSpecs))
+	for _, gem := range gems {
+		gemDirs = append(gemDirs, gem.FullName())
+	}
+	for _, spec := range gitSpecs {
+		gemDirs = append(gemDirs, fmt.Sprintf("%s-%s", spec.Name, spec.Version))
+	}
+	for _, spec := range pathSpecs {
+		gemDirs = append(gemDirs, fmt.Sprintf("%s-%s", spec.Name, spec.Version))
+	}
+	return gemDirs
+}
 
+// binstubsFlag is --binstubs[=<dir>]; bare, it writes to bin/ like `bundle install --binstubs`
+type binstubsFlag struct {
+	dir string
+}
+
+func (f *binstubsFlag) String() string   { return f.dir }
+func (f *binstubsFlag) IsBoolFlag() bool { return true }
+
+func (f *binstubsFlag) Set(value string) error {
+	switch value {
+	case "true":
+		f.dir = "bin"
+	case "false":
+		f.dir = ""
+	default:
+		f.dir = value
+	}
 	return nil
 }
 
+// writeProjectBinstubs writes binstubs into binDir for the executables of the installed
+// gems, returning how many it wrote. Scripts ore didn't write are kept, with a warning.
+func writeProjectBinstubs(binDir, vendorDir string, gemDirs []string) (int, error) {
+	count := 0
+	for _, name := range gemDirs {
+		written, skipped, err := geminstall.WriteProjectBinstubs(filepath.Join(vendorDir, "gems", name), binDir)
+		if err != nil {
+			return count, err
+		}
+		count += len(written)
+		for _, path := range skipped {
+			fmt.Fprintf(os.Stderr, "Warning: skipped %s, which ore didn't generate; remove it to get a binstub\n", path)
+		}
+	}
+	return count, nil
+}
+
+// checkLockfileRuby warns when the active Ruby's major.minor differs from the lockfile's
+// RUBY VERSION - native extensions built for one won't load on the other. With strict
+// set, it fails instead.
+func checkLockfileRuby(lockfilePath string, strict bool) error {
+	active := ruby.ActiveVersion()
+	locked := ruby.LockfileRubyMismatch(lockfilePath, active)
+	if locked == "" {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s was locked with Ruby %s but the active Ruby is %s; native extensions built for one won't load on the other",
+		filepath.Base(lockfilePath), locked, active)
+	if strict {
+		return errdefs.Newf(errdefs.ErrPlatformMismatch, "%s (--strict-ruby)", message)
+	}
+
+	warningStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("11")). // Yellow
+		Bold(true)
+	fmt.Fprintln(os.Stderr, warningStyle.Render("⚠️  Warning: "+message))
+	fmt.Fprintln(os.Stderr, "   Switch Ruby (.ruby-version, ore --ruby), or update the Gemfile's ruby version and run `ore lock`.")
+	return nil
+}
+
+// checkGemfileRubyEngine errors when the Gemfile's ruby directive names an
+// engine (e.g. engine: "jruby") that doesn't match the running Ruby
+func checkGemfileRubyEngine(gemfilePath string) error {
+	if gemfilePath == "" {
+		return nil
+	}
+	req, err := ruby.ParseGemfileRubyRequirement(gemfilePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if req == nil || req.Engine == "" {
+		return nil
+	}
+	return resolver.NewEngineCompatibility(ruby.DetectEngine()).CheckRubyRequirement(req)
+}
+
 func runTreeCommand(args []string) error {
 	fs := flag.NewFlagSet("tree", flag.ContinueOnError)
 	lockfilePath := fs.String("lockfile", defaultLockfilePath(), "Path to Gemfile.lock")
+	format := fs.String("format", "text", "Output format: text or json")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", *format)
+	}
 
 	parsed, err := loadLockfile(*lockfilePath)
 	if err != nil {
@@ -1093,12 +2187,16 @@ func runTreeCommand(args []string) error {
 	// Enrich with group information from Gemfile
 	gemfilePath := detectGemfileFromLock(*lockfilePath)
 	if gemfilePath != "" {
-		if err := enrichGemsWithGroups(gemfilePath, parsed); err != nil {
+		if err := commands.EnrichGemsWithGroups(gemfilePath, parsed); err != nil {
 			// Non-fatal: continue without group info
 			fmt.Fprintf(os.Stderr, "Warning: could not read Gemfile groups: %v\n", err)
 		}
 	}
 
+	if *format == "json" {
+		return printDependencyTreeJSON(os.Stdout, parsed.GemSpecs)
+	}
+
 	// Print tree with colors if TTY, plain if not
 	if isTTY() {
 		printDependencyTree(parsed.GemSpecs)
@@ -1119,9 +2217,15 @@ func runAuditCommand(args []string) error {
 
 	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
 	lockfilePath := fs.String("lockfile", defaultLockfilePath(), "Path to Gemfile.lock")
+	databasePath := fs.String("database", "", "Local advisory database directory (default: $ORE_AUDIT_DB or ~/.local/share/ruby-advisory-db)")
+	without := fs.String("without", "", "Skip gems only in these groups (comma-separated), e.g. development,test")
+	only := fs.String("only", "", "Audit only gems in these groups (comma-separated) and their dependencies")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	if *without != "" && *only != "" {
+		return fmt.Errorf("--without and --only cannot be used together")
+	}
 
 	// Load lockfile
 	parsed, err := loadLockfile(*lockfilePath)
@@ -1129,20 +2233,32 @@ func runAuditCommand(args []string) error {
 		return err
 	}
 
-	// Initialize database
-	db, err := audit.NewDatabase("")
+	// Scope to the groups that ship: the remaining Gemfile gems plus their dependencies
+	gems := parsed.GemSpecs
+	if *without != "" || *only != "" {
+		gemfilePath := detectGemfileFromLock(*lockfilePath)
+		if gemfilePath == "" {
+			return fmt.Errorf("--without and --only need the Gemfile next to %s", *lockfilePath)
+		}
+		if err := commands.EnrichGemsWithGroups(gemfilePath, parsed); err != nil {
+			return err
+		}
+		excludeGroups, includeGroups := auditGroupScope(parsed.GemSpecs, parseGroupList(*without), parseGroupList(*only))
+		gems = filterGemsByGroupsAndDependencies(parsed.GemSpecs, parsed.GemSpecs, excludeGroups, includeGroups)
+	}
+
+	// Initialize database; scanning only reads it, so a pre-provisioned copy works offline
+	db, err := audit.NewDatabase(*databasePath)
 	if err != nil {
 		return err
 	}
-
-	if !db.Exists() {
-		fmt.Println("Advisory database not found. Run `ore audit update` to download it.")
-		return fmt.Errorf("advisory database not found")
+	if err := db.Check(); err != nil {
+		return err
 	}
 
 	// Create scanner and scan
 	scanner := audit.NewScanner(db)
-	result, err := scanner.ScanWithReport(parsed.GemSpecs)
+	result, err := scanner.ScanWithReport(gems)
 	if err != nil {
 		return err
 	}
@@ -1157,13 +2273,30 @@ func runAuditCommand(args []string) error {
 	return nil
 }
 
+// auditGroupScope turns `ore audit --without`/`--only` into the exclude/include groups
+// filterGemsByGroupsAndDependencies expects. --only excludes every other Gemfile group.
+func auditGroupScope(gems []lockfile.GemSpec, without, only []string) (excludeGroups, includeGroups []string) {
+	if len(only) == 0 {
+		return without, nil
+	}
+	for _, gem := range gems {
+		for _, group := range gem.Groups {
+			if !slices.Contains(only, group) && !slices.Contains(excludeGroups, group) {
+				excludeGroups = append(excludeGroups, group)
+			}
+		}
+	}
+	return excludeGroups, only
+}
+
 func runAuditUpdate(args []string) error {
 	fs := flag.NewFlagSet("audit update", flag.ContinueOnError)
+	databasePath := fs.String("database", "", "Advisory database directory to clone or update")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
-	db, err := audit.NewDatabase("")
+	db, err := audit.NewDatabase(*databasePath)
 	if err != nil {
 		return err
 	}
@@ -1274,37 +2407,66 @@ func printVulnerability(vuln audit.Vulnerability) {
 
 func runWhyCommand(args []string) error {
 	fs := flag.NewFlagSet("why", flag.ContinueOnError)
+	top := fs.Int("top", 0, "Rank the N gems with the most direct dependents instead")
+	missing := fs.Bool("missing", false, "Explain why ore install leaves the gems out (not a dependency, excluded group, other platform)")
+	gemfilePath := fs.String("gemfile", defaultGemfilePath(), "Path to Gemfile (with --missing)")
+	without := fs.String("without", "", "Groups ore install would exclude, comma-separated (with --missing; default: as for ore install)")
+	with := fs.String("with", "", "Optional groups ore install would include, comma-separated (with --missing)")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
+	if *top > 0 {
+		return commands.WhyTop(*top)
+	}
 	if len(fs.Args()) == 0 {
-		return fmt.Errorf("usage: ore why <gem>")
+		return fmt.Errorf("usage: ore why <gem>... | ore why --missing <gem>... | ore why --top N")
 	}
 
-	gemName := fs.Args()[0]
-	return commands.Why(gemName)
+	if *missing {
+		lockfilePath := lockfileForGemfile(*gemfilePath)
+		excludeGroups, includeGroups := installGroups(*gemfilePath, *with, *without)
+		currentPlatform := detectCurrentPlatform()
+		for _, name := range fs.Args() {
+			reason, err := explainMissingGem(lockfilePath, *gemfilePath, name, excludeGroups, includeGroups, currentPlatform)
+			if err != nil {
+				return err
+			}
+			fmt.Println(reason)
+		}
+		return nil
+	}
+
+	return commands.Why(fs.Args())
 }
 
 func runOpenCommand(args []string) error {
 	fs := flag.NewFlagSet("open", flag.ContinueOnError)
 	vendorDir := fs.String("vendor", defaultVendorDir(), "Path to installed gems")
+	printPath := fs.Bool("path", false, "Print the path instead of opening an editor")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
 	if len(fs.Args()) == 0 {
-		return fmt.Errorf("usage: ore open <gem>")
+		return fmt.Errorf("usage: ore open <gem>[/<file>[:<line>]] [--path]")
 	}
 
-	gemName := fs.Args()[0]
-	return commands.Open(gemName, *vendorDir)
+	// Flags may also follow the gem: ore open rack --path
+	target := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return err
+	}
+
+	return commands.Open(target, *vendorDir, commands.OpenOptions{PrintPath: *printPath})
 }
 
 func runPristineCommand(args []string) error {
 	fs := flag.NewFlagSet("pristine", flag.ContinueOnError)
 	lockfilePath := fs.String("lockfile", defaultLockfilePath(), "Path to Gemfile.lock")
 	vendorDir := fs.String("vendor", defaultVendorDir(), "Path to installed gems")
+	all := fs.Bool("all", false, "Restore every gem in the lockfile")
+	skipExtensions := fs.Bool("skip-extensions", false, "Skip rebuilding native extensions")
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
@@ -1315,47 +2477,106 @@ func runPristineCommand(args []string) error {
 	}
 
 	gemNames := fs.Args()
-	return commands.Pristine(gemNames, *lockfilePath, cacheDir, *vendorDir)
+	return commands.Pristine(gemNames, *lockfilePath, commands.PristineOptions{
+		All:       *all,
+		CacheDirs: gemCacheLocations(cacheDir),
+		VendorDir: *vendorDir,
+		ExtConfig: buildExtensionConfig(*skipExtensions, false, *vendorDir),
+	})
 }
 
 func runSearchCommand(args []string) error {
 	// Separate query from flags
 	// Accept: ore search rails --limit 3  OR  ore search --limit 3 rails
-	var query string
-	var flagArgs []string
-
-	for i := 0; i < len(args); i++ {
-		if args[i] == "--limit" || args[i] == "-limit" {
-			// Skip flag and its value
-			if i+1 < len(args) {
-				flagArgs = append(flagArgs, args[i], args[i+1])
-				i++ // Skip the value
-			}
-		} else if strings.HasPrefix(args[i], "--limit=") || strings.HasPrefix(args[i], "-limit=") {
-			flagArgs = append(flagArgs, args[i])
-		} else if !strings.HasPrefix(args[i], "-") {
-			// This is the query
-			if query == "" {
-				query = args[i]
-			}
-		}
-	}
+	query, flagArgs := splitSearchArgs(args)
 
 	if query == "" {
-		return fmt.Errorf("usage: ore search <query> [--limit N]")
+		return fmt.Errorf("usage: ore search <query> [--limit N] [--sort relevance|downloads|name] [--exact] [--json] [--source URL]")
 	}
 
 	// Parse flags
 	fs := flag.NewFlagSet("search", flag.ContinueOnError)
-	limit := fs.Int("limit", 10, "Maximum number of results to display")
+	limit := fs.Int("limit", 10, "Maximum number of results to display (more than a page fetches further pages)")
+	sortOrder := fs.String("sort", commands.SortRelevance, "Order results by relevance, downloads or name")
+	exact := fs.Bool("exact", false, "Only show a gem whose name exactly matches the query")
+	jsonOutput := fs.Bool("json", false, "Output results as JSON")
+	source := fs.String("source", "", "Search only this configured source (URL or host)")
 	if err := fs.Parse(flagArgs); err != nil {
 		return err
 	}
+	if !commands.ValidSearchSort(*sortOrder) {
+		return fmt.Errorf("unknown --sort %q (expected relevance, downloads or name)", *sortOrder)
+	}
 
 	// Get gem sources from config
 	sources := getSearchSources()
+	if *source != "" {
+		selected, err := selectSearchSource(sources, *source)
+		if err != nil {
+			return err
+		}
+		sources = []string{selected}
+	}
+
+	opts := commands.SearchOptions{
+		Limit: *limit,
+		Exact: *exact,
+		JSON:  *jsonOutput,
+		Sort:  *sortOrder,
+	}
+	return commands.Search(query, opts, sources)
+}
+
+// searchValueFlags lists search flags that consume the following argument
+var searchValueFlags = map[string]bool{
+	"limit":  true,
+	"sort":   true,
+	"source": true,
+}
+
+// splitSearchArgs separates the query from flags so flags may appear anywhere
+func splitSearchArgs(args []string) (string, []string) {
+	var query string
+	var flagArgs []string
 
-	return commands.Search(query, *limit, sources)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			// This is the query
+			if query == "" {
+				query = arg
+			}
+			continue
+		}
+
+		flagArgs = append(flagArgs, arg)
+		name := strings.TrimLeft(arg, "-")
+		if searchValueFlags[name] && i+1 < len(args) {
+			// Keep the flag's value with it
+			flagArgs = append(flagArgs, args[i+1])
+			i++
+		}
+	}
+
+	return query, flagArgs
+}
+
+// selectSearchSource picks a configured source by full URL or host name
+func selectSearchSource(sources []string, want string) (string, error) {
+	trimmed := strings.TrimSuffix(want, "/")
+	for _, src := range sources {
+		if strings.TrimSuffix(src, "/") == trimmed {
+			return src, nil
+		}
+		if u, err := url.Parse(src); err == nil && u.Host == want {
+			return src, nil
+		}
+	}
+	available := make([]string, len(sources))
+	for i, src := range sources {
+		available[i] = httpclient.RedactURL(src)
+	}
+	return "", fmt.Errorf("source %q is not configured (available: %s)", want, strings.Join(available, ", "))
 }
 
 // getSearchSources returns the list of gem source URLs to search
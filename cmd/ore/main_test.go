@@ -1,18 +1,31 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/contriboss/gemfile-go/gemfile"
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/cmd/ore/commands"
+	"github.com/contriboss/ore-light/internal/errdefs"
 	"github.com/contriboss/ore-light/internal/extensions"
+	"github.com/contriboss/ore-light/internal/geminstall"
+	"github.com/contriboss/ore-light/internal/resolver"
+	"github.com/contriboss/ore-light/internal/ruby"
 )
 
 // TestSimpleGemfileParsing verifies we can parse a Gemfile using the shared gemfile-go module.
@@ -139,16 +152,92 @@ func TestConfigOverrides(t *testing.T) {
 	}
 }
 
+func TestParseExecArgs(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("ORE_VENDOR_DIR", t.TempDir())
+
+	tests := []struct {
+		args         []string
+		wantCommand  []string
+		wantLockfile string
+	}{
+		// Everything after -- belongs to the command, including its own flags
+		{[]string{"--", "rails", "s", "-p", "4000"}, []string{"rails", "s", "-p", "4000"}, defaultLockfilePath()},
+		// ore's options stop at the command
+		{[]string{"--lockfile", "x.lock", "rspec", "--tag", "focus"}, []string{"rspec", "--tag", "focus"}, "x.lock"},
+		{[]string{"rspec", "--lockfile", "y.lock"}, []string{"rspec", "--lockfile", "y.lock"}, defaultLockfilePath()},
+		{[]string{"--lockfile", "x.lock", "--", "--weird-command"}, []string{"--weird-command"}, "x.lock"},
+	}
+	for _, tt := range tests {
+		opts, err := parseExecArgs(tt.args)
+		if err != nil {
+			t.Fatalf("parseExecArgs(%q): %v", tt.args, err)
+		}
+		if !slices.Equal(opts.command, tt.wantCommand) {
+			t.Errorf("parseExecArgs(%q) command = %q, want %q", tt.args, opts.command, tt.wantCommand)
+		}
+		if opts.lockfilePath != tt.wantLockfile {
+			t.Errorf("parseExecArgs(%q) lockfile = %q, want %q", tt.args, opts.lockfilePath, tt.wantLockfile)
+		}
+	}
+
+	if _, err := parseExecArgs([]string{"--"}); err == nil || !strings.Contains(err.Error(), "no command provided") {
+		t.Errorf("expected a missing command error, got %v", err)
+	}
+	if _, err := parseExecArgs([]string{"--format", "doc", "rspec"}); err == nil || !strings.Contains(err.Error(), "after --") {
+		t.Errorf("expected an unknown option to point at --, got %v", err)
+	}
+}
+
 func TestRunLockCommandMissingGemfile(t *testing.T) {
 	tmp := t.TempDir()
 	missing := filepath.Join(tmp, "Gemfile")
 
 	err := runLockCommand([]string{"--gemfile", missing})
-	if err == nil || !strings.Contains(err.Error(), "gemfile not found") {
+	if !errors.Is(err, errdefs.ErrGemfileNotFound) || !strings.Contains(err.Error(), missing) {
 		t.Fatalf("expected missing gemfile error, got %v", err)
 	}
 }
 
+func TestRunLockCommandLockfileNames(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir) // Path gems resolve relative to the working directory
+	if err := os.MkdirAll("mylib", 0755); err != nil {
+		t.Fatal(err)
+	}
+	gemspec := "Gem::Specification.new do |spec|\n  spec.name = \"mylib\"\n  spec.version = \"0.1.0\"\nend\n"
+	if err := os.WriteFile(filepath.Join("mylib", "mylib.gemspec"), []byte(gemspec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gemfile := []byte("source \"https://rubygems.org\"\n\ngem \"mylib\", path: \"./mylib\"\n")
+
+	tests := []struct {
+		gemfile string
+		args    []string
+		want    string
+	}{
+		{gemfile: "Gemfile", want: "Gemfile.lock"},
+		{gemfile: "gems.rb", want: "gems.locked"},
+		{gemfile: "Gemfile", args: []string{"--lockfile", "Gemfile.next.lock"}, want: "Gemfile.next.lock"},
+	}
+	for _, tt := range tests {
+		for _, name := range []string{"Gemfile", "gems.rb", "Gemfile.lock", "gems.locked", "gems.rb.lock", "Gemfile.next.lock"} {
+			_ = os.Remove(name)
+		}
+		if err := os.WriteFile(tt.gemfile, gemfile, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := runLockCommand(append([]string{"--gemfile", tt.gemfile}, tt.args...)); err != nil {
+			t.Fatalf("ore lock --gemfile %s %v: %v", tt.gemfile, tt.args, err)
+		}
+		written, _ := filepath.Glob("*.lock*")
+		if !slices.Equal(written, []string{tt.want}) {
+			t.Errorf("ore lock --gemfile %s %v wrote %v, want [%s]", tt.gemfile, tt.args, written, tt.want)
+		}
+	}
+}
+
 func TestLoadGemSpecs(t *testing.T) {
 	_, thisFile, _, _ := runtime.Caller(0)
 	lockfilePath := filepath.Join(filepath.Dir(thisFile), "..", "..", "testdata", "simple_app", "Gemfile.lock")
@@ -199,6 +288,627 @@ func TestLoadGemSpecs(t *testing.T) {
 	}
 }
 
+func TestLoadLockfileGemRemotes(t *testing.T) {
+	lockfilePath := filepath.Join(t.TempDir(), "Gemfile.lock")
+	content := `GEM
+  remote: https://gems.example.com/
+  specs:
+    acme-client (1.2.0)
+      rack (>= 2.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    nokogiri (1.16.0-x86_64-linux)
+    rack (3.0.8)
+
+PLATFORMS
+  x86_64-linux
+
+DEPENDENCIES
+  acme-client!
+  rack
+`
+	if err := os.WriteFile(lockfilePath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := loadLockfile(lockfilePath)
+	if err != nil {
+		t.Fatalf("loadLockfile returned error: %v", err)
+	}
+
+	want := map[string]string{
+		"acme-client-1.2.0":            "https://gems.example.com/",
+		"nokogiri-1.16.0-x86_64-linux": "https://rubygems.org/",
+		"rack-3.0.8":                   "https://rubygems.org/",
+	}
+	if len(parsed.GemSpecs) != len(want) {
+		t.Fatalf("expected %d gems, got %d", len(want), len(parsed.GemSpecs))
+	}
+	for _, spec := range parsed.GemSpecs {
+		if spec.SourceURL != want[spec.FullName()] {
+			t.Errorf("%s: expected remote %q, got %q", spec.FullName(), want[spec.FullName()], spec.SourceURL)
+		}
+	}
+
+	dm := &downloadManager{}
+	for _, spec := range parsed.GemSpecs {
+		remote := dm.remoteFor(spec)
+		if spec.Name == "acme-client" && remote != "https://gems.example.com/" {
+			t.Errorf("expected acme-client to download from its own remote, got %q", remote)
+		}
+		if spec.Name != "acme-client" && remote != "" {
+			t.Errorf("expected %s to use the configured sources, got %q", spec.Name, remote)
+		}
+	}
+}
+
+func TestDownloadReplacesCachedGemWithStaleChecksum(t *testing.T) {
+	spec := lockfile.GemSpec{Name: "fake", Version: "0.1.0"}
+	gemPath := filepath.Join(t.TempDir(), gemFileName(spec))
+	if err := createFakeGemArchive(gemPath, map[string][]byte{"lib/fake.rb": []byte("module Fake; end\n")}, nil); err != nil {
+		t.Fatalf("failed to create fake gem archive: %v", err)
+	}
+	checksum, err := geminstall.GemSHA256(gemPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.ServeFile(w, r, gemPath)
+	}))
+	defer server.Close()
+
+	// The CHECKSUMS section gives the gem its expected SHA-256
+	lockfilePath := filepath.Join(t.TempDir(), "Gemfile.lock")
+	lockContent := "GEM\n  remote: " + server.URL + "/\n  specs:\n    fake (0.1.0)\n\nPLATFORMS\n  ruby\n\n" +
+		"DEPENDENCIES\n  fake\n\nCHECKSUMS\n  fake (0.1.0) sha256=" + strings.ToUpper(checksum) + "\n"
+	if err := os.WriteFile(lockfilePath, []byte(lockContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := loadLockfile(lockfilePath)
+	if err != nil {
+		t.Fatalf("loadLockfile returned error: %v", err)
+	}
+	if len(parsed.GemSpecs) != 1 || parsed.GemSpecs[0].Checksum != checksum {
+		t.Fatalf("expected fake's checksum %s from CHECKSUMS, got %+v", checksum, parsed.GemSpecs)
+	}
+	spec = parsed.GemSpecs[0]
+
+	dm, err := newDownloadManager(t.TempDir(), []SourceConfig{{URL: server.URL}}, server.Client(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating download manager: %v", err)
+	}
+	dm.quiet = true
+
+	// A cached copy from before the gem was re-released is downloaded again
+	if err := os.WriteFile(dm.cachePathFor(spec), []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := dm.DownloadAll(context.Background(), []lockfile.GemSpec{spec}, false)
+	if err != nil {
+		t.Fatalf("DownloadAll returned error: %v", err)
+	}
+	if report.Downloaded != 1 || requests != 1 {
+		t.Fatalf("expected the stale gem to be downloaded again, got %+v after %d requests", report, requests)
+	}
+	if sum, err := geminstall.GemSHA256(dm.cachePathFor(spec)); err != nil || sum != checksum {
+		t.Fatalf("expected the cache to hold the matching gem, got %s (%v)", sum, err)
+	}
+
+	// Now it matches, so it's reused
+	report, err = dm.DownloadAll(context.Background(), []lockfile.GemSpec{spec}, false)
+	if err != nil {
+		t.Fatalf("DownloadAll returned error: %v", err)
+	}
+	if report.Skipped != 1 || requests != 1 {
+		t.Fatalf("expected the cached gem to be reused, got %+v after %d requests", report, requests)
+	}
+
+	// A download that doesn't match either is rejected
+	spec.Checksum = strings.Repeat("0", 64)
+	if _, err := dm.DownloadAll(context.Background(), []lockfile.GemSpec{spec}, false); err == nil ||
+		!strings.Contains(err.Error(), "doesn't match the lockfile's checksum") {
+		t.Fatalf("expected a checksum mismatch error, got %v", err)
+	}
+}
+
+func TestDownloadAllRetryMissingReportsEveryFailure(t *testing.T) {
+	present := lockfile.GemSpec{Name: "present", Version: "1.0.0"}
+	gemPath := filepath.Join(t.TempDir(), gemFileName(present))
+	if err := createFakeGemArchive(gemPath, map[string][]byte{"lib/present.rb": []byte("module Present; end\n")}, nil); err != nil {
+		t.Fatalf("failed to create fake gem archive: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/downloads/"+gemFileName(present) {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, gemPath)
+	}))
+	defer server.Close()
+
+	gems := []lockfile.GemSpec{
+		{Name: "zeta", Version: "2.0.0"},
+		present,
+		{Name: "alpha", Version: "0.3.0"},
+	}
+	dm, err := newDownloadManager(t.TempDir(), []SourceConfig{{URL: server.URL}}, server.Client(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating download manager: %v", err)
+	}
+	dm.quiet = true
+	dm.retryMissing = true
+
+	report, err := dm.DownloadAll(context.Background(), gems, false)
+	if err == nil {
+		t.Fatal("expected an error listing the missing gems")
+	}
+	if report.Downloaded != 1 || len(report.Failed) != 2 {
+		t.Fatalf("expected the available gem to download and both missing ones to be recorded, got %+v", report)
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "2 gem(s) couldn't be fetched from any source") ||
+		strings.Index(msg, "alpha-0.3.0") > strings.Index(msg, "zeta-2.0.0") || strings.Contains(msg, "present-1.0.0") {
+		t.Errorf("expected alpha and zeta listed in order, got %v", err)
+	}
+}
+
+func TestDownloadAllUsesLocalSource(t *testing.T) {
+	local := t.TempDir()
+	spec := lockfile.GemSpec{Name: "fake", Version: "0.1.0"}
+	if err := createFakeGemArchive(filepath.Join(local, gemFileName(spec)), map[string][]byte{"lib/fake.rb": []byte("module Fake; end\n")}, nil); err != nil {
+		t.Fatalf("failed to create fake gem archive: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s with a local source", r.URL.Path)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	dm, err := newDownloadManager(t.TempDir(), []SourceConfig{{URL: server.URL}}, server.Client(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating download manager: %v", err)
+	}
+	dm.quiet = true
+	dm.localSource = local
+
+	report, err := dm.DownloadAll(context.Background(), []lockfile.GemSpec{spec}, true)
+	if err != nil {
+		t.Fatalf("DownloadAll returned error: %v", err)
+	}
+	if report.Skipped != 1 {
+		t.Errorf("expected the gem to come from the local source, got %+v", report)
+	}
+	if _, err := os.Stat(dm.cachePathFor(spec)); err != nil {
+		t.Errorf("expected the gem to be copied into the cache: %v", err)
+	}
+
+	missing := lockfile.GemSpec{Name: "missing", Version: "1.0.0"}
+	if _, err := dm.DownloadAll(context.Background(), []lockfile.GemSpec{missing}, false); err == nil ||
+		!strings.Contains(err.Error(), "isn't in the local source") {
+		t.Errorf("expected a missing gem error, got %v", err)
+	}
+
+	// A copy that doesn't match the lockfile is reported as such, not as missing
+	mismatched := spec
+	mismatched.Checksum = strings.Repeat("0", 64)
+	if _, err := dm.DownloadAll(context.Background(), []lockfile.GemSpec{mismatched}, true); err == nil ||
+		!strings.Contains(err.Error(), "doesn't match the lockfile's checksum") || strings.Contains(err.Error(), "isn't in the local source") {
+		t.Errorf("expected a checksum mismatch error, got %v", err)
+	}
+}
+
+func TestUnsupportedRubyReason(t *testing.T) {
+	metadata := func(requirements string) []byte {
+		return []byte("name: modern\nrequired_ruby_version: !ruby/object:Gem::Requirement\n  requirements:\n" + requirements)
+	}
+	atLeast32 := metadata("  - - \">=\"\n    - !ruby/object:Gem::Version\n      version: '3.2'\n")
+	tests := []struct {
+		name     string
+		metadata []byte
+		ruby     string
+		want     string
+	}{
+		{"satisfied", atLeast32, "3.3.1", ""},
+		{"too old", atLeast32, "3.1.4", "requires ruby >= 3.2"},
+		{"unknown Ruby", atLeast32, "", ""},
+		{"no requirement", []byte("name: modern\n"), "2.7.8", ""},
+		{"range", metadata("  - - \"~>\"\n    - !ruby/object:Gem::Version\n      version: '2.7'\n"), "3.0.6", "requires ruby ~> 2.7"},
+	}
+	for _, tt := range tests {
+		if got := unsupportedRubyReason(tt.metadata, tt.ruby); got != tt.want {
+			t.Errorf("%s: unsupportedRubyReason(%q) = %q, want %q", tt.name, tt.ruby, got, tt.want)
+		}
+	}
+
+	report := formatRubyRequirementReport("3.1.4", []incompatibleGem{{Name: "modern-1.0.0", Reason: "requires ruby >= 3.2"}})
+	if !strings.Contains(report, "Ruby 3.1.4") || !strings.Contains(report, "  modern-1.0.0: requires ruby >= 3.2\n") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+	if formatRubyRequirementReport("3.1.4", nil) != "" {
+		t.Error("expected no report without mismatches")
+	}
+
+	// Without a runnable Ruby, the installed lockfile's RUBY VERSION is the target,
+	// not the one in the working directory
+	ruby.SetBinary(filepath.Join(t.TempDir(), "no-ruby"))
+	t.Cleanup(func() { ruby.SetBinary("") })
+	t.Chdir(t.TempDir())
+	lockfilePath := filepath.Join(t.TempDir(), "Gemfile.lock")
+	if err := os.WriteFile(lockfilePath, []byte("RUBY VERSION\n   ruby 3.1.4p223\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := targetRubyVersion(lockfilePath); got != "3.1.4" {
+		t.Errorf("expected the lockfile's Ruby 3.1.4, got %q", got)
+	}
+}
+
+func TestExplainMissingGem(t *testing.T) {
+	dir := t.TempDir()
+	gemfilePath := filepath.Join(dir, "Gemfile")
+	gemfileContent := `source "https://rubygems.org"
+
+gem "rack"
+gem "nokogiri"
+gem "sidekiq"
+
+group :test do
+  gem "rspec"
+end
+`
+	lockContent := `GEM
+  remote: https://rubygems.org/
+  specs:
+    diff-lcs (1.5.1)
+    nokogiri (1.16.0-arm64-darwin)
+    rack (3.0.8)
+    rspec (3.13.0)
+      rspec-core (~> 3.13.0)
+    rspec-core (3.13.0)
+      diff-lcs (>= 1.2.0)
+
+PLATFORMS
+  arm64-darwin
+
+DEPENDENCIES
+  nokogiri
+  rack
+  rspec
+`
+	if err := os.WriteFile(gemfilePath, []byte(gemfileContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(gemfilePath+".lock", []byte(lockContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		gem  string
+		want string
+	}{
+		{"puma", "puma is not a dependency"},
+		{"sidekiq", "sidekiq is in Gemfile but not in Gemfile.lock; run `ore lock`"},
+		{"rspec", "rspec is in the test group(s), which are excluded (test)"},
+		{"diff-lcs", "diff-lcs is only needed by gems in excluded groups (test): rspec (test)"},
+		{"nokogiri", "nokogiri is only locked for arm64-darwin, not this platform (x86_64-linux)"},
+		{"rack", "rack isn't filtered out"},
+	}
+	for _, tt := range tests {
+		got, err := explainMissingGem(gemfilePath+".lock", gemfilePath, tt.gem, []string{"test"}, nil, "x86_64-linux")
+		if err != nil {
+			t.Fatalf("explainMissingGem(%s) returned error: %v", tt.gem, err)
+		}
+		if !strings.HasPrefix(got, tt.want) {
+			t.Errorf("explainMissingGem(%s) = %q, want it to start with %q", tt.gem, got, tt.want)
+		}
+	}
+
+	// --with brings the group back
+	got, err := explainMissingGem(gemfilePath+".lock", gemfilePath, "rspec", []string{"test"}, []string{"test"}, "x86_64-linux")
+	if err != nil || !strings.HasPrefix(got, "rspec isn't filtered out") {
+		t.Errorf("expected rspec to be installed with --with test, got %q (%v)", got, err)
+	}
+}
+
+func TestInstallGroupsBundlerSeparators(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BUNDLE_WITHOUT", "development:test")
+	t.Setenv("BUNDLE_WITH", "")
+	gemfilePath := filepath.Join(dir, "Gemfile")
+	if err := os.WriteFile(gemfilePath, []byte("source \"https://rubygems.org\"\n\ngem \"rack\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	exclude, _ := installGroups(gemfilePath, "", "")
+	if !slices.Equal(exclude, []string{"development", "test"}) {
+		t.Errorf("expected BUNDLE_WITHOUT=development:test to exclude two groups, got %q", exclude)
+	}
+
+	// --without stays comma-separated
+	exclude, _ = installGroups(gemfilePath, "", "staging,test")
+	if !slices.Equal(exclude, []string{"staging", "test"}) {
+		t.Errorf("expected --without to override BUNDLE_WITHOUT, got %q", exclude)
+	}
+}
+
+func TestInstallConfigDefaults(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BUNDLE_WITHOUT", "staging")
+	t.Setenv("BUNDLE_WITH", "")
+	t.Setenv("BUNDLE_JOBS", "5")
+	t.Setenv("ORE_VENDOR_DIR", "/tmp/env-gems")
+
+	userConfig := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(userConfig, []byte("[install]\nworkers = 2\nvendor = \"vendor/user\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ORE_CONFIG", userConfig)
+	project := "[install]\nwithout = \"development,test\"\nvendor = \"vendor/bundle\"\nskip_extensions = true\n"
+	if err := os.WriteFile(".ore.toml", []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gemfilePath := filepath.Join(dir, "Gemfile")
+	if err := os.WriteFile(gemfilePath, []byte("source \"https://rubygems.org\"\n\ngem \"rack\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	origCfg := appConfig
+	appConfig = loadConfig()
+	t.Cleanup(func() { appConfig = origCfg })
+
+	// The project file overrides the user file key by key
+	want := InstallConfig{Without: "development,test", Vendor: "vendor/bundle", Workers: 2, SkipExtensions: true}
+	if got := installDefaults(); got != want {
+		t.Errorf("installDefaults() = %+v, want %+v", got, want)
+	}
+
+	// Config overrides the environment and .bundle/config...
+	if got := defaultVendorDir(); got != "vendor/bundle" {
+		t.Errorf("expected [install] vendor to override ORE_VENDOR_DIR, got %q", got)
+	}
+	if got := defaultWorkers(); got != 2 {
+		t.Errorf("expected [install] workers to override BUNDLE_JOBS, got %d", got)
+	}
+	exclude, _ := installGroups(gemfilePath, "", "")
+	if !slices.Equal(exclude, []string{"development", "test"}) {
+		t.Errorf("expected [install] without to override BUNDLE_WITHOUT, got %q", exclude)
+	}
+
+	// ...and explicit flags override config
+	exclude, _ = installGroups(gemfilePath, "", "ci")
+	if !slices.Equal(exclude, []string{"ci"}) {
+		t.Errorf("expected --without to override [install] without, got %q", exclude)
+	}
+}
+
+func TestGitSSHConfigIgnoresProjectConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("ORE_GIT_SSH_COMMAND", "")
+	t.Setenv("ORE_GIT_SSH_KEY", "")
+
+	userConfig := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(userConfig, []byte("git_ssh_key = \"/keys/deploy\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ORE_CONFIG", userConfig)
+	project := "git_ssh_command = \"touch /tmp/pwned\"\ngit_ssh_key = \"/tmp/evil\"\n"
+	if err := os.WriteFile(".ore.toml", []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	origCfg := appConfig
+	appConfig = loadConfig()
+	t.Cleanup(func() { appConfig = origCfg })
+
+	// The checkout can't pick the SSH command or key; the user config still can
+	want := resolver.GitSSHConfig{IdentityFile: "/keys/deploy"}
+	if got := gitSSHConfig(); got != want {
+		t.Errorf("gitSSHConfig() = %+v, want %+v", got, want)
+	}
+
+	t.Setenv("ORE_GIT_SSH_COMMAND", "ssh -o BatchMode=yes")
+	if got := gitSSHConfig(); got.Command != "ssh -o BatchMode=yes" {
+		t.Errorf("expected ORE_GIT_SSH_COMMAND to apply, got %+v", got)
+	}
+}
+
+func TestCleanAfterInstall(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GEM_HOME", t.TempDir())
+	gemfilePath := filepath.Join(dir, "Gemfile")
+	if err := os.WriteFile(gemfilePath, []byte("gem 'rack'\ngem 'nokogiri'\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lock := &lockfile.Lockfile{
+		GemSpecs: []lockfile.GemSpec{
+			{Name: "rack", Version: "3.0.8"},
+			{Name: "nokogiri", Version: "1.16.0", Platform: "java"},
+		},
+		BundledWith: "2.5.6",
+	}
+
+	vendorDir := filepath.Join(dir, "vendor")
+	for _, name := range []string{"rack-3.0.8", "rack-2.2.8", "nokogiri-1.16.0-java", "bundler-2.5.6"} {
+		if err := os.MkdirAll(filepath.Join(vendorDir, "gems", name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(vendorDir, "gems", name))
+		return err == nil
+	}
+
+	// --dry-run only lists what would go
+	if err := cleanAfterInstall(vendorDir, lock, gemfilePath, commands.CleanOptions{DryRun: true, Out: io.Discard}); err != nil {
+		t.Fatalf("dry-run clean returned error: %v", err)
+	}
+	if !exists("rack-2.2.8") {
+		t.Fatal("expected --dry-run to keep every gem")
+	}
+
+	if err := cleanAfterInstall(vendorDir, lock, gemfilePath, commands.CleanOptions{Out: io.Discard}); err != nil {
+		t.Fatalf("clean returned error: %v", err)
+	}
+	if exists("rack-2.2.8") {
+		t.Error("expected the unlocked rack-2.2.8 to be removed")
+	}
+	if !exists("rack-3.0.8") || !exists("bundler-2.5.6") {
+		t.Error("expected the locked rack and Bundler to be kept")
+	}
+	if exists("nokogiri-1.16.0-java") && !platformMatches("java", detectCurrentPlatform()) {
+		t.Error("expected the other platform's nokogiri to be removed")
+	}
+
+	// Never the system gem dir
+	if err := os.MkdirAll(filepath.Join(vendorDir, "gems", "rails-7.1.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GEM_HOME", vendorDir)
+	if err := cleanAfterInstall(vendorDir, lock, gemfilePath, commands.CleanOptions{Out: io.Discard}); err != nil {
+		t.Fatalf("clean returned error: %v", err)
+	}
+	if !exists("rails-7.1.0") {
+		t.Error("expected the system gem dir to be left alone")
+	}
+}
+
+func TestApplyInstallIf(t *testing.T) {
+	dir := t.TempDir()
+	gemfilePath := filepath.Join(dir, "Gemfile")
+	gemfileContent := `source "https://rubygems.org"
+
+gem "rack"
+
+install_if -> { RUBY_PLATFORM =~ /no-such-platform/ } do
+  gem "notifier"
+end
+`
+	if err := os.WriteFile(gemfilePath, []byte(gemfileContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := &lockfile.Lockfile{
+		GemSpecs: []lockfile.GemSpec{
+			{Name: "notifier", Version: "1.0.0", Dependencies: []lockfile.Dependency{{Name: "rack"}, {Name: "notifier-core"}}},
+			{Name: "notifier-core", Version: "1.0.0"},
+			{Name: "rack", Version: "3.0.8"},
+		},
+		Dependencies: []lockfile.Dependency{{Name: "notifier"}, {Name: "rack"}},
+	}
+
+	filtered, err := applyGemfileExclusions(parsed, gemfilePath)
+	if err != nil {
+		t.Fatalf("applyGemfileExclusions returned error: %v", err)
+	}
+	var names []string
+	for _, spec := range filtered.GemSpecs {
+		names = append(names, spec.Name)
+	}
+	if strings.Join(names, ",") != "rack" {
+		t.Fatalf("expected only rack to remain (notifier and its own dependency excluded), got %v", names)
+	}
+}
+
+func TestApplyGemfileExclusionsSkipsOtherEngines(t *testing.T) {
+	t.Setenv("RUBY_ENGINE", "ruby")
+	t.Setenv("RUBY_VERSION", "3.3.0")
+
+	dir := t.TempDir()
+	gemfilePath := filepath.Join(dir, "Gemfile")
+	gemfileContent := `source "https://rubygems.org"
+
+gem "rack"
+gem "jruby-openssl", platforms: :jruby
+gem "byebug", platforms: [:mri, :windows]
+`
+	if err := os.WriteFile(gemfilePath, []byte(gemfileContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := &lockfile.Lockfile{
+		GemSpecs: []lockfile.GemSpec{
+			{Name: "byebug", Version: "11.1.3"},
+			{Name: "jruby-openssl", Version: "0.14.2"},
+			{Name: "rack", Version: "3.0.8"},
+		},
+		Dependencies: []lockfile.Dependency{{Name: "byebug"}, {Name: "jruby-openssl"}, {Name: "rack"}},
+	}
+
+	filtered, err := applyGemfileExclusions(parsed, gemfilePath)
+	if err != nil {
+		t.Fatalf("applyGemfileExclusions returned error: %v", err)
+	}
+	var names []string
+	for _, spec := range filtered.GemSpecs {
+		names = append(names, spec.Name)
+	}
+	if strings.Join(names, ",") != "byebug,rack" {
+		t.Fatalf("expected the :jruby gem to be skipped on MRI, got %v", names)
+	}
+}
+
+func TestApplyGemfileFlag(t *testing.T) {
+	tests := []struct {
+		args         []string
+		gemfile      string
+		wantGemfile  string
+		wantLockfile string
+	}{
+		{[]string{"--gemfile", "gemfiles/rails_7.gemfile"}, "gemfiles/rails_7.gemfile", "gemfiles/rails_7.gemfile", "gemfiles/rails_7.gemfile.lock"},
+		{[]string{"--gemfile", "app/gems.rb"}, "app/gems.rb", "app/gems.rb", filepath.Join("app", "gems.locked")},
+		{[]string{"--gemfile", "Gemfile.next", "--lockfile", "custom.lock"}, "Gemfile.next", "Gemfile.next", "custom.lock"},
+	}
+
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		lockfilePath := fs.String("lockfile", "Gemfile.lock", "")
+		fs.String("gemfile", "", "")
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+
+		gemfilePath := applyGemfileFlag(fs, tt.gemfile, lockfilePath)
+		if gemfilePath != tt.wantGemfile || *lockfilePath != tt.wantLockfile {
+			t.Errorf("%v: got gemfile %q, lockfile %q; want %q, %q", tt.args, gemfilePath, *lockfilePath, tt.wantGemfile, tt.wantLockfile)
+		}
+	}
+}
+
+func TestInstallFromCacheChecksRubyOfInstalledGems(t *testing.T) {
+	cacheDir := t.TempDir()
+	vendorDir := filepath.Join(t.TempDir(), "vendor")
+	spec := lockfile.GemSpec{Name: "modern", Version: "1.0.0"}
+	metadata := "--- !ruby/object:Gem::Specification\nname: modern\nrequired_ruby_version: !ruby/object:Gem::Requirement\n  requirements:\n  - - \">=\"\n    - !ruby/object:Gem::Version\n      version: '3.2'\n"
+	payload := map[string][]byte{"lib/modern.rb": []byte("module Modern; end\n")}
+	if err := createFakeGemArchive(filepath.Join(cacheDir, gemFileName(spec)), payload, []byte(metadata)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	extConfig := &extensions.BuildConfig{SkipExtensions: true}
+	report, err := installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, false, false, false, "", "3.3.0", extConfig)
+	if err != nil || report.Installed != 1 || len(report.RubyMismatches) != 0 {
+		t.Fatalf("expected a clean install under Ruby 3.3.0, got %+v (err %v)", report, err)
+	}
+
+	// Switching to an older Ruby and re-running skips the gem but still warns
+	report, err = installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, false, false, false, "", "3.1.4", extConfig)
+	if err != nil {
+		t.Fatalf("installFromCache returned error: %v", err)
+	}
+	if report.Skipped != 1 || len(report.RubyMismatches) != 1 || report.RubyMismatches[0].Reason != "requires ruby >= 3.2" {
+		t.Errorf("expected the installed gem to be reported for Ruby 3.1.4, got %+v", report)
+	}
+}
+
 func TestInstallFromCache(t *testing.T) {
 	cacheDir := t.TempDir()
 	vendorDir := filepath.Join(t.TempDir(), "vendor")
@@ -246,7 +956,7 @@ STDOUT.binmode
 
 	ctx := context.Background()
 	extConfig := &extensions.BuildConfig{SkipExtensions: true}
-	report, err := installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, false, false, extConfig)
+	report, err := installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, false, false, false, "", "", extConfig)
 	if err != nil {
 		t.Fatalf("installFromCache returned error: %v", err)
 	}
@@ -276,8 +986,14 @@ STDOUT.binmode
 		t.Fatalf("expected gemspec to contain gem name, got: %s", data)
 	}
 
+	// A gem missing from the cache is reported as such
+	missing := lockfile.GemSpec{Name: "absent", Version: "1.0.0"}
+	if _, err := installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{missing}, false, false, false, "", "", extConfig); !errors.Is(err, errdefs.ErrGemNotCached) {
+		t.Fatalf("expected ErrGemNotCached, got %v", err)
+	}
+
 	// Second install without --force should skip
-	report, err = installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, false, false, extConfig)
+	report, err = installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, false, false, false, "", "", extConfig)
 	if err != nil {
 		t.Fatalf("second installFromCache returned error: %v", err)
 	}
@@ -286,7 +1002,7 @@ STDOUT.binmode
 	}
 
 	// Force reinstall should re-extract
-	report, err = installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, true, false, extConfig)
+	report, err = installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, true, false, false, "", "", extConfig)
 	if err != nil {
 		t.Fatalf("forced installFromCache returned error: %v", err)
 	}
@@ -294,9 +1010,24 @@ STDOUT.binmode
 		t.Fatalf("expected reinstall with force, got %+v", report)
 	}
 
+	// --link, then --force without it, must not truncate the cached gem through the link
+	cached, err := os.ReadFile(gemPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, true, false, true, "", "", extConfig); err != nil {
+		t.Fatalf("linked installFromCache returned error: %v", err)
+	}
+	if _, err := installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, true, false, false, "", "", extConfig); err != nil {
+		t.Fatalf("forced installFromCache after --link returned error: %v", err)
+	}
+	if data, err := os.ReadFile(gemPath); err != nil || !bytes.Equal(data, cached) {
+		t.Fatalf("expected the cached gem to survive a --force install after --link (%d bytes, %v)", len(data), err)
+	}
+
 	// Optional Ruby smoke test (skipped if ruby is unavailable)
 	if rubyAvailable && len(marshalData) > 0 {
-		env, err := buildExecutionEnv(vendorDir, []lockfile.GemSpec{spec})
+		env, err := buildExecutionEnv(vendorDir, []lockfile.GemSpec{spec}, execEnvOptions{})
 		if err != nil {
 			t.Fatalf("buildExecutionEnv failed: %v", err)
 		}
@@ -322,3 +1053,653 @@ puts spec.full_name
 		}
 	}
 }
+
+func TestFormatIncompatibleReport(t *testing.T) {
+	jruby := ruby.Engine{Name: ruby.EngineJRuby, Version: "9.4.5.0"}
+	if report := formatIncompatibleReport(jruby, nil); report != "" {
+		t.Errorf("expected no report without skipped gems, got %q", report)
+	}
+
+	report := formatIncompatibleReport(jruby, []incompatibleGem{
+		{Name: "bcrypt-3.1.20", Reason: "has native C extensions but jruby doesn't support them"},
+	})
+	for _, want := range []string{
+		"Skipped 1 gem(s) with C extensions jruby 9.4.5.0 can't build",
+		"  bcrypt-3.1.20: has native C extensions but jruby doesn't support them",
+		"ore lock --add-platform java",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestRecordExtensionResults(t *testing.T) {
+	var report installReport
+	report.recordExtension("nokogiri-1.16.0", extensionBuilt, nil, "")
+	report.recordExtension("pg-1.5.4", extensionFailed, errors.New("exit status 1"), "vendor/extensions/pg-1.5.4/build.log")
+	report.recordExtension("ffi-1.16.3", extensionSkipped, nil, "")
+
+	if report.ExtensionsBuilt != 1 || report.ExtensionsFailed != 1 || report.ExtensionsSkipped != 1 {
+		t.Errorf("expected one of each outcome, got built=%d failed=%d skipped=%d",
+			report.ExtensionsBuilt, report.ExtensionsFailed, report.ExtensionsSkipped)
+	}
+
+	data, err := json.Marshal(report.Extensions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"gem":"pg-1.5.4","status":"failed","error":"exit status 1","log":"vendor/extensions/pg-1.5.4/build.log"}`; !strings.Contains(string(data), want) {
+		t.Errorf("expected JSON to contain %s, got %s", want, data)
+	}
+
+	want := "  pg-1.5.4: exit status 1 (log: vendor/extensions/pg-1.5.4/build.log)\n"
+	if got := formatExtensionFailures(report.Extensions); got != want {
+		t.Errorf("formatExtensionFailures = %q, want %q", got, want)
+	}
+}
+
+func TestInstallFromCacheSharedStore(t *testing.T) {
+	cacheDir := t.TempDir()
+	storeDir := filepath.Join(cacheDir, "store")
+	spec := lockfile.GemSpec{Name: "fake", Version: "0.1.0"}
+
+	metadata := []byte("--- !ruby/object:Gem::Specification\nname: fake\nversion: !ruby/object:Gem::Version\n  version: 0.1.0\nextensions: []\n")
+	payload := map[string][]byte{"lib/fake.rb": []byte("module Fake; end\n")}
+	if err := createFakeGemArchive(filepath.Join(cacheDir, gemFileName(spec)), payload, metadata); err != nil {
+		t.Fatalf("failed to create fake gem archive: %v", err)
+	}
+
+	// Two bundles installing the same gem share one store entry
+	ctx := context.Background()
+	extConfig := &extensions.BuildConfig{SkipExtensions: true}
+	var targets []string
+	for _, vendorDir := range []string{t.TempDir(), t.TempDir()} {
+		report, err := installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, false, false, false, storeDir, "", extConfig)
+		if err != nil {
+			t.Fatalf("installFromCache returned error: %v", err)
+		}
+		if report.Installed != 1 || report.Shared != 1 {
+			t.Fatalf("expected the gem to be installed from the store, got %+v", report)
+		}
+
+		gemDir := filepath.Join(vendorDir, "gems", spec.FullName())
+		target, linked := geminstall.SharedStoreLink(gemDir)
+		if !linked {
+			t.Fatalf("expected %s to link to the shared store", gemDir)
+		}
+		if _, err := os.Stat(filepath.Join(gemDir, "lib", "fake.rb")); err != nil {
+			t.Fatalf("expected lib file through the link: %v", err)
+		}
+		targets = append(targets, target)
+
+		// A store entry removed from under the bundle is reported by ore verify
+		if len(targets) == 2 {
+			if err := os.RemoveAll(target); err != nil {
+				t.Fatal(err)
+			}
+			report := verifyInstalledGems(vendorDir, []lockfile.GemSpec{spec}, nil, nil, ruby.Engine{Name: ruby.EngineMRI})
+			if len(report.Problems) != 1 || report.Problems[0].Problem != "shared store entry missing" {
+				t.Errorf("expected a missing store entry problem, got %+v", report.Problems)
+			}
+		}
+	}
+	if targets[0] != targets[1] {
+		t.Errorf("expected both bundles to share one store entry, got %s and %s", targets[0], targets[1])
+	}
+}
+
+func TestBuildExecutionEnv(t *testing.T) {
+	spec := lockfile.GemSpec{Name: "fake", Version: "0.1.0"}
+	newVendor := func() string {
+		vendorDir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(vendorDir, "gems", spec.FullName(), "lib"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		return vendorDir
+	}
+	lookup := func(env []string, key string) (string, bool) {
+		for _, kv := range env {
+			if value, ok := strings.CutPrefix(kv, key+"="); ok {
+				return value, true
+			}
+		}
+		return "", false
+	}
+
+	t.Setenv("RUBYOPT", "-W0")
+	t.Setenv("BUNDLE_GEMFILE", "/app/Gemfile")
+	t.Setenv("GEM_PATH", "/caller/gems")
+
+	t.Run("isolated vendor dir", func(t *testing.T) {
+		vendorDir := newVendor()
+		t.Setenv("GEM_HOME", "/system/gems")
+
+		env, err := buildExecutionEnv(vendorDir, []lockfile.GemSpec{spec}, execEnvOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value, _ := lookup(env, "GEM_HOME"); value != vendorDir {
+			t.Errorf("GEM_HOME = %q, want %q", value, vendorDir)
+		}
+		if value, _ := lookup(env, "RUBYOPT"); value != "-W0" {
+			t.Errorf("RUBYOPT = %q, want it preserved", value)
+		}
+		if _, ok := lookup(env, "BUNDLE_GEMFILE"); ok {
+			t.Error("expected BUNDLE_GEMFILE to be unset without Bundler")
+		}
+		if value, _ := lookup(env, "RUBYLIB"); !strings.HasPrefix(value, filepath.Join(vendorDir, "gems", spec.FullName(), "lib")) {
+			t.Errorf("RUBYLIB = %q, want the gem's lib first", value)
+		}
+
+		env, err = buildExecutionEnv(vendorDir, []lockfile.GemSpec{spec}, execEnvOptions{Bundler: true, KeepEnv: []string{"GEM_PATH"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value, _ := lookup(env, "BUNDLE_GEMFILE"); value != "/app/Gemfile" {
+			t.Errorf("BUNDLE_GEMFILE = %q, want it kept under Bundler", value)
+		}
+		if value, _ := lookup(env, "GEM_PATH"); value != "/caller/gems" {
+			t.Errorf("GEM_PATH = %q, want the caller's value with --keep-env", value)
+		}
+	})
+
+	t.Run("system gem dir", func(t *testing.T) {
+		vendorDir := newVendor()
+		t.Setenv("GEM_HOME", vendorDir)
+
+		env, err := buildExecutionEnv(vendorDir, []lockfile.GemSpec{spec}, execEnvOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value, _ := lookup(env, "GEM_PATH"); value != "/caller/gems" {
+			t.Errorf("GEM_PATH = %q, want it untouched for system gems", value)
+		}
+		if value, _ := lookup(env, "BUNDLE_GEMFILE"); value != "/app/Gemfile" {
+			t.Errorf("BUNDLE_GEMFILE = %q, want it untouched for system gems", value)
+		}
+	})
+
+	t.Setenv("RUBYOPT", "-rbundler/setup")
+	if !runsUnderBundler("ruby") || !runsUnderBundler("/usr/bin/bundle") {
+		t.Error("expected bundler/setup in RUBYOPT and `bundle` to run under Bundler")
+	}
+}
+
+func TestFetchThenPristine(t *testing.T) {
+	spec := lockfile.GemSpec{Name: "fake", Version: "0.1.0"}
+
+	// Serve a fake gem the way rubygems.org serves /downloads/<name>.gem
+	gemPath := filepath.Join(t.TempDir(), gemFileName(spec))
+	payload := map[string][]byte{
+		"lib/fake.rb": []byte("module Fake; end\n"),
+	}
+	if err := createFakeGemArchive(gemPath, payload, nil); err != nil {
+		t.Fatalf("failed to create fake gem archive: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/downloads/"+gemFileName(spec) {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, gemPath)
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	vendorDir := filepath.Join(t.TempDir(), "vendor")
+
+	dm, err := newDownloadManager(cacheDir, []SourceConfig{{URL: server.URL}}, server.Client(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating download manager: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := dm.DownloadAll(ctx, []lockfile.GemSpec{spec}, true); err != nil {
+		t.Fatalf("DownloadAll returned error: %v", err)
+	}
+
+	extConfig := &extensions.BuildConfig{SkipExtensions: true}
+	if _, err := installFromCache(ctx, cacheDir, vendorDir, []lockfile.GemSpec{spec}, false, false, false, "", "", extConfig); err != nil {
+		t.Fatalf("installFromCache returned error: %v", err)
+	}
+
+	// Tamper with the installed gem, then restore it
+	libFile := filepath.Join(vendorDir, "gems", spec.FullName(), "lib", "fake.rb")
+	if err := os.WriteFile(libFile, []byte("broken"), 0o644); err != nil {
+		t.Fatalf("failed to modify installed gem: %v", err)
+	}
+
+	lockfilePath := filepath.Join(t.TempDir(), "Gemfile.lock")
+	lockContent := "GEM\n  remote: " + server.URL + "/\n  specs:\n    fake (0.1.0)\n\nPLATFORMS\n  ruby\n\nDEPENDENCIES\n  fake\n"
+	if err := os.WriteFile(lockfilePath, []byte(lockContent), 0o644); err != nil {
+		t.Fatalf("failed to write lockfile: %v", err)
+	}
+
+	err = commands.Pristine([]string{"fake"}, lockfilePath, commands.PristineOptions{
+		CacheDirs: []string{cacheDir},
+		VendorDir: vendorDir,
+	})
+	if err != nil {
+		t.Fatalf("Pristine returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(libFile)
+	if err != nil {
+		t.Fatalf("expected restored lib file: %v", err)
+	}
+	if string(data) != "module Fake; end\n" {
+		t.Fatalf("expected pristine contents, got %q", data)
+	}
+}
+
+func TestSelectLockedGems(t *testing.T) {
+	parsed := &lockfile.Lockfile{
+		GemSpecs: []lockfile.GemSpec{
+			{Name: "rails", Version: "7.0.0", Dependencies: []lockfile.Dependency{{Name: "rack"}}},
+			{Name: "rack", Version: "3.0.0"},
+			{Name: "nokogiri", Version: "1.16.0", Dependencies: []lockfile.Dependency{{Name: "mini_portile2"}}},
+			{Name: "mini_portile2", Version: "2.8.5"},
+		},
+	}
+
+	selected, err := selectLockedGems(parsed, []string{"nokogiri"})
+	if err != nil {
+		t.Fatalf("selectLockedGems returned error: %v", err)
+	}
+	var names []string
+	for _, spec := range selected.GemSpecs {
+		names = append(names, spec.Name)
+	}
+	if got := strings.Join(names, ","); got != "nokogiri,mini_portile2" {
+		t.Fatalf("expected nokogiri and its dependency, got %s", got)
+	}
+
+	if _, err := selectLockedGems(parsed, []string{"missing"}); err == nil {
+		t.Fatal("expected error for gem not in lockfile")
+	}
+
+	if !hasFlagArg([]string{"--only=rack"}, "only") || hasFlagArg([]string{"rack"}, "only") {
+		t.Fatal("hasFlagArg did not detect --only correctly")
+	}
+
+	// --dry-run=false doesn't plan the lockfile
+	t.Chdir(t.TempDir())
+	if err := runFetchCommand([]string{"--dry-run=false"}); err == nil || strings.Contains(err.Error(), "lockfile") || strings.Contains(err.Error(), "--only") {
+		t.Errorf("expected --dry-run=false to leave lockfile mode, got %v", err)
+	}
+	if err := runFetchCommand([]string{"--dry-run", "rack"}); err == nil || !strings.Contains(err.Error(), "use --only rack") {
+		t.Errorf("expected gem names with --dry-run to be rejected, got %v", err)
+	}
+}
+
+func TestOptionalGroupsAndWith(t *testing.T) {
+	gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+	content := `source "https://rubygems.org"
+
+gem "rails"
+
+group :docs, optional: true do
+  gem "yard"
+end
+
+group :ci, "lint", :optional => true do
+  gem "rubocop"
+end
+
+group :test do
+  gem "rspec"
+end
+`
+	if err := os.WriteFile(gemfilePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	optional, err := optionalGemfileGroups(gemfilePath)
+	if err != nil {
+		t.Fatalf("optionalGemfileGroups returned error: %v", err)
+	}
+	if strings.Join(optional, ",") != "docs,ci,lint" {
+		t.Fatalf("expected optional groups docs,ci,lint, got %v", optional)
+	}
+
+	gems := []lockfile.GemSpec{
+		{Name: "rails", Groups: []string{"default"}},
+		{Name: "yard", Groups: []string{"docs"}, Dependencies: []lockfile.Dependency{{Name: "webrick"}}},
+		{Name: "webrick"},
+		{Name: "rspec", Groups: []string{"test", "docs"}},
+	}
+	names := func(specs []lockfile.GemSpec) string {
+		var result []string
+		for _, spec := range specs {
+			result = append(result, spec.Name)
+		}
+		return strings.Join(result, ",")
+	}
+
+	if got := names(filterGemsByGroupsAndDependencies(gems, gems, []string{"docs"}, nil)); got != "rails" {
+		t.Errorf("expected optional docs group skipped, got %s", got)
+	}
+	if got := names(filterGemsByGroupsAndDependencies(gems, gems, []string{"test"}, []string{"docs"})); got != "rails,yard,webrick,rspec" {
+		t.Errorf("expected --with docs to include yard (and its deps) and rspec, got %s", got)
+	}
+}
+
+func TestAuditGroupScope(t *testing.T) {
+	gems := []lockfile.GemSpec{
+		{Name: "rails", Groups: []string{"default"}, Dependencies: []lockfile.Dependency{{Name: "rack"}}},
+		{Name: "rack"},
+		{Name: "rspec", Groups: []string{"test"}, Dependencies: []lockfile.Dependency{{Name: "diff-lcs"}}},
+		{Name: "diff-lcs"},
+		{Name: "pry", Groups: []string{"development", "test"}},
+	}
+	audited := func(without, only []string) string {
+		excludeGroups, includeGroups := auditGroupScope(gems, without, only)
+		var result []string
+		for _, spec := range filterGemsByGroupsAndDependencies(gems, gems, excludeGroups, includeGroups) {
+			result = append(result, spec.Name)
+		}
+		return strings.Join(result, ",")
+	}
+
+	if got := audited([]string{"development", "test"}, nil); got != "rails,rack" {
+		t.Errorf("expected --without development,test to audit rails,rack, got %s", got)
+	}
+	if got := audited(nil, []string{"test"}); got != "rspec,diff-lcs,pry" {
+		t.Errorf("expected --only test to audit rspec,diff-lcs,pry, got %s", got)
+	}
+}
+
+func TestNewInstallPlan(t *testing.T) {
+	cacheDir := t.TempDir()
+	dm := &downloadManager{cacheDir: cacheDir}
+
+	cached := lockfile.GemSpec{Name: "ore-plan-cached", Version: "1.0.0"}
+	missing := lockfile.GemSpec{Name: "ore-plan-missing", Version: "2.0.0"}
+	native := lockfile.GemSpec{Name: "ore-plan-native", Version: "1.0.0", Platform: "x86_64-mingw-ucrt"}
+	devOnly := lockfile.GemSpec{Name: "ore-plan-dev", Version: "0.1.0"}
+	if err := os.WriteFile(filepath.Join(cacheDir, gemFileName(cached)), []byte("gem"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	all := []lockfile.GemSpec{cached, missing, native, devOnly}
+	grouped := []lockfile.GemSpec{cached, missing, native}
+	platformed := []lockfile.GemSpec{cached, missing}
+
+	plan := newInstallPlan(dm, all, grouped, platformed, false)
+	if strings.Join(plan.Cached, ",") != "ore-plan-cached-1.0.0" ||
+		strings.Join(plan.Download, ",") != "ore-plan-missing-2.0.0" ||
+		strings.Join(plan.SkippedByPlatform, ",") != "ore-plan-native-1.0.0-x86_64-mingw-ucrt" ||
+		strings.Join(plan.SkippedByGroup, ",") != "ore-plan-dev-0.1.0" {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+
+	if forced := newInstallPlan(dm, all, grouped, platformed, true); len(forced.Cached) != 0 || len(forced.Download) != 2 {
+		t.Errorf("expected --force to plan every gem for download, got %+v", forced)
+	}
+}
+
+func TestVerifyInstalledGems(t *testing.T) {
+	vendorDir := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(vendorDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// rake: complete. rackup: binstub missing. nio4r: extension never built. json: no gemspec.
+	write("gems/rake-13.0.0/exe/rake", "")
+	write("bin/rake", "")
+	write("specifications/rake-13.0.0.gemspec", "")
+	write("gems/rackup-2.1.0/exe/rackup", "")
+	write("specifications/rackup-2.1.0.gemspec", "")
+	write("gems/nio4r-2.7.0/ext/nio4r/extconf.rb", "")
+	write("specifications/nio4r-2.7.0.gemspec", "")
+	write("gems/json-2.7.0/lib/json.rb", "")
+
+	gems := []lockfile.GemSpec{
+		{Name: "rake", Version: "13.0.0"},
+		{Name: "rackup", Version: "2.1.0"},
+		{Name: "nio4r", Version: "2.7.0"},
+		{Name: "json", Version: "2.7.0"},
+		{Name: "missing", Version: "1.0.0"},
+	}
+	report := verifyInstalledGems(vendorDir, gems, nil, nil, ruby.Engine{Name: ruby.EngineMRI})
+
+	var problems []string
+	for _, p := range report.Problems {
+		problems = append(problems, p.Gem+": "+p.Problem)
+	}
+	want := []string{
+		"rackup-2.1.0: executable rackup not linked",
+		"nio4r-2.7.0: native extension not built (or built for another Ruby)",
+		"json-2.7.0: gemspec missing",
+		"missing-1.0.0: not installed",
+	}
+	if report.OK || report.Checked != 5 || strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected report (checked %d):\n%s", report.Checked, strings.Join(problems, "\n"))
+	}
+}
+
+func TestDefaultWorkersFromBundleJobs(t *testing.T) {
+	projectDir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BUNDLE_JOBS", "")
+	t.Chdir(projectDir)
+
+	if got := defaultWorkers(); got != runtime.NumCPU() {
+		t.Errorf("expected CPU count without a jobs setting, got %d", got)
+	}
+
+	if err := os.MkdirAll(".bundle", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(".bundle", "config"), []byte("---\nBUNDLE_JOBS: \"3\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := defaultWorkers(); got != 3 {
+		t.Errorf("expected .bundle/config jobs 3, got %d", got)
+	}
+
+	t.Setenv("BUNDLE_JOBS", "5")
+	if got := defaultWorkers(); got != 5 {
+		t.Errorf("expected BUNDLE_JOBS=5 to override .bundle/config, got %d", got)
+	}
+
+	t.Setenv("BUNDLE_JOBS", "lots")
+	if got := defaultWorkers(); got != runtime.NumCPU() {
+		t.Errorf("expected invalid BUNDLE_JOBS to fall back to CPU count, got %d", got)
+	}
+
+	// An explicit --workers still wins over the configured default
+	t.Setenv("BUNDLE_JOBS", "5")
+	fs := flag.NewFlagSet("install", flag.ContinueOnError)
+	workers := fs.Int("workers", defaultWorkers(), "")
+	if err := fs.Parse([]string{"--workers", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if *workers != 2 {
+		t.Errorf("expected --workers 2 to win, got %d", *workers)
+	}
+}
+
+func TestInstallLockedBundler(t *testing.T) {
+	spec := lockfile.GemSpec{Name: "bundler", Version: "2.7.2"}
+
+	gemPath := filepath.Join(t.TempDir(), gemFileName(spec))
+	payload := map[string][]byte{
+		"lib/bundler.rb": []byte("module Bundler; end\n"),
+		"exe/bundle":     []byte("#!/usr/bin/env ruby\nputs 'bundle'\n"),
+	}
+	if err := createFakeGemArchive(gemPath, payload, nil); err != nil {
+		t.Fatalf("failed to create fake gem archive: %v", err)
+	}
+	var downloads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/downloads/"+gemFileName(spec) {
+			http.NotFound(w, r)
+			return
+		}
+		downloads++
+		http.ServeFile(w, r, gemPath)
+	}))
+	defer server.Close()
+
+	dm, err := newDownloadManager(t.TempDir(), []SourceConfig{{URL: server.URL}}, server.Client(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error creating download manager: %v", err)
+	}
+	dm.quiet = true
+	vendorDir := filepath.Join(t.TempDir(), "vendor")
+	ctx := context.Background()
+	extConfig := &extensions.BuildConfig{SkipExtensions: true}
+
+	if _, err := installLockedBundler(ctx, dm, "", vendorDir, false, extConfig); err == nil {
+		t.Error("expected an error without BUNDLED WITH")
+	}
+
+	report, err := installLockedBundler(ctx, dm, "   2.7.2\n", vendorDir, false, extConfig)
+	if err != nil {
+		t.Fatalf("installLockedBundler returned error: %v", err)
+	}
+	if report.Installed != 1 {
+		t.Fatalf("expected bundler to be installed, got %+v", report)
+	}
+	if _, err := os.Stat(filepath.Join(vendorDir, "gems", "bundler-2.7.2", "lib", "bundler.rb")); err != nil {
+		t.Errorf("expected bundler to be extracted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(vendorDir, "bin", "bundle")); err != nil {
+		t.Errorf("expected a bundle binstub: %v", err)
+	}
+
+	// Already installed at the locked version: nothing to do
+	report, err = installLockedBundler(ctx, dm, "2.7.2", vendorDir, false, extConfig)
+	if err != nil {
+		t.Fatalf("second installLockedBundler returned error: %v", err)
+	}
+	if report.Installed != 0 || report.Skipped != 1 || downloads != 1 {
+		t.Errorf("expected second run to skip without downloading, got %+v (%d downloads)", report, downloads)
+	}
+}
+
+func TestApplyForceRubyPlatform(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BUNDLE_FORCE_RUBY_PLATFORM", "")
+
+	gemfilePath := "Gemfile"
+	gemfile := "source \"https://rubygems.org\"\n\ngem \"nokogiri\", \"~> 1.16\", force_ruby_platform: true\ngem \"ffi\"\n"
+	if err := os.WriteFile(gemfilePath, []byte(gemfile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gems := []lockfile.GemSpec{
+		{Name: "nokogiri", Version: "1.16.0", Platform: "x86_64-linux"},
+		{Name: "nokogiri", Version: "1.16.0", Platform: "arm64-darwin"},
+		{Name: "ffi", Version: "1.17.0", Platform: "x86_64-linux"},
+		{Name: "rack", Version: "3.0.0"},
+	}
+
+	names := func(specs []lockfile.GemSpec) string {
+		var out []string
+		for _, spec := range specs {
+			out = append(out, spec.FullName())
+		}
+		return strings.Join(out, ",")
+	}
+
+	if got := names(applyForceRubyPlatform(gems, gemfilePath)); got != "nokogiri-1.16.0,ffi-1.17.0-x86_64-linux,rack-3.0.0" {
+		t.Errorf("per-gem force_ruby_platform: got %s", got)
+	}
+
+	t.Setenv("BUNDLE_FORCE_RUBY_PLATFORM", "true")
+	if got := names(applyForceRubyPlatform(gems, gemfilePath)); got != "nokogiri-1.16.0,ffi-1.17.0,rack-3.0.0" {
+		t.Errorf("global force_ruby_platform: got %s", got)
+	}
+}
+
+func TestApplyForceRubyPlatformUsesRubyChecksum(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BUNDLE_FORCE_RUBY_PLATFORM", "")
+
+	gemfile := "source \"https://rubygems.org\"\n\ngem \"nokogiri\", force_ruby_platform: true\ngem \"ffi\", force_ruby_platform: true\n"
+	if err := os.WriteFile("Gemfile", []byte(gemfile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lockContent := `GEM
+  remote: https://rubygems.org/
+  specs:
+    ffi (1.17.0-x86_64-linux)
+    nokogiri (1.16.0)
+    nokogiri (1.16.0-x86_64-linux)
+
+PLATFORMS
+  ruby
+  x86_64-linux
+
+DEPENDENCIES
+  ffi
+  nokogiri
+
+CHECKSUMS
+  ffi (1.17.0-x86_64-linux) sha256=` + strings.Repeat("c", 64) + `
+  nokogiri (1.16.0) sha256=` + strings.Repeat("a", 64) + `
+  nokogiri (1.16.0-x86_64-linux) sha256=` + strings.Repeat("b", 64) + `
+`
+	if err := os.WriteFile("Gemfile.lock", []byte(lockContent), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := loadLockfile("Gemfile.lock")
+	if err != nil {
+		t.Fatalf("loadLockfile returned error: %v", err)
+	}
+
+	checksums := make(map[string]string)
+	for _, gem := range applyForceRubyPlatform(parsed.GemSpecs, "Gemfile") {
+		checksums[gem.FullName()] = gem.Checksum
+	}
+	if got := checksums["nokogiri-1.16.0"]; got != strings.Repeat("a", 64) {
+		t.Errorf("expected nokogiri to be checked against the ruby variant's checksum, got %q", got)
+	}
+	// Without a locked ruby variant there's nothing to check the ruby gem against
+	if got, ok := checksums["ffi-1.17.0"]; !ok || got != "" {
+		t.Errorf("expected ffi's platform checksum to be dropped, got %q (%v)", got, ok)
+	}
+}
+
+func TestBuildTreeJSONMarksSharedAndCircular(t *testing.T) {
+	dep := func(name string) lockfile.Dependency { return lockfile.Dependency{Name: name} }
+	specs := []lockfile.GemSpec{
+		{Name: "rails", Version: "7.1.0", Groups: []string{"default"}, Dependencies: []lockfile.Dependency{dep("actionpack"), dep("activesupport")}},
+		{Name: "actionpack", Version: "7.1.0", Dependencies: []lockfile.Dependency{dep("activesupport")}},
+		{Name: "activesupport", Version: "7.1.0", Dependencies: []lockfile.Dependency{dep("actionpack")}},
+		{Name: "nokogiri", Version: "1.16.0", Platform: "x86_64-linux", Groups: []string{"test"}},
+	}
+
+	doc := buildTreeJSON(specs)
+	if doc.Total != 4 || len(doc.Roots) != 2 {
+		t.Fatalf("expected 2 roots of 4 gems, got %+v", doc)
+	}
+	if nokogiri := doc.Roots[0]; nokogiri.Name != "nokogiri" || nokogiri.Platform != "x86_64-linux" || nokogiri.Groups[0] != "test" {
+		t.Errorf("unexpected nokogiri node: %+v", nokogiri)
+	}
+
+	rails := doc.Roots[1]
+	if len(rails.Children) != 2 {
+		t.Fatalf("expected rails to have 2 children, got %+v", rails.Children)
+	}
+	actionpack, activesupport := rails.Children[0], rails.Children[1]
+	// actionpack -> activesupport -> actionpack loops back to an ancestor
+	if len(actionpack.Children) != 1 || len(actionpack.Children[0].Children) != 1 || !actionpack.Children[0].Children[0].Circular {
+		t.Errorf("expected actionpack's cycle to be marked circular, got %+v", actionpack)
+	}
+	// activesupport was already expanded under actionpack
+	if !activesupport.Shared || len(activesupport.Children) != 0 {
+		t.Errorf("expected activesupport to be marked shared, got %+v", activesupport)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/cmd/ore/commands"
+)
+
+// explainMissingGem says why ore install leaves a gem out, checking the same filters in
+// the same order: the lockfile's dependency graph, the Gemfile's install_if and
+// platforms: exclusions, the excluded groups, then the current platform.
+// It backs `ore why --missing <gem>`.
+func explainMissingGem(lockfilePath, gemfilePath, name string, excludeGroups, includeGroups []string, currentPlatform string) (string, error) {
+	parsed, err := loadLockfile(lockfilePath)
+	if err != nil {
+		return "", err
+	}
+	lockName := filepath.Base(lockfilePath)
+
+	// Not in the dependency graph at all
+	if !lockedGemNames(parsed)[name] {
+		if gemfileDeclares(gemfilePath, name) {
+			return fmt.Sprintf("%s is in %s but not in %s; run `ore lock` to resolve it", name, filepath.Base(gemfilePath), lockName), nil
+		}
+		return fmt.Sprintf("%s is not a dependency: neither %s nor any gem in %s depends on it (add it with `ore add %s`)",
+			name, filepath.Base(gemfilePath), lockName, name), nil
+	}
+
+	// Left out by the Gemfile for this machine
+	scoped, err := applyGemfileExclusions(parsed, gemfilePath)
+	if err != nil {
+		return "", err
+	}
+	if !lockedGemNames(scoped)[name] {
+		return fmt.Sprintf("%s is left out by %s: it, or every gem that needs it, has an install_if condition that's false here or platforms: that exclude this one",
+			name, filepath.Base(gemfilePath)), nil
+	}
+
+	// Only needed by excluded groups
+	gems := deduplicateGemSpecs(scoped.GemSpecs)
+	if len(excludeGroups) > 0 && commands.EnrichGemsWithGroups(gemfilePath, scoped) == nil {
+		gems = filterGemsByGroupsAndDependencies(deduplicateGemSpecs(scoped.GemSpecs), scoped.GemSpecs, excludeGroups, includeGroups)
+		if reason := excludedGroupsReason(scoped, gems, name, excludeGroups, includeGroups); reason != "" {
+			return reason, nil
+		}
+	}
+
+	// Only locked for other platforms
+	var platforms []string
+	for _, spec := range applyForceRubyPlatform(gems, gemfilePath) {
+		if spec.Name != name {
+			continue
+		}
+		if spec.Platform == "" || platformMatches(spec.Platform, currentPlatform) {
+			platforms = nil
+			break
+		}
+		platforms = append(platforms, spec.Platform)
+	}
+	if len(platforms) > 0 {
+		sort.Strings(platforms)
+		return fmt.Sprintf("%s is only locked for %s, not this platform (%s); run `ore lock --add-platform %s` to lock a variant for it",
+			name, strings.Join(platforms, ", "), currentPlatform, currentPlatform), nil
+	}
+
+	return fmt.Sprintf("%s isn't filtered out: ore install installs it for this platform and groups. Run `ore install`, or `ore check` to list what isn't installed yet", name), nil
+}
+
+// excludedGroupsReason explains why the group filter dropped name, or returns "" when
+// it kept it. kept is what filterGemsByGroupsAndDependencies returned.
+func excludedGroupsReason(parsed *lockfile.Lockfile, kept []lockfile.GemSpec, name string, excludeGroups, includeGroups []string) string {
+	for _, spec := range kept {
+		if spec.Name == name {
+			return ""
+		}
+	}
+	for _, spec := range parsed.GitSpecs {
+		if spec.Name == name && (len(spec.Groups) == 0 || !isGroupExcluded(spec.Groups, excludeGroups, includeGroups)) {
+			return ""
+		}
+	}
+	for _, spec := range parsed.PathSpecs {
+		if spec.Name == name && (len(spec.Groups) == 0 || !isGroupExcluded(spec.Groups, excludeGroups, includeGroups)) {
+			return ""
+		}
+	}
+
+	excluded := strings.Join(excludeGroups, ", ")
+	hint := fmt.Sprintf("install them with `ore install --with %s`", strings.Join(excludeGroups, ","))
+	if groups := declaredGroups(parsed, name); len(groups) > 0 {
+		return fmt.Sprintf("%s is in the %s group(s), which are excluded (%s); %s", name, strings.Join(groups, ", "), excluded, hint)
+	}
+
+	// A transitive dependency: name the Gemfile gems in excluded groups that need it
+	deps := make(map[string][]lockfile.Dependency)
+	for _, spec := range parsed.GemSpecs {
+		deps[spec.Name] = append(deps[spec.Name], spec.Dependencies...)
+	}
+	var needers []string
+	seen := make(map[string]bool)
+	for _, spec := range parsed.GemSpecs {
+		if seen[spec.Name] || len(spec.Groups) == 0 || !isGroupExcluded(spec.Groups, excludeGroups, includeGroups) || !dependsOn(deps, spec.Name, name) {
+			continue
+		}
+		seen[spec.Name] = true
+		needers = append(needers, fmt.Sprintf("%s (%s)", spec.Name, strings.Join(spec.Groups, ", ")))
+	}
+	sort.Strings(needers)
+	return fmt.Sprintf("%s is only needed by gems in excluded groups (%s): %s; %s", name, excluded, strings.Join(needers, ", "), hint)
+}
+
+// declaredGroups returns the Gemfile groups of name when the Gemfile declares it directly
+func declaredGroups(parsed *lockfile.Lockfile, name string) []string {
+	for _, spec := range parsed.GemSpecs {
+		if spec.Name == name && len(spec.Groups) > 0 {
+			return spec.Groups
+		}
+	}
+	for _, spec := range parsed.GitSpecs {
+		if spec.Name == name && len(spec.Groups) > 0 {
+			return spec.Groups
+		}
+	}
+	for _, spec := range parsed.PathSpecs {
+		if spec.Name == name && len(spec.Groups) > 0 {
+			return spec.Groups
+		}
+	}
+	return nil
+}
+
+// dependsOn reports whether from needs target, directly or transitively
+func dependsOn(deps map[string][]lockfile.Dependency, from, target string) bool {
+	seen := make(map[string]bool)
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		if seen[name] {
+			return false
+		}
+		seen[name] = true
+		for _, dep := range deps[name] {
+			if dep.Name == target || visit(dep.Name) {
+				return true
+			}
+		}
+		return false
+	}
+	return visit(from)
+}
+
+// lockedGemNames returns the names of every gem the lockfile has, whatever its source
+func lockedGemNames(parsed *lockfile.Lockfile) map[string]bool {
+	names := make(map[string]bool)
+	for _, spec := range parsed.GemSpecs {
+		names[spec.Name] = true
+	}
+	for _, spec := range parsed.GitSpecs {
+		names[spec.Name] = true
+	}
+	for _, spec := range parsed.PathSpecs {
+		names[spec.Name] = true
+	}
+	return names
+}
+
+// gemfileDeclares reports whether the Gemfile has a gem entry for name
+func gemfileDeclares(gemfilePath, name string) bool {
+	parsedGemfile, err := gemfile.NewGemfileParser(gemfilePath).Parse()
+	if err != nil {
+		return false
+	}
+	for _, dep := range parsedGemfile.Dependencies {
+		if dep.Name == name {
+			return true
+		}
+	}
+	return false
+}
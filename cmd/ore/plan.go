@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/contriboss/gemfile-go/lockfile"
+)
+
+// installPlan is what `--dry-run` reports: where each locked gem would come from.
+// Building it reads the lockfile and caches only, never the network or the vendor dir.
+type installPlan struct {
+	Download          []string `json:"download"`
+	Cached            []string `json:"cached"`
+	SkippedByPlatform []string `json:"skipped_by_platform"`
+	SkippedByGroup    []string `json:"skipped_by_group"`
+	Git               []string `json:"git"`
+	Path              []string `json:"path"`
+}
+
+// newInstallPlan sorts gems into the plan's categories. all is every locked gem,
+// grouped what's left after group filtering and platformed what's left after platform
+// filtering; the rest fall out of the difference. With force every gem is re-downloaded.
+func newInstallPlan(dm *downloadManager, all, grouped, platformed []lockfile.GemSpec, force bool) *installPlan {
+	plan := &installPlan{
+		Download:          []string{},
+		Cached:            []string{},
+		SkippedByPlatform: []string{},
+		SkippedByGroup:    []string{},
+		Git:               []string{},
+		Path:              []string{},
+	}
+
+	kept := make(map[string]bool)
+	for _, gem := range grouped {
+		kept[gem.Name] = true
+	}
+	for _, gem := range all {
+		if !kept[gem.Name] {
+			plan.SkippedByGroup = append(plan.SkippedByGroup, gem.FullName())
+		}
+	}
+
+	installable := make(map[string]bool)
+	for _, gem := range platformed {
+		installable[gem.FullName()] = true
+	}
+	for _, gem := range grouped {
+		if !installable[gem.FullName()] {
+			plan.SkippedByPlatform = append(plan.SkippedByPlatform, gem.FullName())
+		}
+	}
+
+	for _, gem := range platformed {
+		if !force && dm.findInCaches(gem) != "" {
+			plan.Cached = append(plan.Cached, gem.FullName())
+		} else {
+			plan.Download = append(plan.Download, gem.FullName())
+		}
+	}
+
+	return plan
+}
+
+// addSourceGems records the git and path gems that would be installed, and the ones
+// group filtering leaves out
+func (p *installPlan) addSourceGems(allGit, git []lockfile.GitGemSpec, allPath, path []lockfile.PathGemSpec) {
+	kept := make(map[string]bool)
+	for _, spec := range git {
+		kept[spec.Name] = true
+		p.Git = append(p.Git, fmt.Sprintf("%s-%s", spec.Name, spec.Version))
+	}
+	for _, spec := range path {
+		kept[spec.Name] = true
+		p.Path = append(p.Path, fmt.Sprintf("%s-%s", spec.Name, spec.Version))
+	}
+	for _, spec := range allGit {
+		if !kept[spec.Name] {
+			p.SkippedByGroup = append(p.SkippedByGroup, fmt.Sprintf("%s-%s", spec.Name, spec.Version))
+		}
+	}
+	for _, spec := range allPath {
+		if !kept[spec.Name] {
+			p.SkippedByGroup = append(p.SkippedByGroup, fmt.Sprintf("%s-%s", spec.Name, spec.Version))
+		}
+	}
+}
+
+// printInstallPlan writes the plan as JSON or as a categorized list
+func printInstallPlan(plan *installPlan, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plan)
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true)
+	fmt.Println(headerStyle.Render("Dry run: nothing was downloaded or installed."))
+
+	sections := []struct {
+		title string
+		gems  []string
+	}{
+		{"To download", plan.Download},
+		{"Cached", plan.Cached},
+		{"Git checkouts", plan.Git},
+		{"Path gems", plan.Path},
+		{"Skipped (platform)", plan.SkippedByPlatform},
+		{"Skipped (group)", plan.SkippedByGroup},
+	}
+	for _, section := range sections {
+		if len(section.gems) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s (%d):\n", headerStyle.Render(section.title), len(section.gems))
+		for _, name := range section.gems {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
@@ -279,6 +281,71 @@ func printDependencyTreePlain(specs []lockfile.GemSpec) {
 	fmt.Printf("\nTotal: %d gems\n", len(nodeMap))
 }
 
+// treeJSON is the `ore tree --format json` document
+type treeJSON struct {
+	Roots []treeJSONNode `json:"roots"`
+	Total int            `json:"total"` // Unique gems in the lockfile
+}
+
+// treeJSONNode is a gem in the JSON tree. A gem already expanded earlier under the
+// same root is listed again without children and marked shared, or circular when it
+// depends back on one of its own ancestors.
+type treeJSONNode struct {
+	Name     string         `json:"name"`
+	Version  string         `json:"version"`
+	Platform string         `json:"platform,omitempty"`
+	Groups   []string       `json:"groups,omitempty"`
+	Children []treeJSONNode `json:"children,omitempty"`
+	Shared   bool           `json:"shared,omitempty"`
+	Circular bool           `json:"circular,omitempty"`
+}
+
+// buildTreeJSON builds the JSON tree from the same forest the text renderers print
+func buildTreeJSON(specs []lockfile.GemSpec) treeJSON {
+	nodeMap := buildDependencyTree(specs)
+	doc := treeJSON{Roots: []treeJSONNode{}, Total: len(nodeMap)}
+
+	for _, root := range findRootGems(specs) {
+		if node, exists := nodeMap[root.Name]; exists {
+			doc.Roots = append(doc.Roots, treeNodeJSON(node, make(map[string]bool), make(map[string]bool)))
+		}
+	}
+	return doc
+}
+
+// treeNodeJSON converts node and its subtree; ancestors holds the gems on the current path
+func treeNodeJSON(node *TreeNode, visited, ancestors map[string]bool) treeJSONNode {
+	out := treeJSONNode{
+		Name:     node.Gem.Name,
+		Version:  node.Gem.Version,
+		Platform: node.Gem.Platform,
+		Groups:   node.Gem.Groups,
+	}
+	if ancestors[node.Gem.Name] {
+		out.Circular = true
+		return out
+	}
+	if visited[node.Gem.Name] {
+		out.Shared = true
+		return out
+	}
+
+	visited[node.Gem.Name] = true
+	ancestors[node.Gem.Name] = true
+	for _, child := range node.Children {
+		out.Children = append(out.Children, treeNodeJSON(child, visited, ancestors))
+	}
+	delete(ancestors, node.Gem.Name)
+	return out
+}
+
+// printDependencyTreeJSON writes the dependency tree to w as JSON
+func printDependencyTreeJSON(w io.Writer, specs []lockfile.GemSpec) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(buildTreeJSON(specs))
+}
+
 // isTTY checks if stdout is a terminal
 func isTTY() bool {
 	fileInfo, _ := os.Stdout.Stat()
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/cmd/ore/commands"
+	"github.com/contriboss/ore-light/internal/extensions"
+	"github.com/contriboss/ore-light/internal/geminstall"
+	"github.com/contriboss/ore-light/internal/resolver"
+	"github.com/contriboss/ore-light/internal/ruby"
+)
+
+// verifyProblem is one thing wrong with an installed gem
+type verifyProblem struct {
+	Gem     string `json:"gem"`
+	Problem string `json:"problem"`
+	Path    string `json:"path,omitempty"`
+}
+
+// verifyReport is the result of ore verify, also used for --json output
+type verifyReport struct {
+	OK       bool            `json:"ok"`
+	Checked  int             `json:"checked"`
+	Problems []verifyProblem `json:"problems"`
+}
+
+// runVerifyCommand implements ore verify: a post-install check that every gem ore
+// install would install is present and complete in the vendor directory
+func runVerifyCommand(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	lockfilePath := fs.String("lockfile", defaultLockfilePath(), "Path to Gemfile.lock")
+	vendorDir := fs.String("vendor", defaultVendorDir(), "Vendor directory to verify")
+	without := fs.String("without", "", "Comma-separated list of groups that were not installed")
+	with := fs.String("with", "", "Comma-separated list of optional groups that were installed")
+	jsonOutput := fs.Bool("json", false, "Output results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	parsed, err := loadLockfile(*lockfilePath)
+	if err != nil {
+		return err
+	}
+
+	// Verify what ore install would have installed: same group and platform filtering
+	gemfilePath := detectGemfileFromLock(*lockfilePath)
+	if gemfilePath == "" {
+		gemfilePath = "Gemfile"
+	}
+	if parsed, err = applyGemfileExclusions(parsed, gemfilePath); err != nil {
+		return err
+	}
+	gems := deduplicateGemSpecs(parsed.GemSpecs)
+	gitSpecs, pathSpecs := parsed.GitSpecs, parsed.PathSpecs
+	excludeGroups, includeGroups := installGroups(gemfilePath, *with, *without)
+	if len(excludeGroups) > 0 && commands.EnrichGemsWithGroups(gemfilePath, parsed) == nil {
+		gems = filterGemsByGroupsAndDependencies(gems, parsed.GemSpecs, excludeGroups, includeGroups)
+		gitSpecs = filterGitGemsByGroups(parsed.GitSpecs, excludeGroups, includeGroups)
+		pathSpecs = filterPathGemsByGroups(parsed.PathSpecs, excludeGroups, includeGroups)
+	}
+	gems = filterGemsByPlatform(applyForceRubyPlatform(gems, gemfilePath))
+
+	report := verifyInstalledGems(*vendorDir, gems, gitSpecs, pathSpecs, ruby.DetectEngine())
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printVerifyReport(report)
+	}
+
+	if !report.OK {
+		return fmt.Errorf("%d problem(s) found in %s", len(report.Problems), *vendorDir)
+	}
+	return nil
+}
+
+// verifyInstalledGems checks each gem's directory, gemspec, binstubs and native
+// extensions. Git and path gems have no gemspec in specifications/ to check.
+func verifyInstalledGems(vendorDir string, gems []lockfile.GemSpec, gitSpecs []lockfile.GitGemSpec, pathSpecs []lockfile.PathGemSpec, engine ruby.Engine) *verifyReport {
+	report := &verifyReport{Problems: []verifyProblem{}}
+	problem := func(gem, message, path string) {
+		report.Problems = append(report.Problems, verifyProblem{Gem: gem, Problem: message, Path: path})
+	}
+
+	// checkGemDir checks the parts every installed gem has; false if it isn't installed
+	checkGemDir := func(fullName string) bool {
+		report.Checked++
+		gemDir := filepath.Join(vendorDir, "gems", fullName)
+		if _, err := os.Stat(gemDir); err != nil {
+			if target, linked := geminstall.SharedStoreLink(gemDir); linked {
+				problem(fullName, "shared store entry missing", target)
+			} else {
+				problem(fullName, "not installed", gemDir)
+			}
+			return false
+		}
+
+		if _, names, err := geminstall.GemExecutables(gemDir); err != nil {
+			problem(fullName, fmt.Sprintf("could not read executables: %v", err), gemDir)
+		} else {
+			for _, name := range names {
+				binstub := filepath.Join(vendorDir, "bin", name)
+				if _, err := os.Stat(binstub); err != nil {
+					problem(fullName, fmt.Sprintf("executable %s not linked", name), binstub)
+				}
+			}
+		}
+
+		if needsBuild, err := extensions.NeedsBuild(gemDir, engine); err != nil {
+			problem(fullName, fmt.Sprintf("could not check native extensions: %v", err), gemDir)
+		} else if needsBuild {
+			problem(fullName, "native extension not built (or built for another Ruby)", gemDir)
+		}
+		return true
+	}
+
+	for _, gem := range gems {
+		if !checkGemDir(gem.FullName()) {
+			continue
+		}
+		specPath := filepath.Join(vendorDir, "specifications", gem.FullName()+".gemspec")
+		if _, err := os.Stat(specPath); err != nil {
+			problem(gem.FullName(), "gemspec missing", specPath)
+		}
+	}
+
+	for _, spec := range gitSpecs {
+		fullName := fmt.Sprintf("%s-%s", spec.Name, spec.Version)
+		if !checkGemDir(fullName) {
+			continue
+		}
+		if err := resolver.VerifyGitGem(spec, filepath.Join(vendorDir, "gems", fullName)); err != nil {
+			problem(fullName, err.Error(), "")
+		}
+	}
+
+	for _, spec := range pathSpecs {
+		checkGemDir(fmt.Sprintf("%s-%s", spec.Name, spec.Version))
+	}
+
+	report.OK = len(report.Problems) == 0
+	return report
+}
+
+// printVerifyReport renders a verify report for humans
+func printVerifyReport(report *verifyReport) {
+	if report.OK {
+		successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true) // Green
+		fmt.Println(successStyle.Render(fmt.Sprintf("✓ All %d gems are installed and complete.", report.Checked)))
+		return
+	}
+
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true) // Red
+	pathStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))             // Gray
+	fmt.Println(errorStyle.Render(fmt.Sprintf("Found %d problem(s) in %d gems:", len(report.Problems), report.Checked)))
+	for _, p := range report.Problems {
+		fmt.Printf("  ✗ %s: %s\n", p.Gem, p.Problem)
+		if p.Path != "" {
+			fmt.Printf("    %s\n", pathStyle.Render(p.Path))
+		}
+	}
+	fmt.Println("\nRun `ore install` (or `ore install --force` for incomplete gems) to repair them.")
+}
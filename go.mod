@@ -11,6 +11,7 @@ require (
 	github.com/contriboss/pubgrub-go v0.3.3
 	github.com/contriboss/ruby-extension-go v0.2.4
 	github.com/contriboss/rubygems-client-go v0.1.0
+	github.com/klauspost/compress v1.20.1
 	github.com/magefile/mage v1.15.0
 	github.com/mattn/go-isatty v0.0.20
 	github.com/pelletier/go-toml/v2 v2.2.4
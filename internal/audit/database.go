@@ -17,7 +17,8 @@ const (
 // Ruby developers: This is like a Repository pattern object
 // Manages the ruby-advisory-db git repository
 type Database struct {
-	Path string
+	Path  string
+	Local bool // Path was chosen explicitly (--database, ORE_AUDIT_DB or BUNDLER_AUDIT_DB)
 }
 
 // DefaultDatabasePath returns the default path for the advisory database
@@ -39,8 +40,9 @@ func DefaultDatabasePath() (string, error) {
 	return filepath.Join(home, ".local", "share", "ruby-advisory-db"), nil
 }
 
-// NewDatabase creates a new database instance
+// NewDatabase creates a new database instance. An empty path uses DefaultDatabasePath.
 func NewDatabase(path string) (*Database, error) {
+	local := path != "" || os.Getenv("ORE_AUDIT_DB") != "" || os.Getenv("BUNDLER_AUDIT_DB") != ""
 	if path == "" {
 		var err error
 		path, err = DefaultDatabasePath()
@@ -49,7 +51,25 @@ func NewDatabase(path string) (*Database, error) {
 		}
 	}
 
-	return &Database{Path: path}, nil
+	return &Database{Path: path, Local: local}, nil
+}
+
+// Check reports why the database can't be scanned against, or nil if it can.
+// A pre-provisioned database only needs ruby-advisory-db's gems/<name>/<id>.yml tree.
+func (db *Database) Check() error {
+	if db.Exists() {
+		return nil
+	}
+	if db.Local {
+		return fmt.Errorf("advisory database not found at %s (expected a ruby-advisory-db copy with gems/<name>/*.yml)", db.Path)
+	}
+	return fmt.Errorf("advisory database not found; run `ore audit update` to download it")
+}
+
+// IsGitCheckout reports whether the database is a git clone that Update can pull
+func (db *Database) IsGitCheckout() bool {
+	_, err := os.Stat(filepath.Join(db.Path, ".git"))
+	return err == nil
 }
 
 // Exists checks if the database has been downloaded
@@ -82,6 +102,11 @@ func (db *Database) Update() error {
 		return nil
 	}
 
+	// A copied-in database (e.g. an unpacked tarball in air-gapped CI) has nothing to pull
+	if !db.IsGitCheckout() {
+		return fmt.Errorf("advisory database at %s is not a git checkout; replace it with a newer copy to update it", db.Path)
+	}
+
 	// Update existing database
 	fmt.Println("Updating ruby-advisory-db...")
 
@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// GemFile is a cached .gem (or compressed .gem.zst) file
+type GemFile struct {
+	Path string
+	Size int64
+}
+
+// FindGems returns the gems in the cache directory matching any of the patterns.
+// See MatchGem for what a pattern matches.
+func FindGems(cacheDir string, patterns []string) ([]GemFile, error) {
+	entries, err := os.ReadDir(cacheDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []GemFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		for _, pattern := range patterns {
+			if !MatchGem(entry.Name(), pattern) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, GemFile{Path: filepath.Join(cacheDir, entry.Name()), Size: info.Size()})
+			break
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Path < matches[j].Path })
+	return matches, nil
+}
+
+// Clear empties the cache directory. The shared store is kept unless includeStore is
+// set, since bundles installed with --shared-store link into it.
+func Clear(cacheDir string, includeStore bool) error {
+	entries, err := os.ReadDir(cacheDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == StoreDir && !includeStore {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(cacheDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MatchGem reports whether a cached gem file name matches a pattern:
+//   - a gem name ("nokogiri") matches every version and platform of that gem,
+//     but not other gems sharing the prefix ("nokogiri-diff")
+//   - a full name ("nokogiri-1.16.0") matches that version on any platform
+//   - a pattern with glob characters ("nokogiri-1.1*") is matched as a glob
+func MatchGem(fileName, pattern string) bool {
+	base, ok := strings.CutSuffix(strings.TrimSuffix(fileName, ".zst"), ".gem")
+	if !ok || pattern == "" {
+		return false
+	}
+
+	pattern = strings.TrimSuffix(strings.TrimSuffix(pattern, ".zst"), ".gem")
+	if strings.ContainsAny(pattern, "*?[") {
+		matched, err := filepath.Match(pattern, base)
+		return err == nil && matched
+	}
+
+	if base == pattern {
+		return true
+	}
+	rest, ok := strings.CutPrefix(base, pattern+"-")
+	if !ok {
+		return false
+	}
+	if hasVersionSuffix(pattern) {
+		return true // name-version: rest is the platform
+	}
+	return startsWithDigit(rest) // name: rest must be a version
+}
+
+// hasVersionSuffix reports whether a pattern ends in -<version>, e.g. rack-3.0.8
+func hasVersionSuffix(pattern string) bool {
+	i := strings.LastIndex(pattern, "-")
+	return i >= 0 && startsWithDigit(pattern[i+1:])
+}
+
+func startsWithDigit(s string) bool {
+	return s != "" && unicode.IsDigit(rune(s[0]))
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchGem(t *testing.T) {
+	tests := []struct {
+		file    string
+		pattern string
+		want    bool
+	}{
+		{"nokogiri-1.16.0.gem", "nokogiri", true},
+		{"nokogiri-1.16.0-x86_64-linux.gem", "nokogiri", true},
+		{"nokogiri-1.16.0.gem.zst", "nokogiri", true},
+		{"nokogiri-diff-0.3.0.gem", "nokogiri", false},
+		{"nokogiri-1.16.0-x86_64-linux.gem", "nokogiri-1.16.0", true},
+		{"nokogiri-1.16.0.gem", "nokogiri-1.16.0.gem", true},
+		{"nokogiri-1.16.1.gem", "nokogiri-1.16.0", false},
+		{"nokogiri-1.16.0.gem", "nokogiri-1.16", false},
+		{"nokogiri-1.16.0.gem", "nokogiri-1.1*", true},
+		{"rack-3.0.8.gem", "nokogiri*", false},
+		{"versions.etag", "versions", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchGem(tt.file, tt.pattern); got != tt.want {
+			t.Errorf("MatchGem(%q, %q) = %v, want %v", tt.file, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestFindGems(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"rack-3.0.8.gem", "rack-2.2.8.gem.zst", "rack-test-2.1.0.gem", "rake-13.1.0.gem"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("gem"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches, err := FindGems(dir, []string{"rack", "rake-13.1.0"})
+	if err != nil {
+		t.Fatalf("FindGems: %v", err)
+	}
+	var names []string
+	for _, m := range matches {
+		names = append(names, filepath.Base(m.Path))
+	}
+	want := []string{"rack-2.2.8.gem.zst", "rack-3.0.8.gem", "rake-13.1.0.gem"}
+	if len(names) != len(want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, names)
+			break
+		}
+	}
+
+	if matches, err := FindGems(filepath.Join(dir, "missing"), []string{"rack"}); err != nil || len(matches) != 0 {
+		t.Errorf("expected no matches for a missing cache dir, got %v, %v", matches, err)
+	}
+}
+
+func TestClearKeepsStore(t *testing.T) {
+	dir := t.TempDir()
+	storeGem := filepath.Join(dir, StoreDir, "rack-3.0.8-1f2e3d4c", "lib", "rack.rb")
+	if err := os.MkdirAll(filepath.Dir(storeGem), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{storeGem, filepath.Join(dir, "rack-3.0.8.gem"), filepath.Join(dir, "info", "rack")} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Bundles link into the store, so stats and a plain clear leave it alone
+	stats, err := CollectStats(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 2 {
+		t.Errorf("expected 2 files outside the store, got %d", stats.Files)
+	}
+	if err := Clear(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != StoreDir {
+		t.Errorf("expected only the store to remain, got %v", entries)
+	}
+	if _, err := os.Stat(storeGem); err != nil {
+		t.Errorf("expected the store entry to survive: %v", err)
+	}
+
+	if err := Clear(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected an empty cache dir with the store included, got %v", entries)
+	}
+}
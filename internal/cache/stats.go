@@ -1,9 +1,14 @@
 package cache
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 // Stats represents cache statistics
@@ -12,15 +17,23 @@ type Stats struct {
 	TotalSize int64
 }
 
-// CollectStats walks the cache directory and collects statistics
+// StoreDir is the shared gem store's directory under the cache dir (ore install
+// --shared-store). Bundles link into it, so it isn't counted or cleared with the gems.
+const StoreDir = "store"
+
+// CollectStats walks the cache directory and collects statistics, leaving out the shared store
 func CollectStats(cacheDir string) (Stats, error) {
 	var stats Stats
 
+	storeDir := filepath.Join(cacheDir, StoreDir)
 	err := filepath.WalkDir(cacheDir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 		if d.IsDir() {
+			if path == storeDir {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		info, err := d.Info()
@@ -39,7 +52,79 @@ func CollectStats(cacheDir string) (Stats, error) {
 	return stats, err
 }
 
-// HumanBytes converts bytes to human-readable format (KiB, MiB, GiB, etc)
+// CompressionStats summarizes gems stored compressed (cache_compression = "zstd")
+type CompressionStats struct {
+	Files        int
+	StoredSize   int64 // Bytes on disk
+	OriginalSize int64 // Bytes the same gems take uncompressed
+}
+
+// Saved returns how many bytes compression saves
+func (s CompressionStats) Saved() int64 {
+	return s.OriginalSize - s.StoredSize
+}
+
+// CollectCompressionStats walks the cache directory and sums up compressed .gem.zst files.
+// Original sizes come from the zstd frame header, so no gem needs decompressing.
+func CollectCompressionStats(cacheDir string) (CompressionStats, error) {
+	var stats CompressionStats
+
+	err := filepath.WalkDir(cacheDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".gem.zst") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		original, err := zstdOriginalSize(path)
+		if err != nil {
+			return nil // Not a readable zstd file - leave it out of the totals
+		}
+		stats.Files++
+		stats.StoredSize += info.Size()
+		stats.OriginalSize += original
+		return nil
+	})
+
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+
+	return stats, err
+}
+
+// zstdOriginalSize reads the uncompressed size recorded in a zstd file's frame header
+func zstdOriginalSize(path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	buf := make([]byte, zstd.HeaderMaxSize)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, err
+	}
+	var header zstd.Header
+	if err := header.Decode(buf[:n]); err != nil {
+		return 0, err
+	}
+	if !header.HasFCS {
+		return 0, fmt.Errorf("%s doesn't record its uncompressed size", path)
+	}
+	return int64(header.FrameContentSize), nil
+}
+
+// HumanBytes converts bytes to human-readable format in binary units (KiB, MiB, GiB,
+// etc.), dividing by 1024. Every size ore prints goes through it, so the same cache
+// reports the same number in ore stats and ore cache info.
 func HumanBytes(size int64) string {
 	const unit = 1024
 	if size < unit {
@@ -50,5 +135,11 @@ func HumanBytes(size int64) string {
 		div *= unit
 		exp++
 	}
-	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+	// 1048575 bytes is 1.0 MiB, not 1024.0 KiB
+	value := float64(size) / float64(div)
+	if value >= unit-0.05 && exp < len("KMGTPE")-1 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTPE"[exp])
 }
@@ -0,0 +1,25 @@
+package cache
+
+import "testing"
+
+func TestHumanBytes(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{1048575, "1.0 MiB"},
+		{1048576, "1.0 MiB"},
+		{5 * 1024 * 1024 * 1024, "5.0 GiB"},
+		{1<<40 + 1<<39, "1.5 TiB"},
+	}
+
+	for _, tt := range tests {
+		if got := HumanBytes(tt.size); got != tt.want {
+			t.Errorf("HumanBytes(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
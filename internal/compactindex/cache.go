@@ -72,6 +72,19 @@ func GetInfoPath(cacheDir, gemName string) string {
 	return filepath.Join(cacheDir, "info", gemName)
 }
 
+// GetVersionsETagPath returns where the server's ETag for the versions file is kept.
+// Matches Bundler's versions.etag so both tools revalidate the same way.
+func GetVersionsETagPath(cacheDir string) string {
+	return filepath.Join(cacheDir, "versions.etag")
+}
+
+// GetInfoETagPath returns where the server's ETag for a gem's info file is kept.
+// Bundler's layout: info-etags/{name}-{md5(name)}
+func GetInfoETagPath(cacheDir, gemName string) string {
+	hash := md5.Sum([]byte(gemName))
+	return filepath.Join(cacheDir, "info-etags", fmt.Sprintf("%s-%x", gemName, hash))
+}
+
 // EnsureCacheDirectories creates the necessary cache directory structure.
 func EnsureCacheDirectories(cacheDir string) error {
 	// Create main cache directory
@@ -91,5 +104,11 @@ func EnsureCacheDirectories(cacheDir string) error {
 		return fmt.Errorf("failed to create info-special-characters directory: %w", err)
 	}
 
+	// Create info-etags directory
+	etagDir := filepath.Join(cacheDir, "info-etags")
+	if err := os.MkdirAll(etagDir, 0755); err != nil {
+		return fmt.Errorf("failed to create info-etags directory: %w", err)
+	}
+
 	return nil
 }
@@ -140,6 +140,7 @@ func TestEnsureCacheDirectories(t *testing.T) {
 	dirs := []string{
 		"info",
 		"info-special-characters",
+		"info-etags",
 	}
 
 	for _, dir := range dirs {
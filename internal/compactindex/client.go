@@ -2,13 +2,18 @@ package compactindex
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/contriboss/ore-light/internal/httpclient"
+	"github.com/contriboss/ore-light/internal/sources"
 )
 
 // Client is a compact index HTTP client that maintains a Bundler-compatible cache.
@@ -16,10 +21,13 @@ type Client struct {
 	baseURL    string
 	cacheDir   string
 	httpClient *http.Client
+	revalidate bool                    // Ask the server even about files fetched within the last hour
+	auth       *sources.Authentication // Credentials for a private source; nil for public ones
 }
 
 // NewClient creates a new compact index client.
 // It writes to Bundler's cache location: ~/.bundle/cache/compact_index/{server_slug}/
+// Credentials come from baseURL or, failing that, sources.SetCredentialLookup, as for downloads.
 func NewClient(baseURL string) (*Client, error) {
 	// Compute Bundler cache path
 	cacheDir, err := GetBundlerCachePath(baseURL)
@@ -32,13 +40,28 @@ func NewClient(baseURL string) (*Client, error) {
 		return nil, fmt.Errorf("failed to create cache directories: %w", err)
 	}
 
+	// Credentials go in the Authorization header, so request URLs (and the errors
+	// quoting them) never carry them
+	requestURL := baseURL
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.User != nil {
+		parsed.User = nil
+		requestURL = parsed.String()
+	}
+
 	return &Client{
-		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		baseURL:    strings.TrimSuffix(requestURL, "/"),
 		cacheDir:   cacheDir,
-		httpClient: &http.Client{},
+		httpClient: httpclient.New(),
+		auth:       sources.AuthFor(baseURL),
 	}, nil
 }
 
+// Revalidate makes later requests check cached files with the server (a cheap 304 when
+// unchanged) even if they were fetched within the last hour
+func (c *Client) Revalidate() {
+	c.revalidate = true
+}
+
 // GetVersions fetches and caches the versions file.
 // Returns the parsed entries.
 func (c *Client) GetVersions(ctx context.Context) ([]VersionsEntry, error) {
@@ -46,7 +69,7 @@ func (c *Client) GetVersions(ctx context.Context) ([]VersionsEntry, error) {
 	remotePath := "/versions"
 
 	// Update local cache
-	if err := c.updateFile(ctx, localPath, remotePath); err != nil {
+	if err := c.updateFile(ctx, localPath, GetVersionsETagPath(c.cacheDir), remotePath); err != nil {
 		return nil, fmt.Errorf("failed to update versions file: %w", err)
 	}
 
@@ -54,6 +77,25 @@ func (c *Client) GetVersions(ctx context.Context) ([]VersionsEntry, error) {
 	return ParseVersionsFile(localPath)
 }
 
+// ErrNotCached is returned by CachedVersions when the versions file was never fetched
+var ErrNotCached = errors.New("compact index not cached")
+
+// CachedVersions parses the cached versions file without touching the network, for
+// offline use. Also returns when the file was last fetched or revalidated.
+func (c *Client) CachedVersions() ([]VersionsEntry, time.Time, error) {
+	localPath := filepath.Join(c.cacheDir, "versions")
+	info, err := os.Stat(localPath)
+	if err != nil || info.Size() == 0 {
+		return nil, time.Time{}, fmt.Errorf("%w: %s", ErrNotCached, localPath)
+	}
+
+	entries, err := ParseVersionsFile(localPath)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return entries, info.ModTime(), nil
+}
+
 // GetGemInfo fetches and caches the info file for a specific gem.
 // Returns the parsed version information.
 func (c *Client) GetGemInfo(ctx context.Context, gemName string) ([]VersionInfo, error) {
@@ -61,7 +103,7 @@ func (c *Client) GetGemInfo(ctx context.Context, gemName string) ([]VersionInfo,
 	remotePath := fmt.Sprintf("/info/%s", gemName)
 
 	// Update local cache
-	if err := c.updateFile(ctx, localPath, remotePath); err != nil {
+	if err := c.updateFile(ctx, localPath, GetInfoETagPath(c.cacheDir, gemName), remotePath); err != nil {
 		return nil, fmt.Errorf("failed to update info file for %s: %w", gemName, err)
 	}
 
@@ -70,14 +112,15 @@ func (c *Client) GetGemInfo(ctx context.Context, gemName string) ([]VersionInfo,
 }
 
 // updateFile updates a local cache file using HTTP with ETag and Range support.
-// This implements Bundler's caching strategy.
-func (c *Client) updateFile(ctx context.Context, localPath, remotePath string) error {
+// This implements Bundler's caching strategy: the server's ETag is kept in etagPath
+// and sent back as If-None-Match, so an unchanged file costs a 304.
+func (c *Client) updateFile(ctx context.Context, localPath, etagPath, remotePath string) error {
 	// Check if local file exists
 	localInfo, localErr := os.Stat(localPath)
 
 	// Skip update if file is fresh (modified within last hour)
 	// This matches Bundler's behavior and avoids unnecessary network + MD5 overhead
-	if localErr == nil && localInfo.Size() > 0 {
+	if !c.revalidate && localErr == nil && localInfo.Size() > 0 {
 		fileAge := time.Since(localInfo.ModTime())
 		if fileAge < 1*time.Hour {
 			// Cache is fresh, skip network request entirely
@@ -91,6 +134,7 @@ func (c *Client) updateFile(ctx context.Context, localPath, remotePath string) e
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
+	c.auth.Apply(req)
 
 	var localSize int64
 	if localErr == nil {
@@ -99,10 +143,11 @@ func (c *Client) updateFile(ctx context.Context, localPath, remotePath string) e
 
 	// Add ETag header if we have a cached file
 	if localErr == nil && localSize > 0 {
-		// Compute current ETag (MD5 of file content)
-		etag, err := ComputeInfoFileChecksum(localPath)
-		if err == nil {
-			req.Header.Set("If-None-Match", fmt.Sprintf(`"%s"`, etag))
+		// Prefer the server's stored ETag, falling back to the MD5 of the file content
+		if etag := readETag(etagPath); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		} else if checksum, err := ComputeInfoFileChecksum(localPath); err == nil {
+			req.Header.Set("If-None-Match", fmt.Sprintf(`"%s"`, checksum))
 		}
 
 		// Add Range header for incremental update
@@ -123,7 +168,9 @@ func (c *Client) updateFile(ctx context.Context, localPath, remotePath string) e
 
 	// Handle 304 Not Modified
 	if resp.StatusCode == http.StatusNotModified {
-		// Cache is fresh, nothing to do
+		// Cache is fresh - restart the freshness window so we don't ask again for an hour
+		now := time.Now()
+		_ = os.Chtimes(localPath, now, now)
 		return nil
 	}
 
@@ -138,13 +185,33 @@ func (c *Client) updateFile(ctx context.Context, localPath, remotePath string) e
 		return fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Handle 206 Partial Content (Range response)
+	// Handle 206 Partial Content (Range response) or 200 OK (Full content)
 	if resp.StatusCode == http.StatusPartialContent {
-		return c.appendToFile(localPath, content)
+		err = c.appendToFile(localPath, content)
+	} else {
+		err = c.writeFile(localPath, content)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Remember the server's ETag for the next revalidation; a stale one just costs a full fetch
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		_ = c.writeFile(etagPath, []byte(etag))
+	} else {
+		_ = os.Remove(etagPath)
 	}
 
-	// Handle 200 OK (Full content)
-	return c.writeFile(localPath, content)
+	return nil
+}
+
+// readETag returns the ETag stored at path, or "" when there is none
+func readETag(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
 }
 
 // writeFile writes content to a file atomically (using temp file + rename).
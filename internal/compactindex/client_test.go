@@ -0,0 +1,146 @@
+package compactindex
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/contriboss/ore-light/internal/sources"
+)
+
+func TestClientRevalidatesWithStoredETag(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if inm := r.Header.Get("If-None-Match"); inm != "" {
+			conditional = append(conditional, inm)
+			if inm == `"server-etag"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Header().Set("ETag", `"server-etag"`)
+		_, _ = io.WriteString(w, "---\n1.0.0 |checksum:abc\n")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.GetGemInfo(ctx, "rack"); err != nil {
+		t.Fatalf("first GetGemInfo() error = %v", err)
+	}
+
+	etagPath := GetInfoETagPath(client.GetCacheDir(), "rack")
+	if got := readETag(etagPath); got != `"server-etag"` {
+		t.Fatalf("expected stored ETag, got %q", got)
+	}
+
+	// Age the cached file past the freshness window so the client revalidates
+	infoPath := GetInfoPath(client.GetCacheDir(), "rack")
+	stale := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(infoPath, stale, stale); err != nil {
+		t.Fatalf("failed to age info file: %v", err)
+	}
+
+	versions, err := client.GetGemInfo(ctx, "rack")
+	if err != nil {
+		t.Fatalf("second GetGemInfo() error = %v", err)
+	}
+	if len(versions) != 1 || versions[0].Version != "1.0.0" {
+		t.Errorf("expected cached info after 304, got %+v", versions)
+	}
+	if len(conditional) != 1 || conditional[0] != `"server-etag"` {
+		t.Errorf("expected one revalidation with the stored ETag, got %v", conditional)
+	}
+
+	info, err := os.Stat(infoPath)
+	if err != nil {
+		t.Fatalf("stat info file: %v", err)
+	}
+	if time.Since(info.ModTime()) > time.Hour {
+		t.Errorf("expected 304 to refresh the info file's freshness window")
+	}
+}
+
+func TestClientSendsConfiguredCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, "---\n1.0.0 |checksum:abc\n")
+	}))
+	defer server.Close()
+
+	// The token lives in ore's config rather than the Gemfile URL
+	sources.SetCredentialLookup(func(host string) string {
+		if host == "127.0.0.1" {
+			return "secret-token"
+		}
+		return ""
+	})
+	t.Cleanup(func() { sources.SetCredentialLookup(nil) })
+
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.GetGemInfo(context.Background(), "sidekiq-pro"); err != nil {
+		t.Fatalf("GetGemInfo() error = %v", err)
+	}
+
+	token := "Basic " + base64.StdEncoding.EncodeToString([]byte("secret-token:"))
+	if len(authorization) != 1 || authorization[0] != token {
+		t.Errorf("expected the configured token as basic auth, got %q", authorization)
+	}
+}
+
+func TestCachedVersionsIsOffline(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// Nothing listens here; CachedVersions must never dial it
+	client, err := NewClient("http://127.0.0.1:1")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if _, _, err := client.CachedVersions(); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("expected ErrNotCached before any fetch, got %v", err)
+	}
+
+	versionsPath := filepath.Join(client.GetCacheDir(), "versions")
+	if err := os.WriteFile(versionsPath, []byte("created_at: 2024-01-01T00:00:00Z\n---\nrack 2.2.0,3.0.0 abc123\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fetched := time.Now().Add(-3 * time.Hour)
+	if err := os.Chtimes(versionsPath, fetched, fetched); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, fetchedAt, err := client.CachedVersions()
+	if err != nil {
+		t.Fatalf("CachedVersions() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "rack" || len(entries[0].Versions) != 2 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+	if !fetchedAt.Equal(fetched) {
+		t.Errorf("expected fetch time %v, got %v", fetched, fetchedAt)
+	}
+}
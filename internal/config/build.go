@@ -0,0 +1,197 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// bundleBuildPrefix is the .bundle/config key prefix for `bundle config build.<gem>`
+	bundleBuildPrefix = "BUNDLE_BUILD__"
+
+	// bundleJobs is the .bundle/config key for `bundle config set jobs <n>`
+	bundleJobs = "BUNDLE_JOBS"
+
+	// bundleForceRubyPlatform is the .bundle/config key for `bundle config set force_ruby_platform true`
+	bundleForceRubyPlatform = "BUNDLE_FORCE_RUBY_PLATFORM"
+)
+
+// ReadBundleBuildOptions reads per-gem build options the way Bundler does.
+// Returns a map of gem name to its build arguments.
+// Precedence: BUNDLE_BUILD__<GEM> env > .bundle/config > ~/.bundle/config
+//
+// Ruby developers: `bundle config set build.mysql2 --with-mysql-dir=/opt/mysql`
+// is stored as BUNDLE_BUILD__MYSQL2 and passed to the gem's extconf.rb
+func ReadBundleBuildOptions() map[string][]string {
+	options := make(map[string][]string)
+	for _, values := range bundleConfigLayers() {
+		mergeBuildOptions(options, values)
+	}
+	return options
+}
+
+// ReadBundleJobs returns Bundler's `jobs` setting (BUNDLE_JOBS), or 0 when unset or invalid.
+// Precedence: BUNDLE_JOBS env > .bundle/config > ~/.bundle/config
+//
+// Ruby developers: `bundle config set jobs 4` is how Bundler users tune install concurrency
+func ReadBundleJobs() int {
+	jobs := 0
+	for _, values := range bundleConfigLayers() {
+		value, ok := values[bundleJobs]
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 1 {
+			n = 0
+		}
+		jobs = n
+	}
+	return jobs
+}
+
+// ReadForceRubyPlatform reports whether Bundler's `force_ruby_platform` setting is on,
+// i.e. pure-Ruby gems should be installed instead of precompiled platform gems.
+// Precedence: BUNDLE_FORCE_RUBY_PLATFORM env > .bundle/config > ~/.bundle/config
+func ReadForceRubyPlatform() bool {
+	force := false
+	for _, values := range bundleConfigLayers() {
+		value, ok := values[bundleForceRubyPlatform]
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "true", "1", "yes":
+			force = true
+		default:
+			force = false
+		}
+	}
+	return force
+}
+
+// bundleConfigLayers returns Bundler settings from lowest to highest precedence:
+// ~/.bundle/config, .bundle/config, then the environment
+func bundleConfigLayers() []map[string]string {
+	var layers []map[string]string
+
+	if home, err := os.UserHomeDir(); err == nil {
+		layers = append(layers, readBundleConfigFile(filepath.Join(home, ".bundle", "config")))
+	}
+	layers = append(layers, readBundleConfigFile(filepath.Join(".bundle", "config")))
+
+	env := make(map[string]string)
+	for _, entry := range os.Environ() {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			env[key] = value
+		}
+	}
+
+	return append(layers, env)
+}
+
+// readBundleConfigFile returns the string values of a Bundler config file
+func readBundleConfigFile(path string) map[string]string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil
+	}
+
+	values := make(map[string]string)
+	for key, value := range config {
+		if str, ok := value.(string); ok {
+			values[key] = str
+		}
+	}
+	return values
+}
+
+// mergeBuildOptions copies BUNDLE_BUILD__<GEM> entries from values into options,
+// overriding earlier settings for the same gem
+func mergeBuildOptions(options map[string][]string, values map[string]string) {
+	for key, value := range values {
+		gemName, ok := BuildKeyGemName(key)
+		if !ok {
+			continue
+		}
+		if args := SplitBuildArgs(value); len(args) > 0 {
+			options[gemName] = args
+		} else {
+			delete(options, gemName)
+		}
+	}
+}
+
+// BuildKeyGemName extracts the gem name from a BUNDLE_BUILD__<GEM> key.
+// Bundler encodes "-" in gem names as "___", so BUNDLE_BUILD__NET___SSH is net-ssh.
+func BuildKeyGemName(key string) (string, bool) {
+	return keyGemName(key, bundleBuildPrefix)
+}
+
+// keyGemName extracts the gem name from a per-gem Bundler key such as BUNDLE_BUILD__<GEM>
+func keyGemName(key, prefix string) (string, bool) {
+	if !strings.HasPrefix(key, prefix) {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(key, prefix)
+	if name == "" {
+		return "", false
+	}
+
+	return strings.ToLower(strings.ReplaceAll(name, "___", "-")), true
+}
+
+// SplitBuildArgs splits build options into arguments, honoring quotes and
+// backslash escapes like Ruby's Shellwords (which Bundler uses for build.<gem>).
+// e.g. `--with-opt-dir="/opt/my libs" --enable-foo` -> ["--with-opt-dir=/opt/my libs", "--enable-foo"]
+func SplitBuildArgs(value string) []string {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range value {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args
+}
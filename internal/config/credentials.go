@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// oreCredentialsPrefix is the environment prefix for per-host credentials, e.g.
+// ORE_CREDENTIALS_GEMS_EXAMPLE_COM=token for gems.example.com
+const oreCredentialsPrefix = "ORE_CREDENTIALS_"
+
+// ReadOreCredentials returns ORE_CREDENTIALS_<HOST> for host ("user:pass" or a token),
+// with the host upper-cased and every character other than a letter or digit as "_"
+func ReadOreCredentials(host string) string {
+	return strings.TrimSpace(os.Getenv(oreCredentialsPrefix + credentialsEnvName(host)))
+}
+
+func credentialsEnvName(host string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, host)
+}
+
+// ReadBundleCredentials returns the credentials Bundler has for host, or "".
+// Precedence: BUNDLE_<HOST> env > .bundle/config > ~/.bundle/config
+//
+// Ruby developers: `bundle config gems.example.com user:pass` is stored as
+// BUNDLE_GEMS__EXAMPLE__COM; `bundle config https://gems.example.com/ token` as
+// BUNDLE_HTTPS://GEMS__EXAMPLE__COM/
+func ReadBundleCredentials(host string) string {
+	keys := []string{
+		bundleCredentialsKey(host),
+		bundleCredentialsKey("https://" + host + "/"),
+		bundleCredentialsKey("http://" + host + "/"),
+	}
+
+	credentials := ""
+	for _, values := range bundleConfigLayers() {
+		for _, key := range keys {
+			if value := strings.TrimSpace(values[key]); value != "" {
+				credentials = value
+				break
+			}
+		}
+	}
+	return credentials
+}
+
+// bundleCredentialsKey encodes a host or URL the way Bundler names its settings:
+// upper-cased, "-" as "___" and "." as "__"
+func bundleCredentialsKey(name string) string {
+	name = strings.ReplaceAll(name, "-", "___")
+	name = strings.ReplaceAll(name, ".", "__")
+	return "BUNDLE_" + strings.ToUpper(name)
+}
@@ -0,0 +1,50 @@
+package config
+
+import "strings"
+
+const (
+	// bundleWithout is the .bundle/config key for `bundle config set without <groups>`
+	bundleWithout = "BUNDLE_WITHOUT"
+
+	// bundleWith is the .bundle/config key for `bundle config set with <groups>`
+	bundleWith = "BUNDLE_WITH"
+)
+
+// BundleGroups holds the group selection Bundler persists across installs
+type BundleGroups struct {
+	Without string // Groups to skip (raw BUNDLE_WITHOUT value)
+	With    string // Optional groups to install (raw BUNDLE_WITH value)
+}
+
+// ReadBundleGroups reads the persisted without/with group settings the way Bundler does.
+// Precedence: environment > .bundle/config > ~/.bundle/config
+//
+// Ruby developers: `bundle config set --local without development` is stored as
+// BUNDLE_WITHOUT in .bundle/config and applies to every later `bundle install`
+func ReadBundleGroups() BundleGroups {
+	var groups BundleGroups
+	for _, values := range bundleConfigLayers() {
+		if value, ok := values[bundleWithout]; ok {
+			groups.Without = value
+		}
+		if value, ok := values[bundleWith]; ok {
+			groups.With = value
+		}
+	}
+	return groups
+}
+
+// SplitBundleGroups splits a BUNDLE_WITHOUT or BUNDLE_WITH value into group names.
+//
+// Ruby developers: Bundler separates groups with ":" (`bundle config set without
+// development:test` stores "development:test") and older versions with spaces, so
+// both are accepted here, along with commas as in ore's --without.
+func SplitBundleGroups(value string) []string {
+	groups := strings.FieldsFunc(value, func(r rune) bool {
+		return r == ':' || r == ',' || r == ' ' || r == '\t'
+	})
+	if len(groups) == 0 {
+		return nil
+	}
+	return groups
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"strings"
+)
+
+const (
+	// bundleIgnoreMessages is the .bundle/config key for `bundle config ignore_messages true`
+	bundleIgnoreMessages = "BUNDLE_IGNORE_MESSAGES"
+
+	// bundleIgnoreMessagesPrefix is the key prefix for `bundle config ignore_messages.<gem> true`
+	bundleIgnoreMessagesPrefix = "BUNDLE_IGNORE_MESSAGES__"
+)
+
+// IgnoreMessages records which gems' post-install messages are suppressed
+type IgnoreMessages struct {
+	All  bool            // ignore_messages: silence every gem
+	Gems map[string]bool // ignore_messages.<gem>: per-gem override
+}
+
+// Ignores reports whether post-install messages from gemName should be hidden.
+// A per-gem setting wins over the global one, so ignore_messages.rails false
+// keeps rails' message even when ignore_messages is true.
+func (i IgnoreMessages) Ignores(gemName string) bool {
+	if ignore, ok := i.Gems[strings.ToLower(gemName)]; ok {
+		return ignore
+	}
+	return i.All
+}
+
+// ReadBundleIgnoreMessages reads post-install message suppression the way Bundler does.
+// Precedence: environment > .bundle/config > ~/.bundle/config
+//
+// Ruby developers: `bundle config set ignore_messages.httparty true` is stored as
+// BUNDLE_IGNORE_MESSAGES__HTTPARTY
+func ReadBundleIgnoreMessages() IgnoreMessages {
+	ignore := IgnoreMessages{Gems: make(map[string]bool)}
+	for _, values := range bundleConfigLayers() {
+		for key, value := range values {
+			if key == bundleIgnoreMessages {
+				ignore.All = isTruthy(value)
+			} else if gemName, ok := keyGemName(key, bundleIgnoreMessagesPrefix); ok {
+				ignore.Gems[gemName] = isTruthy(value)
+			}
+		}
+	}
+	return ignore
+}
+
+// isTruthy interprets a Bundler boolean setting
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "1", "yes":
+		return true
+	}
+	return false
+}
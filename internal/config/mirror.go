@@ -0,0 +1,76 @@
+package config
+
+import (
+	"strings"
+)
+
+const (
+	// bundleMirrorPrefix is the .bundle/config key prefix for `bundle config mirror.<url>`
+	bundleMirrorPrefix = "BUNDLE_MIRROR__"
+
+	// mirrorAll is the key for `bundle config mirror.all <url>`, which mirrors every source
+	mirrorAll = "all"
+)
+
+// ReadBundleMirrors reads source mirrors the way Bundler does.
+// Returns a map of normalized source URL (or "all") to mirror URL.
+// Precedence: BUNDLE_MIRROR__<URL> env > .bundle/config > ~/.bundle/config
+//
+// Ruby developers: `bundle config mirror.https://rubygems.org https://gems.example.com`
+// is stored as BUNDLE_MIRROR__HTTPS://RUBYGEMS__ORG/
+func ReadBundleMirrors() map[string]string {
+	mirrors := make(map[string]string)
+	for _, values := range bundleConfigLayers() {
+		for key, value := range values {
+			source, ok := MirrorKeySource(key)
+			if !ok {
+				continue
+			}
+			if value = strings.TrimSpace(value); value != "" {
+				mirrors[source] = value
+			} else {
+				delete(mirrors, source)
+			}
+		}
+	}
+	return mirrors
+}
+
+// MirrorKeySource extracts the mirrored source from a BUNDLE_MIRROR__<URL> key.
+// Bundler encodes "." as "__" and "-" as "___", so BUNDLE_MIRROR__HTTPS://RUBYGEMS__ORG/
+// is https://rubygems.org. Per-mirror settings like fallback_timeout are ignored.
+func MirrorKeySource(key string) (string, bool) {
+	if !strings.HasPrefix(key, bundleMirrorPrefix) {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(key, bundleMirrorPrefix)
+	if name == "" || strings.HasSuffix(name, "__FALLBACK_TIMEOUT") {
+		return "", false
+	}
+
+	name = strings.ReplaceAll(name, "___", "-")
+	name = strings.ReplaceAll(name, "__", ".")
+
+	return normalizeSourceURL(name), true
+}
+
+// ApplyMirror returns the mirror configured for sourceURL, falling back to a
+// `mirror.all` mirror, or sourceURL itself when neither is set
+func ApplyMirror(mirrors map[string]string, sourceURL string) string {
+	if sourceURL == "" {
+		return sourceURL
+	}
+	if mirror, ok := mirrors[normalizeSourceURL(sourceURL)]; ok {
+		return mirror
+	}
+	if mirror, ok := mirrors[mirrorAll]; ok {
+		return mirror
+	}
+	return sourceURL
+}
+
+// normalizeSourceURL makes source URLs comparable: lowercase, no trailing slash
+func normalizeSourceURL(sourceURL string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(sourceURL)), "/")
+}
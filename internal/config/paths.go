@@ -74,35 +74,51 @@ func DefaultCacheDir(cfg *Config) (string, error) {
 	return filepath.Join(home, ".cache", "ore", "gems"), nil
 }
 
+// Where ResolveVendorDir found the install path
+const (
+	VendorSourceEnv     = "env"
+	VendorSourceConfig  = "config"
+	VendorSourceBundle  = "bundle config"
+	VendorSourceDefault = "default"
+)
+
 // DefaultVendorDir returns the default vendor directory
 // It requires Ruby detection functions which will be moved to internal/ruby
 func DefaultVendorDir(cfg *Config, detectRubyVersion func() string, getSystemGemDir func() string) string {
+	dir, _, _ := ResolveVendorDir(cfg, detectRubyVersion, getSystemGemDir)
+	return dir
+}
+
+// ResolveVendorDir returns the default vendor directory, which of the VendorSource*
+// kinds it came from, and the setting that supplied it (e.g. ORE_VENDOR_DIR)
+func ResolveVendorDir(cfg *Config, detectRubyVersion func() string, getSystemGemDir func() string) (dir, source, setting string) {
 	// Priority 1: Environment variables
 	if env := os.Getenv("ORE_VENDOR_DIR"); env != "" {
-		return env
+		return env, VendorSourceEnv, "ORE_VENDOR_DIR"
 	}
 	if env := os.Getenv("ORE_LIGHT_VENDOR_DIR"); env != "" {
-		return env
+		return env, VendorSourceEnv, "ORE_LIGHT_VENDOR_DIR"
 	}
 
 	// Priority 2: Ore config file
 	if cfg != nil && cfg.VendorDir != "" {
-		return cfg.VendorDir
+		return cfg.VendorDir, VendorSourceConfig, "vendor_dir"
 	}
 
 	// Priority 3: Bundler .bundle/config
 	if bundlePath := ReadBundleConfigPath(); bundlePath != "" {
+		setting = "BUNDLE_PATH in .bundle/config"
 		rubyVersion := detectRubyVersion()
 		if rubyVersion != "" {
-			return filepath.Join(bundlePath, "ruby", rubyVersion)
+			return filepath.Join(bundlePath, "ruby", rubyVersion), VendorSourceBundle, setting
 		}
-		return bundlePath
+		return bundlePath, VendorSourceBundle, setting
 	}
 
 	// Priority 4: System gem directory (default - like `gem install`)
 	// This makes ore behave like gem install by default (no isolation)
 	// Users can set BUNDLE_PATH or use --path flag for isolated installs
-	return getSystemGemDir()
+	return getSystemGemDir(), VendorSourceDefault, "system gem directory"
 }
 
 // ReadBundleConfigPath reads the BUNDLE_PATH from .bundle/config
@@ -124,7 +140,7 @@ func ReadBundleConfigPath() string {
 	return ""
 }
 
-// WriteBundleConfig writes a .bundle/config file with the given path
+// WriteBundleConfig sets BUNDLE_PATH in .bundle/config, keeping the other settings.
 // This makes ore compatible with Bundler's configuration system
 func WriteBundleConfig(bundlePath string) error {
 	// Create .bundle directory if it doesn't exist
@@ -132,10 +148,17 @@ func WriteBundleConfig(bundlePath string) error {
 		return fmt.Errorf("failed to create .bundle directory: %w", err)
 	}
 
-	// Create YAML config
-	config := map[string]string{
-		"BUNDLE_PATH": bundlePath,
+	// Load the existing config so `bundle config` settings survive
+	config := make(map[string]interface{})
+	if data, err := os.ReadFile(".bundle/config"); err == nil {
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return fmt.Errorf("failed to read .bundle/config: %w", err)
+		}
+		if config == nil {
+			config = make(map[string]interface{})
+		}
 	}
+	config["BUNDLE_PATH"] = bundlePath
 
 	data, err := yaml.Marshal(config)
 	if err != nil {
@@ -1,6 +1,10 @@
 package config
 
-import "testing"
+import (
+	"os"
+	"slices"
+	"testing"
+)
 
 func TestToMajorMinor(t *testing.T) {
 	tests := []struct {
@@ -23,3 +27,207 @@ func TestToMajorMinor(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildKeyGemName(t *testing.T) {
+	tests := []struct {
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{"BUNDLE_BUILD__MYSQL2", "mysql2", true},
+		{"BUNDLE_BUILD__NET___SSH", "net-ssh", true},
+		{"BUNDLE_BUILD__", "", false},
+		{"BUNDLE_PATH", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := BuildKeyGemName(tt.key)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("BuildKeyGemName(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestSplitBuildArgs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"--use-system-libraries", []string{"--use-system-libraries"}},
+		{"  --with-pg-config=/usr/bin/pg_config   --enable-debug ", []string{"--with-pg-config=/usr/bin/pg_config", "--enable-debug"}},
+		{`--with-opt-dir="/opt/my libs" --foo`, []string{"--with-opt-dir=/opt/my libs", "--foo"}},
+		{`--with-dir='/a b' --x=a\ b`, []string{"--with-dir=/a b", "--x=a b"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got := SplitBuildArgs(tt.input)
+		if len(got) != len(tt.want) {
+			t.Errorf("SplitBuildArgs(%q) = %q, want %q", tt.input, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("SplitBuildArgs(%q) = %q, want %q", tt.input, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestReadBundleBuildOptionsEnvOverride(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	if err := os.MkdirAll(".bundle", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := "BUNDLE_BUILD__NOKOGIRI: \"--use-system-libraries\"\nBUNDLE_BUILD__MYSQL2: \"--with-mysql-dir=/opt/mysql\"\n"
+	if err := os.WriteFile(".bundle/config", []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BUNDLE_BUILD__MYSQL2", "--with-mysql-config=/usr/bin/mysql_config")
+
+	options := ReadBundleBuildOptions()
+	if got := options["nokogiri"]; len(got) != 1 || got[0] != "--use-system-libraries" {
+		t.Errorf("nokogiri options = %q", got)
+	}
+	if got := options["mysql2"]; len(got) != 1 || got[0] != "--with-mysql-config=/usr/bin/mysql_config" {
+		t.Errorf("mysql2 options = %q, want env override", got)
+	}
+}
+
+func TestReadBundleMirrors(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	if err := os.MkdirAll(".bundle", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := "BUNDLE_MIRROR__HTTPS://RUBYGEMS__ORG/: \"https://gems.example.com\"\n" +
+		"BUNDLE_MIRROR__HTTPS://RUBYGEMS__ORG/__FALLBACK_TIMEOUT: \"3\"\n"
+	if err := os.WriteFile(".bundle/config", []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BUNDLE_MIRROR__HTTPS://GEMS__MY___COMPANY__COM", "https://proxy.example.com/my-company")
+
+	mirrors := ReadBundleMirrors()
+	if len(mirrors) != 2 {
+		t.Fatalf("expected 2 mirrors, got %v", mirrors)
+	}
+
+	tests := map[string]string{
+		"https://rubygems.org":        "https://gems.example.com",
+		"https://RubyGems.org/":       "https://gems.example.com",
+		"https://gems.my-company.com": "https://proxy.example.com/my-company",
+		"https://other.example.com":   "https://other.example.com",
+	}
+	for source, want := range tests {
+		if got := ApplyMirror(mirrors, source); got != want {
+			t.Errorf("ApplyMirror(%q) = %q, want %q", source, got, want)
+		}
+	}
+
+	mirrors["all"] = "https://everything.example.com"
+	if got := ApplyMirror(mirrors, "https://other.example.com"); got != "https://everything.example.com" {
+		t.Errorf("expected mirror.all to apply, got %q", got)
+	}
+}
+
+func TestReadCredentials(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	if err := os.MkdirAll(".bundle", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := "BUNDLE_GEMS__MY___COMPANY__COM: \"deploy:s3cret\"\n" +
+		"BUNDLE_HTTPS://PKGS__EXAMPLE__COM/: \"tok123\"\n"
+	if err := os.WriteFile(".bundle/config", []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ReadBundleCredentials("gems.my-company.com"); got != "deploy:s3cret" {
+		t.Errorf("expected host credentials, got %q", got)
+	}
+	if got := ReadBundleCredentials("pkgs.example.com"); got != "tok123" {
+		t.Errorf("expected URL-keyed credentials, got %q", got)
+	}
+	if got := ReadBundleCredentials("rubygems.org"); got != "" {
+		t.Errorf("expected no credentials, got %q", got)
+	}
+
+	t.Setenv("ORE_CREDENTIALS_GEMS_MY_COMPANY_COM", "env-token")
+	if got := ReadOreCredentials("gems.my-company.com"); got != "env-token" {
+		t.Errorf("expected ORE_CREDENTIALS_ value, got %q", got)
+	}
+}
+
+func TestReadBundleIgnoreMessages(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BUNDLE_IGNORE_MESSAGES", "true")
+	t.Setenv("BUNDLE_IGNORE_MESSAGES__RAILS", "false")
+	t.Setenv("BUNDLE_IGNORE_MESSAGES__NET___SSH", "true")
+
+	ignore := ReadBundleIgnoreMessages()
+	if !ignore.Ignores("sass") {
+		t.Error("expected ignore_messages to silence sass")
+	}
+	if ignore.Ignores("rails") {
+		t.Error("expected ignore_messages.rails false to keep rails' message")
+	}
+	if !ignore.Ignores("net-ssh") {
+		t.Error("expected ignore_messages.net-ssh to silence net-ssh")
+	}
+}
+
+func TestWriteBundleConfigKeepsSettings(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BUNDLE_JOBS", "")
+
+	if err := os.MkdirAll(".bundle", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(".bundle/config", []byte("BUNDLE_JOBS: \"4\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteBundleConfig("vendor/bundle"); err != nil {
+		t.Fatalf("WriteBundleConfig returned error: %v", err)
+	}
+
+	if got := ReadBundleConfigPath(); got != "vendor/bundle" {
+		t.Errorf("ReadBundleConfigPath() = %q, want vendor/bundle", got)
+	}
+	if got := ReadBundleJobs(); got != 4 {
+		t.Errorf("expected BUNDLE_JOBS to survive, got %d", got)
+	}
+}
+
+func TestReadBundleGroupsSeparators(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BUNDLE_WITHOUT", "development:test")
+	t.Setenv("BUNDLE_WITH", "")
+
+	groups := ReadBundleGroups()
+	if got := SplitBundleGroups(groups.Without); !slices.Equal(got, []string{"development", "test"}) {
+		t.Errorf("expected development:test to be two groups, got %q", got)
+	}
+	if got := SplitBundleGroups(groups.With); got != nil {
+		t.Errorf("expected no groups from an empty BUNDLE_WITH, got %q", got)
+	}
+
+	for value, want := range map[string][]string{
+		"development test":   {"development", "test"},
+		"development, test":  {"development", "test"},
+		":development::test": {"development", "test"},
+		"staging":            {"staging"},
+	} {
+		if got := SplitBundleGroups(value); !slices.Equal(got, want) {
+			t.Errorf("SplitBundleGroups(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
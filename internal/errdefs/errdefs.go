@@ -0,0 +1,48 @@
+// Package errdefs defines the categories of failure ore reports, so callers can tell
+// them apart with errors.Is instead of matching message text
+package errdefs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrGemfileNotFound means the Gemfile (or gems.rb) doesn't exist
+	ErrGemfileNotFound = errors.New("gemfile not found")
+
+	// ErrLockfileNotFound means the Gemfile has no Gemfile.lock (or gems.locked) yet
+	ErrLockfileNotFound = errors.New("lockfile not found")
+
+	// ErrResolutionFailed means no set of gem versions satisfies the Gemfile
+	ErrResolutionFailed = errors.New("could not resolve dependencies")
+
+	// ErrGemNotCached means a gem to install isn't in the ore cache; run ore download
+	ErrGemNotCached = errors.New("gem not cached")
+
+	// ErrPlatformMismatch means the active Ruby engine or version isn't the one the
+	// Gemfile or lockfile requires
+	ErrPlatformMismatch = errors.New("platform mismatch")
+)
+
+// Newf formats an error like fmt.Errorf (including %w) that also matches kind with
+// errors.Is. The message is exactly what was formatted; kind only categorizes it.
+//
+//	errdefs.Newf(errdefs.ErrGemNotCached, "gem %s is not cached", name)
+func Newf(kind error, format string, a ...any) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, a...)}
+}
+
+// kindError pairs an error with its category
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
@@ -0,0 +1,24 @@
+package errdefs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestNewfKeepsMessageAndCause(t *testing.T) {
+	err := Newf(ErrLockfileNotFound, "failed to open lockfile: %w", fs.ErrNotExist)
+
+	if got, want := err.Error(), "failed to open lockfile: file does not exist"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, ErrLockfileNotFound) {
+		t.Error("expected errors.Is to match the kind")
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("expected errors.Is to match the wrapped cause")
+	}
+	if errors.Is(err, ErrGemfileNotFound) {
+		t.Error("expected errors.Is not to match another kind")
+	}
+}
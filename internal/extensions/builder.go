@@ -3,11 +3,15 @@ package extensions
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/contriboss/ore-light/internal/ruby"
 	rubyext "github.com/contriboss/ruby-extension-go"
@@ -20,14 +24,26 @@ type BuildConfig struct {
 	Verbose        bool
 	Parallel       int
 	RubyPath       string
-	VendorDir      string // Path to vendor directory (e.g., vendor/bundle) for GEM_HOME/GEM_PATH
+	VendorDir      string              // Path to vendor directory (e.g., vendor/bundle) for GEM_HOME/GEM_PATH
+	Timeout        time.Duration       // Per-gem build timeout (0 disables)
+	CFlags         string              // Extra CFLAGS for C/C++ extensions
+	LDFlags        string              // Extra LDFLAGS for C/C++ extensions
+	BuildOptions   map[string][]string // Per-gem extconf/configure args, keyed by gem name (bundle config build.<gem>)
 }
 
+// DefaultBuildTimeout bounds a single gem's extension build so a hung extconf.rb can't stall install
+const DefaultBuildTimeout = 10 * time.Minute
+
 // This is like RubyGems' ext builder but as a Go service object
 // Wraps ruby-extension-go for building native extensions (C, Rust, etc.)
 type Builder struct {
 	factory *rubyext.BuilderFactory
 	config  *BuildConfig
+	abiOnce sync.Once
+	abi     string // Ruby ABI used for build caching
+
+	extDirOnce sync.Once
+	extDir     string // RubyGems' extensions/<platform>/<api> for the Ruby, "" if unknown
 }
 
 // NewBuilder creates a new extension builder
@@ -52,6 +68,7 @@ type BuildResult struct {
 	Skipped             bool
 	Error               error
 	MissingDependencies []string // Build-time dependencies that were missing (e.g., rake)
+	LogPath             string   // Full build output (vendor/extensions/<gem>/build.log)
 }
 
 // HasExtensions checks if a gem directory contains extensions compatible with the given Ruby engine
@@ -96,6 +113,12 @@ func HasExtensions(gemDir string, engine ruby.Engine) (bool, []string, error) {
 		if isJavaFile && engine.Name != ruby.EngineJRuby {
 			return nil
 		}
+		// JRuby can't load C (or other native) extensions: only Java sources and the
+		// Rakefiles that compile them (rake-compiler's JavaExtensionTask) build there
+		isRakefile := name == "Rakefile" || name == "rakefile"
+		if engine.Name == ruby.EngineJRuby && !isJavaFile && !isRakefile {
+			return nil
+		}
 
 		isLangFile := isJavaFile || isOtherLangFile
 
@@ -113,10 +136,50 @@ func HasExtensions(gemDir string, engine ruby.Engine) (bool, []string, error) {
 	if err != nil {
 		return false, nil, err
 	}
+	if engine.Name == ruby.EngineJRuby {
+		extensions = javaExtensions(extensions)
+	}
 
 	return len(extensions) > 0, extensions, nil
 }
 
+// javaExtensions narrows a JRuby extension list to what builds Java: Java sources, and
+// the Rakefiles whose directory holds them (rake-compiler's JavaExtensionTask). Like gem
+// install, such a Rakefile compiles its Java sources into the gem's .jar, so they're
+// dropped. A Rakefile with no Java beside it builds C (ExtensionTask) and is dropped.
+func javaExtensions(extensions []string) []string {
+	isJava := func(ext string) bool {
+		name := filepath.Base(ext)
+		return name == "build.xml" || name == "pom.xml" || strings.ToLower(filepath.Ext(ext)) == ".java"
+	}
+	// under reports whether path is inside the directory of the Rakefile rakefile
+	under := func(path, rakefile string) bool {
+		dir := filepath.Dir(rakefile)
+		return dir == "." || strings.HasPrefix(path, dir+string(filepath.Separator))
+	}
+
+	var rakefiles []string
+	for _, ext := range extensions {
+		if !strings.EqualFold(filepath.Base(ext), "rakefile") {
+			continue
+		}
+		for _, other := range extensions {
+			if isJava(other) && under(other, ext) {
+				rakefiles = append(rakefiles, ext)
+				break
+			}
+		}
+	}
+
+	kept := slices.Clone(rakefiles)
+	for _, ext := range extensions {
+		if isJava(ext) && !slices.ContainsFunc(rakefiles, func(rakefile string) bool { return under(ext, rakefile) }) {
+			kept = append(kept, ext)
+		}
+	}
+	return kept
+}
+
 // BuildExtensions builds all extensions for a gem compatible with the given Ruby engine
 func (b *Builder) BuildExtensions(ctx context.Context, gemDir, gemName string, engine ruby.Engine) (*BuildResult, error) {
 	result := &BuildResult{
@@ -147,7 +210,7 @@ func (b *Builder) BuildExtensions(ctx context.Context, gemDir, gemName string, e
 	// Verify Ruby is available
 	rubyPath := b.config.RubyPath
 	if rubyPath == "" {
-		rubyPath = "ruby"
+		rubyPath = ruby.Binary()
 	}
 
 	if _, err := exec.LookPath(rubyPath); err != nil {
@@ -155,6 +218,18 @@ func (b *Builder) BuildExtensions(ctx context.Context, gemDir, gemName string, e
 		return result, result.Error
 	}
 
+	// Reuse previously built extensions when sources are unchanged and Ruby's ABI matches
+	abi := b.rubyABI(rubyPath)
+	if IsBuildCached(gemDir, abi) {
+		if b.config.Verbose {
+			fmt.Printf("Using cached extensions for %s (%s)\n", gemName, abi)
+		}
+		b.writeRubyGemsLayout(gemDir, gemName, rubyPath)
+		result.Skipped = true
+		result.Success = true
+		return result, nil
+	}
+
 	// Get Ruby version
 	rubyVersion, err := getRubyVersion(rubyPath)
 	if err != nil {
@@ -170,9 +245,14 @@ func (b *Builder) BuildExtensions(ctx context.Context, gemDir, gemName string, e
 		Verbose:     b.config.Verbose,
 		Parallel:    b.config.Parallel,
 		Env:         b.buildGemEnvironment(),
+		BuildArgs:   b.buildOptionsFor(gemName),
 		// StopOnFailure: true, // Stop on first failure
 	}
 
+	if b.config.Verbose && len(buildConfig.BuildArgs) > 0 {
+		fmt.Printf("Using build options for %s: %s\n", gemName, strings.Join(buildConfig.BuildArgs, " "))
+	}
+
 	// Check tool availability before building
 	if err := b.checkToolsForExtensions(extensions); err != nil {
 		// Warn about missing tools but continue (some gems might have optional extensions)
@@ -181,8 +261,22 @@ func (b *Builder) BuildExtensions(ctx context.Context, gemDir, gemName string, e
 		}
 	}
 
+	// Enforce the per-gem timeout through the context
+	buildCtx := ctx
+	if b.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		buildCtx, cancel = context.WithTimeout(ctx, b.config.Timeout)
+		defer cancel()
+	}
+
 	// Build all extensions
-	results, err := b.factory.BuildAllExtensions(ctx, buildConfig, extensions)
+	results, err := b.factory.BuildAllExtensions(buildCtx, buildConfig, extensions)
+	if err != nil && errors.Is(buildCtx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("build timed out after %s: %w", b.config.Timeout, err)
+	}
+
+	// Always keep the full build output, like gem install's mkmf.log
+	result.LogPath = b.writeBuildLog(gemName, results, err)
 
 	// Process results even if there's an error, to collect MissingDependencies
 	var builtExtensions []string
@@ -220,9 +314,79 @@ func (b *Builder) BuildExtensions(ctx context.Context, gemDir, gemName string, e
 
 	result.Extensions = builtExtensions
 	result.Success = true
+
+	// Record the ABI so the next install can skip this build
+	if err := WriteBuildMarker(gemDir, abi); err != nil && b.config.Verbose {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record build marker for %s: %v\n", gemName, err)
+	}
+
+	b.writeRubyGemsLayout(gemDir, gemName, rubyPath)
+
 	return result, nil
 }
 
+// writeRubyGemsLayout mirrors a built gem into the vendor dir's extensions/ and
+// build_info/ directories, like `gem install`. Best effort: ore itself loads the
+// extensions from lib/, so a failure here only affects RubyGems tools.
+func (b *Builder) writeRubyGemsLayout(gemDir, gemName, rubyPath string) {
+	if b.config.VendorDir == "" {
+		return
+	}
+	b.extDirOnce.Do(func() {
+		b.extDir, _ = DetectExtensionDir(rubyPath)
+	})
+	if b.extDir == "" {
+		return
+	}
+	if err := WriteRubyGemsExtensionLayout(b.config.VendorDir, b.extDir, gemDir, gemName, b.buildOptionsFor(gemName)); err != nil && b.config.Verbose {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write RubyGems extension layout for %s: %v\n", gemName, err)
+	}
+}
+
+// writeBuildLog writes the combined output of all extension builds for a gem
+// to vendor/extensions/<gem>/build.log and returns its path ("" if not written)
+func (b *Builder) writeBuildLog(gemName string, results []*rubyext.BuildResult, buildErr error) string {
+	if b.config.VendorDir == "" {
+		return ""
+	}
+
+	logDir := filepath.Join(b.config.VendorDir, "extensions", gemName)
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		return ""
+	}
+
+	var log strings.Builder
+	for _, extResult := range results {
+		if extResult == nil {
+			continue
+		}
+		for _, line := range extResult.Output {
+			log.WriteString(line)
+			log.WriteString("\n")
+		}
+		if extResult.Error != nil {
+			fmt.Fprintf(&log, "error: %v\n", extResult.Error)
+		}
+	}
+	if buildErr != nil {
+		fmt.Fprintf(&log, "build failed: %v\n", buildErr)
+	}
+
+	logPath := filepath.Join(logDir, "build.log")
+	if err := os.WriteFile(logPath, []byte(log.String()), 0o644); err != nil {
+		return ""
+	}
+	return logPath
+}
+
+// rubyABI returns the ABI identifier for rubyPath, detected once per builder
+func (b *Builder) rubyABI(rubyPath string) string {
+	b.abiOnce.Do(func() {
+		b.abi, _ = DetectRubyABI(rubyPath)
+	})
+	return b.abi
+}
+
 // checkToolsForExtensions checks if required build tools are available for the extensions
 func (b *Builder) checkToolsForExtensions(extensions []string) error {
 	var missingTools []string
@@ -261,7 +425,15 @@ func (b *Builder) checkToolsForExtensions(extensions []string) error {
 func (b *Builder) buildGemEnvironment() map[string]string {
 	env := make(map[string]string)
 
-	// If no vendor directory configured, return empty env
+	// Compiler flags apply regardless of where gems are installed
+	if b.config.CFlags != "" {
+		env["CFLAGS"] = b.config.CFlags
+	}
+	if b.config.LDFlags != "" {
+		env["LDFLAGS"] = b.config.LDFlags
+	}
+
+	// If no vendor directory configured, only compiler flags are needed
 	if b.config.VendorDir == "" {
 		return env
 	}
@@ -285,6 +457,21 @@ func (b *Builder) buildGemEnvironment() map[string]string {
 	return env
 }
 
+// buildOptionsFor returns the configured build arguments for a gem.
+// gemName may be a full name like "mysql2-0.5.6" or "nokogiri-1.16.0-x86_64-linux".
+func (b *Builder) buildOptionsFor(gemName string) []string {
+	if args, ok := b.config.BuildOptions[gemName]; ok {
+		return args
+	}
+	for name, args := range b.config.BuildOptions {
+		rest, found := strings.CutPrefix(gemName, name+"-")
+		if found && rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+			return args
+		}
+	}
+	return nil
+}
+
 // getRubyVersion executes ruby -v and extracts the version
 func getRubyVersion(rubyPath string) (string, error) {
 	cmd := exec.Command(rubyPath, "-v")
@@ -315,8 +502,8 @@ func ShouldSkipExtensions() bool {
 	return false
 }
 
-// IsRubyAvailable checks if Ruby is available in PATH
+// IsRubyAvailable checks if the selected Ruby (see ruby.Binary) is available
 func IsRubyAvailable() bool {
-	_, err := exec.LookPath("ruby")
+	_, err := exec.LookPath(ruby.Binary())
 	return err == nil
 }
@@ -2,11 +2,14 @@ package extensions
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/contriboss/ore-light/internal/ruby"
+	rubyext "github.com/contriboss/ruby-extension-go"
 )
 
 func TestHasExtensions(t *testing.T) {
@@ -349,3 +352,120 @@ func TestNewBuilder(t *testing.T) {
 		}
 	})
 }
+
+func TestWriteBuildLog(t *testing.T) {
+	vendorDir := t.TempDir()
+	builder := NewBuilder(&BuildConfig{VendorDir: vendorDir})
+
+	results := []*rubyext.BuildResult{
+		{Output: []string{"checking for foo.h... no", "*** extconf.rb failed ***"}},
+	}
+	logPath := builder.writeBuildLog("mygem-1.0.0", results, fmt.Errorf("one or more extensions failed"))
+
+	expected := filepath.Join(vendorDir, "extensions", "mygem-1.0.0", "build.log")
+	if logPath != expected {
+		t.Fatalf("expected log at %s, got %q", expected, logPath)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read build log: %v", err)
+	}
+	if !strings.Contains(string(data), "extconf.rb failed") || !strings.Contains(string(data), "build failed") {
+		t.Errorf("build log missing output: %s", data)
+	}
+}
+
+func TestBuildOptionsFor(t *testing.T) {
+	builder := NewBuilder(&BuildConfig{
+		BuildOptions: map[string][]string{
+			"mysql2":   {"--with-mysql-dir=/opt/mysql"},
+			"nokogiri": {"--use-system-libraries"},
+		},
+	})
+
+	tests := []struct {
+		gemName string
+		want    string
+	}{
+		{"mysql2", "--with-mysql-dir=/opt/mysql"},
+		{"mysql2-0.5.6", "--with-mysql-dir=/opt/mysql"},
+		{"nokogiri-1.16.0-x86_64-linux", "--use-system-libraries"},
+		{"nokogiri-diff-0.3.0", ""},
+		{"pg-1.5.4", ""},
+	}
+
+	for _, tt := range tests {
+		got := strings.Join(builder.buildOptionsFor(tt.gemName), " ")
+		if got != tt.want {
+			t.Errorf("buildOptionsFor(%q) = %q, want %q", tt.gemName, got, tt.want)
+		}
+	}
+
+	env := NewBuilder(&BuildConfig{CFlags: "-O2", LDFlags: "-L/opt/lib"}).buildGemEnvironment()
+	if env["CFLAGS"] != "-O2" || env["LDFLAGS"] != "-L/opt/lib" {
+		t.Errorf("buildGemEnvironment() = %v, want CFLAGS and LDFLAGS set", env)
+	}
+}
+
+func TestHasExtensionsJRuby(t *testing.T) {
+	jruby := ruby.Engine{Name: ruby.EngineJRuby, Version: "9.4.5.0"}
+	write := func(t *testing.T, dir string, files ...string) {
+		t.Helper()
+		for _, file := range files {
+			path := filepath.Join(dir, file)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(""), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// C extensions can't be built on JRuby
+	cGem := t.TempDir()
+	write(t, cGem, "ext/bcrypt/extconf.rb", "ext/bcrypt/Makefile")
+	if has, exts, err := HasExtensions(cGem, jruby); err != nil || has {
+		t.Errorf("expected no JRuby-buildable extensions in a C gem, got %v (%v)", exts, err)
+	}
+	if needs, err := NeedsBuild(cGem, jruby); err != nil || needs {
+		t.Errorf("expected a C gem not to need a build on JRuby, got %v (%v)", needs, err)
+	}
+
+	// A Rakefile building C (rake-compiler's ExtensionTask) isn't a Java build
+	rakeCGem := t.TempDir()
+	write(t, rakeCGem, "ext/Rakefile", "ext/ox/extconf.rb", "ext/ox/ox.c")
+	if has, exts, err := HasExtensions(rakeCGem, jruby); err != nil || has {
+		t.Errorf("expected no JRuby-buildable extensions in a C gem with a Rakefile, got %v (%v)", exts, err)
+	}
+	mixedGem := t.TempDir()
+	write(t, mixedGem, "ext/ox/Rakefile", "ext/ox/ox.c", "ext/java/Rakefile", "ext/java/Ox.java")
+	if _, exts, err := HasExtensions(mixedGem, jruby); err != nil || len(exts) != 1 || exts[0] != filepath.Join("ext", "java", "Rakefile") {
+		t.Errorf("expected only the Java Rakefile, got %v (%v)", exts, err)
+	}
+
+	// Java sources are built, through the Rakefile when there is one
+	javaGem := t.TempDir()
+	write(t, javaGem, "ext/Rakefile", "ext/java/JrJacksonService.java", "ext/java/RubyJacksonModule.java")
+	has, exts, err := HasExtensions(javaGem, jruby)
+	if err != nil || !has || len(exts) != 1 || exts[0] != filepath.Join("ext", "Rakefile") {
+		t.Errorf("expected the Rakefile to build the Java extension, got %v (%v)", exts, err)
+	}
+	if needs, err := NeedsBuild(javaGem, jruby); err != nil || !needs {
+		t.Errorf("expected an unbuilt Java extension to need a build on JRuby, got %v (%v)", needs, err)
+	}
+
+	// Without a Rakefile the Java sources are compiled directly
+	javacGem := t.TempDir()
+	write(t, javacGem, "ext/java/Puma.java")
+	if has, exts, err := HasExtensions(javacGem, jruby); err != nil || !has || len(exts) != 1 {
+		t.Errorf("expected the Java source as the extension, got %v (%v)", exts, err)
+	}
+
+	// Once the jar is built, nothing is left to do
+	write(t, javaGem, "lib/jrjackson/jars/jrjackson-1.2.35.jar")
+	if needs, err := NeedsBuild(javaGem, jruby); err != nil || needs {
+		t.Errorf("expected a built Java extension not to need a build, got %v (%v)", needs, err)
+	}
+}
@@ -0,0 +1,148 @@
+package extensions
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/contriboss/ore-light/internal/ruby"
+)
+
+// buildMarkerName is written to a gem's ext/ directory after a successful build.
+// It records the Ruby ABI the extensions were compiled against.
+const buildMarkerName = ".ore-build-abi"
+
+// artifactExtensions are compiled extension outputs (see hasCompiledArtifacts)
+var artifactExtensions = []string{".so", ".bundle", ".dll", ".dylib", ".jar"}
+
+// DetectRubyABI returns an identifier for the ABI native extensions are built against,
+// e.g. "ruby 3.4.0 x86_64-linux". Changes on Ruby upgrades or engine switches.
+//
+// Ruby developers: RbConfig::CONFIG["ruby_version"] is the ABI version
+// (the "3.4.0" in ~/.gem/ruby/3.4.0), not the full RUBY_VERSION
+func DetectRubyABI(rubyPath string) (string, error) {
+	if rubyPath == "" {
+		rubyPath = ruby.Binary()
+	}
+	cmd := exec.Command(rubyPath, "-e", `print RUBY_ENGINE, " ", RbConfig::CONFIG["ruby_version"], " ", RbConfig::CONFIG["arch"]`)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+var (
+	defaultABIOnce sync.Once
+	defaultABI     string
+)
+
+// defaultRubyABI returns the ABI of the selected Ruby, detected once per process
+func defaultRubyABI() string {
+	defaultABIOnce.Do(func() {
+		defaultABI, _ = DetectRubyABI(ruby.Binary())
+	})
+	return defaultABI
+}
+
+// IsBuildCached reports whether a gem's compiled extensions can be reused:
+// the ABI marker must match abi and every artifact under lib/ must be newer
+// than all extension sources under ext/.
+func IsBuildCached(gemDir, abi string) bool {
+	if abi == "" || readBuildMarker(gemDir) != abi {
+		return false
+	}
+
+	oldestArtifact, ok := oldestArtifactTime(filepath.Join(gemDir, "lib"))
+	if !ok {
+		return false
+	}
+
+	return !newestSourceTime(filepath.Join(gemDir, "ext")).After(oldestArtifact)
+}
+
+// WriteBuildMarker records the ABI a gem's extensions were built against
+func WriteBuildMarker(gemDir, abi string) error {
+	if abi == "" {
+		return nil
+	}
+	extDir := filepath.Join(gemDir, "ext")
+	if err := os.MkdirAll(extDir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(extDir, buildMarkerName), []byte(abi+"\n"), 0o644)
+}
+
+// readBuildMarker returns the recorded ABI, or "" if no build has been recorded
+func readBuildMarker(gemDir string) string {
+	data, err := os.ReadFile(filepath.Join(gemDir, "ext", buildMarkerName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// oldestArtifactTime returns the modification time of the oldest compiled artifact in dir
+func oldestArtifactTime(dir string) (time.Time, bool) {
+	var oldest time.Time
+	found := false
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isArtifact(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if !found || info.ModTime().Before(oldest) {
+			oldest = info.ModTime()
+			found = true
+		}
+		return nil
+	})
+	return oldest, found
+}
+
+// newestSourceTime returns the newest modification time of extension sources in dir.
+// Build outputs (objects, artifacts, logs, Makefiles) and the marker are ignored.
+func newestSourceTime(dir string) time.Time {
+	var newest time.Time
+	_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || isBuildOutput(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	return newest
+}
+
+// isArtifact reports whether path is a compiled extension
+func isArtifact(path string) bool {
+	ext := filepath.Ext(path)
+	for _, candidate := range artifactExtensions {
+		if ext == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// isBuildOutput reports whether path was produced by a build rather than shipped in the gem
+func isBuildOutput(path string) bool {
+	switch filepath.Base(path) {
+	case buildMarkerName, "Makefile", "mkmf.log", "extconf.h", "gem_make.out":
+		return true
+	}
+	ext := filepath.Ext(path)
+	return ext == ".o" || ext == ".obj" || isArtifact(path)
+}
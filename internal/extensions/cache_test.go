@@ -0,0 +1,61 @@
+package extensions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIsBuildCached(t *testing.T) {
+	const abi = "ruby 3.4.0 x86_64-linux"
+
+	gemDir := t.TempDir()
+	extDir := filepath.Join(gemDir, "ext", "myext")
+	libDir := filepath.Join(gemDir, "lib", "myext")
+	for _, dir := range []string{extDir, libDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := filepath.Join(extDir, "myext.c")
+	artifact := filepath.Join(libDir, "myext.so")
+	if err := os.WriteFile(source, []byte("int x;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(artifact, []byte("ELF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(source, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	// No marker yet: nothing to reuse
+	if IsBuildCached(gemDir, abi) {
+		t.Fatal("expected cache miss without a build marker")
+	}
+
+	if err := WriteBuildMarker(gemDir, abi); err != nil {
+		t.Fatalf("WriteBuildMarker failed: %v", err)
+	}
+	if !IsBuildCached(gemDir, abi) {
+		t.Fatal("expected cache hit with matching ABI and fresh artifact")
+	}
+
+	// Ruby upgrade invalidates the cache
+	if IsBuildCached(gemDir, "ruby 3.5.0 x86_64-linux") {
+		t.Error("expected cache miss after ABI change")
+	}
+
+	// Editing a source after the build invalidates the cache
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(source, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if IsBuildCached(gemDir, abi) {
+		t.Error("expected cache miss when sources are newer than artifacts")
+	}
+}
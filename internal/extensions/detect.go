@@ -8,10 +8,12 @@ import (
 )
 
 // NeedsBuild checks if a gem directory needs extension building.
-// It returns true if the gem has extension sources but no compiled artifacts.
+// It returns true if the gem has extension sources but no compiled artifacts,
+// or if the artifacts were built by ore against a different Ruby ABI.
 func NeedsBuild(gemDir string, engine ruby.Engine) (bool, error) {
-	// Short-circuit: Skip engines that don't support native extensions
-	if !engine.SupportsNativeExtensions() {
+	// Short-circuit: Skip engines that don't support native extensions. JRuby still
+	// builds Java extensions.
+	if !engine.SupportsNativeExtensions() && engine.Name != ruby.EngineJRuby {
 		return false, nil
 	}
 
@@ -22,7 +24,16 @@ func NeedsBuild(gemDir string, engine ruby.Engine) (bool, error) {
 	}
 
 	// Check if compiled artifacts already exist
-	return !hasCompiledArtifacts(gemDir), nil
+	if !hasCompiledArtifacts(gemDir) {
+		return true, nil
+	}
+
+	// Artifacts built by ore are stale after a Ruby upgrade
+	if marker := readBuildMarker(gemDir); marker != "" && defaultRubyABI() != "" && marker != defaultRubyABI() {
+		return true, nil
+	}
+
+	return false, nil
 }
 
 // hasCompiledArtifacts checks for compiled extension files in the gem directory.
@@ -0,0 +1,102 @@
+package extensions
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// buildCompleteName marks a finished build in a RubyGems extension directory
+const buildCompleteName = "gem.build_complete"
+
+// DetectExtensionDir returns where RubyGems keeps a Ruby's compiled extensions,
+// relative to the gem dir: extensions/<platform>/<extension api version>.
+//
+// Ruby developers: this is Gem::BasicSpecification#extensions_dir, e.g.
+// extensions/x86_64-linux/3.4.0 (or 3.4.0-static for a static libruby)
+func DetectExtensionDir(rubyPath string) (string, error) {
+	cmd := exec.Command(rubyPath, "-e", `print Gem::Platform.local.to_s, "/", Gem.extension_api_version`)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	platform, api, ok := strings.Cut(strings.TrimSpace(string(output)), "/")
+	if !ok || platform == "" || api == "" {
+		return "", fmt.Errorf("unexpected extension dir %q", output)
+	}
+	return filepath.Join("extensions", platform, api), nil
+}
+
+// WriteRubyGemsExtensionLayout records a built gem the way `gem install` does, so
+// RubyGems tools (gem list, gem pristine) see it as complete: the compiled artifacts
+// under lib/ are copied to <gem dir>/<extensionDir>/<gem>/ with a gem.build_complete
+// marker, and non-empty build args go to build_info/<gem>.info.
+func WriteRubyGemsExtensionLayout(vendorDir, extensionDir, gemDir, gemName string, buildArgs []string) error {
+	destDir := filepath.Join(vendorDir, extensionDir, gemName)
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return err
+	}
+
+	libDir := filepath.Join(gemDir, "lib")
+	err := filepath.WalkDir(libDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if d.IsDir() || !isArtifact(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(libDir, path)
+		if err != nil {
+			return err
+		}
+		return copyArtifact(path, filepath.Join(destDir, rel))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to copy extensions for %s: %w", gemName, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(destDir, buildCompleteName), nil, 0o644); err != nil {
+		return err
+	}
+
+	if len(buildArgs) == 0 {
+		return nil
+	}
+	infoDir := filepath.Join(vendorDir, "build_info")
+	if err := os.MkdirAll(infoDir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(infoDir, gemName+".info"), []byte(strings.Join(buildArgs, "\n")+"\n"), 0o644)
+}
+
+// copyArtifact copies a compiled extension, keeping its permissions
+func copyArtifact(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
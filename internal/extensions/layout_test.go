@@ -0,0 +1,45 @@
+package extensions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteRubyGemsExtensionLayout(t *testing.T) {
+	vendorDir := t.TempDir()
+	gemDir := filepath.Join(vendorDir, "gems", "nio4r-2.7.0")
+	for rel, content := range map[string]string{
+		"lib/nio4r_ext.so": "elf",
+		"lib/nio.rb":       "require 'nio4r_ext'",
+		"ext/nio4r/nio.c":  "int main;",
+	} {
+		path := filepath.Join(gemDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	extensionDir := filepath.Join("extensions", "x86_64-linux", "3.4.0")
+	if err := WriteRubyGemsExtensionLayout(vendorDir, extensionDir, gemDir, "nio4r-2.7.0", []string{"--with-cflags=-O2"}); err != nil {
+		t.Fatalf("WriteRubyGemsExtensionLayout: %v", err)
+	}
+
+	destDir := filepath.Join(vendorDir, extensionDir, "nio4r-2.7.0")
+	for _, name := range []string{"nio4r_ext.so", buildCompleteName} {
+		if _, err := os.Stat(filepath.Join(destDir, name)); err != nil {
+			t.Errorf("expected %s in extension dir: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(destDir, "nio.rb")); err == nil {
+		t.Error("expected only compiled artifacts to be copied")
+	}
+
+	info, err := os.ReadFile(filepath.Join(vendorDir, "build_info", "nio4r-2.7.0.info"))
+	if err != nil || string(info) != "--with-cflags=-O2\n" {
+		t.Errorf("expected build args in build_info, got %q (%v)", info, err)
+	}
+}
@@ -9,24 +9,9 @@ import (
 
 // LinkGemBinaries creates binstub wrappers for gem executables
 func LinkGemBinaries(gemDir, binDir string) error {
-	// Modern gems use exe/ directory, older ones use bin/
-	// Try exe/ first, fall back to bin/
-	exeDir := filepath.Join(gemDir, "exe")
-	entries, err := os.ReadDir(exeDir)
+	exeDir, names, err := GemExecutables(gemDir)
 	if err != nil {
-		if os.IsNotExist(err) {
-			// Try bin/ directory as fallback
-			exeDir = filepath.Join(gemDir, "bin")
-			entries, err = os.ReadDir(exeDir)
-			if err != nil {
-				if os.IsNotExist(err) {
-					return nil
-				}
-				return err
-			}
-		} else {
-			return err
-		}
+		return err
 	}
 
 	// Get gem name from directory (e.g., "vendor/gems/rake-13.3.0" -> "rake-13.3.0")
@@ -35,8 +20,7 @@ func LinkGemBinaries(gemDir, binDir string) error {
 	// Get vendor root (parent of gems directory)
 	vendorRoot := filepath.Dir(filepath.Dir(gemDir))
 
-	for _, entry := range entries {
-		execName := entry.Name()
+	for _, execName := range names {
 		originalExec := filepath.Join(exeDir, execName)
 		binstubPath := filepath.Join(binDir, execName)
 
@@ -49,24 +33,105 @@ func LinkGemBinaries(gemDir, binDir string) error {
 	return nil
 }
 
+// GemExecutables returns the directory holding an installed gem's executables and
+// their names. Modern gems use exe/, older ones bin/; a gem with neither has none.
+func GemExecutables(gemDir string) (string, []string, error) {
+	for _, dir := range []string{"exe", "bin"} {
+		exeDir := filepath.Join(gemDir, dir)
+		entries, err := os.ReadDir(exeDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return exeDir, names, nil
+	}
+	return "", nil, nil
+}
+
+// binstubMarker identifies binstubs ore wrote, so regenerating never overwrites a
+// hand-written script (like a Rails app's bin/rails)
+const binstubMarker = "# This file was generated by ore-light."
+
+// WriteProjectBinstubs writes project binstubs (like `bundle install --binstubs`) for an
+// installed gem's executables into binDir, usually the app's bin/. Unlike the wrappers
+// in <vendor>/bin they find the vendor directory relative to themselves, so they keep
+// working when the project is checked out elsewhere. Existing files ore didn't write
+// are left alone and returned as skipped.
+func WriteProjectBinstubs(gemDir, binDir string) (written, skipped []string, err error) {
+	exeDir, names, err := GemExecutables(gemDir)
+	if err != nil || len(names) == 0 {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		return nil, nil, err
+	}
+
+	vendorRoot := filepath.Dir(filepath.Dir(gemDir))
+	for _, execName := range names {
+		binstubPath := filepath.Join(binDir, execName)
+		if existing, err := os.ReadFile(binstubPath); err == nil && !strings.Contains(string(existing), binstubMarker) {
+			skipped = append(skipped, binstubPath)
+			continue
+		}
+
+		content := binstubContent(execName, relativeRubyPath(binDir, vendorRoot), relativeRubyPath(binDir, filepath.Join(exeDir, execName)))
+		if err := os.WriteFile(binstubPath, []byte(content), 0o755); err != nil {
+			return written, skipped, fmt.Errorf("failed to create binstub for %s: %w", execName, err)
+		}
+		written = append(written, binstubPath)
+	}
+	return written, skipped, nil
+}
+
+// relativeRubyPath returns a Ruby expression for target, relative to the script in dir
+func relativeRubyPath(dir, target string) string {
+	absDir, errDir := filepath.Abs(dir)
+	absTarget, errTarget := filepath.Abs(target)
+	if errDir != nil || errTarget != nil {
+		return fmt.Sprintf("%q", target)
+	}
+	rel, err := filepath.Rel(absDir, absTarget)
+	if err != nil {
+		return fmt.Sprintf("%q", absTarget)
+	}
+	return fmt.Sprintf("File.expand_path(%q, __dir__)", filepath.ToSlash(rel))
+}
+
 // createBinstub creates a Ruby wrapper script (binstub) for a gem executable
 func createBinstub(binstubPath, originalExec, gemName, vendorRoot string) error {
 	execName := filepath.Base(originalExec)
+	content := binstubContent(execName, fmt.Sprintf("\"%s\"", vendorRoot), fmt.Sprintf("\"%s\"", originalExec))
 
+	// Write binstub file
+	if err := os.WriteFile(binstubPath, []byte(content), 0755); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// binstubContent returns the binstub script; vendorRoot and exec are Ruby expressions
+func binstubContent(execName, vendorRoot, exec string) string {
 	// Create binstub content - manually construct to ensure proper Ruby syntax
 	var binstub strings.Builder
 	binstub.WriteString("#!/usr/bin/env ruby\n")
 	binstub.WriteString("# frozen_string_literal: true\n")
 	binstub.WriteString("\n")
 	binstub.WriteString("#\n")
-	binstub.WriteString("# This file was generated by ore-light.\n")
+	binstub.WriteString(binstubMarker + "\n")
 	binstub.WriteString("#\n")
 	binstub.WriteString(fmt.Sprintf("# The application '%s' is installed as part of a gem, and\n", execName))
 	binstub.WriteString("# this file is here to facilitate running it.\n")
 	binstub.WriteString("#\n")
 	binstub.WriteString("\n")
 	binstub.WriteString("# Set up gem environment for ore-light vendor directory\n")
-	binstub.WriteString(fmt.Sprintf("vendor_root = \"%s\"\n", vendorRoot))
+	binstub.WriteString(fmt.Sprintf("vendor_root = %s\n", vendorRoot))
 	binstub.WriteString("ENV[\"GEM_HOME\"] = vendor_root\n")
 	binstub.WriteString("ENV[\"GEM_PATH\"] = vendor_root\n")
 	binstub.WriteString("\n")
@@ -79,12 +144,7 @@ func createBinstub(binstubPath, originalExec, gemName, vendorRoot string) error
 	binstub.WriteString("end\n")
 	binstub.WriteString("\n")
 	binstub.WriteString("# Load the actual executable\n")
-	binstub.WriteString(fmt.Sprintf("load \"%s\"\n", originalExec))
+	binstub.WriteString(fmt.Sprintf("load %s\n", exec))
 
-	// Write binstub file
-	if err := os.WriteFile(binstubPath, []byte(binstub.String()), 0755); err != nil {
-		return err
-	}
-
-	return nil
+	return binstub.String()
 }
@@ -0,0 +1,62 @@
+package geminstall
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteProjectBinstubs(t *testing.T) {
+	project := t.TempDir()
+	gemDir := filepath.Join(project, "vendor", "ruby", "gems", "rake-13.1.0")
+	if err := os.MkdirAll(filepath.Join(gemDir, "exe"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"rake", "rails"} {
+		if err := os.WriteFile(filepath.Join(gemDir, "exe", name), []byte("puts 1\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	binDir := filepath.Join(project, "bin")
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	custom := "#!/usr/bin/env ruby\nAPP_PATH = File.expand_path(\"../config/application\", __dir__)\n"
+	if err := os.WriteFile(filepath.Join(binDir, "rails"), []byte(custom), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	written, skipped, err := WriteProjectBinstubs(gemDir, binDir)
+	if err != nil {
+		t.Fatalf("WriteProjectBinstubs: %v", err)
+	}
+	if len(written) != 1 || filepath.Base(written[0]) != "rake" {
+		t.Errorf("expected only bin/rake to be written, got %v", written)
+	}
+	if len(skipped) != 1 || filepath.Base(skipped[0]) != "rails" {
+		t.Errorf("expected the hand-written bin/rails to be skipped, got %v", skipped)
+	}
+
+	content, err := os.ReadFile(filepath.Join(binDir, "rake"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`vendor_root = File.expand_path("../vendor/ruby", __dir__)`,
+		`load File.expand_path("../vendor/ruby/gems/rake-13.1.0/exe/rake", __dir__)`,
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected binstub to contain %s, got:\n%s", want, content)
+		}
+	}
+	if kept, _ := os.ReadFile(filepath.Join(binDir, "rails")); string(kept) != custom {
+		t.Errorf("expected bin/rails to be left alone, got:\n%s", kept)
+	}
+
+	// Regenerating overwrites ore's own binstubs
+	if written, _, err := WriteProjectBinstubs(gemDir, binDir); err != nil || len(written) != 1 {
+		t.Errorf("expected bin/rake to be regenerated, got %v, %v", written, err)
+	}
+}
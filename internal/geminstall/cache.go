@@ -0,0 +1,115 @@
+package geminstall
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressedGemExt is appended to .gem files the ore cache stores compressed
+// (cache_compression = "zstd"), e.g. <cache>/rack-3.0.0.gem.zst
+const CompressedGemExt = ".zst"
+
+// GemFileName returns the cached .gem file name for a locked gem.
+// Gems are stored flat in each cache dir, e.g. <cache>/nokogiri-1.16.0-x86_64-linux.gem
+func GemFileName(gem lockfile.GemSpec) string {
+	return fmt.Sprintf("%s.gem", gem.FullName())
+}
+
+// FindCachedGem returns the path of gem's .gem file in the first cache dir that has it,
+// or "" if no cache holds it. A compressed copy counts; open it with OpenGem.
+func FindCachedGem(cacheDirs []string, gem lockfile.GemSpec) string {
+	fileName := GemFileName(gem)
+	for _, cacheDir := range cacheDirs {
+		for _, name := range []string{fileName, fileName + CompressedGemExt} {
+			path := filepath.Join(cacheDir, name)
+			if stat, err := os.Stat(path); err == nil && !stat.IsDir() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// IsCompressedGem reports whether a cached gem path is stored compressed
+func IsCompressedGem(path string) bool {
+	return strings.HasSuffix(path, ".gem"+CompressedGemExt)
+}
+
+// OpenGem opens a cached .gem file, transparently decompressing a compressed one
+func OpenGem(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !IsCompressedGem(path) {
+		return file, nil
+	}
+
+	decoder, err := zstd.NewReader(file, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to open compressed gem %s: %w", path, err)
+	}
+	return &compressedGem{Decoder: decoder, file: file}, nil
+}
+
+// compressedGem closes both the zstd stream and the underlying file
+type compressedGem struct {
+	*zstd.Decoder
+	file *os.File
+}
+
+func (c *compressedGem) Close() error {
+	c.Decoder.Close()
+	return c.file.Close()
+}
+
+// CompressGem writes a compressed copy of the .gem at srcPath to destPath atomically.
+// The frame header records the original size, so ore cache info can report savings
+// without decompressing anything.
+func CompressGem(srcPath, destPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(destPath), "ore-*.gem.zst")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return err
+	}
+	encoder.ResetContentSize(tmp, info.Size())
+	if _, err := io.Copy(encoder, src); err != nil {
+		_ = encoder.Close()
+		return fmt.Errorf("failed to compress %s: %w", srcPath, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to compress %s: %w", srcPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), destPath)
+}
@@ -0,0 +1,112 @@
+package geminstall
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/cache"
+)
+
+func TestCompressedGemCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	gem := lockfile.GemSpec{Name: "rack", Version: "3.0.0"}
+
+	plain := filepath.Join(t.TempDir(), GemFileName(gem))
+	gemData := buildTestGem(t, map[string]string{"lib/rack.rb": "module Rack; end\n"})
+	if err := os.WriteFile(plain, gemData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	compressed := filepath.Join(cacheDir, GemFileName(gem)+CompressedGemExt)
+	if err := CompressGem(plain, compressed); err != nil {
+		t.Fatalf("CompressGem returned error: %v", err)
+	}
+
+	// ore cache info reads the original size from the zstd frame header
+	stats, err := cache.CollectCompressionStats(cacheDir)
+	if err != nil || stats.Files != 1 || stats.OriginalSize != int64(len(gemData)) {
+		t.Errorf("expected one compressed gem of %d bytes, got %+v (err %v)", len(gemData), stats, err)
+	}
+
+	found := FindCachedGem([]string{cacheDir}, gem)
+	if found != compressed || !IsCompressedGem(found) {
+		t.Fatalf("expected compressed cache entry %s, got %q", compressed, found)
+	}
+
+	destDir := t.TempDir()
+	metadata, err := ExtractGemContents(found, destDir)
+	if err != nil {
+		t.Fatalf("ExtractGemContents returned error: %v", err)
+	}
+	if string(metadata) != "--- !ruby/object:Gem::Specification\nname: rack\n" {
+		t.Errorf("unexpected metadata %q", metadata)
+	}
+	if data, err := os.ReadFile(filepath.Join(destDir, "lib", "rack.rb")); err != nil || string(data) != "module Rack; end\n" {
+		t.Errorf("expected extracted lib/rack.rb, got %q (err %v)", data, err)
+	}
+
+	// vendor/cache gets the plain .gem, whether copied or "linked"
+	vendorCache := filepath.Join(t.TempDir(), "cache", GemFileName(gem))
+	if err := LinkGemToVendorCache(found, vendorCache); err != nil {
+		t.Fatalf("LinkGemToVendorCache returned error: %v", err)
+	}
+	if data, err := os.ReadFile(vendorCache); err != nil || !bytes.Equal(data, gemData) {
+		t.Errorf("expected vendor/cache to hold the uncompressed gem (err %v)", err)
+	}
+}
+
+// buildTestGem builds a minimal .gem archive (metadata.gz + data.tar.gz)
+func buildTestGem(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var data bytes.Buffer
+	gz := gzip.NewWriter(&data)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var metadata bytes.Buffer
+	mgz := gzip.NewWriter(&metadata)
+	if _, err := mgz.Write([]byte("--- !ruby/object:Gem::Specification\nname: rack\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var gem bytes.Buffer
+	outer := tar.NewWriter(&gem)
+	for _, entry := range []struct {
+		name string
+		data []byte
+	}{{"metadata.gz", metadata.Bytes()}, {"data.tar.gz", data.Bytes()}} {
+		if err := outer.WriteHeader(&tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := outer.Write(entry.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := outer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return gem.Bytes()
+}
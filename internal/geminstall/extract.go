@@ -70,7 +70,7 @@ var copyBufPool = sync.Pool{
 // ExtractMetadataOnly extracts only the metadata from a .gem file without extracting contents
 // This is much faster than ExtractGemContents and useful for compatibility checks
 func ExtractMetadataOnly(gemPath string) ([]byte, error) {
-	file, err := os.Open(gemPath)
+	file, err := OpenGem(gemPath)
 	if err != nil {
 		return nil, err
 	}
@@ -103,10 +103,10 @@ func ExtractMetadataOnly(gemPath string) ([]byte, error) {
 	return nil, fmt.Errorf("metadata not found in %s", gemPath)
 }
 
-// ExtractGemContents extracts a .gem file to the destination directory
-// Returns the metadata YAML bytes
+// ExtractGemContents extracts a .gem file (plain or a compressed cache entry) to the
+// destination directory. Returns the metadata YAML bytes
 func ExtractGemContents(gemPath, destDir string) ([]byte, error) {
-	file, err := os.Open(gemPath)
+	file, err := OpenGem(gemPath)
 	if err != nil {
 		return nil, err
 	}
@@ -228,6 +228,22 @@ func EnsureDir(path string) error {
 	return os.MkdirAll(path, 0o755)
 }
 
+// gemSubdirectories are the directories RubyGems creates in every gem dir
+var gemSubdirectories = []string{"build_info", "cache", "doc", "extensions", "gems", "plugins", "specifications"}
+
+// EnsureGemSubdirectories creates RubyGems' standard layout in a gem dir, so one ore
+// installs into (including the system gem dir) looks like one `gem install` populated.
+//
+// Ruby developers: this is Gem.ensure_gem_subdirectories
+func EnsureGemSubdirectories(gemDir string) error {
+	for _, dir := range gemSubdirectories {
+		if err := EnsureDir(filepath.Join(gemDir, dir)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func writeFileFromReader(path string, r io.Reader, mode os.FileMode) error {
 	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
 	if err != nil {
@@ -258,13 +274,16 @@ func decompressMetadata(data []byte) ([]byte, error) {
 	return io.ReadAll(reader)
 }
 
-// CopyGemToVendorCache copies a gem file to the vendor cache directory
+// CopyGemToVendorCache copies a gem file to the vendor cache directory.
+// destPath may be a hardlink to srcPath left by an earlier --link install, so the copy
+// is written next to it and renamed into place instead of truncating the shared inode.
 func CopyGemToVendorCache(srcPath, destPath string) error {
 	if err := EnsureDir(filepath.Dir(destPath)); err != nil {
 		return err
 	}
 
-	src, err := os.Open(srcPath)
+	// vendor/cache always gets a plain .gem, even from a compressed ore cache
+	src, err := OpenGem(srcPath)
 	if err != nil {
 		return err
 	}
@@ -272,17 +291,23 @@ func CopyGemToVendorCache(srcPath, destPath string) error {
 		_ = src.Close()
 	}()
 
-	dest, err := os.Create(destPath)
+	tmp, err := os.CreateTemp(filepath.Dir(destPath), ".ore-*.gem")
 	if err != nil {
 		return err
 	}
 	defer func() {
-		_ = dest.Close()
+		_ = os.Remove(tmp.Name())
 	}()
 
-	if _, err := io.Copy(dest, src); err != nil {
+	if _, err := io.Copy(tmp, src); err != nil {
+		_ = tmp.Close()
 		return err
 	}
-
-	return nil
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), destPath)
 }
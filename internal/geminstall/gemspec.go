@@ -2,13 +2,17 @@ package geminstall
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
 	"text/template"
 
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/logger"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,9 +26,9 @@ const (
 type gemMetadata struct {
 	Name        string       `yaml:"name"`
 	Version     versionField `yaml:"version"`
-	Authors     []string     `yaml:"authors"`
+	Authors     stringList   `yaml:"authors"`
 	Author      string       `yaml:"author"`
-	Email       interface{}  `yaml:"email"` // Can be string or []string
+	Email       stringList   `yaml:"email"` // Can be string or []string
 	Homepage    string       `yaml:"homepage"`
 	Summary     string       `yaml:"summary"`
 	Description string       `yaml:"description"`
@@ -32,6 +36,154 @@ type gemMetadata struct {
 	License     string       `yaml:"license"`
 	Platform    string       `yaml:"platform"`
 	Extensions  []string     `yaml:"extensions"` // Native C extensions
+	// RequirePaths are the gem's load path entries relative to its dir, ["lib"] when unset
+	RequirePaths stringList `yaml:"require_paths"`
+	// RequiredRubyVersion is the gem's Gem::Requirement on RUBY_VERSION
+	RequiredRubyVersion metadataRequirement `yaml:"required_ruby_version"`
+	// Dependencies are Gem::Dependency objects, decoded lazily so an unusual
+	// shape never breaks parsing of the fields above
+	Dependencies yaml.Node `yaml:"dependencies"`
+}
+
+// stringList is a metadata field RubyGems allows as a string or an array of strings
+// (authors, email). Null and empty entries are skipped, and a value of any other shape
+// decodes as empty instead of failing the whole document.
+type stringList struct {
+	Values []string
+	Array  bool // The metadata had an array, e.g. email: [a@example.com, b@example.com]
+}
+
+// UnmarshalYAML accepts a scalar or a sequence of scalars
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag != "!!null" && strings.TrimSpace(node.Value) != "" {
+			l.Values = []string{node.Value}
+		}
+	case yaml.SequenceNode:
+		l.Array = true
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode && item.Tag != "!!null" && strings.TrimSpace(item.Value) != "" {
+				l.Values = append(l.Values, item.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// metadataDependency represents a Gem::Dependency entry after tag stripping
+type metadataDependency struct {
+	Name        string              `yaml:"name"`
+	Type        string              `yaml:"type"` // ":runtime" or ":development"
+	Requirement metadataRequirement `yaml:"requirement"`
+}
+
+// metadataRequirement represents a Gem::Requirement after tag stripping
+type metadataRequirement struct {
+	Requirements [][]interface{} `yaml:"requirements"` // [[">=", {version: "0"}], ...]
+}
+
+// String renders requirements like Gem::Requirement#to_s (e.g. "~> 3.0, >= 3.0.1")
+func (r metadataRequirement) String() string {
+	parts := r.clauses()
+	if len(parts) == 0 {
+		return ">= 0"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// clauses returns each requirement as "<op> <version>", or nil when there are none
+func (r metadataRequirement) clauses() []string {
+	var parts []string
+	for _, req := range r.Requirements {
+		if len(req) != 2 {
+			continue
+		}
+		op, _ := req[0].(string)
+		var version string
+		switch v := req[1].(type) {
+		case map[string]interface{}:
+			version = fmt.Sprint(v["version"])
+		case string:
+			version = v
+		}
+		if op == "" || version == "" {
+			continue
+		}
+		parts = append(parts, op+" "+version)
+	}
+	return parts
+}
+
+// GemMetadata is the subset of gem metadata exposed to commands (e.g. ore info)
+type GemMetadata struct {
+	Name         string
+	Version      string
+	Platform     string // "ruby" for pure-Ruby gems
+	Summary      string
+	Homepage     string
+	Authors      []string
+	Licenses     []string
+	Dependencies []MetadataDependency // Runtime dependencies only
+	// RequiredRubyVersion is the gem's requirement on RUBY_VERSION, ">= 0" if it has none
+	RequiredRubyVersion string
+}
+
+// MetadataDependency is a runtime dependency declared in gem metadata
+type MetadataDependency struct {
+	Name        string
+	Requirement string
+}
+
+// ParseGemMetadata parses the metadata YAML from a .gem file into GemMetadata
+func ParseGemMetadata(metadataYAML []byte) (*GemMetadata, error) {
+	cleanedYAML := stripRubyYAMLTags(metadataYAML)
+
+	// Fields of an unexpected type are left empty rather than failing the rest
+	var gemMeta gemMetadata
+	var typeErr *yaml.TypeError
+	if err := yaml.Unmarshal(cleanedYAML, &gemMeta); err != nil && !errors.As(err, &typeErr) {
+		return nil, fmt.Errorf("failed to parse gem metadata: %w", err)
+	}
+
+	authors := gemMeta.Authors.Values
+	if len(authors) == 0 && gemMeta.Author != "" {
+		authors = []string{gemMeta.Author}
+	}
+
+	licenses := gemMeta.Licenses
+	if len(licenses) == 0 && gemMeta.License != "" {
+		licenses = []string{gemMeta.License}
+	}
+
+	var deps []MetadataDependency
+	for _, node := range gemMeta.Dependencies.Content {
+		var dep metadataDependency
+		if err := node.Decode(&dep); err != nil || dep.Name == "" {
+			continue
+		}
+		if dep.Type == ":development" {
+			continue
+		}
+		deps = append(deps, MetadataDependency{Name: dep.Name, Requirement: dep.Requirement.String()})
+	}
+
+	platform := gemMeta.Platform
+	if platform == "" {
+		platform = "ruby"
+	}
+
+	return &GemMetadata{
+		Name:                gemMeta.Name,
+		Version:             gemMeta.Version.String(),
+		Platform:            platform,
+		Summary:             gemMeta.Summary,
+		Homepage:            gemMeta.Homepage,
+		Authors:             authors,
+		Licenses:            licenses,
+		Dependencies:        deps,
+		RequiredRubyVersion: gemMeta.RequiredRubyVersion.String(),
+	}, nil
 }
 
 // versionField handles both nested and simple version formats
@@ -76,8 +228,8 @@ func stripRubyYAMLTags(data []byte) []byte {
 	// Use regex to remove all Ruby object tags in one pass
 	result := rubyTagPattern.ReplaceAll(data, []byte(""))
 
-	// Debug: log cleaned YAML if ORE_DEBUG_YAML is set
-	if os.Getenv("ORE_DEBUG_YAML") != "" {
+	// Debug: log cleaned YAML with --debug (or ORE_DEBUG_YAML)
+	if logger.Debugging() {
 		fmt.Fprintf(os.Stderr, "=== Cleaned YAML ===\n%s\n=== End ===\n", string(result))
 	}
 
@@ -109,22 +261,24 @@ func WriteGemSpecification(vendorDir string, spec lockfile.GemSpec, metadataYAML
 	cleanedYAML := stripRubyYAMLTags(metadataYAML)
 
 	var gemMeta gemMetadata
-	if err := yaml.Unmarshal(cleanedYAML, &gemMeta); err != nil {
+	var typeErr *yaml.TypeError
+	if err := yaml.Unmarshal(cleanedYAML, &gemMeta); errors.As(err, &typeErr) {
+		// Fields of an unexpected type are left empty; keep the ones that decoded
+		if logger.Debugging() {
+			fmt.Fprintf(os.Stderr, "YAML type errors for %s: %v\n", spec.FullName(), err)
+		}
+	} else if err != nil {
 		// Debug: log parsing error
-		if os.Getenv("ORE_DEBUG") != "" {
+		if logger.Debugging() {
 			fmt.Fprintf(os.Stderr, "YAML parse error for %s: %v\n", spec.FullName(), err)
 		}
-		// If parsing fails, use basic metadata
-		gemMeta = gemMetadata{
-			Name:    spec.Name,
-			Version: versionField{Version: spec.Version},
-			Authors: []string{"Gem Authors"},
-			Email:   "ore@example.com",
-		}
-	} else if os.Getenv("ORE_DEBUG") != "" {
+		// If parsing fails, generateGemspecCode fills in basic metadata
+		gemMeta = gemMetadata{}
+	}
+	if logger.Debugging() {
 		// Debug: show extracted metadata
 		fmt.Fprintf(os.Stderr, "Extracted metadata for %s: name=%s version=%s authors=%v email=%v\n",
-			spec.FullName(), gemMeta.Name, gemMeta.Version.String(), gemMeta.Authors, gemMeta.Email)
+			spec.FullName(), gemMeta.Name, gemMeta.Version.String(), gemMeta.Authors.Values, gemMeta.Email.Values)
 	}
 
 	// Build proper Ruby gemspec code
@@ -140,7 +294,7 @@ func WriteGemSpecification(vendorDir string, spec lockfile.GemSpec, metadataYAML
 
 // gemspecTemplate is the template for generating RubyGems-compatible gemspec files
 const gemspecTemplate = `# -*- encoding: utf-8 -*-
-# stub: {{.Name}} {{.Version}} {{.Platform}} lib
+# stub: {{.Name}} {{.Version}} {{.Platform}} {{.StubRequirePaths}}
 
 Gem::Specification.new do |s|
   s.name = {{printf "%q" .Name}}
@@ -149,11 +303,18 @@ Gem::Specification.new do |s|
   s.platform = {{printf "%q" .Platform}}
 {{- end}}
   s.authors = [{{range $i, $a := .Authors}}{{if $i}}, {{end}}{{printf "%q" $a}}{{end}}]
-  s.email = {{printf "%q" .Email}}
+{{- if .EmailArray}}
+  s.email = [{{range $i, $e := .Emails}}{{if $i}}, {{end}}{{printf "%q" $e}}{{end}}]
+{{- else}}
+  s.email = {{printf "%q" (index .Emails 0)}}
+{{- end}}
   s.homepage = {{printf "%q" .Homepage}}
   s.licenses = [{{range $i, $l := .Licenses}}{{if $i}}, {{end}}{{printf "%q" $l}}{{end}}]
+{{- if .RequiredRubyVersion}}
+  s.required_ruby_version = Gem::Requirement.new({{range $i, $r := .RequiredRubyVersion}}{{if $i}}, {{end}}{{printf "%q" $r}}{{end}})
+{{- end}}
   s.required_rubygems_version = Gem::Requirement.new(">= 0")
-  s.require_paths = ["lib"]
+  s.require_paths = [{{range $i, $p := .RequirePaths}}{{if $i}}, {{end}}{{printf "%q" $p}}{{end}}]
   s.rubygems_version = "{{.RubygemsVersion}}"
   s.summary = {{printf "%q" .Summary}}
   s.description = {{printf "%q" .Description}}
@@ -177,7 +338,8 @@ type gemspecData struct {
 	Version         string
 	Platform        string
 	Authors         []string
-	Email           string
+	Emails          []string // At least one
+	EmailArray      bool     // Write s.email as an array, like the metadata
 	Homepage        string
 	Licenses        []string
 	Summary         string
@@ -185,34 +347,16 @@ type gemspecData struct {
 	Dependencies    []lockfile.Dependency
 	RubygemsVersion string
 	Extensions      []string // Native C extensions
-}
-
-// extractEmail handles both string and array email types from YAML
-func extractEmail(emailField interface{}) string {
-	switch v := emailField.(type) {
-	case string:
-		return v
-	case []interface{}:
-		// Array of emails - return first non-empty
-		for _, e := range v {
-			if s, ok := e.(string); ok && s != "" {
-				return s
-			}
-		}
-	case []string:
-		// Already string array
-		for _, s := range v {
-			if s != "" {
-				return s
-			}
-		}
-	}
-	return ""
+	// RequiredRubyVersion is the gem's Ruby requirement clauses, nil for ">= 0"
+	RequiredRubyVersion []string
+	RequirePaths        []string // At least one
+	// StubRequirePaths joins RequirePaths with NUL, as RubyGems reads them from the stub line
+	StubRequirePaths string
 }
 
 func generateGemspecCode(spec lockfile.GemSpec, meta *gemMetadata) string {
 	// Handle authors - array or single
-	authors := meta.Authors
+	authors := meta.Authors.Values
 	if len(authors) == 0 && meta.Author != "" {
 		authors = []string{meta.Author}
 	}
@@ -238,10 +382,12 @@ func generateGemspecCode(spec lockfile.GemSpec, meta *gemMetadata) string {
 		platform = "ruby"
 	}
 
-	// Email default - handle both string and array types
-	email := extractEmail(meta.Email)
-	if email == "" {
-		email = "ore@example.com"
+	// Email default - keep every address, as an array when the metadata had one
+	emails := meta.Email.Values
+	emailArray := meta.Email.Array || len(emails) > 1
+	if len(emails) == 0 {
+		emails = []string{"ore@example.com"}
+		emailArray = false
 	}
 
 	// Homepage default
@@ -268,19 +414,34 @@ func generateGemspecCode(spec lockfile.GemSpec, meta *gemMetadata) string {
 		extensions = spec.Extensions
 	}
 
+	// ">= 0" is RubyGems' default, so it's left out
+	var requiredRuby []string
+	if clauses := meta.RequiredRubyVersion.clauses(); !slices.Equal(clauses, []string{">= 0"}) {
+		requiredRuby = clauses
+	}
+
+	requirePaths := meta.RequirePaths.Values
+	if len(requirePaths) == 0 {
+		requirePaths = []string{"lib"}
+	}
+
 	data := gemspecData{
-		Name:            spec.Name,
-		Version:         spec.Version,
-		Platform:        platform,
-		Authors:         authors,
-		Email:           email,
-		Homepage:        homepage,
-		Licenses:        licenses,
-		Summary:         summary,
-		Description:     description,
-		Dependencies:    spec.Dependencies,
-		RubygemsVersion: DEFAULT_RUBYGEMS_VERSION,
-		Extensions:      extensions,
+		Name:                spec.Name,
+		Version:             spec.Version,
+		Platform:            platform,
+		Authors:             authors,
+		Emails:              emails,
+		EmailArray:          emailArray,
+		Homepage:            homepage,
+		Licenses:            licenses,
+		Summary:             summary,
+		Description:         description,
+		Dependencies:        spec.Dependencies,
+		RubygemsVersion:     DEFAULT_RUBYGEMS_VERSION,
+		Extensions:          extensions,
+		RequiredRubyVersion: requiredRuby,
+		RequirePaths:        requirePaths,
+		StubRequirePaths:    strings.Join(requirePaths, "\x00"),
 	}
 
 	var buf bytes.Buffer
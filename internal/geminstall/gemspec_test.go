@@ -0,0 +1,187 @@
+package geminstall
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/lockfile"
+)
+
+const sampleMetadata = `--- !ruby/object:Gem::Specification
+name: rack
+version: !ruby/object:Gem::Version
+  version: 3.0.8
+platform: ruby
+authors:
+- Leah Neukirchen
+email: leah@vuxu.org
+homepage: https://github.com/rack/rack
+licenses:
+- MIT
+summary: A modular Ruby webserver interface.
+required_ruby_version: !ruby/object:Gem::Requirement
+  requirements:
+  - - ">="
+    - !ruby/object:Gem::Version
+      version: 2.4.0
+dependencies:
+- !ruby/object:Gem::Dependency
+  name: webrick
+  requirement: !ruby/object:Gem::Requirement
+    requirements:
+    - - "~>"
+      - !ruby/object:Gem::Version
+        version: '1.8'
+    - - ">="
+      - !ruby/object:Gem::Version
+        version: 1.8.1
+  type: :runtime
+  prerelease: false
+- !ruby/object:Gem::Dependency
+  name: minitest
+  requirement: !ruby/object:Gem::Requirement
+    requirements:
+    - - ">="
+      - !ruby/object:Gem::Version
+        version: '0'
+  type: :development
+  prerelease: false
+`
+
+func TestParseGemMetadata(t *testing.T) {
+	meta, err := ParseGemMetadata([]byte(sampleMetadata))
+	if err != nil {
+		t.Fatalf("ParseGemMetadata returned error: %v", err)
+	}
+
+	if meta.Name != "rack" || meta.Version != "3.0.8" {
+		t.Fatalf("unexpected name/version: %s %s", meta.Name, meta.Version)
+	}
+	if meta.Homepage != "https://github.com/rack/rack" {
+		t.Errorf("unexpected homepage: %q", meta.Homepage)
+	}
+	if len(meta.Licenses) != 1 || meta.Licenses[0] != "MIT" {
+		t.Errorf("unexpected licenses: %v", meta.Licenses)
+	}
+	if meta.RequiredRubyVersion != ">= 2.4.0" {
+		t.Errorf("unexpected required_ruby_version: %q", meta.RequiredRubyVersion)
+	}
+
+	if len(meta.Dependencies) != 1 {
+		t.Fatalf("expected only the runtime dependency, got %v", meta.Dependencies)
+	}
+	dep := meta.Dependencies[0]
+	if dep.Name != "webrick" || dep.Requirement != "~> 1.8, >= 1.8.1" {
+		t.Errorf("unexpected dependency: %+v", dep)
+	}
+}
+
+func TestWriteGemSpecificationKeepsAuthorsAndEmails(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		want     []string
+	}{
+		{
+			name: "arrays",
+			metadata: `name: multi
+authors:
+- Jane Doe
+- John Roe
+email:
+- jane@example.com
+- john@example.com
+`,
+			want: []string{
+				`s.authors = ["Jane Doe", "John Roe"]`,
+				`s.email = ["jane@example.com", "john@example.com"]`,
+			},
+		},
+		{
+			name: "single email string and a null author",
+			metadata: `name: multi
+authors:
+- Jane Doe
+-
+- John Roe
+email: jane@example.com
+`,
+			want: []string{
+				`s.authors = ["Jane Doe", "John Roe"]`,
+				`s.email = "jane@example.com"`,
+			},
+		},
+		{
+			name: "one-element email array",
+			metadata: `name: multi
+author: Jane Doe
+email:
+- jane@example.com
+`,
+			want: []string{
+				`s.authors = ["Jane Doe"]`,
+				`s.email = ["jane@example.com"]`,
+			},
+		},
+		{
+			// A field of the wrong type doesn't cost the others
+			name: "malformed licenses",
+			metadata: `name: multi
+authors:
+- Jane Doe
+- John Roe
+email:
+- jane@example.com
+- john@example.com
+licenses:
+  unexpected: map
+`,
+			want: []string{
+				`s.authors = ["Jane Doe", "John Roe"]`,
+				`s.email = ["jane@example.com", "john@example.com"]`,
+			},
+		},
+		{
+			name:     "absent",
+			metadata: "name: multi\n",
+			want: []string{
+				`s.authors = ["Gem Authors"]`,
+				`s.email = "ore@example.com"`,
+				`s.require_paths = ["lib"]`,
+			},
+		},
+		{
+			name: "require paths",
+			metadata: `name: multi
+require_paths:
+- lib
+- ext/multi
+`,
+			want: []string{
+				"# stub: multi 1.0.0 ruby lib\x00ext/multi\n",
+				`s.require_paths = ["lib", "ext/multi"]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vendorDir := t.TempDir()
+			spec := lockfile.GemSpec{Name: "multi", Version: "1.0.0"}
+			if err := WriteGemSpecification(vendorDir, spec, []byte(tt.metadata)); err != nil {
+				t.Fatalf("WriteGemSpecification returned error: %v", err)
+			}
+			data, err := os.ReadFile(filepath.Join(vendorDir, "specifications", "multi-1.0.0.gemspec"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(string(data), line) {
+					t.Errorf("expected %s in gemspec:\n%s", line, data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package geminstall
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// LinkGemToVendorCache places a cached .gem into vendor/cache without duplicating its data.
+// It tries a copy-on-write reflink first, then a hardlink, and falls back to a plain copy
+// (e.g. when the cache and vendor dir are on different devices).
+//
+// Note: a hardlinked vendor/cache/*.gem shares its inode with the ore cache, so
+// modifying either file in place changes both. Reflinks don't have this problem.
+func LinkGemToVendorCache(srcPath, destPath string) error {
+	if err := EnsureDir(filepath.Dir(destPath)); err != nil {
+		return err
+	}
+
+	// Already linked (same file) - nothing to do
+	if srcInfo, err := os.Stat(srcPath); err == nil {
+		if destInfo, err := os.Stat(destPath); err == nil && os.SameFile(srcInfo, destInfo) {
+			return nil
+		}
+	}
+
+	if err := os.Remove(destPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// A compressed cache entry can't be shared as-is; vendor/cache needs the plain .gem
+	if IsCompressedGem(srcPath) {
+		return CopyGemToVendorCache(srcPath, destPath)
+	}
+
+	if err := reflinkFile(srcPath, destPath); err == nil {
+		return nil
+	}
+	if err := os.Link(srcPath, destPath); err == nil {
+		return nil
+	}
+
+	return CopyGemToVendorCache(srcPath, destPath)
+}
@@ -0,0 +1,59 @@
+package geminstall
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkGemToVendorCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	vendorDir := t.TempDir()
+
+	src := filepath.Join(cacheDir, "rack-3.0.0.gem")
+	if err := os.WriteFile(src, []byte("gem contents"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(vendorDir, "cache", "rack-3.0.0.gem")
+
+	// Linking twice must be idempotent
+	for range 2 {
+		if err := LinkGemToVendorCache(src, dest); err != nil {
+			t.Fatalf("LinkGemToVendorCache returned error: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("expected vendor cache file: %v", err)
+	}
+	if string(data) != "gem contents" {
+		t.Fatalf("unexpected vendor cache contents %q", data)
+	}
+}
+
+func TestCopyAfterLinkKeepsCachedGem(t *testing.T) {
+	cacheDir := t.TempDir()
+	vendorDir := t.TempDir()
+
+	src := filepath.Join(cacheDir, "rack-3.0.0.gem")
+	if err := os.WriteFile(src, []byte("gem contents"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(vendorDir, "cache", "rack-3.0.0.gem")
+
+	// ore install --link, then ore install --force without --link
+	if err := LinkGemToVendorCache(src, dest); err != nil {
+		t.Fatalf("LinkGemToVendorCache returned error: %v", err)
+	}
+	if err := CopyGemToVendorCache(src, dest); err != nil {
+		t.Fatalf("CopyGemToVendorCache returned error: %v", err)
+	}
+
+	for _, path := range []string{src, dest} {
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != "gem contents" {
+			t.Errorf("expected %s to keep the gem, got %q (%v)", path, data, err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+//go:build linux
+
+package geminstall
+
+import (
+	"os"
+	"syscall"
+)
+
+// ficlone is the FICLONE ioctl (btrfs, XFS, bcachefs copy-on-write clones)
+const ficlone = 0x40049409
+
+// reflinkFile creates dest as a copy-on-write clone of src
+func reflinkFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, out.Fd(), ficlone, in.Fd())
+	closeErr := out.Close()
+	if errno != 0 {
+		_ = os.Remove(dest)
+		return errno
+	}
+	return closeErr
+}
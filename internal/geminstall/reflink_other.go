@@ -0,0 +1,10 @@
+//go:build !linux
+
+package geminstall
+
+import "errors"
+
+// reflinkFile is only implemented on Linux; other platforms fall back to hardlinks
+func reflinkFile(src, dest string) error {
+	return errors.New("reflink not supported on this platform")
+}
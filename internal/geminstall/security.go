@@ -0,0 +1,331 @@
+package geminstall
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TrustPolicy mirrors a Gem::Security::Policy: which parts of a signed gem are checked.
+// Ruby developers: these are the policies behind `gem install -P HighSecurity`
+type TrustPolicy struct {
+	Name         string
+	VerifyData   bool // Signatures must match metadata.gz, data.tar.gz and checksums.yaml.gz
+	VerifySigner bool // Signing cert must be valid now and signed by its issuer
+	VerifyChain  bool // Every cert in the chain must be signed by the one before it
+	VerifyRoot   bool // Chain root must be a valid self-signed cert
+	OnlyTrusted  bool // Chain root must be in the trust store
+	OnlySigned   bool // Unsigned gems are rejected
+}
+
+// The RubyGems policies, from least to most strict
+var (
+	NoSecurity       = &TrustPolicy{Name: "NoSecurity"}
+	AlmostNoSecurity = &TrustPolicy{Name: "AlmostNoSecurity", VerifyData: true}
+	LowSecurity      = &TrustPolicy{Name: "LowSecurity", VerifyData: true, VerifySigner: true}
+	MediumSecurity   = &TrustPolicy{Name: "MediumSecurity", VerifyData: true, VerifySigner: true, VerifyChain: true, VerifyRoot: true, OnlyTrusted: true}
+	HighSecurity     = &TrustPolicy{Name: "HighSecurity", VerifyData: true, VerifySigner: true, VerifyChain: true, VerifyRoot: true, OnlyTrusted: true, OnlySigned: true}
+)
+
+var trustPolicies = []*TrustPolicy{NoSecurity, AlmostNoSecurity, LowSecurity, MediumSecurity, HighSecurity}
+
+// ParseTrustPolicy looks up a policy by name, case-insensitively ("HighSecurity", "high").
+// An empty name means NoSecurity.
+func ParseTrustPolicy(name string) (*TrustPolicy, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return NoSecurity, nil
+	}
+	names := make([]string, 0, len(trustPolicies))
+	for _, policy := range trustPolicies {
+		full := strings.ToLower(policy.Name)
+		if name == full || name+"security" == full {
+			return policy, nil
+		}
+		names = append(names, policy.Name)
+	}
+	return nil, fmt.Errorf("unknown trust policy %q (use %s)", name, strings.Join(names, ", "))
+}
+
+// DefaultTrustDir returns the trust store: $ORE_TRUST_DIR, else RubyGems' ~/.gem/trust
+// (where `gem cert --add` puts certificates)
+func DefaultTrustDir() string {
+	if dir := os.Getenv("ORE_TRUST_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".gem", "trust")
+}
+
+// signedEntries are the .gem members RubyGems digests and signs
+var signedEntries = []string{"metadata.gz", "data.tar.gz", "checksums.yaml.gz"}
+
+// gemSignatures holds what VerifyGem reads from a .gem archive
+type gemSignatures struct {
+	digests    map[string]map[crypto.Hash][]byte // entry -> hash -> digest
+	signatures map[string][]byte                 // entry -> contents of entry.sig
+	metadata   []byte
+}
+
+// VerifyGem checks a .gem (plain or a compressed cache entry) against policy, using the
+// PEM certificates in trustDir for OnlyTrusted. Returns nil when the gem is acceptable.
+func VerifyGem(gemPath string, policy *TrustPolicy, trustDir string) error {
+	if policy == nil || *policy == *NoSecurity {
+		return nil
+	}
+
+	contents, err := readGemSignatures(gemPath)
+	if err != nil {
+		return err
+	}
+
+	if len(contents.signatures) == 0 {
+		if policy.OnlySigned {
+			return fmt.Errorf("unsigned gems are not allowed by the %s policy", policy.Name)
+		}
+		return nil
+	}
+
+	chain, err := parseCertChain(contents.metadata)
+	if err != nil {
+		return err
+	}
+	if len(chain) == 0 {
+		return fmt.Errorf("missing signing certificate")
+	}
+
+	now := time.Now()
+	signer := chain[len(chain)-1]
+	root := chain[0]
+
+	if policy.VerifySigner {
+		if err := checkValidity(signer, now); err != nil {
+			return err
+		}
+		if len(chain) > 1 {
+			if err := checkIssuedBy(signer, chain[len(chain)-2]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if policy.VerifyChain {
+		for i, cert := range chain {
+			if err := checkValidity(cert, now); err != nil {
+				return err
+			}
+			if i > 0 {
+				if err := checkIssuedBy(cert, chain[i-1]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if policy.VerifyRoot {
+		if err := checkValidity(root, now); err != nil {
+			return err
+		}
+		if err := checkIssuedBy(root, root); err != nil {
+			return fmt.Errorf("root certificate %s is not self-signed", root.Subject)
+		}
+	}
+
+	if policy.OnlyTrusted {
+		trusted, err := isTrusted(root, trustDir)
+		if err != nil {
+			return err
+		}
+		if !trusted {
+			return fmt.Errorf("root cert %s is not trusted (add it to %s)", root.Subject, trustDir)
+		}
+	}
+
+	if policy.VerifyData {
+		for _, name := range signedEntries {
+			digests, ok := contents.digests[name]
+			if !ok {
+				continue
+			}
+			signature, ok := contents.signatures[name]
+			if !ok {
+				return fmt.Errorf("missing signature for %s", name)
+			}
+			if !verifyDigest(signer.PublicKey, digests, signature) {
+				return fmt.Errorf("invalid signature for %s", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// readGemSignatures digests the signed members of a .gem and collects their .sig entries
+func readGemSignatures(gemPath string) (*gemSignatures, error) {
+	file, err := OpenGem(gemPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	contents := &gemSignatures{
+		digests:    make(map[string]map[crypto.Hash][]byte),
+		signatures: make(map[string][]byte),
+	}
+
+	tr := tar.NewReader(file)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if signed, ok := strings.CutSuffix(header.Name, ".sig"); ok {
+			signature, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			contents.signatures[signed] = signature
+			continue
+		}
+
+		var metadata bytes.Buffer
+		var reader io.Reader = tr
+		if header.Name == "metadata.gz" {
+			reader = io.TeeReader(tr, &metadata)
+		}
+
+		// RubyGems signs with SHA256; gems signed before RubyGems 2.4 used SHA1
+		sha256Hash, sha1Hash := sha256.New(), sha1.New()
+		if _, err := io.Copy(io.MultiWriter(sha256Hash, sha1Hash), reader); err != nil {
+			return nil, err
+		}
+		contents.digests[header.Name] = map[crypto.Hash][]byte{
+			crypto.SHA256: sha256Hash.Sum(nil),
+			crypto.SHA1:   sha1Hash.Sum(nil),
+		}
+
+		if header.Name == "metadata.gz" {
+			contents.metadata, err = decompressMetadata(metadata.Bytes())
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return contents, nil
+}
+
+// parseCertChain reads the gemspec's cert_chain (root first, signer last)
+func parseCertChain(metadata []byte) ([]*x509.Certificate, error) {
+	var spec struct {
+		CertChain []string `yaml:"cert_chain"`
+	}
+	if err := yaml.Unmarshal(stripRubyYAMLTags(metadata), &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse gem metadata: %w", err)
+	}
+
+	chain := make([]*x509.Certificate, 0, len(spec.CertChain))
+	for _, encoded := range spec.CertChain {
+		block, _ := pem.Decode([]byte(encoded))
+		if block == nil {
+			return nil, fmt.Errorf("invalid certificate in cert_chain")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in cert_chain: %w", err)
+		}
+		chain = append(chain, cert)
+	}
+	return chain, nil
+}
+
+func checkValidity(cert *x509.Certificate, now time.Time) error {
+	if now.Before(cert.NotBefore) {
+		return fmt.Errorf("certificate %s not valid before %s", cert.Subject, cert.NotBefore.Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate %s not valid after %s", cert.Subject, cert.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// checkIssuedBy verifies cert's signature with issuer's key. Unlike CheckSignatureFrom it
+// doesn't require the issuer to be a CA: `gem cert --build` certs have CA:FALSE.
+func checkIssuedBy(cert, issuer *x509.Certificate) error {
+	if err := issuer.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		return fmt.Errorf("certificate %s was not issued by %s: %w", cert.Subject, issuer.Subject, err)
+	}
+	return nil
+}
+
+// isTrusted reports whether root is one of the PEM certificates in trustDir
+func isTrusted(root *x509.Certificate, trustDir string) (bool, error) {
+	entries, err := os.ReadDir(trustDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read trust store: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(trustDir, entry.Name()))
+		if err != nil {
+			return false, fmt.Errorf("failed to read trust store: %w", err)
+		}
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			if bytes.Equal(block.Bytes, root.Raw) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// verifyDigest checks a signature over an entry's digest. RubyGems signs the digest
+// bytes themselves (key.sign(SHA256.new, digest)), so they are hashed once more here.
+func verifyDigest(publicKey any, digests map[crypto.Hash][]byte, signature []byte) bool {
+	for _, algorithm := range []crypto.Hash{crypto.SHA256, crypto.SHA1} {
+		h := algorithm.New()
+		h.Write(digests[algorithm])
+		hashed := h.Sum(nil)
+
+		switch key := publicKey.(type) {
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(key, algorithm, hashed, signature) == nil {
+				return true
+			}
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(key, hashed, signature) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+package geminstall
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTrustPolicy(t *testing.T) {
+	for name, want := range map[string]*TrustPolicy{
+		"":             NoSecurity,
+		"HighSecurity": HighSecurity,
+		"medium":       MediumSecurity,
+		"lowsecurity":  LowSecurity,
+	} {
+		if got, err := ParseTrustPolicy(name); err != nil || got != want {
+			t.Errorf("ParseTrustPolicy(%q) = %v, %v; want %s", name, got, err, want.Name)
+		}
+	}
+	if _, err := ParseTrustPolicy("paranoid"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+func TestVerifyGem(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gems", Organization: []string{"example"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true, // CA:FALSE, like `gem cert --build`
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+
+	dir := t.TempDir()
+	unsigned := writeGemFile(t, dir, "unsigned.gem", buildSignedTestGem(t, nil, nil, false))
+	signed := writeGemFile(t, dir, "signed.gem", buildSignedTestGem(t, key, certPEM, false))
+	tampered := writeGemFile(t, dir, "tampered.gem", buildSignedTestGem(t, key, certPEM, true))
+
+	trustDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		gem     string
+		policy  *TrustPolicy
+		wantErr string
+	}{
+		{"no security ignores tampering", tampered, NoSecurity, ""},
+		{"medium allows unsigned", unsigned, MediumSecurity, ""},
+		{"high rejects unsigned", unsigned, HighSecurity, "unsigned gems are not allowed"},
+		{"low accepts untrusted signer", signed, LowSecurity, ""},
+		{"low rejects tampered data", tampered, LowSecurity, "invalid signature for data.tar.gz"},
+		{"medium rejects untrusted root", signed, MediumSecurity, "is not trusted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyGem(tt.gem, tt.policy, trustDir)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// Trusting the root (as `gem cert --add` would) satisfies HighSecurity
+	if err := os.WriteFile(filepath.Join(trustDir, "cert-gems.pem"), certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyGem(signed, HighSecurity, trustDir); err != nil {
+		t.Errorf("expected trusted signed gem to pass HighSecurity, got %v", err)
+	}
+}
+
+func writeGemFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// buildSignedTestGem builds a .gem whose members are signed the way RubyGems does it:
+// key.sign(SHA256, SHA256(member)). With tamper, data.tar.gz changes after signing.
+func buildSignedTestGem(t *testing.T, key *rsa.PrivateKey, certPEM []byte, tamper bool) []byte {
+	t.Helper()
+
+	metadataYAML := "--- !ruby/object:Gem::Specification\nname: rack\n"
+	if certPEM != nil {
+		metadataYAML += "cert_chain:\n- |\n  " + strings.ReplaceAll(strings.TrimSpace(string(certPEM)), "\n", "\n  ") + "\n"
+	}
+	var metadata bytes.Buffer
+	gz := gzip.NewWriter(&metadata)
+	if _, err := gz.Write([]byte(metadataYAML)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	members := []struct {
+		name string
+		data []byte
+	}{
+		{"metadata.gz", metadata.Bytes()},
+		{"data.tar.gz", []byte("pretend this is a data tarball")},
+	}
+
+	var gem bytes.Buffer
+	tw := tar.NewWriter(&gem)
+	write := func(name string, data []byte) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o444, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, member := range members {
+		data := member.data
+		write(member.name, data)
+		if key == nil {
+			continue
+		}
+		if tamper && member.name == "data.tar.gz" {
+			data = []byte("the tarball that was actually signed")
+		}
+		digest := sha256.Sum256(data)
+		hashed := sha256.Sum256(digest[:])
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		write(member.name+".sig", signature)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return gem.Bytes()
+}
@@ -0,0 +1,152 @@
+package geminstall
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GemRequire is what a Gemfile dependency asks to be required at boot.
+// Ruby developers: this is the `require:` option of `gem "foo", require: "foo/rails"`
+type GemRequire struct {
+	Name   string
+	Groups []string
+	Paths  []string // nil requires the gem by name, empty is `require: false`
+}
+
+// NewGemRequire builds a GemRequire from a parsed Gemfile dependency. require is the
+// parser's value: nil when absent, "" for `require: false`, else a path or an array.
+func NewGemRequire(name string, groups []string, require *string) GemRequire {
+	if len(groups) == 0 {
+		groups = []string{"default"}
+	}
+	entry := GemRequire{Name: name, Groups: groups}
+	if require == nil {
+		return entry
+	}
+
+	entry.Paths = []string{}
+	value := strings.Trim(strings.TrimSpace(*require), "[]")
+	for _, path := range strings.Split(value, ",") {
+		path = strings.Trim(strings.TrimSpace(path), `'":`)
+		if path != "" && path != "false" {
+			entry.Paths = append(entry.Paths, path)
+		}
+	}
+	return entry
+}
+
+// StandaloneSetupPath returns where `--standalone` writes setup.rb for a vendor dir:
+// <path>/bundler/setup.rb for gems installed in <path>/ruby/<version>, like Bundler.
+// A vendor dir without the ruby/<version> suffix is the bundle root itself.
+func StandaloneSetupPath(vendorDir string) string {
+	root := vendorDir
+	if filepath.Base(filepath.Dir(vendorDir)) == "ruby" {
+		root = filepath.Dir(filepath.Dir(vendorDir))
+	}
+	return filepath.Join(root, "bundler", "setup.rb")
+}
+
+// requirePathsPattern matches `s.require_paths = ["lib", "ext"]` in an installed gemspec
+var requirePathsPattern = regexp.MustCompile(`(?m)^\s*s\.require_paths\s*=\s*\[([^\]]*)\]`)
+
+// gemRequirePaths returns the require_paths of the gem installed as vendorDir/gems/<dir>,
+// read from its installed gemspec. Gems without one (git and path gems) use ["lib"].
+func gemRequirePaths(vendorDir, dir string) []string {
+	data, err := os.ReadFile(filepath.Join(vendorDir, "specifications", dir+".gemspec"))
+	if err != nil {
+		return []string{"lib"}
+	}
+	match := requirePathsPattern.FindSubmatch(data)
+	if match == nil {
+		return []string{"lib"}
+	}
+
+	var paths []string
+	for _, path := range strings.Split(string(match[1]), ",") {
+		if path = strings.Trim(strings.TrimSpace(path), `'"`); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return []string{"lib"}
+	}
+	return paths
+}
+
+// WriteStandaloneSetup writes a setup.rb that puts the installed gems (directory names
+// under vendorDir/gems) on the load path without Bundler, and defines Ore.require to
+// require the Gemfile's gems by group, honoring their require: options
+func WriteStandaloneSetup(setupPath, vendorDir string, gemDirs []string, requires []GemRequire) error {
+	setupDir, err := filepath.Abs(filepath.Dir(setupPath))
+	if err != nil {
+		return err
+	}
+	gemsDir, err := filepath.Abs(filepath.Join(vendorDir, "gems"))
+	if err != nil {
+		return err
+	}
+
+	var setup strings.Builder
+	setup.WriteString("# frozen_string_literal: true\n")
+	setup.WriteString("#\n")
+	setup.WriteString("# This file was generated by ore-light (ore install --standalone).\n")
+	setup.WriteString("# Require it instead of bundler/setup to use the bundle without Bundler.\n")
+	setup.WriteString("#\n\n")
+
+	for _, dir := range gemDirs {
+		for _, requirePath := range gemRequirePaths(vendorDir, dir) {
+			rel, err := filepath.Rel(setupDir, filepath.Join(gemsDir, dir, requirePath))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&setup, "$:.unshift File.expand_path(\"#{__dir__}/%s\")\n", filepath.ToSlash(rel))
+		}
+	}
+
+	setup.WriteString("\n")
+	setup.WriteString("# Like Bundler.require: requires the Gemfile's gems in the given groups (default: :default)\n")
+	setup.WriteString("module Ore\n")
+	setup.WriteString("  REQUIRES = [\n")
+	for _, entry := range requires {
+		groups := make([]string, len(entry.Groups))
+		for i, group := range entry.Groups {
+			groups[i] = ":" + group
+		}
+		paths := "nil"
+		if entry.Paths != nil {
+			quoted := make([]string, len(entry.Paths))
+			for i, path := range entry.Paths {
+				quoted[i] = fmt.Sprintf("%q", path)
+			}
+			paths = "[" + strings.Join(quoted, ", ") + "]"
+		}
+		fmt.Fprintf(&setup, "    [%q, [%s], %s],\n", entry.Name, strings.Join(groups, ", "), paths)
+	}
+	setup.WriteString("  ].freeze\n\n")
+	setup.WriteString("  def self.require(*groups)\n")
+	setup.WriteString("    groups = groups.empty? ? [:default] : groups.map(&:to_sym)\n")
+	setup.WriteString("    REQUIRES.each do |name, gem_groups, paths|\n")
+	setup.WriteString("      next if (gem_groups & groups).empty?\n")
+	setup.WriteString("      next paths.each { |path| Kernel.require(path) } if paths\n")
+	setup.WriteString("\n")
+	setup.WriteString("      begin\n")
+	setup.WriteString("        Kernel.require(name)\n")
+	setup.WriteString("      rescue LoadError\n")
+	setup.WriteString("        raise unless name.include?(\"-\")\n")
+	setup.WriteString("        Kernel.require(name.tr(\"-\", \"/\"))\n")
+	setup.WriteString("      end\n")
+	setup.WriteString("    end\n")
+	setup.WriteString("  end\n")
+	setup.WriteString("end\n")
+
+	if err := EnsureDir(setupDir); err != nil {
+		return err
+	}
+	if err := os.WriteFile(setupPath, []byte(setup.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", setupPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package geminstall
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/lockfile"
+)
+
+func TestNewGemRequire(t *testing.T) {
+	rails, requireFalse, list := "foo/rails", "", `["a", "b/c"]`
+	tests := []struct {
+		name    string
+		require *string
+		want    []string
+	}{
+		{"autorequire", nil, nil},
+		{"require path", &rails, []string{"foo/rails"}},
+		{"require false", &requireFalse, []string{}},
+		{"require array", &list, []string{"a", "b/c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewGemRequire("foo", nil, tt.require)
+			if (got.Paths == nil) != (tt.want == nil) || !slices.Equal(got.Paths, tt.want) {
+				t.Errorf("Paths = %#v, want %#v", got.Paths, tt.want)
+			}
+			if !slices.Equal(got.Groups, []string{"default"}) {
+				t.Errorf("Groups = %v, want [default]", got.Groups)
+			}
+		})
+	}
+}
+
+func TestWriteStandaloneSetup(t *testing.T) {
+	vendorDir := filepath.Join(t.TempDir(), "bundle", "ruby", "3.4.0")
+	setupPath := StandaloneSetupPath(vendorDir)
+	if want := filepath.Join(filepath.Dir(filepath.Dir(vendorDir)), "bundler", "setup.rb"); setupPath != want {
+		t.Fatalf("StandaloneSetupPath = %s, want %s", setupPath, want)
+	}
+
+	skip := ""
+	requires := []GemRequire{
+		NewGemRequire("rack", nil, nil),
+		NewGemRequire("rspec", []string{"test"}, &skip),
+	}
+	// multi's load path comes from the require_paths in its installed gemspec
+	multi := lockfile.GemSpec{Name: "multi", Version: "1.0.0"}
+	if err := WriteGemSpecification(vendorDir, multi, []byte("name: multi\nrequire_paths:\n- lib\n- ext/multi\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteStandaloneSetup(setupPath, vendorDir, []string{"rack-3.0.0", "multi-1.0.0"}, requires); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(setupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup := string(data)
+	for _, want := range []string{
+		`$:.unshift File.expand_path("#{__dir__}/../ruby/3.4.0/gems/rack-3.0.0/lib")`,
+		`$:.unshift File.expand_path("#{__dir__}/../ruby/3.4.0/gems/multi-1.0.0/lib")`,
+		`$:.unshift File.expand_path("#{__dir__}/../ruby/3.4.0/gems/multi-1.0.0/ext/multi")`,
+		`["rack", [:default], nil],`,
+		`["rspec", [:test], []],`,
+	} {
+		if !strings.Contains(setup, want) {
+			t.Errorf("setup.rb missing %q:\n%s", want, setup)
+		}
+	}
+}
+
+func TestStandaloneSetupPathWithoutRubyVersionDir(t *testing.T) {
+	// --vendor vendor/bundle installs gems right there, so setup.rb stays inside it
+	vendorDir := filepath.Join(t.TempDir(), "vendor", "bundle")
+	if got, want := StandaloneSetupPath(vendorDir), filepath.Join(vendorDir, "bundler", "setup.rb"); got != want {
+		t.Errorf("StandaloneSetupPath = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+package geminstall
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/contriboss/ore-light/internal/cache"
+)
+
+// SharedStoreDir returns the shared gem store kept under an ore cache dir.
+// Entries are named <fullname>-<digest>, e.g. <cache>/store/rack-3.0.0-1f2e3d4c5b6a7980
+func SharedStoreDir(cacheDir string) string {
+	return filepath.Join(cacheDir, cache.StoreDir)
+}
+
+// InstallFromStore installs the gem at gemPath as destDir (vendor/gems/<fullname>) from the
+// shared store. The gem is extracted into the store once per unique .gem content, and
+// destDir becomes a symlink to that entry; where symlinks aren't available the entry is
+// copied instead. Returns whether destDir was linked.
+//
+// Ruby developers: every bundle using the same gem version shares one extracted copy, so
+// editing files under a linked vendor/gems/<fullname> changes them for all of them.
+func InstallFromStore(storeDir, gemPath, fullName, destDir string) (bool, error) {
+	entry, err := ensureStoreEntry(storeDir, gemPath, fullName)
+	if err != nil {
+		return false, err
+	}
+
+	if err := EnsureDir(filepath.Dir(destDir)); err != nil {
+		return false, err
+	}
+	if err := os.RemoveAll(destDir); err != nil {
+		return false, err
+	}
+	if err := os.Symlink(entry, destDir); err == nil {
+		return true, nil
+	}
+	if err := copyTree(entry, destDir); err != nil {
+		return false, fmt.Errorf("failed to copy %s from the shared store: %w", fullName, err)
+	}
+	return false, nil
+}
+
+// ensureStoreEntry returns the store entry for a gem, extracting it first if needed
+func ensureStoreEntry(storeDir, gemPath, fullName string) (string, error) {
+	digest, err := gemDigest(gemPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", gemPath, err)
+	}
+	absStore, err := filepath.Abs(storeDir)
+	if err != nil {
+		return "", err
+	}
+	entry := filepath.Join(absStore, fullName+"-"+digest)
+	if info, err := os.Stat(entry); err == nil && info.IsDir() {
+		return entry, nil
+	}
+
+	if err := EnsureDir(absStore); err != nil {
+		return "", err
+	}
+	// Extract next to the entry and rename it into place, so other bundles never see
+	// a half-extracted gem
+	tmpDir, err := os.MkdirTemp(absStore, ".tmp-"+fullName+"-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	if _, err := ExtractGemContents(gemPath, tmpDir); err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", fullName, err)
+	}
+	if err := os.Chmod(tmpDir, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpDir, entry); err != nil {
+		// Another install extracted the same gem first
+		if info, statErr := os.Stat(entry); statErr == nil && info.IsDir() {
+			return entry, nil
+		}
+		return "", err
+	}
+	return entry, nil
+}
+
+// gemDigest returns a short SHA-256 of a cached gem's .gem content, so a compressed
+// and an uncompressed copy of the same gem share a store entry
+func gemDigest(gemPath string) (string, error) {
+	sum, err := GemSHA256(gemPath)
+	if err != nil {
+		return "", err
+	}
+	return sum[:16], nil
+}
+
+// GemSHA256 returns the hex SHA-256 of a cached gem's .gem content, decompressing
+// <name>.gem.zst first; this is the checksum a lockfile's CHECKSUMS section records
+func GemSHA256(gemPath string) (string, error) {
+	reader, err := OpenGem(gemPath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// SharedStoreLink reports whether an installed gem dir is a symlink, as --shared-store
+// installs them, and returns the store entry it points to
+func SharedStoreLink(gemDir string) (string, bool) {
+	info, err := os.Lstat(gemDir)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return "", false
+	}
+	target, err := os.Readlink(gemDir)
+	if err != nil {
+		return "", false
+	}
+	return target, true
+}
+
+// copyTree copies a directory tree, keeping file modes and symlinks
+func copyTree(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = file.Close()
+			}()
+			return writeFileFromReader(target, file, info.Mode().Perm())
+		}
+	})
+}
@@ -0,0 +1,246 @@
+// Package httpcache keeps registry API responses on disk and revalidates them
+// with conditional requests, so repeated searches and lookups cost a 304.
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/contriboss/ore-light/internal/config"
+	"github.com/contriboss/ore-light/internal/httpclient"
+)
+
+// CacheHeader is set on responses that were served from the on-disk cache
+const CacheHeader = "X-Ore-Cache"
+
+// entry is a cached response and the validators used to revalidate it
+type entry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+	Body         []byte    `json:"body"`
+}
+
+// Transport is an http.RoundTripper that stores GET responses carrying an ETag or
+// Last-Modified header and revalidates them with If-None-Match/If-Modified-Since.
+// A 304 Not Modified is answered with the cached body as a 200 OK.
+type Transport struct {
+	Dir  string            // Directory holding cached responses
+	Base http.RoundTripper // Underlying transport (http.DefaultTransport when nil)
+}
+
+var (
+	dirMu sync.RWMutex
+	dir   string
+)
+
+// SetDir sets where clients created afterwards keep responses, e.g. <ore cache dir>/http.
+// Until it's called, the http subdirectory of the default ore cache dir is used.
+func SetDir(d string) {
+	dirMu.Lock()
+	defer dirMu.Unlock()
+	dir = d
+}
+
+// DefaultDir returns the HTTP cache directory: the one given to SetDir, or the
+// http subdirectory of the default ore cache dir
+func DefaultDir() (string, error) {
+	dirMu.RLock()
+	d := dir
+	dirMu.RUnlock()
+	if d != "" {
+		return d, nil
+	}
+
+	cacheDir, err := config.DefaultCacheDir(nil)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "http"), nil
+}
+
+// NewClient returns an HTTP client that caches responses under DefaultDir.
+// Falls back to an uncached client when the cache dir can't be determined.
+func NewClient() *http.Client {
+	dir, err := DefaultDir()
+	if err != nil {
+		return httpclient.New()
+	}
+	return &http.Client{
+		Transport: &Transport{Dir: dir, Base: httpclient.NewTransport()},
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheable(req) {
+		return t.base().RoundTrip(req)
+	}
+
+	key := cacheKey(req.URL.String())
+	cached := t.load(key)
+
+	outgoing := req
+	if cached != nil {
+		outgoing = req.Clone(req.Context())
+		if cached.ETag != "" {
+			outgoing.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			outgoing.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := t.base().RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		_ = resp.Body.Close()
+		return cached.response(req, resp), nil
+	}
+
+	if resp.StatusCode != http.StatusOK || !storable(resp) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// A failed write only costs us the next revalidation
+	_ = t.save(key, &entry{
+		URL:          req.URL.String(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		ContentType:  resp.Header.Get("Content-Type"),
+		StoredAt:     time.Now(),
+		Body:         body,
+	})
+
+	return resp, nil
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// cacheable reports whether a request may be served from or stored in the cache.
+// Range and caller-supplied conditional requests are passed through untouched.
+func cacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	for _, header := range []string{"Range", "If-None-Match", "If-Modified-Since"} {
+		if req.Header.Get(header) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// storable reports whether a response has validators and may be written to disk
+func storable(resp *http.Response) bool {
+	if strings.Contains(strings.ToLower(resp.Header.Get("Cache-Control")), "no-store") {
+		return false
+	}
+	return resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// cacheKey returns the file name stem for a URL
+func cacheKey(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *Transport) path(key string) string {
+	return filepath.Join(t.Dir, key[:2], key+".json")
+}
+
+func (t *Transport) load(key string) *entry {
+	data, err := os.ReadFile(t.path(key))
+	if err != nil {
+		return nil
+	}
+	var cached entry
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+	return &cached
+}
+
+// save writes an entry atomically (temp file + rename)
+func (t *Transport) save(key string, e *entry) error {
+	path := t.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// response builds a 200 OK from a cached entry, keeping the 304's fresh headers
+func (e *entry) response(req *http.Request, notModified *http.Response) *http.Response {
+	header := notModified.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	if e.ContentType != "" {
+		header.Set("Content-Type", e.ContentType)
+	}
+	header.Set(CacheHeader, "revalidated")
+	header.Del("Content-Length")
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
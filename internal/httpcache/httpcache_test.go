@@ -0,0 +1,91 @@
+package httpcache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransportRevalidatesWithETag(t *testing.T) {
+	var full, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `[{"name":"rack"}]`)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{Dir: t.TempDir()}}
+
+	for i := range 3 {
+		resp, err := client.Get(server.URL + "/api/v1/search.json?query=rack")
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, resp.StatusCode)
+		}
+		if string(body) != `[{"name":"rack"}]` {
+			t.Errorf("request %d: unexpected body %q", i, body)
+		}
+		if i > 0 && resp.Header.Get(CacheHeader) == "" {
+			t.Errorf("request %d: expected response served from cache", i)
+		}
+		if resp.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request %d: expected cached content type, got %q", i, resp.Header.Get("Content-Type"))
+		}
+	}
+
+	if full != 1 || notModified != 2 {
+		t.Errorf("expected 1 full response and 2 revalidations, got %d and %d", full, notModified)
+	}
+}
+
+func TestTransportSkipsResponsesWithoutValidators(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-Modified-Since") != "" || r.Header.Get("If-None-Match") != "" {
+			t.Errorf("unexpected conditional request")
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{Dir: t.TempDir()}}
+	for range 2 {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestNewClientUsesSetDir(t *testing.T) {
+	want := t.TempDir()
+	SetDir(want)
+	t.Cleanup(func() { SetDir("") })
+
+	transport, ok := NewClient().Transport.(*Transport)
+	if !ok {
+		t.Fatalf("expected a caching transport")
+	}
+	if transport.Dir != want {
+		t.Errorf("expected cache dir %s, got %s", want, transport.Dir)
+	}
+}
@@ -0,0 +1,78 @@
+// Package httpclient builds the HTTP clients ore uses to talk to gem servers,
+// so downloads, search and the compact index share one timeout policy.
+package httpclient
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds each phase of a request (dial, TLS handshake, response headers)
+const DefaultTimeout = 60 * time.Second
+
+var (
+	timeoutMu sync.RWMutex
+	timeout   time.Duration
+)
+
+// SetTimeout sets the per-phase timeout for clients created after the call
+func SetTimeout(d time.Duration) {
+	timeoutMu.Lock()
+	defer timeoutMu.Unlock()
+	timeout = d
+}
+
+// Timeout returns the timeout set by SetTimeout, or DefaultTimeout
+func Timeout() time.Duration {
+	timeoutMu.RLock()
+	defer timeoutMu.RUnlock()
+	if timeout <= 0 {
+		return DefaultTimeout
+	}
+	return timeout
+}
+
+// ParseTimeout parses a Go duration ("90s", "2m") or a bare number of seconds ("90")
+func ParseTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.Atoi(value); err == nil {
+		value = fmt.Sprintf("%ds", seconds)
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("timeout must be positive")
+	}
+	return d, nil
+}
+
+// NewTransport returns a transport that applies Timeout to dialing, the TLS handshake
+// and waiting for response headers. There is no deadline on the body, so a large
+// download that keeps streaming isn't killed mid-way like with http.Client.Timeout.
+func NewTransport() *http.Transport {
+	d := Timeout()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = Proxy
+	transport.DialContext = (&net.Dialer{
+		Timeout:   d,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = d
+	transport.ResponseHeaderTimeout = d
+
+	return transport
+}
+
+// New returns an HTTP client using NewTransport
+func New() *http.Client {
+	return &http.Client{Transport: NewTransport()}
+}
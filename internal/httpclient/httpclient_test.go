@@ -0,0 +1,47 @@
+package httpclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90":    90 * time.Second,
+		"90s":   90 * time.Second,
+		"2m":    2 * time.Minute,
+		" 5 ":   5 * time.Second,
+		"1m30s": 90 * time.Second,
+	}
+	for value, want := range tests {
+		got, err := ParseTimeout(value)
+		if err != nil {
+			t.Errorf("ParseTimeout(%q) error = %v", value, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseTimeout(%q) = %v, want %v", value, got, want)
+		}
+	}
+
+	for _, value := range []string{"", "abc", "0", "-5s"} {
+		if _, err := ParseTimeout(value); err == nil {
+			t.Errorf("ParseTimeout(%q) expected error", value)
+		}
+	}
+}
+
+func TestNewTransportUsesTimeout(t *testing.T) {
+	SetTimeout(3 * time.Minute)
+	defer SetTimeout(0)
+
+	transport := NewTransport()
+	if transport.ResponseHeaderTimeout != 3*time.Minute || transport.TLSHandshakeTimeout != 3*time.Minute {
+		t.Errorf("expected per-phase timeouts of 3m, got header=%v tls=%v",
+			transport.ResponseHeaderTimeout, transport.TLSHandshakeTimeout)
+	}
+
+	if client := New(); client.Timeout != 0 {
+		t.Errorf("expected no overall client timeout, got %v", client.Timeout)
+	}
+}
@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Proxy picks the proxy for a request. Precedence:
+//  1. ORE_SOCKS_PROXY (always used, even for NO_PROXY hosts)
+//  2. HTTPS_PROXY / HTTP_PROXY for the request's scheme, honoring NO_PROXY
+//  3. ALL_PROXY, honoring NO_PROXY
+//
+// socks5:// and socks5h:// proxies are dialed by net/http's built-in SOCKS5 client
+// through the transport's dialer, so TLS and the dial timeout apply as usual.
+func Proxy(req *http.Request) (*url.URL, error) {
+	if value := os.Getenv("ORE_SOCKS_PROXY"); value != "" {
+		return parseProxyURL(value, "socks5")
+	}
+
+	proxyURL, err := http.ProxyFromEnvironment(req)
+	if err != nil || proxyURL != nil {
+		return proxyURL, err
+	}
+
+	value := getenvAny("ALL_PROXY", "all_proxy")
+	if value == "" || noProxy(req.URL.Hostname()) {
+		return nil, nil
+	}
+	return parseProxyURL(value, "socks5")
+}
+
+// parseProxyURL parses a proxy setting, assuming defaultScheme for "host:port" values
+func parseProxyURL(value, defaultScheme string) (*url.URL, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "://") {
+		value = defaultScheme + "://" + value
+	}
+
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", value, err)
+	}
+
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h", "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use socks5, socks5h, http or https)", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", value)
+	}
+
+	return proxyURL, nil
+}
+
+// noProxy reports whether NO_PROXY excludes host, using curl's rules:
+// "*" matches everything, "example.com" and ".example.com" match the domain and its subdomains
+func noProxy(host string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range strings.Split(getenvAny("NO_PROXY", "no_proxy"), ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		entry = strings.TrimPrefix(entry, ".")
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// getenvAny returns the first non-empty environment variable among names
+func getenvAny(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package httpclient
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestProxyPrecedence(t *testing.T) {
+	// http.ProxyFromEnvironment reads its variables once per process, so keep them unset
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("https_proxy", "")
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("http_proxy", "")
+	t.Setenv("NO_PROXY", "internal.example.com")
+	t.Setenv("no_proxy", "")
+
+	req := httptest.NewRequest("GET", "https://rubygems.org/versions", nil)
+	internal := httptest.NewRequest("GET", "https://gems.internal.example.com/versions", nil)
+
+	t.Setenv("ALL_PROXY", "socks5h://127.0.0.1:1080")
+	t.Setenv("ORE_SOCKS_PROXY", "")
+	if got, err := Proxy(req); err != nil || got == nil || got.String() != "socks5h://127.0.0.1:1080" {
+		t.Errorf("expected ALL_PROXY, got %v (err %v)", got, err)
+	}
+	if got, _ := Proxy(internal); got != nil {
+		t.Errorf("expected NO_PROXY host to bypass ALL_PROXY, got %v", got)
+	}
+
+	t.Setenv("ORE_SOCKS_PROXY", "proxy.local:9050")
+	if got, err := Proxy(internal); err != nil || got == nil || got.String() != "socks5://proxy.local:9050" {
+		t.Errorf("expected ORE_SOCKS_PROXY to win, got %v (err %v)", got, err)
+	}
+
+	t.Setenv("ORE_SOCKS_PROXY", "ftp://proxy.local:21")
+	if _, err := Proxy(req); err == nil {
+		t.Error("expected unsupported scheme error")
+	}
+}
+
+func TestTransportDialsThroughSOCKS5(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "via socks")
+	}))
+	defer server.Close()
+
+	var connections atomic.Int32
+	proxyAddr := startSOCKS5Proxy(t, &connections)
+	t.Setenv("ORE_SOCKS_PROXY", "socks5://"+proxyAddr)
+
+	resp, err := New().Get(server.URL)
+	if err != nil {
+		t.Fatalf("request through proxy failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if string(body) != "via socks" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if connections.Load() != 1 {
+		t.Errorf("expected 1 proxied connection, got %d", connections.Load())
+	}
+}
+
+// startSOCKS5Proxy runs a minimal no-auth SOCKS5 CONNECT proxy for tests
+func startSOCKS5Proxy(t *testing.T, connections *atomic.Int32) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			connections.Add(1)
+			go serveSOCKS5(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func serveSOCKS5(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	// Greeting: VER NMETHODS METHODS...
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return
+	}
+
+	// Request: VER CMD RSV ATYP DST.ADDR DST.PORT
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		addr := make([]byte, 4)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return
+		}
+		host = net.IP(addr).String()
+	case 3:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return
+		}
+		name := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return
+		}
+		host = string(name)
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer func() { _ = target.Close() }()
+
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	go func() { _, _ = io.Copy(target, conn) }()
+	_, _ = io.Copy(conn, target)
+}
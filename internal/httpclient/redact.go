@@ -0,0 +1,95 @@
+package httpclient
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces secrets in URLs shown to the user
+const redacted = "***"
+
+// secretParams are query parameters that carry credentials (compared case-insensitively)
+var secretParams = map[string]bool{
+	"token":                true,
+	"access_token":         true,
+	"auth_token":           true,
+	"private_token":        true,
+	"api_key":              true,
+	"apikey":               true,
+	"key":                  true,
+	"secret":               true,
+	"password":             true,
+	"x-amz-signature":      true,
+	"x-amz-credential":     true,
+	"x-amz-security-token": true,
+}
+
+// RedactURL masks credentials in a URL before it is printed or put in an error:
+// the password in user:pass@ (user:***@), a bare token in token@ (***@) and the
+// values of token-like query parameters. Strings that don't parse are returned as-is.
+func RedactURL(raw string) string {
+	if !strings.Contains(raw, "@") && !strings.Contains(raw, "?") {
+		return raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return raw
+	}
+
+	if parsed.User != nil {
+		username := parsed.User.Username()
+		password, hasPassword := parsed.User.Password()
+		switch {
+		case hasPassword && password == redacted:
+			// net/http's own errors mask the password but keep a token sent as the user name
+			parsed.User = url.User(redacted)
+		case hasPassword && password != "" && password != "x-oauth-basic":
+			parsed.User = url.UserPassword(username, redacted)
+		case username != "":
+			// token@ and token:@ carry the secret in the user name
+			parsed.User = url.User(redacted)
+		}
+	}
+
+	if parsed.RawQuery != "" {
+		query := parsed.Query()
+		changed := false
+		for name := range query {
+			if secretParams[strings.ToLower(name)] {
+				query[name] = []string{redacted}
+				changed = true
+			}
+		}
+		if changed {
+			parsed.RawQuery = query.Encode()
+		}
+	}
+
+	// url.URL escapes "*" in user info; keep the mask readable
+	return strings.ReplaceAll(parsed.String(), "%2A%2A%2A", redacted)
+}
+
+// urlPattern finds URLs inside free text such as error messages or git output
+var urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+
+// RedactURLs applies RedactURL to every URL in text
+func RedactURLs(text string) string {
+	return urlPattern.ReplaceAllStringFunc(text, RedactURL)
+}
+
+// RedactError returns err with the URLs in its message redacted; errors.Is and
+// errors.As still see the original error
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err}
+}
+
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string { return RedactURLs(e.err.Error()) }
+func (e *redactedError) Unwrap() error { return e.err }
@@ -1,45 +1,76 @@
 package logger
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 	"strings"
+	"sync/atomic"
+)
+
+// Level is how much ore prints, set with the global --quiet, --verbose (-v) and
+// --debug flags. Commands consult it through Quiet, Verbose and Debugging.
+type Level int
+
+const (
+	LevelQuiet   Level = iota - 1 // Errors only
+	LevelNormal                   // Progress and summaries
+	LevelVerbose                  // Plus per-gem resolution, download and extension detail
+	LevelDebug                    // Plus gem metadata (YAML) dumps
 )
 
 var (
 	// Default logger instance
 	Log *slog.Logger
+
+	level atomic.Int32
 )
 
 func init() {
-	// Initialize with default logger (info level)
-	SetupLogger(false)
+	// ORE_DEBUG works without the --debug flag, e.g. when ore is used as a library
+	Setup(EnvLevel(LevelNormal))
 }
 
-// SetupLogger configures the global logger
-// verbose=true enables debug level, false uses info level
-func SetupLogger(verbose bool) {
-	level := slog.LevelInfo
-	if verbose {
-		level = slog.LevelDebug
+// EnvLevel applies the environment to a level given on the command line: the older
+// ORE_DEBUG and ORE_DEBUG_YAML switches and ORE_LOG_LEVEL=debug all mean LevelDebug
+func EnvLevel(l Level) Level {
+	if os.Getenv("ORE_DEBUG") != "" || os.Getenv("ORE_DEBUG_YAML") != "" ||
+		strings.EqualFold(os.Getenv("ORE_LOG_LEVEL"), "debug") {
+		return LevelDebug
+	}
+	return l
+}
+
+// Setup sets the verbosity and configures the global logger to match: quiet logs only
+// errors, verbose and debug include debug messages. ORE_LOG_LEVEL overrides the
+// logger's level (debug, info, warn or error).
+func Setup(l Level) {
+	level.Store(int32(l))
+
+	slogLevel := slog.LevelInfo
+	switch {
+	case l <= LevelQuiet:
+		slogLevel = slog.LevelError
+	case l >= LevelVerbose:
+		slogLevel = slog.LevelDebug
 	}
 
 	// Check environment variable for log level override
 	if envLevel := os.Getenv("ORE_LOG_LEVEL"); envLevel != "" {
 		switch strings.ToLower(envLevel) {
 		case "debug":
-			level = slog.LevelDebug
+			slogLevel = slog.LevelDebug
 		case "info":
-			level = slog.LevelInfo
+			slogLevel = slog.LevelInfo
 		case "warn", "warning":
-			level = slog.LevelWarn
+			slogLevel = slog.LevelWarn
 		case "error":
-			level = slog.LevelError
+			slogLevel = slog.LevelError
 		}
 	}
 
 	opts := &slog.HandlerOptions{
-		Level: level,
+		Level: slogLevel,
 	}
 
 	handler := slog.NewTextHandler(os.Stderr, opts)
@@ -47,6 +78,40 @@ func SetupLogger(verbose bool) {
 	slog.SetDefault(Log)
 }
 
+// CurrentLevel returns the verbosity set by Setup
+func CurrentLevel() Level {
+	return Level(level.Load())
+}
+
+// Quiet reports whether only errors should be printed
+func Quiet() bool {
+	return CurrentLevel() <= LevelQuiet
+}
+
+// Printf prints a progress or summary line to stdout unless --quiet is set
+func Printf(format string, args ...any) {
+	if !Quiet() {
+		fmt.Printf(format, args...)
+	}
+}
+
+// Println is Printf for a line without formatting
+func Println(args ...any) {
+	if !Quiet() {
+		fmt.Println(args...)
+	}
+}
+
+// Verbose reports whether per-gem detail should be printed
+func Verbose() bool {
+	return CurrentLevel() >= LevelVerbose
+}
+
+// Debugging reports whether debug dumps should be printed
+func Debugging() bool {
+	return CurrentLevel() >= LevelDebug
+}
+
 // Debug logs a debug message
 func Debug(msg string, args ...any) {
 	Log.Debug(msg, args...)
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestEnvLevelMapsDebugVariables(t *testing.T) {
+	for _, name := range []string{"ORE_DEBUG", "ORE_DEBUG_YAML"} {
+		t.Setenv("ORE_DEBUG", "")
+		t.Setenv("ORE_DEBUG_YAML", "")
+		t.Setenv("ORE_LOG_LEVEL", "")
+		if got := EnvLevel(LevelNormal); got != LevelNormal {
+			t.Fatalf("expected LevelNormal without debug variables, got %v", got)
+		}
+		t.Setenv(name, "1")
+		if got := EnvLevel(LevelQuiet); got != LevelDebug {
+			t.Errorf("expected %s to mean LevelDebug, got %v", name, got)
+		}
+	}
+}
+
+func TestSetupLevels(t *testing.T) {
+	t.Setenv("ORE_LOG_LEVEL", "")
+	defer Setup(LevelNormal)
+
+	tests := []struct {
+		level                     Level
+		quiet, verbose, debugging bool
+		slogDebug, slogInfo       bool
+	}{
+		{LevelQuiet, true, false, false, false, false},
+		{LevelNormal, false, false, false, false, true},
+		{LevelVerbose, false, true, false, true, true},
+		{LevelDebug, false, true, true, true, true},
+	}
+
+	for _, tt := range tests {
+		Setup(tt.level)
+		if Quiet() != tt.quiet || Verbose() != tt.verbose || Debugging() != tt.debugging {
+			t.Errorf("level %v: Quiet=%v Verbose=%v Debugging=%v", tt.level, Quiet(), Verbose(), Debugging())
+		}
+		ctx := context.Background()
+		if Log.Enabled(ctx, slog.LevelDebug) != tt.slogDebug || Log.Enabled(ctx, slog.LevelInfo) != tt.slogInfo {
+			t.Errorf("level %v: unexpected log level", tt.level)
+		}
+	}
+}
+
+func TestPrintfHonorsQuiet(t *testing.T) {
+	t.Setenv("ORE_LOG_LEVEL", "")
+	defer Setup(LevelNormal)
+
+	capture := func(level Level) string {
+		Setup(level)
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		stdout := os.Stdout
+		os.Stdout = w
+		Printf("✨ Wrote %s\n", "Gemfile.lock")
+		Println("💡 done")
+		os.Stdout = stdout
+		_ = w.Close()
+		out, _ := io.ReadAll(r)
+		return string(out)
+	}
+
+	if got := capture(LevelNormal); got != "✨ Wrote Gemfile.lock\n💡 done\n" {
+		t.Errorf("expected status lines at the normal level, got %q", got)
+	}
+	if got := capture(LevelQuiet); got != "" {
+		t.Errorf("expected nothing under --quiet, got %q", got)
+	}
+}
@@ -13,8 +13,10 @@ import (
 // CompactIndexSource implements pubgrub.Source using Bundler's compact index cache.
 type CompactIndexSource struct {
 	client      *compactindex.Client
-	cache       map[string]map[string][]pubgrub.Term // gem -> version -> dependencies
-	versions    map[string][]pubgrub.Version         // gem -> versions (cached)
+	cache       map[string]map[string][]pubgrub.Term  // gem -> version -> dependencies
+	versions    map[string][]pubgrub.Version          // gem -> versions (cached)
+	infos       map[string][]compactindex.VersionInfo // gem -> parsed info file
+	graph       *graphCache                           // Info kept between runs; nil when disabled
 	mu          sync.RWMutex
 	sourceURL   string
 	versionPins map[string]string
@@ -32,6 +34,8 @@ func NewCompactIndexSource(baseURL string) (*CompactIndexSource, error) {
 		client:      client,
 		cache:       make(map[string]map[string][]pubgrub.Term),
 		versions:    make(map[string][]pubgrub.Version),
+		infos:       make(map[string][]compactindex.VersionInfo),
+		graph:       newGraphCache(client),
 		sourceURL:   baseURL,
 		versionPins: nil,
 	}, nil
@@ -44,6 +48,15 @@ func (s *CompactIndexSource) SetVersionPins(pins map[string]string) {
 	s.versionPins = pins
 }
 
+// Refresh makes the source fetch every gem's info from the server instead of reusing
+// what earlier runs resolved (ore lock --refresh)
+func (s *CompactIndexSource) Refresh() {
+	s.client.Revalidate()
+	if s.graph != nil {
+		s.graph.refresh = true
+	}
+}
+
 // SourceURL returns the URL of this gem source.
 func (s *CompactIndexSource) SourceURL() string {
 	return s.sourceURL
@@ -74,17 +87,16 @@ func (s *CompactIndexSource) GetVersions(name pubgrub.Name) ([]pubgrub.Version,
 	}
 	s.mu.RUnlock()
 
-	// Fetch from compact index
-	ctx := context.Background()
-	infoList, err := s.client.GetGemInfo(ctx, gemName)
+	infoList, err := s.gemInfo(gemName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get gem info for %s: %w", gemName, err)
+		return nil, err
 	}
 
 	// Convert to SemverVersions
 	semverVersions := make([]pubgrub.Version, 0, len(infoList))
 	for _, info := range infoList {
-		// Skip platform-specific versions for now
+		// Only ruby variants are locked, so a force_ruby_platform gem can always be
+		// installed from source; install picks platform builds itself
 		if info.Platform != "" {
 			continue
 		}
@@ -125,11 +137,9 @@ func (s *CompactIndexSource) GetDependencies(name pubgrub.Name, version pubgrub.
 	}
 	s.mu.RUnlock()
 
-	// Fetch from compact index
-	ctx := context.Background()
-	infoList, err := s.client.GetGemInfo(ctx, gemName)
+	infoList, err := s.gemInfo(gemName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get gem info for %s: %w", gemName, err)
+		return nil, err
 	}
 
 	// Find the specific version
@@ -176,3 +186,29 @@ func (s *CompactIndexSource) GetDependencies(name pubgrub.Name, version pubgrub.
 
 	return terms, nil
 }
+
+// gemInfo returns a gem's parsed info file, read once per run and reused across runs
+// through the graph cache while the gem is unchanged
+func (s *CompactIndexSource) gemInfo(gemName string) ([]compactindex.VersionInfo, error) {
+	s.mu.RLock()
+	infoList, ok := s.infos[gemName]
+	s.mu.RUnlock()
+	if ok {
+		return infoList, nil
+	}
+
+	ctx := context.Background()
+	infoList, ok = s.graph.load(ctx, gemName)
+	if !ok {
+		var err error
+		if infoList, err = s.client.GetGemInfo(ctx, gemName); err != nil {
+			return nil, fmt.Errorf("failed to get gem info for %s: %w", gemName, err)
+		}
+		s.graph.save(gemName, infoList)
+	}
+
+	s.mu.Lock()
+	s.infos[gemName] = infoList
+	s.mu.Unlock()
+	return infoList, nil
+}
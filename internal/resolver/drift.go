@@ -0,0 +1,91 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/contriboss/gemfile-go/lockfile"
+)
+
+// GitGemDrift reports how a git gem's source has moved away from the lockfile: the
+// locked revision is gone from the remote, or the branch, tag or ref the Gemfile asks
+// for now resolves to another commit. branch, tag and ref are the Gemfile's. It returns
+// "" when the source still matches; errors are failures to reach the repository.
+func GitGemDrift(ctx context.Context, spec lockfile.GitGemSpec, branch, tag, ref string) (string, error) {
+	g, err := NewGitSource(spec.Remote, branch, tag, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to create git source: %w", err)
+	}
+	repoDir := g.getRepoDir()
+	if err := g.cloneOrUpdate(ctx, repoDir); err != nil {
+		return "", err
+	}
+
+	if _, err := g.resolveCommit(ctx, repoDir, spec.Revision); err != nil {
+		var notFound *RevisionNotFoundError
+		if errors.As(err, &notFound) {
+			notFound.Gem = spec.Name
+		}
+		return err.Error(), nil
+	}
+
+	output, err := exec.Command("git", "-C", repoDir, "rev-parse", "--verify", "--quiet", g.refName()+"^{commit}").Output()
+	if err != nil {
+		return fmt.Sprintf("%s no longer exists in the repository; update the Gemfile and run `ore update %s`", describeGitRef(branch, tag, ref), spec.Name), nil
+	}
+	current := strings.TrimSpace(string(output))
+	if !strings.HasPrefix(current, strings.ToLower(spec.Revision)) {
+		return fmt.Sprintf("%s now points at %s, but the lockfile locks %s; run `ore update %s` to re-lock it",
+			describeGitRef(branch, tag, ref), shortRevision(current), shortRevision(spec.Revision), spec.Name), nil
+	}
+	return "", nil
+}
+
+// describeGitRef names the branch, tag or ref a git gem follows, for messages
+func describeGitRef(branch, tag, ref string) string {
+	switch {
+	case tag != "":
+		return "tag " + tag
+	case branch != "":
+		return "branch " + branch
+	case ref != "":
+		return "ref " + ref
+	default:
+		return "the default branch"
+	}
+}
+
+// PathGemDrift reports how a path gem's source has moved away from what was locked
+// and installed: its gemspec declares another version than the lockfile, or its files
+// changed since ore install copied them to gemDir. It returns "" when neither happened.
+func PathGemDrift(spec lockfile.PathGemSpec, gemDir string) (string, error) {
+	p, err := NewPathSource(spec.Remote)
+	if err != nil {
+		return "", err
+	}
+
+	// Only the gem's own gemspec says which version it is; a path may hold several
+	gemspecPath := filepath.Join(p.AbsPath, spec.Name+".gemspec")
+	if _, statErr := os.Stat(gemspecPath); statErr == nil {
+		version, _, err := p.parseGemspec(gemspecPath)
+		// A version set from a constant (Demo::VERSION) can't be read statically
+		if err == nil && version != "" && unicode.IsDigit(rune(version[0])) && version != spec.Version {
+			return fmt.Sprintf("%s.gemspec is now version %s, but the lockfile locks %s; run `ore lock` to re-lock it", spec.Name, version, spec.Version), nil
+		}
+	}
+
+	changed, err := PathGemChanged(spec, gemDir)
+	if err != nil {
+		return "", err
+	}
+	if changed {
+		return fmt.Sprintf("files in %s changed since it was installed (content hash differs); run `ore install` to reinstall it", spec.Remote), nil
+	}
+	return "", nil
+}
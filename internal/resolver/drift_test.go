@@ -0,0 +1,106 @@
+package resolver
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/lockfile"
+)
+
+func TestGitGemDrift(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir()) // Keep the git cache out of the real ~/.cache
+
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=ore", "GIT_AUTHOR_EMAIL=ore@example.com", "GIT_COMMITTER_NAME=ore", "GIT_COMMITTER_EMAIL=ore@example.com")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	git("init", "--quiet")
+	git("commit", "--quiet", "--allow-empty", "-m", "first")
+	first := git("rev-parse", "HEAD")
+	branch := git("rev-parse", "--abbrev-ref", "HEAD")
+	git("tag", "v0.1.0")
+
+	spec := lockfile.GitGemSpec{Name: "demo", Version: "0.1.0", Remote: repo, Revision: first}
+	ctx := context.Background()
+	if reason, err := GitGemDrift(ctx, spec, branch, "", ""); err != nil || reason != "" {
+		t.Fatalf("expected no drift, got %q, %v", reason, err)
+	}
+
+	// The branch moved on since it was locked
+	git("commit", "--quiet", "--allow-empty", "-m", "second")
+	reason, err := GitGemDrift(ctx, spec, branch, "", "")
+	if err != nil || !strings.Contains(reason, "branch "+branch+" now points at") || !strings.Contains(reason, "ore update demo") {
+		t.Errorf("expected moved branch drift, got %q, %v", reason, err)
+	}
+
+	// A tag that still points at the locked commit hasn't drifted
+	if reason, err := GitGemDrift(ctx, spec, "", "v0.1.0", ""); err != nil || reason != "" {
+		t.Errorf("expected tag to match, got %q, %v", reason, err)
+	}
+
+	// The tag was force-moved on the remote after the cache fetched it
+	git("tag", "--force", "v0.1.0")
+	if reason, err := GitGemDrift(ctx, spec, "", "v0.1.0", ""); err != nil || !strings.Contains(reason, "tag v0.1.0 now points at") {
+		t.Errorf("expected moved tag drift, got %q, %v", reason, err)
+	}
+
+	// The Gemfile names a branch that's gone
+	if reason, err := GitGemDrift(ctx, spec, "gone", "", ""); err != nil || !strings.Contains(reason, "branch gone no longer exists") {
+		t.Errorf("expected missing branch drift, got %q, %v", reason, err)
+	}
+
+	// The locked revision is gone from the remote
+	spec.Revision = strings.Repeat("0", 40)
+	if reason, err := GitGemDrift(ctx, spec, branch, "", ""); err != nil || !strings.Contains(reason, "ore update demo") {
+		t.Errorf("expected missing revision drift, got %q, %v", reason, err)
+	}
+}
+
+func TestPathGemDrift(t *testing.T) {
+	source := t.TempDir()
+	writeGemspec := func(version string) {
+		t.Helper()
+		gemspec := "Gem::Specification.new do |s|\n  s.name = \"demo\"\n  s.version = \"" + version + "\"\nend\n"
+		if err := os.WriteFile(filepath.Join(source, "demo.gemspec"), []byte(gemspec), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeGemspec("0.1.0")
+
+	spec := lockfile.PathGemSpec{Name: "demo", Version: "0.1.0", Remote: source}
+	gemDir := filepath.Join(t.TempDir(), "demo-0.1.0")
+	if err := CopyPathGem(spec, gemDir); err != nil {
+		t.Fatalf("CopyPathGem: %v", err)
+	}
+	if reason, err := PathGemDrift(spec, gemDir); err != nil || reason != "" {
+		t.Fatalf("expected no drift, got %q, %v", reason, err)
+	}
+
+	// Edited after install
+	if err := os.WriteFile(filepath.Join(source, "README.md"), []byte("demo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if reason, err := PathGemDrift(spec, gemDir); err != nil || !strings.Contains(reason, "content hash differs") {
+		t.Errorf("expected content drift, got %q, %v", reason, err)
+	}
+
+	// Version bumped without re-locking
+	writeGemspec("0.2.0")
+	if reason, err := PathGemDrift(spec, gemDir); err != nil || !strings.Contains(reason, "now version 0.2.0") {
+		t.Errorf("expected version drift, got %q, %v", reason, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/errdefs"
 	"github.com/contriboss/ore-light/internal/ruby"
 )
 
@@ -67,6 +68,13 @@ func (ec *EngineCompatibility) IsCompatible(gem lockfile.GemSpec) bool {
 	return ec.engine.SupportsNativeExtensions()
 }
 
+// LacksExtensionSupport reports whether a gem is incompatible only because it has
+// native C extensions the engine can't build (e.g. a platform "ruby" gem with an
+// extconf.rb on JRuby), rather than because it's built for another platform
+func (ec *EngineCompatibility) LacksExtensionSupport(gem lockfile.GemSpec) bool {
+	return ec.isPlatformCompatible(gem.Platform) && hasNativeCExtension(gem) && !ec.engine.SupportsNativeExtensions()
+}
+
 // isPlatformCompatible checks if a platform is compatible with the engine
 func (ec *EngineCompatibility) isPlatformCompatible(platform string) bool {
 	if platform == "" || platform == "ruby" {
@@ -131,3 +139,22 @@ func (ec *EngineCompatibility) GetIncompatibilityReason(gem lockfile.GemSpec) st
 
 	return "incompatible with " + ec.engine.Name
 }
+
+// CheckRubyRequirement validates the active engine against the Gemfile's ruby directive
+// Ruby developers: This mirrors Bundler's "Your Ruby engine is X, but your Gemfile specified Y"
+func (ec *EngineCompatibility) CheckRubyRequirement(req *ruby.Requirement) error {
+	if req == nil || req.Engine == "" {
+		return nil
+	}
+
+	if ec.engine.Name != req.Engine {
+		return errdefs.Newf(errdefs.ErrPlatformMismatch, "your Ruby engine is %s, but your Gemfile specified %s", ec.engine.Name, req.Engine)
+	}
+
+	// engine_version pins the engine's own release (JRuby 9.4.0.0), not RUBY_VERSION
+	if req.EngineVersion != "" && ec.engine.EngineVersion != "" && ec.engine.EngineVersion != req.EngineVersion {
+		return errdefs.Newf(errdefs.ErrPlatformMismatch, "your %s version is %s, but your Gemfile specified %s %s", req.Engine, ec.engine.EngineVersion, req.Engine, req.EngineVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package resolver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/errdefs"
+	"github.com/contriboss/ore-light/internal/ruby"
+)
+
+func TestCheckRubyRequirement(t *testing.T) {
+	ec := NewEngineCompatibility(ruby.Engine{Name: ruby.EngineMRI, Version: "3.3.0"})
+
+	if err := ec.CheckRubyRequirement(&ruby.Requirement{Version: "3.3.0"}); err != nil {
+		t.Errorf("expected no error without engine, got %v", err)
+	}
+	if err := ec.CheckRubyRequirement(&ruby.Requirement{Version: "3.3.0", Engine: ruby.EngineMRI}); err != nil {
+		t.Errorf("expected matching engine to pass, got %v", err)
+	}
+
+	err := ec.CheckRubyRequirement(&ruby.Requirement{Version: "3.1.4", Engine: ruby.EngineJRuby})
+	if err == nil || !strings.Contains(err.Error(), "jruby") {
+		t.Errorf("expected engine mismatch error, got %v", err)
+	}
+
+	// engine_version is checked against RUBY_ENGINE_VERSION, when it's known
+	jruby := NewEngineCompatibility(ruby.Engine{Name: ruby.EngineJRuby, Version: "3.1.4", EngineVersion: "9.4.5.0"})
+	req := &ruby.Requirement{Version: "3.1.4", Engine: ruby.EngineJRuby, EngineVersion: "9.4.0.0"}
+	if err := jruby.CheckRubyRequirement(req); !errors.Is(err, errdefs.ErrPlatformMismatch) || !strings.Contains(err.Error(), "jruby version is 9.4.5.0") {
+		t.Errorf("expected engine version mismatch error, got %v", err)
+	}
+	req.EngineVersion = "9.4.5.0"
+	if err := jruby.CheckRubyRequirement(req); err != nil {
+		t.Errorf("expected matching engine version to pass, got %v", err)
+	}
+	unknown := NewEngineCompatibility(ruby.Engine{Name: ruby.EngineJRuby})
+	if err := unknown.CheckRubyRequirement(&ruby.Requirement{Engine: ruby.EngineJRuby, EngineVersion: "9.4.0.0"}); err != nil {
+		t.Errorf("expected an undetected engine version to pass, got %v", err)
+	}
+}
+
+func TestRenderLockfileRubyVersion(t *testing.T) {
+	lock := &lockfile.Lockfile{
+		GemSpecs:    []lockfile.GemSpec{{Name: "rack", Version: "3.0.0", SourceURL: "https://rubygems.org/"}},
+		Platforms:   []string{"ruby"},
+		BundledWith: "2.7.2",
+	}
+	req := &ruby.Requirement{Version: "3.1.4", Engine: ruby.EngineJRuby, EngineVersion: "9.4.0.0"}
+
+	content, err := renderLockfile(lock, req)
+	if err != nil {
+		t.Fatalf("renderLockfile returned error: %v", err)
+	}
+
+	out := string(content)
+	rubyIdx := strings.Index(out, "RUBY VERSION\n   ruby 3.1.4 (jruby 9.4.0.0)\n")
+	bundledIdx := strings.Index(out, "BUNDLED WITH")
+	if rubyIdx < 0 || bundledIdx < 0 || rubyIdx > bundledIdx {
+		t.Fatalf("expected RUBY VERSION before BUNDLED WITH, got:\n%s", out)
+	}
+
+	parsed, err := lockfile.Parse(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("failed to re-parse rendered lockfile: %v", err)
+	}
+	if parsed.BundledWith != "2.7.2" {
+		t.Errorf("expected BUNDLED WITH to survive, got %q", parsed.BundledWith)
+	}
+}
+
+func TestEngineCompatibilityDecisions(t *testing.T) {
+	mri := ruby.Engine{Name: ruby.EngineMRI, Version: "3.3.0"}
+	jruby := ruby.Engine{Name: ruby.EngineJRuby, Version: "9.4.5.0"}
+	truffle := ruby.Engine{Name: ruby.EngineTruffleRuby, Version: "24.0.0"}
+
+	pure := lockfile.GemSpec{Name: "rack", Version: "3.0.0"}
+	cExt := lockfile.GemSpec{Name: "bcrypt", Version: "3.1.20", Extensions: []string{"ext/mri/extconf.rb"}}
+	jarExt := lockfile.GemSpec{Name: "jrjackson", Version: "0.4.18", Extensions: []string{"ext/Rakefile"}}
+	javaPlatform := lockfile.GemSpec{Name: "nokogiri", Version: "1.16.0", Platform: "java"}
+	linuxPlatform := lockfile.GemSpec{Name: "nokogiri", Version: "1.16.0", Platform: "x86_64-linux"}
+
+	tests := []struct {
+		name         string
+		engine       ruby.Engine
+		gem          lockfile.GemSpec
+		compatible   bool
+		lacksSupport bool
+		reason       string
+	}{
+		{"pure gem on JRuby", jruby, pure, true, false, ""},
+		{"C extension on MRI", mri, cExt, true, false, ""},
+		{"C extension on TruffleRuby", truffle, cExt, true, false, ""},
+		{"C extension on JRuby", jruby, cExt, false, true, "has native C extensions but jruby doesn't support them"},
+		{"jar extension on JRuby", jruby, jarExt, true, false, ""},
+		{"java platform gem on JRuby", jruby, javaPlatform, true, false, ""},
+		{"java platform gem on MRI", mri, javaPlatform, false, false, "requires Java platform but using mri"},
+		{"java platform gem on TruffleRuby", truffle, javaPlatform, false, false, "requires Java platform but using truffleruby"},
+		{"native platform gem on JRuby", jruby, linuxPlatform, false, false, "requires platform x86_64-linux but using JRuby (java platform)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := NewEngineCompatibility(tt.engine)
+			if got := ec.IsCompatible(tt.gem); got != tt.compatible {
+				t.Errorf("IsCompatible() = %v, want %v", got, tt.compatible)
+			}
+			if got := ec.LacksExtensionSupport(tt.gem); got != tt.lacksSupport {
+				t.Errorf("LacksExtensionSupport() = %v, want %v", got, tt.lacksSupport)
+			}
+			if got := ec.GetIncompatibilityReason(tt.gem); got != tt.reason {
+				t.Errorf("GetIncompatibilityReason() = %q, want %q", got, tt.reason)
+			}
+		})
+	}
+}
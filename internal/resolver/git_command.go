@@ -0,0 +1,179 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/contriboss/ore-light/internal/httpclient"
+)
+
+// GitFailure classifies why a git network operation failed
+type GitFailure int
+
+const (
+	GitFailureUnknown GitFailure = iota
+	GitFailureAuth
+	GitFailureUnreachable
+	GitFailureNotFound
+	GitFailureHostKey // SSH host key unknown or changed
+)
+
+// Retry settings for git clone/fetch; variables so tests can shorten the backoff
+var (
+	gitAttempts     = 3
+	gitRetryBackoff = 2 * time.Second
+)
+
+// Substrings of git's stderr for each failure class, checked in order
+var gitFailurePatterns = []struct {
+	failure  GitFailure
+	patterns []string
+}{
+	{GitFailureNotFound, []string{
+		"repository not found",
+		"does not appear to be a git repository",
+		"' does not exist", // fatal: repository '<path>' does not exist
+		"' not found",
+		"the requested url returned error: 404",
+	}},
+	{GitFailureHostKey, []string{
+		"host key verification failed",
+		"no ed25519 host key is known",
+		"no rsa host key is known",
+		"no ecdsa host key is known",
+		"remote host identification has changed",
+	}},
+	{GitFailureAuth, []string{
+		"authentication failed",
+		"could not read username",
+		"could not read password",
+		"terminal prompts disabled",
+		"permission denied (publickey",
+		"access denied",
+		"the requested url returned error: 401",
+		"the requested url returned error: 403",
+	}},
+	{GitFailureUnreachable, []string{
+		"could not resolve host",
+		"could not resolve hostname",
+		"connection timed out",
+		"connection refused",
+		"connection reset",
+		"failed to connect",
+		"network is unreachable",
+		"no route to host",
+		"operation timed out",
+		"temporary failure in name resolution",
+		"the remote end hung up unexpectedly",
+		"early eof",
+		"rpc failed",
+		"gnutls_handshake",
+		"ssl_connect",
+		"the requested url returned error: 5",
+	}},
+}
+
+// GitCommandError is a failed git network operation (clone or fetch)
+type GitCommandError struct {
+	Remote  string
+	Command string // The git command line, with credentials redacted
+	Failure GitFailure
+	Output  string // git's stderr, with credentials redacted
+	Hint    string // What to set up, for SSH remotes
+	Err     error
+}
+
+func (e *GitCommandError) Error() string {
+	remote := httpclient.RedactURL(e.Remote)
+	var msg string
+	switch e.Failure {
+	case GitFailureAuth:
+		msg = fmt.Sprintf("authentication required for %s; check your git credentials or SSH key", remote)
+	case GitFailureUnreachable:
+		msg = fmt.Sprintf("can't reach %s; check your network connection", remote)
+	case GitFailureHostKey:
+		msg = fmt.Sprintf("SSH host key verification failed for %s", remote)
+	case GitFailureNotFound:
+		msg = fmt.Sprintf("repository %s not found; check the URL (private repositories also need credentials)", remote)
+	default:
+		msg = fmt.Sprintf("git failed for %s: %v", remote, e.Err)
+	}
+
+	if e.Hint != "" {
+		msg += "\n  " + e.Hint
+	}
+	msg += "\n  command: " + e.Command
+	if output := strings.TrimSpace(e.Output); output != "" {
+		msg += "\n  " + strings.ReplaceAll(output, "\n", "\n  ")
+	}
+	return msg
+}
+
+func (e *GitCommandError) Unwrap() error {
+	return e.Err
+}
+
+// classifyGitFailure matches git's output against known failure messages
+func classifyGitFailure(output string) GitFailure {
+	output = strings.ToLower(output)
+	for _, class := range gitFailurePatterns {
+		for _, pattern := range class.patterns {
+			if strings.Contains(output, pattern) {
+				return class.failure
+			}
+		}
+	}
+	return GitFailureUnknown
+}
+
+// runGitNetwork runs a git command that talks to remote, retrying with exponential
+// backoff while the failure looks transient (host unreachable). Auth and not-found
+// failures are returned straight away, since retrying won't fix them.
+func runGitNetwork(ctx context.Context, remote string, args ...string) error {
+	backoff := gitRetryBackoff
+	var lastErr error
+
+	for attempt := 1; attempt <= gitAttempts; attempt++ {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Env = gitEnv(remote)
+		output, err := cmd.CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		redacted := httpclient.RedactURLs(string(output))
+		cmdErr := &GitCommandError{
+			Remote:  remote,
+			Command: httpclient.RedactURLs("git " + strings.Join(args, " ")),
+			Failure: classifyGitFailure(redacted),
+			Output:  redacted,
+			Err:     err,
+		}
+		if IsSSHRemote(remote) {
+			cmdErr.Hint = sshHint(remote, cmdErr.Failure)
+		}
+		var exitErr *exec.ExitError
+		if cmdErr.Failure != GitFailureUnreachable || !errors.As(err, &exitErr) {
+			return cmdErr
+		}
+		lastErr = cmdErr
+
+		if attempt < gitAttempts {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+
+	return lastErr
+}
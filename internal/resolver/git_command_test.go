@@ -0,0 +1,64 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyGitFailure(t *testing.T) {
+	tests := []struct {
+		output string
+		want   GitFailure
+	}{
+		{"remote: Repository not found.\nfatal: repository 'https://github.com/acme/missing.git/' not found", GitFailureNotFound},
+		{"fatal: could not read Username for 'https://github.com': terminal prompts disabled", GitFailureAuth},
+		{"git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.", GitFailureAuth},
+		{"fatal: unable to access 'https://github.com/acme/app.git/': Could not resolve host: github.com", GitFailureUnreachable},
+		{"ssh: connect to host github.com port 22: Connection timed out", GitFailureUnreachable},
+		{"error: RPC failed; curl 56 GnuTLS recv error\nfatal: early EOF", GitFailureUnreachable},
+		{"fatal: not a valid object name", GitFailureUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := classifyGitFailure(tt.output); got != tt.want {
+			t.Errorf("classifyGitFailure(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestRunGitNetworkReportsFailure(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	remote := filepath.Join(t.TempDir(), "missing.git")
+	err := runGitNetwork(context.Background(), remote, "clone", "--quiet", remote, filepath.Join(t.TempDir(), "repo"))
+
+	var gitErr *GitCommandError
+	if !errors.As(err, &gitErr) {
+		t.Fatalf("expected a GitCommandError, got %v", err)
+	}
+	if gitErr.Failure != GitFailureNotFound {
+		t.Errorf("expected a not-found failure, got %v: %v", gitErr.Failure, err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "not found") || !strings.Contains(msg, "command: git clone --quiet "+remote) {
+		t.Errorf("expected the remote and failed command in the message, got:\n%s", msg)
+	}
+}
+
+func TestRunGitNetworkCanceled(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	remote := filepath.Join(t.TempDir(), "missing.git")
+	if err := runGitNetwork(ctx, remote, "clone", "--quiet", remote, filepath.Join(t.TempDir(), "repo")); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
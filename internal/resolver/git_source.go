@@ -2,15 +2,20 @@ package resolver
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/contriboss/gemfile-go/gemfile"
+	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/httpclient"
 	"github.com/contriboss/pubgrub-go"
 )
 
@@ -74,11 +79,16 @@ func (g *GitSource) GetVersions(name pubgrub.Name) ([]pubgrub.Version, error) {
 
 // Resolve clones the repository and parses the gemspec
 func (g *GitSource) Resolve() error {
+	return g.ResolveContext(context.Background())
+}
+
+// ResolveContext is Resolve with a context that cancels the clone/fetch
+func (g *GitSource) ResolveContext(ctx context.Context) error {
 	// Create a unique directory name for this repo
 	repoDir := g.getRepoDir()
 
 	// Clone or update the repository
-	if err := g.cloneOrUpdate(repoDir); err != nil {
+	if err := g.cloneOrUpdate(ctx, repoDir); err != nil {
 		return fmt.Errorf("failed to clone/update repo: %w", err)
 	}
 
@@ -104,11 +114,12 @@ func (g *GitSource) GetRevision() string {
 	return g.resolvedRevision
 }
 
-// cloneOrUpdate clones the repository or updates if it already exists
-func (g *GitSource) cloneOrUpdate(repoDir string) error {
+// cloneOrUpdate clones the repository or updates if it already exists.
+// Transient network failures are retried; see runGitNetwork.
+func (g *GitSource) cloneOrUpdate(ctx context.Context, repoDir string) error {
 	if _, err := os.Stat(filepath.Join(repoDir, ".git")); err == nil {
 		// Repository exists, update it
-		return g.updateRepo(repoDir)
+		return g.updateRepo(ctx, repoDir)
 	}
 
 	// Clone the repository
@@ -116,39 +127,40 @@ func (g *GitSource) cloneOrUpdate(repoDir string) error {
 		return err
 	}
 
-	cmd := exec.Command("git", "clone", "--quiet", g.URL, repoDir)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("git clone failed: %w\n%s", err, string(output))
+	if err := runGitNetwork(ctx, g.URL, "clone", "--quiet", g.URL, repoDir); err != nil {
+		// Don't leave a half-cloned repo for the next run to trip over
+		_ = os.RemoveAll(repoDir)
+		return err
 	}
 
 	return nil
 }
 
-// updateRepo updates an existing repository
-func (g *GitSource) updateRepo(repoDir string) error {
-	cmd := exec.Command("git", "-C", repoDir, "fetch", "--quiet", "origin")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("git fetch failed: %w\n%s", err, string(output))
+// updateRepo updates an existing repository. Tags are fetched with --force so a tag
+// moved on the remote isn't left pointing at its old commit.
+func (g *GitSource) updateRepo(ctx context.Context, repoDir string) error {
+	return runGitNetwork(ctx, g.URL, "-C", repoDir, "fetch", "--quiet", "--tags", "--force", "origin")
+}
+
+// refName returns what to check out: the tag, the remote branch, the ref, or the
+// remote's default branch
+func (g *GitSource) refName() string {
+	switch {
+	case g.Tag != "":
+		return g.Tag
+	case g.Branch != "":
+		return "origin/" + g.Branch
+	case g.Ref != "":
+		return g.Ref
+	default:
+		// Default to main/master
+		return "origin/HEAD"
 	}
-	return nil
 }
 
 // checkoutRef checks out the specified branch, tag, or ref
 func (g *GitSource) checkoutRef(repoDir string) (string, error) {
-	// Determine what to checkout
-	var ref string
-	if g.Tag != "" {
-		ref = g.Tag
-	} else if g.Branch != "" {
-		ref = "origin/" + g.Branch
-	} else if g.Ref != "" {
-		ref = g.Ref
-	} else {
-		// Default to main/master
-		ref = "origin/HEAD"
-	}
+	ref := g.refName()
 
 	// Checkout the ref
 	cmd := exec.Command("git", "-C", repoDir, "checkout", "--quiet", ref)
@@ -254,10 +266,10 @@ func getGitCacheDir() (string, error) {
 
 // CloneAtRevision clones the repository at a specific revision to a destination directory
 // This is used during gem installation
-func (g *GitSource) CloneAtRevision(revision, destDir string) error {
+func (g *GitSource) CloneAtRevision(ctx context.Context, revision, destDir string) error {
 	// First ensure the repo is in our cache
 	repoDir := g.getRepoDir()
-	if err := g.cloneOrUpdate(repoDir); err != nil {
+	if err := g.cloneOrUpdate(ctx, repoDir); err != nil {
 		return err
 	}
 
@@ -266,9 +278,14 @@ func (g *GitSource) CloneAtRevision(revision, destDir string) error {
 		return err
 	}
 
+	commit, err := g.resolveCommit(ctx, repoDir, revision)
+	if err != nil {
+		return err
+	}
+
 	// Use git archive to export the specific revision
 	// This is cleaner than clone + checkout as it doesn't include .git
-	cmd := exec.Command("git", "-C", repoDir, "archive", revision)
+	cmd := exec.Command("git", "-C", repoDir, "archive", commit)
 	archiveData, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("git archive failed: %w", err)
@@ -281,5 +298,78 @@ func (g *GitSource) CloneAtRevision(revision, destDir string) error {
 		return fmt.Errorf("tar extraction failed: %w\n%s", err, string(output))
 	}
 
+	return writeSourceMarker(destDir, commit)
+}
+
+// RevisionNotFoundError means a locked revision is no longer in the git remote, usually
+// because its branch was force-pushed and the commit garbage-collected
+type RevisionNotFoundError struct {
+	Gem      string // Set by CloneGitGem
+	Remote   string
+	Revision string
+}
+
+func (e *RevisionNotFoundError) Error() string {
+	msg := fmt.Sprintf("locked revision %s is no longer in %s (force-pushed branch or garbage-collected commit?)", e.Revision, httpclient.RedactURL(e.Remote))
+	if e.Gem != "" {
+		msg += fmt.Sprintf("; run `ore update %s` to re-resolve it", e.Gem)
+	}
+	return msg
+}
+
+// commitSHAPattern matches a full or abbreviated commit SHA
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// resolveCommit returns the full SHA of a locked revision. The revision must be that
+// commit (or an abbreviation of it): a branch or tag name could have moved since locking.
+// It comes from the lockfile, so anything else is rejected before it reaches git.
+func (g *GitSource) resolveCommit(ctx context.Context, repoDir, revision string) (string, error) {
+	if !commitSHAPattern.MatchString(revision) {
+		return "", fmt.Errorf("locked revision %q is not a commit SHA; run `ore update` to re-lock it", revision)
+	}
+
+	revParse := func() ([]byte, error) {
+		return exec.Command("git", "-C", repoDir, "rev-parse", "--verify", "--quiet", revision+"^{commit}").Output()
+	}
+	output, err := revParse()
+	if err != nil {
+		// Not on any fetched branch; servers that allow it can still send the commit by SHA
+		fetchErr := runGitNetwork(ctx, g.URL, "-C", repoDir, "fetch", "--quiet", "origin", "--", revision)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		if fetchErr == nil {
+			output, err = revParse()
+		}
+	}
+	if err != nil {
+		return "", &RevisionNotFoundError{Remote: g.URL, Revision: revision}
+	}
+
+	commit := strings.TrimSpace(string(output))
+	if !strings.HasPrefix(commit, strings.ToLower(revision)) {
+		return "", fmt.Errorf("locked revision %s is not a commit SHA (it now points at %s); run `ore update` to re-lock it", revision, shortRevision(commit))
+	}
+	return commit, nil
+}
+
+// CloneGitGem exports a locked git gem at its locked revision into destDir.
+// Used by both ore install and ore pristine.
+func CloneGitGem(ctx context.Context, spec lockfile.GitGemSpec, destDir string) error {
+	gitSource, err := NewGitSource(spec.Remote, spec.Branch, spec.Tag, spec.Revision)
+	if err != nil {
+		return fmt.Errorf("failed to create git source: %w", err)
+	}
+
+	// Clone at the locked revision
+	if err := gitSource.CloneAtRevision(ctx, spec.Revision, destDir); err != nil {
+		var notFound *RevisionNotFoundError
+		if errors.As(err, &notFound) {
+			notFound.Gem = spec.Name
+			return notFound
+		}
+		return fmt.Errorf("failed to clone at revision %s: %w", spec.Revision, err)
+	}
+
 	return nil
 }
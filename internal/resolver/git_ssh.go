@@ -0,0 +1,139 @@
+package resolver
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// GitSSHConfig controls how git reaches SSH remotes (git@github.com:org/repo.git).
+// It applies to every clone/fetch, both when locking and when installing.
+type GitSSHConfig struct {
+	Command      string // Replaces GIT_SSH_COMMAND, e.g. "ssh -o StrictHostKeyChecking=accept-new"
+	IdentityFile string // Private key to use instead of the SSH agent, e.g. a CI deploy key
+}
+
+var (
+	gitSSHMu     sync.RWMutex
+	gitSSHConfig GitSSHConfig
+)
+
+// SetGitSSH sets the SSH configuration for git commands run after the call
+func SetGitSSH(cfg GitSSHConfig) {
+	gitSSHMu.Lock()
+	defer gitSSHMu.Unlock()
+	gitSSHConfig = cfg
+}
+
+func currentGitSSH() GitSSHConfig {
+	gitSSHMu.RLock()
+	defer gitSSHMu.RUnlock()
+	return gitSSHConfig
+}
+
+// IsSSHRemote reports whether git reaches remote over SSH: ssh:// and git+ssh://
+// URLs, and scp-style remotes like git@github.com:org/repo.git
+func IsSSHRemote(remote string) bool {
+	if scheme, _, found := strings.Cut(remote, "://"); found {
+		scheme = strings.ToLower(scheme)
+		return scheme == "ssh" || scheme == "git+ssh" || scheme == "ssh+git"
+	}
+	// scp-style: a colon before the first slash, and not a Windows drive letter (C:\...)
+	colon := strings.Index(remote, ":")
+	slash := strings.IndexAny(remote, `/\`)
+	return colon > 1 && (slash == -1 || colon < slash)
+}
+
+// sshHost returns the host of an SSH remote, for diagnostics
+func sshHost(remote string) string {
+	if strings.Contains(remote, "://") {
+		if parsed, err := url.Parse(remote); err == nil {
+			return parsed.Hostname()
+		}
+		return remote
+	}
+	host, _, _ := strings.Cut(remote, ":")
+	if _, after, found := strings.Cut(host, "@"); found {
+		host = after
+	}
+	return host
+}
+
+// gitEnv returns the environment for a git command talking to remote
+func gitEnv(remote string) []string {
+	// Fail instead of hanging on a credential prompt nobody will answer
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if !IsSSHRemote(remote) {
+		return env
+	}
+	if command := gitSSHCommand(currentGitSSH()); command != "" {
+		env = append(env, "GIT_SSH_COMMAND="+command)
+	}
+	return env
+}
+
+// gitSSHCommand builds GIT_SSH_COMMAND from the configuration, or returns "" to leave
+// git's own setting alone. An identity file is added to the configured command (or
+// the inherited GIT_SSH_COMMAND, or plain ssh) with IdentitiesOnly, so ssh offers
+// that key rather than whatever the agent holds.
+func gitSSHCommand(cfg GitSSHConfig) string {
+	command := cfg.Command
+	if cfg.IdentityFile == "" {
+		return command
+	}
+	if command == "" {
+		command = os.Getenv("GIT_SSH_COMMAND")
+	}
+	if command == "" {
+		command = "ssh"
+	}
+	return fmt.Sprintf("%s -i %s -o IdentitiesOnly=yes", command, shellQuote(expandHome(cfg.IdentityFile)))
+}
+
+// expandHome expands a leading ~/ (as in git_ssh_key = "~/.ssh/deploy_key") to the
+// home directory; the shell would, but the path is quoted and stat'ed as is
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok && path != "~" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
+
+// shellQuote quotes a value for the shell git runs GIT_SSH_COMMAND with
+func shellQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n'\"\\$`;&|<>()*?[]#~") {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// sshHint explains an SSH failure in terms of what to set up, or returns ""
+func sshHint(remote string, failure GitFailure) string {
+	host := sshHost(remote)
+	cfg := currentGitSSH()
+
+	switch failure {
+	case GitFailureHostKey:
+		return fmt.Sprintf("%s isn't in known_hosts; add it with `ssh-keyscan %s >> ~/.ssh/known_hosts`", host, host)
+	case GitFailureAuth:
+		if cfg.IdentityFile != "" {
+			if _, err := os.Stat(expandHome(cfg.IdentityFile)); err != nil {
+				return fmt.Sprintf("the configured SSH key %s can't be read: %v", cfg.IdentityFile, err)
+			}
+			return fmt.Sprintf("%s rejected the configured SSH key %s; check it has access to the repository", host, cfg.IdentityFile)
+		}
+		if cfg.Command == "" && os.Getenv("SSH_AUTH_SOCK") == "" && os.Getenv("GIT_SSH_COMMAND") == "" {
+			return "no SSH agent is running (SSH_AUTH_SOCK is unset); start one with your key loaded, or set git_ssh_key in the ore config (or ORE_GIT_SSH_KEY)"
+		}
+		return fmt.Sprintf("%s rejected the SSH keys offered; check `ssh -T %s` works", host, host)
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsSSHRemote(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   bool
+	}{
+		{"git@github.com:acme/app.git", true},
+		{"ssh://git@github.com/acme/app.git", true},
+		{"git+ssh://git@gitlab.example.com:2222/acme/app.git", true},
+		{"https://github.com/acme/app.git", false},
+		{"git://github.com/acme/app.git", false},
+		{"/srv/git/app.git", false},
+		{"./vendor/app:old", false},
+		{`C:\repos\app`, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsSSHRemote(tt.remote); got != tt.want {
+			t.Errorf("IsSSHRemote(%q) = %v, want %v", tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestGitSSHCommand(t *testing.T) {
+	t.Setenv("GIT_SSH_COMMAND", "")
+
+	tests := []struct {
+		cfg  GitSSHConfig
+		want string
+	}{
+		{GitSSHConfig{}, ""},
+		{GitSSHConfig{Command: "ssh -p 2222"}, "ssh -p 2222"},
+		{GitSSHConfig{IdentityFile: "/keys/deploy"}, "ssh -i /keys/deploy -o IdentitiesOnly=yes"},
+		{GitSSHConfig{Command: "ssh -o StrictHostKeyChecking=accept-new", IdentityFile: "/my keys/deploy"},
+			"ssh -o StrictHostKeyChecking=accept-new -i '/my keys/deploy' -o IdentitiesOnly=yes"},
+	}
+
+	for _, tt := range tests {
+		if got := gitSSHCommand(tt.cfg); got != tt.want {
+			t.Errorf("gitSSHCommand(%+v) = %q, want %q", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestGitEnvOnlySetsSSHCommandForSSHRemotes(t *testing.T) {
+	SetGitSSH(GitSSHConfig{Command: "ssh -p 2222"})
+	defer SetGitSSH(GitSSHConfig{})
+
+	hasCommand := func(env []string) bool {
+		for _, entry := range env {
+			if entry == "GIT_SSH_COMMAND=ssh -p 2222" {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasCommand(gitEnv("git@github.com:acme/app.git")) {
+		t.Error("expected GIT_SSH_COMMAND for an SSH remote")
+	}
+	if hasCommand(gitEnv("https://github.com/acme/app.git")) {
+		t.Error("expected no GIT_SSH_COMMAND for an HTTPS remote")
+	}
+}
+
+func TestSSHHint(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	t.Setenv("GIT_SSH_COMMAND", "")
+	remote := "git@github.com:acme/app.git"
+
+	if hint := sshHint(remote, GitFailureAuth); !strings.Contains(hint, "no SSH agent") {
+		t.Errorf("expected a missing-agent hint, got %q", hint)
+	}
+	if hint := sshHint(remote, GitFailureHostKey); !strings.Contains(hint, "ssh-keyscan github.com") {
+		t.Errorf("expected a known_hosts hint, got %q", hint)
+	}
+
+	SetGitSSH(GitSSHConfig{IdentityFile: "/nonexistent/deploy_key"})
+	defer SetGitSSH(GitSSHConfig{})
+	if hint := sshHint(remote, GitFailureAuth); !strings.Contains(hint, "can't be read") {
+		t.Errorf("expected an unreadable-key hint, got %q", hint)
+	}
+
+	// A key under ~ is found in the home directory
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "deploy_key"), []byte("key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	SetGitSSH(GitSSHConfig{IdentityFile: "~/.ssh/deploy_key"})
+	if hint := sshHint(remote, GitFailureAuth); !strings.Contains(hint, "rejected the configured SSH key") {
+		t.Errorf("expected the key under ~ to be readable, got %q", hint)
+	}
+	if command := gitSSHCommand(currentGitSSH()); !strings.Contains(command, "-i "+filepath.Join(home, ".ssh", "deploy_key")) {
+		t.Errorf("expected ~ expanded in the ssh command, got %q", command)
+	}
+}
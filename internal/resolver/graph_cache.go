@@ -0,0 +1,140 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/contriboss/ore-light/internal/compactindex"
+	"github.com/contriboss/ore-light/internal/logger"
+)
+
+var (
+	graphCacheMu  sync.RWMutex
+	graphCacheDir string
+)
+
+// SetGraphCacheDir sets where resolved versions and dependencies are kept between
+// runs, e.g. <ore cache dir>/resolve. Sources created afterwards use it; "" disables it.
+func SetGraphCacheDir(dir string) {
+	graphCacheMu.Lock()
+	defer graphCacheMu.Unlock()
+	graphCacheDir = dir
+}
+
+func currentGraphCacheDir() string {
+	graphCacheMu.RLock()
+	defer graphCacheMu.RUnlock()
+	return graphCacheDir
+}
+
+// graphCache keeps one source's resolved gem info (every version and its dependencies)
+// on disk as <dir>/<source slug>/<gem>.json. Each entry records the MD5 of the compact
+// index info file it was read from, which is the checksum the source's versions file
+// lists for the gem, so an entry is reused until a release or yank changes that gem.
+// A relock with nothing new then only revalidates /versions instead of every /info/<gem>.
+type graphCache struct {
+	dir     string // This source's entries
+	client  *compactindex.Client
+	refresh bool // Don't reuse entries (ore lock --refresh); fresh results are still saved
+
+	checksumsOnce sync.Once
+	checksums     map[string]string // gem -> info checksum in /versions; nil if unavailable
+}
+
+// graphEntry is a gem's cached info
+type graphEntry struct {
+	Checksum string                     `json:"checksum"`
+	Versions []compactindex.VersionInfo `json:"versions"`
+}
+
+// newGraphCache returns the graph cache for client's source, or nil when it's disabled
+func newGraphCache(client *compactindex.Client) *graphCache {
+	dir := currentGraphCacheDir()
+	if dir == "" {
+		return nil
+	}
+	// Bundler's {host}.{port}.{md5(url)} slug keys entries by source
+	return &graphCache{dir: filepath.Join(dir, filepath.Base(client.GetCacheDir())), client: client}
+}
+
+// load returns gemName's cached info if the source still lists the checksum it was
+// saved with. The versions file is only fetched once an entry needs checking, so a
+// cold cache costs no extra request.
+func (c *graphCache) load(ctx context.Context, gemName string) ([]compactindex.VersionInfo, bool) {
+	if c == nil || c.refresh {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(gemName))
+	if err != nil {
+		return nil, false
+	}
+	var entry graphEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Checksum == "" {
+		return nil, false
+	}
+	if entry.Checksum != c.currentChecksum(ctx, gemName) {
+		return nil, false
+	}
+	return entry.Versions, true
+}
+
+// save records gemName's info as just read from the compact index cache
+func (c *graphCache) save(gemName string, infos []compactindex.VersionInfo) {
+	if c == nil {
+		return
+	}
+	checksum, err := compactindex.ComputeInfoFileChecksum(compactindex.GetInfoPath(c.client.GetCacheDir(), gemName))
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(graphEntry{Checksum: checksum, Versions: infos})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+
+	// Write next to the entry and rename it into place, so a concurrent ore lock
+	// never reads half an entry
+	tmp, err := os.CreateTemp(c.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	_ = os.Rename(tmp.Name(), c.path(gemName))
+}
+
+// currentChecksum returns the info checksum the source's versions file lists for gemName
+func (c *graphCache) currentChecksum(ctx context.Context, gemName string) string {
+	c.checksumsOnce.Do(func() {
+		entries, err := c.client.GetVersions(ctx)
+		if err != nil {
+			logger.Debug("dependency cache disabled: versions file unavailable", "error", err)
+			return
+		}
+		// Later lines of the versions file supersede earlier ones
+		c.checksums = make(map[string]string, len(entries))
+		for _, entry := range entries {
+			c.checksums[entry.Name] = entry.Checksum
+		}
+	})
+	return c.checksums[gemName]
+}
+
+// path returns where gemName's entry lives, named like its compact index info file
+func (c *graphCache) path(gemName string) string {
+	return filepath.Join(c.dir, filepath.Base(compactindex.GetInfoPath(c.client.GetCacheDir(), gemName))+".json")
+}
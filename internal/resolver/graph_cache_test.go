@@ -0,0 +1,98 @@
+package resolver
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGraphCacheReusesUnchangedGems(t *testing.T) {
+	home := t.TempDir() // Bundler's compact index cache lives under ~/.bundle
+	t.Setenv("HOME", home)
+	t.Chdir(t.TempDir())
+
+	info := "---\n3.0.0 |checksum:abc\n"
+	versions := fmt.Sprintf("created_at: 2024-01-01T00:00:00Z\n---\nrack 3.0.0 %x\n", md5.Sum([]byte(info)))
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		info, versions := info, versions
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/versions":
+			_, _ = w.Write([]byte(versions))
+		case "/info/rack":
+			_, _ = w.Write([]byte(info))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	SetGraphCacheDir(filepath.Join(t.TempDir(), "resolve"))
+	t.Cleanup(func() { SetGraphCacheDir("") })
+
+	gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+	if err := os.WriteFile(gemfilePath, []byte(fmt.Sprintf("source %q\n\ngem \"rack\"\n", server.URL)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Age the compact index cache past its one-hour freshness window, so only the
+	// graph cache can spare the info request
+	staleCompactIndex := func() {
+		old := time.Now().Add(-2 * time.Hour)
+		_ = filepath.WalkDir(filepath.Join(home, ".bundle"), func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				_ = os.Chtimes(path, old, old)
+			}
+			return nil
+		})
+	}
+	resolveCounting := func(opts LockOptions) map[string]int {
+		t.Helper()
+		mu.Lock()
+		clear(requests)
+		mu.Unlock()
+		staleCompactIndex()
+		if _, err := resolve(gemfilePath, opts, io.Discard); err != nil {
+			t.Fatalf("resolve failed: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return map[string]int{"/versions": requests["/versions"], "/info/rack": requests["/info/rack"]}
+	}
+
+	// A cold cache fetches the gem's info and nothing else
+	if got := resolveCounting(LockOptions{}); got["/info/rack"] != 1 || got["/versions"] != 0 {
+		t.Errorf("first resolve: expected only /info/rack, got %v", got)
+	}
+
+	// Unchanged since then: the versions file confirms the cached entry
+	if got := resolveCounting(LockOptions{}); got["/info/rack"] != 0 || got["/versions"] != 1 {
+		t.Errorf("second resolve: expected only /versions, got %v", got)
+	}
+
+	// --refresh goes back to the source
+	if got := resolveCounting(LockOptions{Refresh: true}); got["/info/rack"] != 1 {
+		t.Errorf("refresh: expected /info/rack to be fetched again, got %v", got)
+	}
+
+	// A new release changes the gem's checksum, invalidating the entry
+	mu.Lock()
+	info = "---\n3.0.0 |checksum:abc\n3.1.0 |checksum:def\n"
+	versions = fmt.Sprintf("created_at: 2024-01-01T00:00:00Z\n---\nrack 3.0.0,3.1.0 %x\n", md5.Sum([]byte(info)))
+	mu.Unlock()
+	if got := resolveCounting(LockOptions{}); got["/info/rack"] != 1 {
+		t.Errorf("after a release: expected /info/rack to be fetched again, got %v", got)
+	}
+}
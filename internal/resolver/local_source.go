@@ -0,0 +1,135 @@
+package resolver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/contriboss/ore-light/internal/geminstall"
+	"github.com/contriboss/pubgrub-go"
+)
+
+// LocalGemSource implements pubgrub.Source from a directory of .gem files, reading
+// versions and dependencies from each gem's metadata instead of a gem server. It backs
+// ore lock --local-source for air-gapped machines, like Bundler's vendor/cache.
+//
+// As with CompactIndexSource, only pure-Ruby (platform "ruby") gems are resolved;
+// platform gems in the directory are listed in Unreadable.
+type LocalGemSource struct {
+	Dir         string
+	Unreadable  []string                             // .gem files skipped (unreadable metadata or a platform gem), with why
+	gems        map[string]map[string][]pubgrub.Term // gem -> version -> dependencies
+	mu          sync.RWMutex
+	versionPins map[string]string
+}
+
+// NewLocalGemSource reads the metadata of every .gem file in dir. Gems ore stores
+// compressed (cache_compression) count too, so dir may be an ore cache.
+func NewLocalGemSource(dir string) (*LocalGemSource, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local gem source: %w", err)
+	}
+
+	s := &LocalGemSource{Dir: dir, gems: make(map[string]map[string][]pubgrub.Term)}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (!strings.HasSuffix(name, ".gem") && !geminstall.IsCompressedGem(name)) {
+			continue
+		}
+		if err := s.add(filepath.Join(dir, name)); err != nil {
+			s.Unreadable = append(s.Unreadable, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	return s, nil
+}
+
+// add records the gem at path
+func (s *LocalGemSource) add(path string) error {
+	metadataYAML, err := geminstall.ExtractMetadataOnly(path)
+	if err != nil {
+		return err
+	}
+	meta, err := geminstall.ParseGemMetadata(metadataYAML)
+	if err != nil {
+		return err
+	}
+	if meta.Name == "" || meta.Version == "" {
+		return fmt.Errorf("metadata has no name or version")
+	}
+	if meta.Platform != "ruby" {
+		return fmt.Errorf("%s platform gem; only pure-Ruby (platform \"ruby\") gems are resolved", meta.Platform)
+	}
+
+	var terms []pubgrub.Term
+	for _, dep := range meta.Dependencies {
+		var condition pubgrub.Condition = NewAnyVersionCondition()
+		if dep.Requirement != "" && dep.Requirement != ">= 0" {
+			if semverCond, err := NewSemverCondition(dep.Requirement); err == nil {
+				condition = semverCond
+			}
+		}
+		terms = append(terms, pubgrub.NewTerm(pubgrub.MakeName(dep.Name), condition))
+	}
+
+	if s.gems[meta.Name] == nil {
+		s.gems[meta.Name] = make(map[string][]pubgrub.Term)
+	}
+	s.gems[meta.Name][meta.Version] = terms
+	return nil
+}
+
+// SetVersionPins sets version pins for selective updates.
+func (s *LocalGemSource) SetVersionPins(pins map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versionPins = pins
+}
+
+// Refresh is a no-op: the directory was read when the source was created
+func (s *LocalGemSource) Refresh() {}
+
+// GetVersions returns the versions of name found in the directory, lowest first.
+func (s *LocalGemSource) GetVersions(name pubgrub.Name) ([]pubgrub.Version, error) {
+	gemName := name.Value()
+
+	s.mu.RLock()
+	pinnedVersion := s.versionPins[gemName]
+	s.mu.RUnlock()
+	if pinnedVersion != "" {
+		semverVer, err := NewSemverVersion(pinnedVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pinned version %s for %s: %w", pinnedVersion, gemName, err)
+		}
+		return []pubgrub.Version{semverVer}, nil
+	}
+
+	versions, ok := s.gems[gemName]
+	if !ok {
+		return nil, fmt.Errorf("no %s gem in local source %s", gemName, s.Dir)
+	}
+	semverVersions := make([]pubgrub.Version, 0, len(versions))
+	for version := range versions {
+		semverVer, err := NewSemverVersion(version)
+		if err != nil {
+			continue
+		}
+		semverVersions = append(semverVersions, semverVer)
+	}
+	slices.SortFunc(semverVersions, func(a, b pubgrub.Version) int {
+		return a.Sort(b)
+	})
+	return semverVersions, nil
+}
+
+// GetDependencies returns the runtime dependencies from a gem's metadata.
+func (s *LocalGemSource) GetDependencies(name pubgrub.Name, version pubgrub.Version) ([]pubgrub.Term, error) {
+	deps, ok := s.gems[name.Value()][version.String()]
+	if !ok {
+		return nil, fmt.Errorf("version %s not found for gem %s in local source %s", version, name.Value(), s.Dir)
+	}
+	return deps, nil
+}
@@ -0,0 +1,118 @@
+package resolver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeLocalGem writes a .gem file holding only metadata, which is all resolution reads
+func writeLocalGem(t *testing.T, dir, name, version, platform string, deps map[string]string) {
+	t.Helper()
+	metadata := "--- !ruby/object:Gem::Specification\nname: " + name +
+		"\nversion: !ruby/object:Gem::Version\n  version: " + version + "\nplatform: " + platform + "\ndependencies:\n"
+	for dep, requirement := range deps {
+		op, v, _ := strings.Cut(requirement, " ")
+		metadata += "- !ruby/object:Gem::Dependency\n  name: " + dep + "\n  requirement: !ruby/object:Gem::Requirement\n    requirements:\n" +
+			"    - - \"" + op + "\"\n      - !ruby/object:Gem::Version\n        version: '" + v + "'\n  type: :runtime\n"
+	}
+
+	var metadataGz bytes.Buffer
+	gz := gzip.NewWriter(&metadataGz)
+	_, _ = gz.Write([]byte(metadata))
+	_ = gz.Close()
+
+	var gem bytes.Buffer
+	tw := tar.NewWriter(&gem)
+	_ = tw.WriteHeader(&tar.Header{Name: "metadata.gz", Mode: 0o644, Size: int64(metadataGz.Len())})
+	_, _ = tw.Write(metadataGz.Bytes())
+	_ = tw.Close()
+
+	fileName := name + "-" + version
+	if platform != "ruby" {
+		fileName += "-" + platform
+	}
+	if err := os.WriteFile(filepath.Join(dir, fileName+".gem"), gem.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveFromLocalSource(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	gems := t.TempDir()
+	writeLocalGem(t, gems, "app", "1.0.0", "ruby", map[string]string{"rack": ">= 2.0"})
+	writeLocalGem(t, gems, "rack", "2.2.0", "ruby", nil)
+	writeLocalGem(t, gems, "rack", "3.0.0", "ruby", nil)
+	writeLocalGem(t, gems, "rack", "3.1.0", "java", nil) // Other platforms aren't resolved, but are reported
+	if err := os.WriteFile(filepath.Join(gems, "broken-1.0.0.gem"), []byte("not a gem"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The source is never contacted: its URL is only the lockfile remote
+	gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+	if err := os.WriteFile(gemfilePath, []byte("source \"https://gems.invalid\"\n\ngem \"app\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var progress bytes.Buffer
+	res, err := resolve(gemfilePath, LockOptions{LocalSource: gems}, &progress)
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if !strings.Contains(progress.String(), "Warning: skipping gem in the local source: broken-1.0.0.gem") {
+		t.Errorf("expected the unreadable gem to be reported with the progress, got:\n%s", progress.String())
+	}
+	if !strings.Contains(progress.String(), "Warning: skipping gem in the local source: rack-3.1.0-java.gem: java platform gem") {
+		t.Errorf("expected the platform gem to be reported with the progress, got:\n%s", progress.String())
+	}
+	locked := make(map[string]string)
+	for _, spec := range res.lock.GemSpecs {
+		locked[spec.Name] = spec.Version
+		if spec.SourceURL != "https://gems.invalid/" {
+			t.Errorf("expected %s to keep the Gemfile's remote, got %q", spec.Name, spec.SourceURL)
+		}
+		if spec.Name == "app" && (len(spec.Dependencies) != 1 || spec.Dependencies[0].Name != "rack") {
+			t.Errorf("expected app's rack dependency from its metadata, got %+v", spec.Dependencies)
+		}
+	}
+	if len(locked) != 2 || locked["app"] != "1.0.0" || locked["rack"] != "3.0.0" {
+		t.Errorf("expected app 1.0.0 and rack 3.0.0, got %v", locked)
+	}
+
+	// A gem that isn't in the directory fails resolution rather than going online
+	if err := os.WriteFile(gemfilePath, []byte("source \"https://gems.invalid\"\n\ngem \"missing\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolve(gemfilePath, LockOptions{LocalSource: gems}, io.Discard); err == nil || !strings.Contains(err.Error(), "no missing gem in local source") {
+		t.Errorf("expected a missing gem error, got %v", err)
+	}
+}
+
+func TestLockForceRubyPlatformGemLocksRubyVariant(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	gems := t.TempDir()
+	writeLocalGem(t, gems, "nokogiri", "1.16.0", "ruby", nil)
+	writeLocalGem(t, gems, "nokogiri", "1.16.0", "x86_64-linux", nil)
+
+	gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+	gemfile := "source \"https://gems.invalid\"\n\ngem \"nokogiri\", force_ruby_platform: true\n"
+	if err := os.WriteFile(gemfilePath, []byte(gemfile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// ore install builds forced gems from source, so the ruby variant must be locked
+	var out bytes.Buffer
+	if err := GenerateLockfileWithOptions(gemfilePath, LockOptions{LocalSource: gems, Output: &out}); err != nil {
+		t.Fatalf("GenerateLockfileWithOptions returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "    nokogiri (1.16.0)\n") {
+		t.Errorf("expected the ruby variant of nokogiri to be locked, got:\n%s", out.String())
+	}
+}
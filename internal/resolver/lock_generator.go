@@ -1,16 +1,26 @@
 package resolver
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/contriboss/gemfile-go/gemfile"
 	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/config"
+	"github.com/contriboss/ore-light/internal/errdefs"
+	"github.com/contriboss/ore-light/internal/httpclient"
+	"github.com/contriboss/ore-light/internal/logger"
+	"github.com/contriboss/ore-light/internal/ruby"
 	"github.com/contriboss/pubgrub-go"
 )
 
@@ -36,56 +46,181 @@ func GenerateLockfileWithPins(gemfilePath string, versionPins map[string]string)
 // versionPins is a map of gem name -> exact version to pin (used for selective updates).
 // platforms is a list of additional platforms to add to the lockfile (e.g., "x86_64-linux", "java").
 func GenerateLockfileWithPlatforms(gemfilePath string, versionPins map[string]string, platforms []string) error {
+	return GenerateLockfileWithOptions(gemfilePath, LockOptions{VersionPins: versionPins, Platforms: platforms})
+}
+
+// LockOptions controls how GenerateLockfileWithOptions resolves and where the result goes
+type LockOptions struct {
+	VersionPins map[string]string // Gem name -> exact version to pin (used for selective updates)
+	Platforms   []string          // Additional platforms to add to the lockfile
+	Output      io.Writer         // When set, the lockfile is rendered here and nothing is written to disk
+	Verbose     bool              // Print a line per resolved gem instead of a spinner/status line
+
+	// NormalizePlatforms collapses redundant PLATFORMS entries (see normalizePlatform),
+	// like `bundle lock --normalize-platforms`
+	NormalizePlatforms bool
+
+	// LockfilePath is the lockfile to read and write instead of the Gemfile's own
+	// (Gemfile.lock, or gems.locked for gems.rb)
+	LockfilePath string
+
+	// Refresh fetches every gem's versions and dependencies from its source instead of
+	// reusing those cached by earlier runs (see SetGraphCacheDir)
+	Refresh bool
+
+	// LocalSource is a directory of .gem files to resolve every rubygems source's gems
+	// from instead of the network (see LocalGemSource)
+	LocalSource string
+}
+
+// GenerateLockfileWithOptions resolves gem dependencies and writes the lockfile.
+// With opts.Output set, progress goes to stderr so the rendered lockfile can be piped,
+// e.g. `diff <(ore lock --print) Gemfile.lock`.
+func GenerateLockfileWithOptions(gemfilePath string, opts LockOptions) error {
+	// Keep stdout clean for the rendered lockfile
+	var progressOut io.Writer = os.Stdout
+	if opts.Output != nil {
+		progressOut = os.Stderr
+	}
+
+	res, err := resolve(gemfilePath, opts, progressOut)
+	if err != nil {
+		// Source fetch errors can quote a Gemfile URL with its credentials
+		return httpclient.RedactError(err)
+	}
+	lock, lockfilePath := res.lock, res.lockfilePath
+
+	// Print instead of writing (ore lock --print)
+	if opts.Output != nil {
+		if err := writeLockfile(lock, res.rubyReq, opts.Output); err != nil {
+			return fmt.Errorf("failed to render lockfile: %w", err)
+		}
+		fmt.Fprintf(progressOut, "\n✨ Resolved %d dependencies (%d gems); %s left unchanged\n", len(lock.Dependencies), len(lock.GemSpecs), lockfilePath)
+		return nil
+	}
+
+	// Write lockfile
+	var content bytes.Buffer
+	if err := writeLockfile(lock, res.rubyReq, &content); err != nil {
+		return fmt.Errorf("failed to render lockfile: %w", err)
+	}
+	if err := os.WriteFile(lockfilePath, content.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+
+	fmt.Fprintf(progressOut, "\n✨ Resolved %d dependencies and wrote %d gems to %s\n", len(lock.Dependencies), len(lock.GemSpecs), lockfilePath)
+	return nil
+}
+
+// Resolve resolves the Gemfile's dependencies and returns the lockfile ore lock would
+// write, without writing anything or printing progress or warnings. pins maps gem names to exact
+// versions to keep, as for GenerateLockfileWithPins. The RUBY VERSION section isn't
+// part of lockfile.Lockfile; read it with ruby.ParseGemfileRubyRequirement.
+//
+// Ruby developers: this is Bundler::Definition#resolve without #lock
+func Resolve(gemfilePath string, pins map[string]string) (*lockfile.Lockfile, error) {
+	res, err := resolve(gemfilePath, LockOptions{VersionPins: pins}, io.Discard)
+	if err != nil {
+		return nil, httpclient.RedactError(err)
+	}
+	return res.lock, nil
+}
+
+// resolution is a resolved lockfile and what's needed to write it
+type resolution struct {
+	lock         *lockfile.Lockfile
+	rubyReq      *ruby.Requirement // The Gemfile's ruby directive, for RUBY VERSION
+	lockfilePath string
+}
+
+// resolve parses the Gemfile and resolves its dependencies, reporting progress to progressOut
+func resolve(gemfilePath string, opts LockOptions, progressOut io.Writer) (*resolution, error) {
+	versionPins, platforms := opts.VersionPins, opts.Platforms
+
+	if _, err := os.Stat(gemfilePath); errors.Is(err, fs.ErrNotExist) {
+		return nil, errdefs.Newf(errdefs.ErrGemfileNotFound, "gemfile not found at %s", gemfilePath)
+	}
+
 	// Parse Gemfile
 	parser := gemfile.NewGemfileParser(gemfilePath)
 	parsed, err := parser.Parse()
 	if err != nil {
-		return fmt.Errorf("failed to parse Gemfile: %w", err)
+		return nil, fmt.Errorf("failed to parse Gemfile: %w", err)
+	}
+
+	// Validate the Gemfile's ruby engine requirement against the running engine
+	// e.g. ruby "3.3.0", engine: "jruby", engine_version: "9.4.0.0"
+	rubyReq, err := ruby.ParseGemfileRubyRequirement(gemfilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ruby directive: %w", err)
+	}
+	if rubyReq != nil && rubyReq.Engine != "" {
+		if err := NewEngineCompatibility(ruby.DetectEngine()).CheckRubyRequirement(rubyReq); err != nil {
+			return nil, err
+		}
 	}
 
 	// Handle gemspec directives
 	// Ruby developers: This is like when your Gemfile contains `gemspec`
 	// It loads dependencies from the .gemspec file
 	if len(parsed.Gemspecs) > 0 {
-		if err := loadGemspecDependencies(gemfilePath, parsed); err != nil {
-			return fmt.Errorf("failed to load gemspec dependencies: %w", err)
+		if err := loadGemspecDependencies(gemfilePath, parsed, progressOut); err != nil {
+			return nil, fmt.Errorf("failed to load gemspec dependencies: %w", err)
 		}
 	}
 
-	// Determine default source URL from Gemfile sources
+	progress := newResolveProgress(progressOut, opts.Verbose || logger.Verbose())
+
+	// Determine default source URL from the Gemfile's top-level source
 	// Respects configured sources, fallback to rubygems.org
-	defaultSourceURL := "https://rubygems.org"
-	for _, src := range parsed.Sources {
-		if src.Type == "rubygems" && src.URL != "" {
-			defaultSourceURL = src.URL
-			break // Use first rubygems source as default
-		}
-	}
+	defaultSourceURL := defaultGemSourceURL(parsed)
 
 	// Create RubyGems sources for different gem servers
 	// This is like Bundler's source management (rubygems.org, custom mirrors, etc.)
 	sources := make(map[string]*RubyGemsSource)
+	remotes := make(map[*RubyGemsSource]string) // source -> lockfile remote (before mirroring)
+	mirrors := config.ReadBundleMirrors()
+	var localSource *LocalGemSource
+	if opts.LocalSource != "" {
+		if localSource, err = NewLocalGemSource(opts.LocalSource); err != nil {
+			return nil, err
+		}
+		for _, skipped := range localSource.Unreadable {
+			fmt.Fprintf(progressOut, "Warning: skipping gem in the local source: %s\n", skipped)
+		}
+	}
 	getSource := func(url string) *RubyGemsSource {
+		url = strings.TrimSuffix(url, "/")
 		if url == "" {
 			url = defaultSourceURL
 		}
 		if src, ok := sources[url]; ok {
 			return src
 		}
-		src := NewRubyGemsSourceWithURL(url)
+		// Fetch from the mirror (or the local source) but keep the original URL as the lockfile remote
+		var src *RubyGemsSource
+		if localSource != nil {
+			src = newLocalRubyGemsSource(url, localSource)
+		} else {
+			src = NewRubyGemsSourceWithURL(config.ApplyMirror(mirrors, url))
+		}
+		src.progress = progress
+		// Apply version pins to all sources for selective updates
+		if versionPins != nil {
+			src.SetVersionPins(versionPins)
+		}
+		if opts.Refresh {
+			src.Refresh()
+		}
 		sources[url] = src
+		remotes[src] = url
 		return src
 	}
 
-	// Default source for gems without explicit source
+	// Default source for gems without explicit source; gems in source blocks
+	// resolve from their own source
 	defaultSource := getSource(defaultSourceURL)
-
-	// Apply version pins to all sources for selective updates
-	if versionPins != nil {
-		for _, src := range sources {
-			src.SetVersionPins(versionPins)
-		}
-	}
+	gemSource := newScopedSource(defaultSource)
 
 	// Convert Gemfile dependencies to PubGrub terms
 	var allSolutions []pubgrub.NameVersion
@@ -99,7 +234,8 @@ func GenerateLockfileWithPlatforms(gemfilePath string, versionPins map[string]st
 	gitDeps := make(map[string]*gemfile.GemDependency)
 	pathDeps := make(map[string]*gemfile.GemDependency)
 
-	fmt.Printf("Resolving dependencies...\n")
+	progress.Start()
+	defer progress.Stop()
 
 	// Create a root source for all dependencies
 	// The new pubgrub-go uses a root package to collect all requirements
@@ -115,17 +251,17 @@ func GenerateLockfileWithPlatforms(gemfilePath string, versionPins map[string]st
 
 		// Check if this is a git dependency
 		if dep.Source != nil && dep.Source.Type == "git" {
-			fmt.Printf("Resolving %s from git...\n", dep.Name)
+			progress.Resolving(dep.Name, "git")
 			gitDeps[dep.Name] = &dep
 
 			// Create git source and resolve
 			gitSource, err := NewGitSource(dep.Source.URL, dep.Source.Branch, dep.Source.Tag, dep.Source.Ref)
 			if err != nil {
-				return fmt.Errorf("failed to create git source for %s: %w", dep.Name, err)
+				return nil, fmt.Errorf("failed to create git source for %s: %w", dep.Name, err)
 			}
 
 			if err := gitSource.Resolve(); err != nil {
-				return fmt.Errorf("failed to resolve git gem %s: %w", dep.Name, err)
+				return nil, fmt.Errorf("failed to resolve git gem %s: %w", dep.Name, err)
 			}
 
 			// Get dependencies from the git gem
@@ -160,17 +296,17 @@ func GenerateLockfileWithPlatforms(gemfilePath string, versionPins map[string]st
 
 		// Check if this is a path dependency
 		if dep.Source != nil && dep.Source.Type == "path" {
-			fmt.Printf("Resolving %s from path...\n", dep.Name)
+			progress.Resolving(dep.Name, "path")
 			pathDeps[dep.Name] = &dep
 
 			// Create path source and resolve
 			pathSource, err := NewPathSource(dep.Source.URL)
 			if err != nil {
-				return fmt.Errorf("failed to create path source for %s: %w", dep.Name, err)
+				return nil, fmt.Errorf("failed to create path source for %s: %w", dep.Name, err)
 			}
 
 			if err := pathSource.Resolve(); err != nil {
-				return fmt.Errorf("failed to resolve path gem %s: %w", dep.Name, err)
+				return nil, fmt.Errorf("failed to resolve path gem %s: %w", dep.Name, err)
 			}
 
 			// Get dependencies from the path gem
@@ -203,17 +339,13 @@ func GenerateLockfileWithPlatforms(gemfilePath string, versionPins map[string]st
 		// Determine which source URL to record for this gem
 		// Respect configured source, fallback to default
 		gemSourceURL := defaultSourceURL + "/"
-		if dep.Source != nil && dep.Source.Type == "rubygems" {
-			gemSourceURL = dep.Source.URL
-			if gemSourceURL != "" {
-				// Ensure URL ends with /
-				if gemSourceURL[len(gemSourceURL)-1] != '/' {
-					gemSourceURL += "/"
-				}
-			}
+		if dep.Source != nil && dep.Source.Type == "rubygems" && dep.Source.URL != "" {
+			// Ensure URL ends with /
+			gemSourceURL = strings.TrimSuffix(dep.Source.URL, "/") + "/"
+			gemSource.scope(dep.Name, getSource(dep.Source.URL))
 		}
 
-		fmt.Printf("Resolving %s from %s...\n", dep.Name, gemSourceURL)
+		progress.Resolving(dep.Name, gemSourceURL)
 
 		// Store gem source for later
 		gemSources[dep.Name] = gemSourceURL
@@ -252,14 +384,21 @@ func GenerateLockfileWithPlatforms(gemfilePath string, versionPins map[string]st
 	// This resolves all dependencies together with proper conflict resolution
 	// Enable incompatibility tracking for detailed error messages
 	unifiedSolver := pubgrub.NewSolverWithOptions(
-		[]pubgrub.Source{rootSource, defaultSource},
+		[]pubgrub.Source{rootSource, gemSource},
 		pubgrub.WithIncompatibilityTracking(true),
 	)
 
 	// Solve all dependencies at once
 	solution, err := unifiedSolver.Solve(rootSource.Term())
+	progress.Stop()
 	if err != nil {
-		return fmt.Errorf(`could not resolve dependencies
+		// Only a version conflict counts as ErrResolutionFailed; a source that couldn't be
+		// read (network, bad index) says nothing about whether the Gemfile can be resolved
+		var noSolution *pubgrub.NoSolutionError
+		if !errors.As(err, &noSolution) && !errors.As(err, new(pubgrub.ErrNoSolutionFound)) {
+			return nil, fmt.Errorf("failed to resolve dependencies: %w", err)
+		}
+		return nil, errdefs.Newf(errdefs.ErrResolutionFailed, `could not resolve dependencies
 
   This could mean:
   - No versions satisfy the constraints
@@ -282,7 +421,7 @@ func GenerateLockfileWithPlatforms(gemfilePath string, versionPins map[string]st
 
 		// Inherit source from dependencies (use default if not set)
 		if gemSources[pkgName] == "" {
-			gemSources[pkgName] = defaultSourceURL + "/"
+			gemSources[pkgName] = remotes[gemSource.sourceFor(pkgName)] + "/"
 		}
 	}
 
@@ -292,17 +431,19 @@ func GenerateLockfileWithPlatforms(gemfilePath string, versionPins map[string]st
 	})
 
 	// Determine lockfile path - supports both Gemfile.lock and gems.locked
-	lockfilePath := determineLockfilePath(gemfilePath)
+	lockfilePath := opts.LockfilePath
+	if lockfilePath == "" {
+		lockfilePath = determineLockfilePath(gemfilePath)
+	}
 
 	// Convert to lockfile specs and fetch dependencies
-	depSource := NewRubyGemsSource()
 	specs := make([]lockfile.GemSpec, len(allSolutions))
 	for i, pkg := range allSolutions {
 		gemName := pkg.Name.Value()
 		version := pkg.Version.String()
 
 		// Get dependencies for this gem
-		deps, depsErr := depSource.GetDependencies(pkg.Name, pkg.Version)
+		deps, depsErr := gemSource.sourceFor(gemName).GetDependencies(pkg.Name, pkg.Version)
 		if depsErr != nil {
 			// If we can't fetch dependencies, continue without them
 			deps = []pubgrub.Term{}
@@ -336,12 +477,17 @@ func GenerateLockfileWithPlatforms(gemfilePath string, versionPins map[string]st
 		GemSpecs:  specs,
 		GitSpecs:  gitSpecs,
 		PathSpecs: pathSpecs,
-		Platforms: detectPlatforms(lockfilePath, platforms),
+		Platforms: detectPlatforms(lockfilePath, platforms, opts.NormalizePlatforms),
 		Dependencies: func() []lockfile.Dependency {
 			var deps []lockfile.Dependency
 			for _, dep := range parsed.Dependencies {
+				// Bundler marks gems with their own source (git, path or a source block) with "!"
+				name := dep.Name
+				if dep.Source != nil {
+					name += "!"
+				}
 				deps = append(deps, lockfile.Dependency{
-					Name:        dep.Name,
+					Name:        name,
 					Constraints: dep.Constraints,
 				})
 			}
@@ -350,14 +496,115 @@ func GenerateLockfileWithPlatforms(gemfilePath string, versionPins map[string]st
 		BundledWith: detectBundlerVersion(lockfilePath),
 	}
 
-	// Write lockfile
-	writer := lockfile.NewLockfileWriter()
-	if err := writer.WriteFile(lock, lockfilePath); err != nil {
-		return fmt.Errorf("failed to write lockfile: %w", err)
+	return &resolution{lock: lock, rubyReq: rubyReq, lockfilePath: lockfilePath}, nil
+}
+
+// renderLockfile serializes the lockfile, adding the RUBY VERSION section
+// when the Gemfile has a ruby directive (the gemfile-go writer doesn't emit it).
+// PLATFORMS is deduplicated and ordered by sortPlatforms.
+func renderLockfile(lock *lockfile.Lockfile, rubyReq *ruby.Requirement) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := lockfile.NewLockfileWriter().Write(lock, &buf); err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("\n✨ Resolved %d dependencies and wrote %d gems to %s\n", len(parsed.Dependencies), len(specs), lockfilePath)
-	return nil
+	content := rewritePlatformsSection(buf.Bytes(), sortPlatforms(lock.Platforms, false))
+	content = sourcedDependencyPattern.ReplaceAll(content, []byte("  $1 $2!"))
+	if rubyReq == nil {
+		return content, nil
+	}
+
+	// Bundler places RUBY VERSION between DEPENDENCIES and BUNDLED WITH
+	section := []byte("RUBY VERSION\n   " + rubyReq.LockfileString() + "\n\n")
+	if idx := bytes.Index(content, []byte("BUNDLED WITH\n")); idx >= 0 {
+		out := make([]byte, 0, len(content)+len(section))
+		out = append(out, content[:idx]...)
+		out = append(out, section...)
+		out = append(out, content[idx:]...)
+		return out, nil
+	}
+
+	return append(content, append([]byte("\n"), section...)...), nil
+}
+
+// sourcedDependencyPattern matches a DEPENDENCIES entry the gemfile-go writer renders as
+// "name! (requirement)"; Bundler writes "name (requirement)!"
+var sourcedDependencyPattern = regexp.MustCompile(`(?m)^  ([^\s!]+)! (\(.*\))$`)
+
+// ParseLockfile parses a lockfile like lockfile.ParseFile, keeping the requirements of
+// DEPENDENCIES entries marked "!" (gems with their own source), which gemfile-go drops.
+// Their names keep the "!".
+func ParseLockfile(path string) (*lockfile.Lockfile, error) {
+	lock, err := lockfile.ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sourced := make(map[string][]string)
+	_, section, _ := bytes.Cut(content, []byte("\nDEPENDENCIES\n"))
+	for _, line := range strings.Split(string(section), "\n") {
+		if !strings.HasPrefix(line, "  ") {
+			break
+		}
+		entry, ok := strings.CutSuffix(strings.TrimSpace(line), ")!")
+		if !ok {
+			continue
+		}
+		if name, requirement, ok := strings.Cut(entry, " ("); ok {
+			sourced[name] = strings.Split(requirement, ", ")
+		}
+	}
+	for i, dep := range lock.Dependencies {
+		if constraints, ok := sourced[dep.Name]; ok && len(dep.Constraints) == 0 {
+			lock.Dependencies[i].Name = dep.Name + "!"
+			lock.Dependencies[i].Constraints = constraints
+		}
+	}
+	return lock, nil
+}
+
+// rewritePlatformsSection replaces the PLATFORMS entries with platforms, in order.
+// The gemfile-go writer sorts them alphabetically, which puts ruby mid-list.
+func rewritePlatformsSection(content []byte, platforms []string) []byte {
+	header := []byte("\nPLATFORMS\n")
+	start := bytes.Index(content, header)
+	if start < 0 {
+		return content
+	}
+	start += len(header)
+
+	end := start
+	for end < len(content) && bytes.HasPrefix(content[end:], []byte("  ")) {
+		if next := bytes.IndexByte(content[end:], '\n'); next >= 0 {
+			end += next + 1
+		} else {
+			end = len(content)
+		}
+	}
+
+	var section bytes.Buffer
+	for _, platform := range platforms {
+		section.WriteString("  " + platform + "\n")
+	}
+
+	out := make([]byte, 0, len(content))
+	out = append(out, content[:start]...)
+	out = append(out, section.Bytes()...)
+	return append(out, content[end:]...)
+}
+
+// writeLockfile renders the lockfile to w
+func writeLockfile(lock *lockfile.Lockfile, rubyReq *ruby.Requirement, w io.Writer) error {
+	content, err := renderLockfile(lock, rubyReq)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
 }
 
 // determineLockfilePath determines the lockfile path based on the Gemfile path.
@@ -378,86 +625,155 @@ func determineLockfilePath(gemfilePath string) string {
 // 2. Current platform (e.g., "arm64-darwin-24", "x86_64-linux")
 // 3. Any existing platforms from previous lockfile
 // 4. Additional platforms specified via --add-platform flag
-func detectPlatforms(lockfilePath string, additionalPlatforms []string) []string {
-	platformSet := make(map[string]bool)
-
+func detectPlatforms(lockfilePath string, additionalPlatforms []string, normalize bool) []string {
 	// Always include "ruby" for platform-independent gems
-	platformSet["ruby"] = true
+	platforms := []string{"ruby"}
 
 	// Read existing platforms from lockfile if it exists
-	if _, err := os.Stat(lockfilePath); err == nil {
-		if file, err := os.Open(lockfilePath); err == nil {
-			defer func() {
-				_ = file.Close()
-			}()
-			if parsed, err := lockfile.Parse(file); err == nil {
-				for _, p := range parsed.Platforms {
-					platformSet[p] = true
-				}
-			}
+	if file, err := os.Open(lockfilePath); err == nil {
+		defer func() {
+			_ = file.Close()
+		}()
+		if parsed, err := lockfile.Parse(file); err == nil {
+			platforms = append(platforms, parsed.Platforms...)
 		}
 	}
 
 	// Add current platform if Ruby is available
-	cmd := exec.Command("ruby", "-e", "puts RUBY_PLATFORM")
-	output, err := cmd.Output()
-	if err == nil {
-		platform := regexp.MustCompile(`\s+`).ReplaceAllString(string(output), "")
-		if platform != "" && platform != "ruby" {
-			platformSet[platform] = true
-		}
-	}
+	platforms = append(platforms, currentRubyPlatform())
 
 	// Add additional platforms from --add-platform flags
-	for _, p := range additionalPlatforms {
-		if p != "" {
-			platformSet[p] = true
+	platforms = append(platforms, additionalPlatforms...)
+
+	return sortPlatforms(platforms, normalize)
+}
+
+// sortPlatforms returns platforms in PLATFORMS order: blank entries dropped, duplicates
+// removed (after normalizePlatform when normalize is set, along with platforms another
+// entry covers), ruby first and the rest sorted, so relocking on different machines
+// doesn't reshuffle the section.
+func sortPlatforms(platforms []string, normalize bool) []string {
+	var others []string
+	hasRuby := false
+	for _, p := range platforms {
+		p = strings.TrimSpace(p)
+		if normalize {
+			p = normalizePlatform(p)
 		}
+		switch {
+		case p == "":
+		case p == "ruby":
+			hasRuby = true
+		case !slices.Contains(others, p):
+			others = append(others, p)
+		}
+	}
+	if normalize {
+		others = slices.DeleteFunc(slices.Clone(others), func(p string) bool {
+			return slices.ContainsFunc(others, func(general string) bool { return platformCovers(general, p) })
+		})
+	}
+	sort.Strings(others)
+
+	if !hasRuby {
+		return others
+	}
+	return append([]string{"ruby"}, others...)
+}
+
+// currentRubyPlatform returns the active Ruby's RUBY_PLATFORM, or "" without Ruby
+func currentRubyPlatform() string {
+	output, err := exec.Command(ruby.Binary(), "-e", "puts RUBY_PLATFORM").Output()
+	if err != nil {
+		return ""
+	}
+	return regexp.MustCompile(`\s+`).ReplaceAllString(string(output), "")
+}
+
+// versionedOSPattern matches an OS with a release number, e.g. darwin-23 or darwin23
+var versionedOSPattern = regexp.MustCompile(`^(darwin|freebsd|openbsd|netbsd|solaris)-?\d+(\.\d+)*$`)
+
+// normalizePlatform returns the canonical PLATFORMS entry for platform:
+//   - OS release numbers are dropped: arm64-darwin-23 and arm64-darwin24 are arm64-darwin
+//     (precompiled gems are published per architecture, not per macOS release)
+//   - glibc Linux loses its -gnu suffix: x86_64-linux-gnu is x86_64-linux
+//     (musl, e.g. x86_64-linux-musl, is a different platform and stays)
+//
+// ruby, java and Windows platforms are already canonical.
+func normalizePlatform(platform string) string {
+	arch, osName, ok := strings.Cut(platform, "-")
+	if !ok {
+		return platform
+	}
+	if match := versionedOSPattern.FindStringSubmatch(osName); match != nil {
+		return arch + "-" + match[1]
+	}
+	if osName == "linux-gnu" {
+		return arch + "-linux"
 	}
+	return platform
+}
 
-	// Convert set to sorted slice for consistent output
-	platforms := make([]string, 0, len(platformSet))
-	for p := range platformSet {
-		platforms = append(platforms, p)
+// platformCovers reports whether gems for general also serve specific, making a specific
+// PLATFORMS entry redundant: universal-darwin covers arm64-darwin, and java covers
+// universal-java-17 (RubyGems' name for it)
+func platformCovers(general, specific string) bool {
+	if general == "java" {
+		return strings.HasPrefix(specific, "universal-java")
+	}
+	generalArch, generalOS, ok := strings.Cut(general, "-")
+	if !ok || generalArch != "universal" || general == specific {
+		return false
 	}
-	sort.Strings(platforms)
+	_, specificOS, ok := strings.Cut(specific, "-")
+	return ok && specificOS == generalOS
+}
+
+// DEFAULT_BUNDLER_VERSION is the Bundler version written to BUNDLED WITH when nothing
+// better is known. Update this to match the current stable Bundler release
+const DEFAULT_BUNDLER_VERSION = "2.7.2"
 
-	return platforms
+var bundlerVersionPattern = regexp.MustCompile(`Bundler version (\d+\.\d+\.\d+)`)
+
+// bundleVersionOutput runs `bundle --version`; swapped out in tests
+var bundleVersionOutput = func() ([]byte, error) {
+	return exec.Command("bundle", "--version").Output()
 }
 
-// detectBundlerVersion attempts to detect the Bundler version from:
+// detectBundlerVersion picks the Bundler version for BUNDLED WITH, cheapest source first:
 // 1. Existing Gemfile.lock's BUNDLED WITH section (if exists)
-// 2. Running `bundle --version` and parsing output
-// 3. Fallback to a reasonable default
+// 2. InstalledBundlerVersion
 func detectBundlerVersion(lockfilePath string) string {
-	// Try to read existing lockfile
-	if _, err := os.Stat(lockfilePath); err == nil {
-		if existingLock, err := lockfile.ParseFile(lockfilePath); err == nil {
-			if existingLock.BundledWith != "" {
-				return existingLock.BundledWith
-			}
+	if existingLock, err := lockfile.ParseFile(lockfilePath); err == nil && existingLock.BundledWith != "" {
+		return strings.TrimSpace(existingLock.BundledWith)
+	}
+	return InstalledBundlerVersion()
+}
+
+// InstalledBundlerVersion returns the Bundler version to record when the lockfile has none:
+// 1. ORE_BUNDLER_VERSION or BUNDLER_VERSION from the environment
+// 2. Running `bundle --version` and parsing output
+// 3. DEFAULT_BUNDLER_VERSION
+func InstalledBundlerVersion() string {
+	for _, name := range []string{"ORE_BUNDLER_VERSION", "BUNDLER_VERSION"} {
+		if version := strings.TrimSpace(os.Getenv(name)); version != "" {
+			return version
 		}
 	}
 
-	// Try running bundle --version
-	cmd := exec.Command("bundle", "--version")
-	output, err := cmd.Output()
-	if err == nil {
-		// Parse output like "Bundler version 2.5.23"
-		versionRegex := regexp.MustCompile(`Bundler version (\d+\.\d+\.\d+)`)
-		if matches := versionRegex.FindStringSubmatch(string(output)); len(matches) > 1 {
+	// Parse output like "Bundler version 2.5.23"
+	if output, err := bundleVersionOutput(); err == nil {
+		if matches := bundlerVersionPattern.FindStringSubmatch(string(output)); len(matches) > 1 {
 			return matches[1]
 		}
 	}
 
-	// Fallback to DEFAULT_BUNDLER_VERSION constant
-	// Note: This should match the constant in cmd/ore/main.go
-	return "2.7.2"
+	return DEFAULT_BUNDLER_VERSION
 }
 
 // loadGemspecDependencies loads dependencies from .gemspec files referenced by gemspec directives.
 // Ruby developers: This is equivalent to evaluating the `gemspec` directive in your Gemfile.
-func loadGemspecDependencies(gemfilePath string, parsed *gemfile.ParsedGemfile) error {
+func loadGemspecDependencies(gemfilePath string, parsed *gemfile.ParsedGemfile, progress io.Writer) error {
 	gemfileDir := filepath.Dir(gemfilePath)
 
 	// Track gemspec names to filter out the gem itself from dependencies
@@ -482,7 +798,7 @@ func loadGemspecDependencies(gemfilePath string, parsed *gemfile.ParsedGemfile)
 
 		// Parse each gemspec file and merge dependencies
 		for _, gemspecPath := range gemspecFiles {
-			fmt.Printf("Loading dependencies from %s...\n", filepath.Base(gemspecPath))
+			fmt.Fprintf(progress, "Loading dependencies from %s...\n", filepath.Base(gemspecPath))
 
 			gemspecParser := gemfile.NewGemspecParser(gemspecPath)
 			gemspecFile, err := gemspecParser.Parse()
@@ -532,6 +848,16 @@ func loadGemspecDependencies(gemfilePath string, parsed *gemfile.ParsedGemfile)
 	return nil
 }
 
+// ApplyGemspecDependencies adds the dependencies of the Gemfile's `gemspec` directives
+// to parsed.Dependencies, as ore lock writes them to DEPENDENCIES, and drops the
+// gemspec's own gem.
+func ApplyGemspecDependencies(gemfilePath string, parsed *gemfile.ParsedGemfile) error {
+	if len(parsed.Gemspecs) == 0 {
+		return nil
+	}
+	return loadGemspecDependencies(gemfilePath, parsed, io.Discard)
+}
+
 // findGemspecFiles finds .gemspec files in the given directory matching the glob pattern.
 func findGemspecFiles(searchPath, globPattern, specificName string) ([]string, error) {
 	// If a specific name is provided, look for that exact gemspec
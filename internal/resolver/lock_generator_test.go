@@ -0,0 +1,320 @@
+package resolver
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/lockfile"
+	"github.com/contriboss/ore-light/internal/errdefs"
+	"github.com/contriboss/ore-light/internal/ruby"
+)
+
+func TestGenerateLockfileWithOptionsPrint(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir) // Path gems resolve relative to the working directory
+	gemfilePath := filepath.Join(tmpDir, "Gemfile")
+	gemfileContent := `source "https://rubygems.org"
+
+gem "mylib", path: "./mylib"
+`
+	if err := os.WriteFile(gemfilePath, []byte(gemfileContent), 0644); err != nil {
+		t.Fatalf("failed to write Gemfile: %v", err)
+	}
+
+	gemspec := `Gem::Specification.new do |spec|
+  spec.name = "mylib"
+  spec.version = "0.1.0"
+  spec.summary = "A local gem"
+end
+`
+	if err := os.MkdirAll(filepath.Join(tmpDir, "mylib"), 0755); err != nil {
+		t.Fatalf("failed to create gem dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "mylib", "mylib.gemspec"), []byte(gemspec), 0644); err != nil {
+		t.Fatalf("failed to write gemspec: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := GenerateLockfileWithOptions(gemfilePath, LockOptions{Output: &out}); err != nil {
+		t.Fatalf("GenerateLockfileWithOptions returned error: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "mylib (0.1.0)") {
+		t.Errorf("expected printed lockfile to contain mylib (0.1.0), got:\n%s", out.String())
+	}
+	if _, err := os.Stat(gemfilePath + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("expected no lockfile to be written, stat returned %v", err)
+	}
+}
+
+func TestResolveReturnsLockfileWithoutWriting(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	gemfilePath := filepath.Join(tmpDir, "Gemfile")
+	if err := os.WriteFile(gemfilePath, []byte("source \"https://rubygems.org\"\n\ngem \"mylib\", path: \"./mylib\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gemspec := "Gem::Specification.new do |spec|\n  spec.name = \"mylib\"\n  spec.version = \"0.2.0\"\nend\n"
+	if err := os.MkdirAll(filepath.Join(tmpDir, "mylib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "mylib", "mylib.gemspec"), []byte(gemspec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := Resolve(gemfilePath, nil)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if len(lock.PathSpecs) != 1 || lock.PathSpecs[0].Name != "mylib" || lock.PathSpecs[0].Version != "0.2.0" {
+		t.Errorf("expected mylib 0.2.0 as a path gem, got %+v", lock.PathSpecs)
+	}
+	if len(lock.Dependencies) != 1 || lock.Dependencies[0].Name != "mylib!" {
+		t.Errorf("expected mylib! (a path gem) as the only dependency, got %+v", lock.Dependencies)
+	}
+	if len(lock.Platforms) == 0 {
+		t.Error("expected platforms to be filled in")
+	}
+	if _, err := os.Stat(gemfilePath + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("expected no lockfile to be written, stat returned %v", err)
+	}
+}
+
+func TestGenerateLockfileKeepsOtherEngines(t *testing.T) {
+	t.Setenv("RUBY_ENGINE", "ruby")
+	t.Setenv("RUBY_VERSION", "3.3.0")
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	gemfilePath := filepath.Join(tmpDir, "Gemfile")
+	gemfileContent := `source "https://rubygems.org"
+
+gem "mylib", path: "./mylib"
+gem "javalib", path: "./javalib", platforms: :jruby
+`
+	if err := os.WriteFile(gemfilePath, []byte(gemfileContent), 0644); err != nil {
+		t.Fatalf("failed to write Gemfile: %v", err)
+	}
+	for _, name := range []string{"mylib", "javalib"} {
+		gemspec := "Gem::Specification.new do |spec|\n  spec.name = \"" + name + "\"\n  spec.version = \"0.1.0\"\nend\n"
+		if err := os.MkdirAll(filepath.Join(tmpDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, name, name+".gemspec"), []byte(gemspec), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := GenerateLockfileWithOptions(gemfilePath, LockOptions{Output: &out}); err != nil {
+		t.Fatalf("GenerateLockfileWithOptions returned error: %v", err)
+	}
+	// JRuby users install from the same lockfile; ore install skips javalib on MRI
+	if !strings.Contains(out.String(), "mylib (0.1.0)") || !strings.Contains(out.String(), "javalib (0.1.0)") {
+		t.Errorf("expected the :jruby gem to be locked on MRI too, got:\n%s", out.String())
+	}
+}
+
+func TestGenerateLockfileKeepsInstallIfGems(t *testing.T) {
+	t.Setenv("ORE_TEST_INSTALL_IF", "")
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	gemfilePath := filepath.Join(tmpDir, "Gemfile")
+	gemfileContent := `source "https://rubygems.org"
+
+install_if -> { ENV["ORE_TEST_INSTALL_IF"] == "1" } do
+  gem "notifier", path: "./notifier"
+end
+`
+	if err := os.WriteFile(gemfilePath, []byte(gemfileContent), 0644); err != nil {
+		t.Fatalf("failed to write Gemfile: %v", err)
+	}
+	gemspec := "Gem::Specification.new do |spec|\n  spec.name = \"notifier\"\n  spec.version = \"0.1.0\"\nend\n"
+	if err := os.MkdirAll(filepath.Join(tmpDir, "notifier"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "notifier", "notifier.gemspec"), []byte(gemspec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The condition is false here, but the lockfile must not depend on the machine
+	var out bytes.Buffer
+	if err := GenerateLockfileWithOptions(gemfilePath, LockOptions{Output: &out}); err != nil {
+		t.Fatalf("GenerateLockfileWithOptions returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "notifier (0.1.0)") {
+		t.Errorf("expected the install_if gem to be locked, got:\n%s", out.String())
+	}
+}
+
+func TestDetectBundlerVersionPrefersLockfile(t *testing.T) {
+	calls := 0
+	original := bundleVersionOutput
+	bundleVersionOutput = func() ([]byte, error) {
+		calls++
+		return []byte("Bundler version 2.5.23\n"), nil
+	}
+	defer func() { bundleVersionOutput = original }()
+	t.Setenv("ORE_BUNDLER_VERSION", "")
+	t.Setenv("BUNDLER_VERSION", "")
+
+	lockPath := filepath.Join(t.TempDir(), "Gemfile.lock")
+	lockContent := "GEM\n  remote: https://rubygems.org/\n  specs:\n\nPLATFORMS\n  ruby\n\nDEPENDENCIES\n\nBUNDLED WITH\n   2.6.9\n"
+	if err := os.WriteFile(lockPath, []byte(lockContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := detectBundlerVersion(lockPath); got != "2.6.9" {
+		t.Errorf("expected BUNDLED WITH 2.6.9, got %q", got)
+	}
+	if calls != 0 {
+		t.Errorf("expected no `bundle --version` subprocess, got %d", calls)
+	}
+
+	missing := filepath.Join(t.TempDir(), "Gemfile.lock")
+	t.Setenv("BUNDLER_VERSION", "2.6.1")
+	if got := detectBundlerVersion(missing); got != "2.6.1" || calls != 0 {
+		t.Errorf("expected BUNDLER_VERSION 2.6.1 without a subprocess, got %q (%d calls)", got, calls)
+	}
+
+	t.Setenv("BUNDLER_VERSION", "")
+	if got := detectBundlerVersion(missing); got != "2.5.23" || calls != 1 {
+		t.Errorf("expected `bundle --version` fallback 2.5.23, got %q (%d calls)", got, calls)
+	}
+}
+
+func TestPlatformsDedupedAcrossRelocks(t *testing.T) {
+	// No Ruby, so the host platform isn't added
+	ruby.SetBinary(filepath.Join(t.TempDir(), "ruby"))
+	t.Cleanup(func() { ruby.SetBinary("") })
+
+	lockfilePath := filepath.Join(t.TempDir(), "Gemfile.lock")
+	relock := func(additional ...string) string {
+		lock := &lockfile.Lockfile{Platforms: detectPlatforms(lockfilePath, additional, false)}
+		content, err := renderLockfile(lock, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(lockfilePath, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	relock("x86_64-linux", " ", "arm64-darwin")
+	relock("x86_64-linux ", "ruby", "")
+	content := relock("arm64-darwin", "aarch64-linux")
+	if !strings.Contains(content, "PLATFORMS\n  ruby\n  aarch64-linux\n  arm64-darwin\n  x86_64-linux\n\n") {
+		t.Errorf("expected ruby first and the rest sorted without duplicates, got:\n%s", content)
+	}
+
+	// The writer orders and deduplicates platforms it's handed directly, too
+	rendered, err := renderLockfile(&lockfile.Lockfile{Platforms: []string{"x86_64-linux", "ruby", "x86_64-linux", ""}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(rendered), "PLATFORMS\n  ruby\n  x86_64-linux\n\n") {
+		t.Errorf("unexpected PLATFORMS section:\n%s", rendered)
+	}
+}
+
+func TestNormalizePlatform(t *testing.T) {
+	tests := map[string]string{
+		"arm64-darwin-23":   "arm64-darwin",
+		"arm64-darwin24":    "arm64-darwin",
+		"x86_64-linux-gnu":  "x86_64-linux",
+		"x86_64-linux-musl": "x86_64-linux-musl",
+		"x64-mingw-ucrt":    "x64-mingw-ucrt",
+		"ruby":              "ruby",
+		"java":              "java",
+	}
+	for platform, want := range tests {
+		if got := normalizePlatform(platform); got != want {
+			t.Errorf("normalizePlatform(%q) = %q, want %q", platform, got, want)
+		}
+	}
+
+	lockfilePath := filepath.Join(t.TempDir(), "Gemfile.lock")
+	lock := "GEM\n  remote: https://rubygems.org/\n  specs:\n\nPLATFORMS\n  arm64-darwin-23\n  arm64-darwin-24\n  x86_64-linux\n\nDEPENDENCIES\n"
+	if err := os.WriteFile(lockfilePath, []byte(lock), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// No Ruby, so the host platform isn't added
+	ruby.SetBinary(filepath.Join(t.TempDir(), "ruby"))
+	t.Cleanup(func() { ruby.SetBinary("") })
+	platforms := detectPlatforms(lockfilePath, []string{"x86_64-linux-gnu"}, true)
+	if strings.Join(platforms, ",") != "ruby,arm64-darwin,x86_64-linux" {
+		t.Errorf("unexpected normalized platforms %v", platforms)
+	}
+
+	// Entries another kept platform covers are dropped too
+	covered := sortPlatforms([]string{"x86_64-darwin-22", "universal-darwin", "arm64-darwin", "universal-java-17", "java", "x86_64-linux", "x86_64-linux"}, true)
+	if strings.Join(covered, ",") != "java,universal-darwin,x86_64-linux" {
+		t.Errorf("unexpected normalized platforms %v", covered)
+	}
+	if kept := sortPlatforms([]string{"universal-darwin", "arm64-darwin"}, false); len(kept) != 2 {
+		t.Errorf("expected covered platforms kept without normalize, got %v", kept)
+	}
+}
+
+func TestResolveMissingGemfile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "Gemfile")
+	_, err := Resolve(missing, nil)
+	if !errors.Is(err, errdefs.ErrGemfileNotFound) || !strings.Contains(err.Error(), missing) {
+		t.Errorf("expected ErrGemfileNotFound naming %s, got %v", missing, err)
+	}
+}
+
+func TestResolveRedactsSourceCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	server := httptest.NewServer(http.NotFoundHandler())
+	source := strings.Replace(server.URL, "http://", "http://secret-token:@", 1)
+	server.Close() // Fetching from it now fails with an error quoting the URL
+
+	gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+	if err := os.WriteFile(gemfilePath, []byte("source \""+source+"\"\n\ngem \"rack\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Resolve(gemfilePath, nil)
+	if err == nil || strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("expected a resolve error without the token, got %v", err)
+	}
+}
+
+func TestRenderLockfileMarksSourcedDependencies(t *testing.T) {
+	lock := &lockfile.Lockfile{
+		Platforms: []string{"ruby"},
+		Dependencies: []lockfile.Dependency{
+			{Name: "mylib!"},
+			{Name: "private!", Constraints: []string{">= 2", "< 3"}},
+			{Name: "rack", Constraints: []string{"~> 3.0"}},
+		},
+	}
+	content, err := renderLockfile(lock, nil)
+	if err != nil {
+		t.Fatalf("renderLockfile returned error: %v", err)
+	}
+	if want := "DEPENDENCIES\n  mylib!\n  private (>= 2, < 3)!\n  rack (~> 3.0)\n"; !strings.Contains(string(content), want) {
+		t.Errorf("expected %q, got:\n%s", want, content)
+	}
+
+	lockfilePath := filepath.Join(t.TempDir(), "Gemfile.lock")
+	if err := os.WriteFile(lockfilePath, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseLockfile(lockfilePath)
+	if err != nil {
+		t.Fatalf("ParseLockfile returned error: %v", err)
+	}
+	for _, dep := range parsed.Dependencies {
+		if dep.Name == "private!" && strings.Join(dep.Constraints, ", ") == ">= 2, < 3" {
+			return
+		}
+	}
+	t.Errorf("expected private! (>= 2, < 3) to be read back, got %+v", parsed.Dependencies)
+}
@@ -0,0 +1,76 @@
+package resolver
+
+import (
+	"strings"
+
+	"github.com/contriboss/gemfile-go/lockfile"
+)
+
+// ApplyGemRemotes sets each gem's SourceURL to the remote of the GEM section it's
+// locked in; the lockfile parser doesn't record it. Gemfile source blocks produce
+// one GEM section per source, and those gems must be downloaded from their own remote.
+func ApplyGemRemotes(parsed *lockfile.Lockfile, content []byte) {
+	remotes := make(map[string]string) // full name -> remote
+	inGemSection := false
+	remote := ""
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case line == "GEM":
+			inGemSection, remote = true, ""
+		case line != "" && !strings.HasPrefix(line, " "):
+			inGemSection = false
+		case !inGemSection:
+		case strings.HasPrefix(line, "  remote: "):
+			remote = strings.TrimSpace(strings.TrimPrefix(line, "  remote: "))
+		case strings.HasPrefix(line, "    ") && !strings.HasPrefix(line, "     "):
+			// "    nokogiri (1.16.0-x86_64-linux)"
+			name, version, ok := strings.Cut(strings.TrimSpace(line), " (")
+			if ok && remote != "" {
+				remotes[name+"-"+strings.TrimSuffix(version, ")")] = remote
+			}
+		}
+	}
+
+	for i := range parsed.GemSpecs {
+		if remote, ok := remotes[parsed.GemSpecs[i].FullName()]; ok {
+			parsed.GemSpecs[i].SourceURL = remote
+		}
+	}
+}
+
+// ApplyGemChecksums sets each gem's Checksum to the SHA-256 recorded for it in the
+// lockfile's CHECKSUMS section (Bundler 2.5+), which the lockfile parser skips.
+// Gems without an entry, or with only other algorithms, keep an empty Checksum.
+func ApplyGemChecksums(parsed *lockfile.Lockfile, content []byte) {
+	checksums := make(map[string]string) // full name -> sha256 hex
+	inChecksums := false
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case line == "CHECKSUMS":
+			inChecksums = true
+		case line != "" && !strings.HasPrefix(line, " "):
+			inChecksums = false
+		case !inChecksums:
+		default:
+			// "  nokogiri (1.16.0-x86_64-linux) sha256=4e5f..."
+			spec, sums, ok := strings.Cut(strings.TrimSpace(line), ") ")
+			name, version, found := strings.Cut(spec, " (")
+			if !ok || !found {
+				continue
+			}
+			for _, sum := range strings.Split(sums, ",") {
+				if hex, ok := strings.CutPrefix(strings.TrimSpace(sum), "sha256="); ok {
+					checksums[name+"-"+version] = strings.ToLower(hex)
+				}
+			}
+		}
+	}
+
+	for i := range parsed.GemSpecs {
+		if checksum, ok := checksums[parsed.GemSpecs[i].FullName()]; ok {
+			parsed.GemSpecs[i].Checksum = checksum
+		}
+	}
+}
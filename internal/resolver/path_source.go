@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/contriboss/gemfile-go/gemfile"
+	"github.com/contriboss/gemfile-go/lockfile"
 	"github.com/contriboss/pubgrub-go"
 )
 
@@ -181,8 +182,7 @@ func (p *PathSource) CopyToVendor(destDir string) error {
 		// Skip directories that could cause infinite recursion
 		// This prevents copying vendor/bundle/gems/foo into vendor/bundle/gems/foo/vendor/bundle/gems/foo/...
 		if info.IsDir() {
-			skipDirs := []string{".git", "vendor", ".bundle", "tmp"}
-			for _, skip := range skipDirs {
+			for _, skip := range pathGemSkipDirs {
 				if info.Name() == skip {
 					return filepath.SkipDir
 				}
@@ -200,6 +200,27 @@ func (p *PathSource) CopyToVendor(destDir string) error {
 	})
 }
 
+// CopyPathGem copies a locked path gem into destDir.
+// Used by both ore install and ore pristine.
+func CopyPathGem(spec lockfile.PathGemSpec, destDir string) error {
+	pathSource, err := NewPathSource(spec.Remote)
+	if err != nil {
+		return fmt.Errorf("failed to create path source: %w", err)
+	}
+
+	// Hash before copying, so edits made mid-copy show up as a change next time
+	contentHash, err := pathContentHash(pathSource.AbsPath)
+	if err != nil {
+		return err
+	}
+
+	if err := pathSource.CopyToVendor(destDir); err != nil {
+		return fmt.Errorf("failed to copy to vendor: %w", err)
+	}
+
+	return writeSourceMarker(destDir, contentHash)
+}
+
 // copyFile copies a file from src to dst
 func copyFile(src, dst string, mode os.FileMode) error {
 	input, err := os.ReadFile(src)
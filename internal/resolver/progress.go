@@ -0,0 +1,142 @@
+package resolver
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/contriboss/ore-light/internal/httpclient"
+	"github.com/mattn/go-isatty"
+)
+
+// plainProgressInterval is how often a non-terminal gets a status line while resolving
+const plainProgressInterval = 5 * time.Second
+
+// resolveProgress reports dependency resolution without a line per gem.
+// On a terminal it redraws a single spinner line with the gem being resolved and a
+// running count; elsewhere (CI logs, pipes) it prints a plain status line now and then.
+// In verbose mode it prints the classic "Resolving <gem> from <source>..." lines instead.
+type resolveProgress struct {
+	out     io.Writer
+	verbose bool
+	tty     bool
+
+	mu      sync.Mutex
+	seen    map[string]bool
+	current string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newResolveProgress(out io.Writer, verbose bool) *resolveProgress {
+	p := &resolveProgress{
+		out:     out,
+		verbose: verbose,
+		seen:    make(map[string]bool),
+	}
+	if file, ok := out.(*os.File); ok {
+		p.tty = isatty.IsTerminal(file.Fd())
+	}
+	return p
+}
+
+// Start begins reporting; pair with Stop
+func (p *resolveProgress) Start() {
+	if p.verbose || !p.tty {
+		fmt.Fprintf(p.out, "Resolving dependencies...\n")
+	}
+	if p.verbose {
+		return
+	}
+
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	if p.tty {
+		go p.spin()
+	} else {
+		go p.tick()
+	}
+}
+
+// Resolving records that a gem is being looked up. from names the source for the
+// verbose line ("git", "path" or a URL); gems found while solving pass "".
+func (p *resolveProgress) Resolving(name, from string) {
+	p.mu.Lock()
+	p.seen[name] = true
+	p.current = name
+	p.mu.Unlock()
+
+	if p.verbose && from != "" {
+		fmt.Fprintf(p.out, "Resolving %s from %s...\n", name, httpclient.RedactURL(from))
+	}
+}
+
+// Stop ends reporting and clears the spinner line, leaving room for the summary
+func (p *resolveProgress) Stop() {
+	if p.stop == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	p.stop = nil
+}
+
+func (p *resolveProgress) status() (string, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.current, len(p.seen)
+}
+
+// spin redraws the status line on a terminal
+func (p *resolveProgress) spin() {
+	defer close(p.done)
+
+	frames := spinner.MiniDot
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("86")) // Cyan
+	ticker := time.NewTicker(frames.FPS)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		current, count := p.status()
+		line := fmt.Sprintf("%s Resolving dependencies... %d gems", style.Render(frames.Frames[frame%len(frames.Frames)]), count)
+		if current != "" {
+			line += " (" + current + ")"
+		}
+		fmt.Fprintf(p.out, "\r\033[K%s", line)
+
+		select {
+		case <-p.stop:
+			fmt.Fprint(p.out, "\r\033[K")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick prints a plain status line when resolution is still going and has progressed
+func (p *resolveProgress) tick() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(plainProgressInterval)
+	defer ticker.Stop()
+
+	reported := 0
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			current, count := p.status()
+			if count == reported {
+				continue
+			}
+			reported = count
+			fmt.Fprintf(p.out, "Still resolving... %d gems so far (%s)\n", count, current)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package resolver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestResolveProgressOutput(t *testing.T) {
+	var quiet bytes.Buffer
+	progress := newResolveProgress(&quiet, false)
+	progress.Start()
+	progress.Resolving("rails", "https://rubygems.org/")
+	progress.Resolving("rack", "")
+	progress.Stop()
+	progress.Stop() // Safe to call twice (explicit stop plus defer)
+
+	if got := quiet.String(); got != "Resolving dependencies...\n" {
+		t.Errorf("expected only the header when not verbose, got %q", got)
+	}
+	if _, count := progress.status(); count != 2 {
+		t.Errorf("expected 2 gems counted, got %d", count)
+	}
+
+	var verbose bytes.Buffer
+	progress = newResolveProgress(&verbose, true)
+	progress.Start()
+	progress.Resolving("rails", "https://rubygems.org/")
+	progress.Resolving("rack", "")
+	progress.Stop()
+
+	if !strings.Contains(verbose.String(), "Resolving rails from https://rubygems.org/...") {
+		t.Errorf("expected per-gem line in verbose mode, got %q", verbose.String())
+	}
+	if strings.Contains(verbose.String(), "rack") {
+		t.Errorf("expected gems found while solving to stay quiet, got %q", verbose.String())
+	}
+
+	// Credentials in a Gemfile source URL never reach the log
+	var private bytes.Buffer
+	progress = newResolveProgress(&private, true)
+	progress.Resolving("sidekiq-pro", "https://secret-token:@gems.contribsys.com/")
+	if strings.Contains(private.String(), "secret-token") {
+		t.Errorf("expected the source URL to be redacted, got %q", private.String())
+	}
+}
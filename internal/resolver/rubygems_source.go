@@ -6,15 +6,24 @@ import (
 	"github.com/contriboss/pubgrub-go"
 )
 
+// gemIndex is where a RubyGemsSource reads versions and dependencies from: the
+// server's compact index, or a LocalGemSource standing in for it (--local-source)
+type gemIndex interface {
+	pubgrub.Source
+	SetVersionPins(pins map[string]string)
+	Refresh()
+}
+
 // RubyGemsSource implements pubgrub.Source using Bundler's compact index.
 //
 // This now delegates to CompactIndexSource which uses Bundler's cache.
 // Kept for backward compatibility with existing code.
 type RubyGemsSource struct {
-	compactSource *CompactIndexSource                  // Compact index client (Bundler-compatible)
+	compactSource gemIndex                             // Compact index client (Bundler-compatible) or local .gem files
 	cache         map[string]map[string][]pubgrub.Term // Legacy cache (unused now)
 	sourceURL     string                               // The source URL
 	versionPins   map[string]string                    // Optional version pins
+	progress      *resolveProgress                     // Told about each gem looked up, if set
 }
 
 // NewRubyGemsSource creates a new RubyGems source for dependency resolution
@@ -40,6 +49,16 @@ func NewRubyGemsSourceWithURL(baseURL string) *RubyGemsSource {
 	}
 }
 
+// newLocalRubyGemsSource creates a source for the gem server at baseURL that reads
+// gems from local instead, so the lockfile still names baseURL as their remote
+func newLocalRubyGemsSource(baseURL string, local *LocalGemSource) *RubyGemsSource {
+	return &RubyGemsSource{
+		compactSource: local,
+		cache:         make(map[string]map[string][]pubgrub.Term),
+		sourceURL:     baseURL,
+	}
+}
+
 // SetVersionPins sets version pins for selective updates.
 // When a gem is pinned, GetVersions will return only the pinned version.
 func (s *RubyGemsSource) SetVersionPins(pins map[string]string) {
@@ -47,6 +66,11 @@ func (s *RubyGemsSource) SetVersionPins(pins map[string]string) {
 	s.versionPins = pins
 }
 
+// Refresh bypasses the dependency data cached by earlier runs
+func (s *RubyGemsSource) Refresh() {
+	s.compactSource.Refresh()
+}
+
 // SourceURL returns the URL of this gem source
 func (s *RubyGemsSource) SourceURL() string {
 	return s.sourceURL
@@ -61,5 +85,8 @@ func (s *RubyGemsSource) GetDependencies(name pubgrub.Name, version pubgrub.Vers
 // GetVersions returns all available versions for a package.
 // Delegates to compact index source.
 func (s *RubyGemsSource) GetVersions(name pubgrub.Name) ([]pubgrub.Version, error) {
+	if s.progress != nil {
+		s.progress.Resolving(name.Value(), "")
+	}
 	return s.compactSource.GetVersions(name)
 }
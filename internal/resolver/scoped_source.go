@@ -0,0 +1,95 @@
+package resolver
+
+import (
+	"strings"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+	"github.com/contriboss/pubgrub-go"
+)
+
+// scopedSource routes each gem to the gem server it's scoped to, falling back to the
+// default source. Gems are scoped by a Gemfile source block or `source:` option.
+//
+// Ruby developers: like Bundler, dependencies of a scoped gem are looked up in the
+// same source first, so a private gem can depend on other gems from that server:
+//
+//	source "https://gems.example.com" do
+//	  gem "acme-client"   # acme-client and its acme-* dependencies come from gems.example.com
+//	end
+type scopedSource struct {
+	defaultSource *RubyGemsSource
+	scoped        map[string]*RubyGemsSource // gem name -> source
+}
+
+func newScopedSource(defaultSource *RubyGemsSource) *scopedSource {
+	return &scopedSource{
+		defaultSource: defaultSource,
+		scoped:        make(map[string]*RubyGemsSource),
+	}
+}
+
+// scope pins a gem to a source
+func (s *scopedSource) scope(name string, source *RubyGemsSource) {
+	s.scoped[name] = source
+}
+
+// sourceFor returns the source a gem resolves from
+func (s *scopedSource) sourceFor(name string) *RubyGemsSource {
+	if source, ok := s.scoped[name]; ok {
+		return source
+	}
+	return s.defaultSource
+}
+
+// GetVersions implements pubgrub.Source
+func (s *scopedSource) GetVersions(name pubgrub.Name) ([]pubgrub.Version, error) {
+	return s.sourceFor(name.Value()).GetVersions(name)
+}
+
+// GetDependencies implements pubgrub.Source. Dependencies of a scoped gem that its
+// source also serves are scoped to it before the solver asks for their versions.
+func (s *scopedSource) GetDependencies(name pubgrub.Name, version pubgrub.Version) ([]pubgrub.Term, error) {
+	source := s.sourceFor(name.Value())
+	deps, err := source.GetDependencies(name, version)
+	if err != nil || source == s.defaultSource {
+		return deps, err
+	}
+
+	for _, dep := range deps {
+		depName := dep.Name.Value()
+		if _, ok := s.scoped[depName]; ok {
+			continue
+		}
+		if versions, err := source.GetVersions(dep.Name); err == nil && len(versions) > 0 {
+			s.scoped[depName] = source
+		}
+	}
+	return deps, nil
+}
+
+// defaultGemSourceURL returns the Gemfile's global source: the first top-level
+// `source "..."` line. Sources only declared as blocks don't count, since gems
+// outside the block never use them.
+func defaultGemSourceURL(parsed *gemfile.ParsedGemfile) string {
+	// gemfile-go lists block sources in parsed.Sources too; count them per URL
+	blocks := make(map[string]int)
+	seen := make(map[*gemfile.Source]bool)
+	for _, dep := range parsed.Dependencies {
+		if dep.Source != nil && dep.Source.Type == "rubygems" && !seen[dep.Source] {
+			seen[dep.Source] = true
+			blocks[dep.Source.URL]++
+		}
+	}
+
+	for _, src := range parsed.Sources {
+		if src.Type != "rubygems" || src.URL == "" {
+			continue
+		}
+		if blocks[src.URL] > 0 {
+			blocks[src.URL]--
+			continue
+		}
+		return strings.TrimSuffix(src.URL, "/")
+	}
+	return "https://rubygems.org"
+}
@@ -0,0 +1,69 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+)
+
+func TestDefaultGemSourceURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		gemfile string
+		want    string
+	}{
+		{
+			name: "global source before a block",
+			gemfile: `source "https://rubygems.org"
+
+source "https://gems.example.com" do
+  gem "acme-client"
+end
+`,
+			want: "https://rubygems.org",
+		},
+		{
+			name: "block before the global source",
+			gemfile: `source "https://gems.example.com" do
+  gem "acme-client"
+end
+
+source "https://mirror.example.com/"
+gem "rack"
+`,
+			want: "https://mirror.example.com",
+		},
+		{
+			name: "only a block",
+			gemfile: `source "https://gems.example.com" do
+  gem "acme-client"
+end
+`,
+			want: "https://rubygems.org",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "Gemfile")
+			if err := os.WriteFile(path, []byte(tt.gemfile), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := gemfile.NewGemfileParser(path).Parse()
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			if got := defaultGemSourceURL(parsed); got != tt.want {
+				t.Errorf("defaultGemSourceURL() = %q, want %q", got, tt.want)
+			}
+			for _, dep := range parsed.Dependencies {
+				if dep.Name == "acme-client" && (dep.Source == nil || dep.Source.URL != "https://gems.example.com") {
+					t.Errorf("expected acme-client scoped to its source block, got %+v", dep.Source)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package resolver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/contriboss/gemfile-go/lockfile"
+)
+
+// sourceMarkerName is written to an installed git or path gem's directory.
+// It records what was installed: the commit SHA for git gems, a content hash for path gems.
+const sourceMarkerName = ".ore-source"
+
+// pathGemSkipDirs are left out when copying (and hashing) a path gem
+var pathGemSkipDirs = []string{".git", "vendor", ".bundle", "tmp"}
+
+// InstalledSource returns the revision or content hash recorded for an installed
+// git or path gem, or "" for gems installed before it was recorded
+func InstalledSource(gemDir string) string {
+	data, err := os.ReadFile(filepath.Join(gemDir, sourceMarkerName))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func writeSourceMarker(gemDir, source string) error {
+	return os.WriteFile(filepath.Join(gemDir, sourceMarkerName), []byte(source+"\n"), 0o644)
+}
+
+// VerifyGitGem checks that an installed git gem is the commit the lockfile locks.
+// Gems installed without a recorded revision pass, since there is nothing to compare.
+func VerifyGitGem(spec lockfile.GitGemSpec, gemDir string) error {
+	installed := InstalledSource(gemDir)
+	if installed == "" || spec.Revision == "" || strings.HasPrefix(installed, spec.Revision) {
+		return nil
+	}
+	return fmt.Errorf("git gem %s is installed at %s but Gemfile.lock locks %s", spec.Name, shortRevision(installed), shortRevision(spec.Revision))
+}
+
+// PathGemChanged reports whether a path gem's source differs from the installed copy.
+// Gems installed without a recorded hash are reported unchanged.
+func PathGemChanged(spec lockfile.PathGemSpec, gemDir string) (bool, error) {
+	installed := InstalledSource(gemDir)
+	if installed == "" {
+		return false, nil
+	}
+	pathSource, err := NewPathSource(spec.Remote)
+	if err != nil {
+		return false, err
+	}
+	current, err := pathContentHash(pathSource.AbsPath)
+	if err != nil {
+		return false, err
+	}
+	return current != installed, nil
+}
+
+// pathContentHash digests a path gem's files (names, modes and contents), skipping
+// the directories CopyToVendor skips
+func pathContentHash(root string) (string, error) {
+	hash := sha256.New()
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if info.IsDir() {
+			for _, skip := range pathGemSkipDirs {
+				if info.Name() == skip {
+					return filepath.SkipDir
+				}
+			}
+			return nil
+		}
+
+		fmt.Fprintf(hash, "%s\x00%o\x00", filepath.ToSlash(relPath), info.Mode().Perm())
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		_, err = io.Copy(hash, file)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", root, err)
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// shortRevision abbreviates a commit SHA for messages, like git's --short
+func shortRevision(revision string) string {
+	if len(revision) > 12 {
+		return revision[:12]
+	}
+	return revision
+}
@@ -0,0 +1,121 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/lockfile"
+)
+
+func TestCloneGitGemRecordsRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("HOME", t.TempDir()) // Keep the git cache out of the real ~/.cache
+
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=ore", "GIT_AUTHOR_EMAIL=ore@example.com", "GIT_COMMITTER_NAME=ore", "GIT_COMMITTER_EMAIL=ore@example.com")
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+		return strings.TrimSpace(string(output))
+	}
+	git("init", "--quiet")
+	if err := os.WriteFile(filepath.Join(repo, "demo.gemspec"), []byte("Gem::Specification.new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "--quiet", "-m", "first")
+	first := git("rev-parse", "HEAD")
+	git("commit", "--quiet", "--allow-empty", "-m", "second")
+	second := git("rev-parse", "HEAD")
+
+	spec := lockfile.GitGemSpec{Name: "demo", Version: "0.1.0", Remote: repo, Revision: first}
+	gemDir := filepath.Join(t.TempDir(), "demo-0.1.0")
+	if err := CloneGitGem(context.Background(), spec, gemDir); err != nil {
+		t.Fatalf("CloneGitGem: %v", err)
+	}
+	if got := InstalledSource(gemDir); got != first {
+		t.Fatalf("expected recorded revision %s, got %q", first, got)
+	}
+	if err := VerifyGitGem(spec, gemDir); err != nil {
+		t.Errorf("expected installed gem to match the lock, got %v", err)
+	}
+
+	// Re-locked to the next commit: the installed copy no longer matches
+	spec.Revision = second
+	if err := VerifyGitGem(spec, gemDir); err == nil || !strings.Contains(err.Error(), "locks "+second[:12]) {
+		t.Errorf("expected revision mismatch error, got %v", err)
+	}
+
+	// A commit that's gone from the remote (force-push, gc) gets an actionable error
+	spec.Revision = strings.Repeat("0", 40)
+	err := CloneGitGem(context.Background(), spec, filepath.Join(t.TempDir(), "demo-0.1.0"))
+	var notFound *RevisionNotFoundError
+	if !errors.As(err, &notFound) || notFound.Gem != "demo" || !strings.Contains(err.Error(), "ore update demo") || !strings.Contains(err.Error(), repo) {
+		t.Errorf("expected RevisionNotFoundError suggesting ore update demo, got %v", err)
+	}
+
+	// A branch name isn't a pinned commit, so it can't be trusted to match the lock
+	spec.Revision = "HEAD"
+	if err := CloneGitGem(context.Background(), spec, filepath.Join(t.TempDir(), "demo-0.1.0")); err == nil || !strings.Contains(err.Error(), "not a commit SHA") {
+		t.Errorf("expected moved-ref error, got %v", err)
+	}
+
+	// The revision comes from the lockfile; it must never reach git as an option
+	marker := filepath.Join(t.TempDir(), "pwned")
+	spec.Revision = "--upload-pack=touch " + marker
+	if err := CloneGitGem(context.Background(), spec, filepath.Join(t.TempDir(), "demo-0.1.0")); err == nil || !strings.Contains(err.Error(), "not a commit SHA") {
+		t.Errorf("expected option-like revision to be rejected, got %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("expected the option-like revision not to run a command")
+	}
+}
+
+func TestPathGemChanged(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "demo.gemspec"), []byte("Gem::Specification.new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := lockfile.PathGemSpec{Name: "demo", Version: "0.1.0", Remote: source}
+	gemDir := filepath.Join(t.TempDir(), "demo-0.1.0")
+	if err := CopyPathGem(spec, gemDir); err != nil {
+		t.Fatalf("CopyPathGem: %v", err)
+	}
+	if changed, err := PathGemChanged(spec, gemDir); err != nil || changed {
+		t.Fatalf("expected fresh copy to be unchanged, got %v, %v", changed, err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(source, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "lib", "demo.rb"), []byte("module Demo; end\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := PathGemChanged(spec, gemDir); err != nil || !changed {
+		t.Errorf("expected edited source to be reported changed, got %v, %v", changed, err)
+	}
+
+	// Skipped directories don't count
+	if err := os.MkdirAll(filepath.Join(source, "tmp"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := pathContentHash(source)
+	if err := os.WriteFile(filepath.Join(source, "tmp", "cache"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := pathContentHash(source); after != before {
+		t.Error("expected files under tmp/ to be ignored")
+	}
+}
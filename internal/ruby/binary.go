@@ -0,0 +1,195 @@
+package ruby
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	binaryMu   sync.RWMutex
+	binaryPath string
+)
+
+// SetBinary pins the Ruby executable ore invokes for the rest of the process
+func SetBinary(path string) {
+	binaryMu.Lock()
+	defer binaryMu.Unlock()
+	binaryPath = path
+}
+
+// Binary returns the Ruby executable set by SetBinary, or "ruby" to use whatever is on PATH
+func Binary() string {
+	binaryMu.RLock()
+	defer binaryMu.RUnlock()
+	if binaryPath == "" {
+		return "ruby"
+	}
+	return binaryPath
+}
+
+// ResolveBinary picks the Ruby executable for a project.
+// Priority:
+//  1. override (an explicit --ruby path)
+//  2. A version-manager install matching the version DetectRubyVersion selects; a
+//     lockfile locked for another engine (e.g. JRuby) only matches that engine's installs
+//  3. "ruby" from PATH
+//
+// Ruby developers: PATH `ruby` may be a shim or a system Ruby that disagrees with
+// .ruby-version, so we go straight to ~/.rbenv/versions/<v>/bin/ruby and friends
+func ResolveBinary(lockfilePath, gemfilePath, override string) string {
+	if override != "" {
+		return override
+	}
+
+	// "ruby 3.1.4p0 (jruby 9.4.5.0)": installs are named jruby-9.4.5.0, and an MRI 3.1
+	// install must not stand in for it
+	if DetectRubyVersionFromEnv() == "" {
+		if engine := DetectEngineFromLockfile(lockfilePath); engine.Name != "" {
+			if path := FindInstalledRuby(engine.Name + "-" + engine.Version); path != "" {
+				return path
+			}
+			return "ruby"
+		}
+	}
+
+	// Keep the full version (3.4.7) - installs are named after it, not the ABI version
+	version := DetectRubyVersion(lockfilePath, gemfilePath, func(v string) string { return v }, "")
+	if version != "" {
+		if path := FindInstalledRuby(version); path != "" {
+			return path
+		}
+	}
+
+	return "ruby"
+}
+
+// FindInstalledRuby returns the ruby binary of a version-manager install of version.
+// An exact match wins; otherwise the newest install with the same major.minor is used.
+// Returns "" when no matching install exists.
+func FindInstalledRuby(version string) string {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "ruby-")
+	if version == "" {
+		return ""
+	}
+
+	var candidates []string // Install directories with the same major.minor
+	for _, root := range versionManagerRoots() {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := strings.TrimPrefix(entry.Name(), "ruby-")
+			binary := filepath.Join(root, entry.Name(), "bin", rubyExecutableName())
+			if _, err := os.Stat(binary); err != nil {
+				continue
+			}
+			if name == version {
+				return binary
+			}
+			if sameMajorMinor(name, version) {
+				candidates = append(candidates, binary)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return versionLess(installVersion(candidates[i]), installVersion(candidates[j]))
+	})
+	return candidates[len(candidates)-1]
+}
+
+// versionManagerRoots returns directories holding one subdirectory per installed Ruby
+func versionManagerRoots() []string {
+	var roots []string
+
+	home, _ := os.UserHomeDir()
+	envOrHome := func(envVar string, homeParts ...string) string {
+		if dir := os.Getenv(envVar); dir != "" {
+			return dir
+		}
+		if home == "" {
+			return ""
+		}
+		return filepath.Join(append([]string{home}, homeParts...)...)
+	}
+
+	// rbenv
+	if dir := envOrHome("RBENV_ROOT", ".rbenv"); dir != "" {
+		roots = append(roots, filepath.Join(dir, "versions"))
+	}
+
+	// mise
+	if dir := envOrHome("MISE_DATA_DIR", ".local", "share", "mise"); dir != "" {
+		roots = append(roots, filepath.Join(dir, "installs", "ruby"))
+	}
+
+	// asdf
+	if dir := envOrHome("ASDF_DATA_DIR", ".asdf"); dir != "" {
+		roots = append(roots, filepath.Join(dir, "installs", "ruby"))
+	}
+
+	// chruby / ruby-install and rvm use "ruby-<version>" directory names
+	if home != "" {
+		roots = append(roots,
+			filepath.Join(home, ".rubies"),
+			filepath.Join(home, ".rvm", "rubies"),
+		)
+	}
+	roots = append(roots, "/opt/rubies")
+
+	return roots
+}
+
+// rubyExecutableName returns the interpreter's file name on this OS
+func rubyExecutableName() string {
+	if runtime.GOOS == "windows" {
+		return "ruby.exe"
+	}
+	return "ruby"
+}
+
+// installVersion extracts the version from <root>/<version>/bin/ruby
+func installVersion(binary string) string {
+	return strings.TrimPrefix(filepath.Base(filepath.Dir(filepath.Dir(binary))), "ruby-")
+}
+
+// sameMajorMinor reports whether two versions share major.minor ("3.4.7" and "3.4")
+func sameMajorMinor(a, b string) bool {
+	aParts := strings.SplitN(a, ".", 3)
+	bParts := strings.SplitN(b, ".", 3)
+	if len(aParts) < 2 || len(bParts) < 2 {
+		return false
+	}
+	return aParts[0] == bParts[0] && aParts[1] == bParts[1]
+}
+
+// versionLess compares dotted versions numerically; non-numeric segments sort first
+func versionLess(a, b string) bool {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		switch {
+		case aErr == nil && bErr == nil && aNum != bNum:
+			return aNum < bNum
+		case aErr != nil && bErr == nil:
+			return true
+		case aErr == nil && bErr != nil:
+			return false
+		case aErr != nil && bErr != nil && aParts[i] != bParts[i]:
+			return aParts[i] < bParts[i]
+		}
+	}
+	return len(aParts) < len(bParts)
+}
@@ -0,0 +1,129 @@
+package ruby
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFindInstalledRuby(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("RBENV_ROOT", "")
+	t.Setenv("MISE_DATA_DIR", "")
+	t.Setenv("ASDF_DATA_DIR", "")
+
+	install := func(parts ...string) string {
+		binDir := filepath.Join(append([]string{home}, parts...)...)
+		binDir = filepath.Join(binDir, "bin")
+		if err := os.MkdirAll(binDir, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", binDir, err)
+		}
+		binary := filepath.Join(binDir, rubyExecutableName())
+		if err := os.WriteFile(binary, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatalf("failed to write %s: %v", binary, err)
+		}
+		return binary
+	}
+
+	rbenv334 := install(".rbenv", "versions", "3.3.4")
+	mise349 := install(".local", "share", "mise", "installs", "ruby", "3.4.9")
+	install(".local", "share", "mise", "installs", "ruby", "3.4.10")
+	mise3410 := filepath.Join(home, ".local", "share", "mise", "installs", "ruby", "3.4.10", "bin", rubyExecutableName())
+	chruby325 := install(".rubies", "ruby-3.2.5")
+
+	tests := map[string]string{
+		"3.3.4":      rbenv334,
+		"3.4.9":      mise349,
+		"3.4.0":      mise3410, // Same ABI, newest patch release
+		"3.4":        mise3410,
+		"ruby-3.2.5": chruby325,
+		"3.2.5":      chruby325,
+		"3.1.0":      "",
+		"system":     "",
+	}
+
+	for version, want := range tests {
+		if got := FindInstalledRuby(version); got != want {
+			t.Errorf("FindInstalledRuby(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
+
+func TestResolveBinaryOverride(t *testing.T) {
+	if got := ResolveBinary("Gemfile.lock", "Gemfile", "/opt/ruby/bin/ruby"); got != "/opt/ruby/bin/ruby" {
+		t.Errorf("expected override to win, got %q", got)
+	}
+}
+
+func TestResolveBinaryMatchesLockfileEngine(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("RBENV_ROOT", "")
+	t.Setenv("MISE_DATA_DIR", "")
+	t.Setenv("ASDF_DATA_DIR", "")
+	t.Setenv("RBENV_VERSION", "")
+	t.Setenv("ASDF_RUBY_VERSION", "")
+
+	install := func(name string) string {
+		binDir := filepath.Join(home, ".rbenv", "versions", name, "bin")
+		if err := os.MkdirAll(binDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		binary := filepath.Join(binDir, rubyExecutableName())
+		if err := os.WriteFile(binary, []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		return binary
+	}
+	mri := install("3.1.6")
+
+	project := t.TempDir()
+	lockfilePath := filepath.Join(project, "Gemfile.lock")
+	gemfilePath := filepath.Join(project, "Gemfile")
+	writeLock := func(rubyLine string) {
+		lock := "GEM\n  remote: https://rubygems.org/\n  specs:\n\nRUBY VERSION\n   " + rubyLine + "\n\nBUNDLED WITH\n   2.5.0\n"
+		if err := os.WriteFile(lockfilePath, []byte(lock), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A JRuby lockfile reports MRI's version too, but MRI 3.1 isn't a JRuby install
+	writeLock("ruby 3.1.4 (jruby 9.4.5.0)")
+	if got := ResolveBinary(lockfilePath, gemfilePath, ""); got != "ruby" {
+		t.Errorf("expected PATH ruby without a JRuby install, got %q", got)
+	}
+	jruby := install("jruby-9.4.5.0")
+	if got := ResolveBinary(lockfilePath, gemfilePath, ""); got != jruby {
+		t.Errorf("expected the JRuby install %q, got %q", jruby, got)
+	}
+	if engine := DetectEngineFromLockfile(lockfilePath); engine.Name != EngineJRuby || engine.Version != "9.4.5.0" {
+		t.Errorf("expected jruby 9.4.5.0, got %+v", engine)
+	}
+
+	writeLock("ruby 3.1.4p223")
+	if got := ResolveBinary(lockfilePath, gemfilePath, ""); got != mri {
+		t.Errorf("expected the MRI install %q, got %q", mri, got)
+	}
+}
+
+func TestDetectEngineUsesPinnedBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub ruby is a shell script")
+	}
+	stub := filepath.Join(t.TempDir(), "jruby")
+	script := "#!/bin/sh\nprintf 'jruby\\n3.1.4\\n9.4.5.0\\n'\n"
+	if err := os.WriteFile(stub, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write stub ruby: %v", err)
+	}
+	t.Setenv("ORE_RUBY", stub)
+	t.Setenv("RUBY_ENGINE", "")
+	SetBinary(ResolveBinary("Gemfile.lock", "Gemfile", os.Getenv("ORE_RUBY")))
+	t.Cleanup(func() { SetBinary("") })
+
+	got := DetectEngine()
+	if got.Name != EngineJRuby || got.Version != "3.1.4" || got.EngineVersion != "9.4.5.0" {
+		t.Errorf("DetectEngine() = %+v, want the ORE_RUBY stub's jruby 9.4.5.0 (ruby 3.1.4)", got)
+	}
+}
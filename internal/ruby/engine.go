@@ -10,6 +10,10 @@ import (
 type Engine struct {
 	Name    string // mri, jruby, truffleruby, mruby
 	Version string // e.g., "3.4.0", "9.4.0.0" (for JRuby)
+
+	// EngineVersion is RUBY_ENGINE_VERSION when detected from the running Ruby, e.g.
+	// "9.4.5.0" for a JRuby whose RUBY_VERSION is "3.1.4"; "" when unknown
+	EngineVersion string
 }
 
 // EngineType constants for common Ruby engines
@@ -23,7 +27,7 @@ const (
 // DetectEngine detects the current Ruby engine and version
 // Priority:
 // 1. RUBY_ENGINE environment variable
-// 2. Running `ruby -e "puts RUBY_ENGINE"` command
+// 2. Running `<Binary()> -e "puts RUBY_ENGINE"`, so --ruby/ORE_RUBY is honored
 // 3. Default to "mri" if detection fails
 func DetectEngine() Engine {
 	// Try environment variable first (fast)
@@ -42,29 +46,36 @@ func DetectEngine() Engine {
 	return detectEngineFromCommand()
 }
 
-// detectEngineFromCommand runs ruby commands to detect engine
+// detectEngineFromCommand runs the pinned Ruby once to detect engine and versions
 func detectEngineFromCommand() Engine {
 	engine := Engine{
 		Name:    EngineMRI, // default
 		Version: "",
 	}
 
-	// Detect engine name
-	cmd := exec.Command("ruby", "-e", "puts RUBY_ENGINE")
+	// One process: JRuby in particular takes a while to start
+	cmd := exec.Command(Binary(), "-e", "puts RUBY_ENGINE, RUBY_VERSION, RUBY_ENGINE_VERSION")
 	output, err := cmd.Output()
-	if err == nil {
-		engine.Name = normalizeEngineName(strings.TrimSpace(string(output)))
+	if err != nil {
+		return engine
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) != "" {
+		engine.Name = normalizeEngineName(lines[0])
+	}
+	if len(lines) > 1 {
+		engine.Version = strings.TrimSpace(lines[1])
+	}
+	if len(lines) > 2 {
+		engine.EngineVersion = strings.TrimSpace(lines[2])
 	}
-
-	// Detect version
-	engine.Version = detectVersionFromCommand()
 
 	return engine
 }
 
 // detectVersionFromCommand gets Ruby version
 func detectVersionFromCommand() string {
-	cmd := exec.Command("ruby", "-e", "puts RUBY_VERSION")
+	cmd := exec.Command(Binary(), "-e", "puts RUBY_VERSION")
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -0,0 +1,305 @@
+package ruby
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// InstallIfGem is a gem declared inside one or more `install_if` blocks
+type InstallIfGem struct {
+	Name       string
+	Conditions []string // Lambda bodies of the enclosing install_if blocks, outermost first
+}
+
+var (
+	installIfPattern     = regexp.MustCompile(`^install_if\b(.*?)\bdo\s*(\|[^|]*\|)?$`)
+	installIfLambda      = regexp.MustCompile(`(?:->\s*(?:\(\s*\))?|\blambda|\bproc)\s*\{([^}]*)\}`)
+	installIfGemPattern  = regexp.MustCompile(`^gem\s*\(?\s*["']([\w.-]+)["']`)
+	blockOpenerPattern   = regexp.MustCompile(`^(if|unless|case|begin|while|until|def|class|module)\b|\bdo\s*(\|[^|]*\|)?$`)
+	blockCloserPattern   = regexp.MustCompile(`^end\b`)
+	rubyPlatformVariable = `(?:RUBY_PLATFORM|RbConfig::CONFIG\[\s*["']host_os["']\s*\])`
+	platformMatchPattern = regexp.MustCompile(`^` + rubyPlatformVariable + `\s*(=~|!~)\s*/(.*)/([imx]*)$`)
+	platformCallPattern  = regexp.MustCompile(`^` + rubyPlatformVariable + `\.(match\?|include\?|start_with\?)\(\s*(?:/(.*)/([imx]*)|["']([^"']*)["'])\s*\)$`)
+	envPresencePattern   = regexp.MustCompile(`^ENV(?:\[\s*["']([^"']+)["']\s*\]|\.(?:key\?|has_key\?|include\?)\(\s*["']([^"']+)["']\s*\)|\.fetch\(\s*["']([^"']+)["']\s*,\s*(?:nil|false)\s*\))$`)
+	envNilPattern        = regexp.MustCompile(`^ENV\[\s*["']([^"']+)["']\s*\]\.nil\?$`)
+	envComparePattern    = regexp.MustCompile(`^ENV\[\s*["']([^"']+)["']\s*\]\s*(==|!=)\s*["']([^"']*)["']$`)
+)
+
+// InstallIfExclusions returns the gems whose install_if condition is false on this
+// machine; platform returns its RUBY_PLATFORM ("" if unknown) and is only called when
+// the Gemfile has install_if blocks. Gems with conditions that can't be evaluated are
+// kept, with a warning written to warnings. A missing Gemfile has no exclusions.
+func InstallIfExclusions(gemfilePath string, platform func() string, warnings io.Writer) ([]string, error) {
+	gems, err := ParseGemfileInstallIf(gemfilePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil || len(gems) == 0 {
+		return nil, err
+	}
+
+	localPlatform := platform()
+	var excluded []string
+	for _, gem := range gems {
+		include, decided := gem.Evaluate(localPlatform)
+		if !include {
+			excluded = append(excluded, gem.Name)
+		} else if !decided {
+			fmt.Fprintf(warnings, "Warning: can't evaluate the install_if condition for %s (%s) without Ruby; including it\n", gem.Name, strings.Join(gem.Conditions, ", "))
+		}
+	}
+	return excluded, nil
+}
+
+// ParseGemfileInstallIf returns the gems a Gemfile declares inside install_if blocks.
+// Returns nil when the Gemfile has none.
+//
+// Ruby developers: gemfile-go ignores install_if and reports these gems as
+// unconditional, so we scan for the blocks ourselves:
+//
+//	install_if -> { RUBY_PLATFORM =~ /darwin/ } do
+//	  gem "terminal-notifier"
+//	end
+func ParseGemfileInstallIf(gemfilePath string) ([]InstallIfGem, error) {
+	file, err := os.Open(gemfilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	type openBlock struct {
+		conditions []string // Set for install_if blocks, nil for other blocks
+	}
+	var stack []openBlock
+	var gems []InstallIfGem
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := stripRubyComment(strings.TrimSpace(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if match := installIfPattern.FindStringSubmatch(line); match != nil {
+			var conditions []string
+			for _, lambda := range installIfLambda.FindAllStringSubmatch(match[1], -1) {
+				conditions = append(conditions, strings.TrimSpace(lambda[1]))
+			}
+			if len(conditions) == 0 {
+				// e.g. a method reference: install_if method(:windows?) do
+				condition := strings.TrimSpace(match[1])
+				if strings.HasPrefix(condition, "(") && strings.HasSuffix(condition, ")") {
+					condition = strings.TrimSpace(condition[1 : len(condition)-1])
+				}
+				conditions = []string{condition}
+			}
+			stack = append(stack, openBlock{conditions: conditions})
+			continue
+		}
+
+		if match := installIfGemPattern.FindStringSubmatch(line); match != nil {
+			var conditions []string
+			for _, block := range stack {
+				conditions = append(conditions, block.conditions...)
+			}
+			if len(conditions) > 0 {
+				gems = append(gems, InstallIfGem{Name: match[1], Conditions: conditions})
+			}
+		}
+
+		switch {
+		case blockCloserPattern.MatchString(line):
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case blockOpenerPattern.MatchString(line) && len(stack) > 0:
+			stack = append(stack, openBlock{})
+		}
+	}
+
+	return gems, scanner.Err()
+}
+
+// stripRubyComment drops a trailing # comment, leaving # inside quotes alone
+func stripRubyComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return strings.TrimSpace(line[:i])
+		}
+	}
+	return line
+}
+
+// Evaluate decides whether the gem is installed on a machine with the given
+// RUBY_PLATFORM (e.g. "x86_64-linux", or "" if unknown). decided is false when a condition can't be
+// evaluated without running Ruby; such gems are included, like Bundler would if the
+// condition held. Understood conditions are platform checks (RUBY_PLATFORM,
+// RbConfig::CONFIG["host_os"], Gem.win_platform?) and environment variable checks,
+// combined with !, && and ||.
+func (g InstallIfGem) Evaluate(platform string) (include, decided bool) {
+	decided = true
+	for _, condition := range g.Conditions {
+		value, ok := evaluateInstallIfCondition(condition, platform)
+		if !ok {
+			decided = false
+			continue
+		}
+		if !value {
+			return false, true
+		}
+	}
+	return true, decided
+}
+
+// evaluateInstallIfCondition evaluates an install_if lambda body
+func evaluateInstallIfCondition(condition, platform string) (value, ok bool) {
+	condition = strings.TrimSpace(condition)
+	if condition == "" || strings.ContainsAny(condition, "()") && !isCallOnly(condition) {
+		return false, false
+	}
+
+	// || binds looser than &&
+	if parts := strings.Split(condition, "||"); len(parts) > 1 {
+		for _, part := range parts {
+			v, ok := evaluateInstallIfCondition(part, platform)
+			if !ok {
+				return false, false
+			}
+			value = value || v
+		}
+		return value, true
+	}
+	if parts := strings.Split(condition, "&&"); len(parts) > 1 {
+		value = true
+		for _, part := range parts {
+			v, ok := evaluateInstallIfCondition(part, platform)
+			if !ok {
+				return false, false
+			}
+			value = value && v
+		}
+		return value, true
+	}
+
+	if rest, negated := strings.CutPrefix(condition, "!"); negated && !strings.HasPrefix(rest, "=") {
+		v, ok := evaluateInstallIfCondition(rest, platform)
+		return !v, ok
+	}
+
+	return evaluateInstallIfTerm(condition, platform)
+}
+
+// isCallOnly reports whether a condition's parentheses all belong to method calls
+// like ENV.key?("CI"), rather than grouping that the evaluator doesn't handle
+func isCallOnly(condition string) bool {
+	for i, r := range condition {
+		if r == '(' && (i == 0 || condition[i-1] == ' ' || condition[i-1] == '!' || condition[i-1] == '(') {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateInstallIfTerm evaluates a single check, without operators
+func evaluateInstallIfTerm(term, platform string) (value, ok bool) {
+	term = strings.TrimSpace(term)
+	hostOS := platform
+	if _, osName, found := strings.Cut(platform, "-"); found {
+		hostOS = osName
+	}
+
+	if platform == "" && (strings.Contains(term, "RUBY_PLATFORM") || strings.Contains(term, "RbConfig") || strings.Contains(term, "win_platform")) {
+		return false, false // Platform unknown
+	}
+
+	switch term {
+	case "true":
+		return true, true
+	case "false", "nil":
+		return false, true
+	case "Gem.win_platform?":
+		return strings.Contains(platform, "mingw") || strings.Contains(platform, "mswin"), true
+	}
+
+	subject := func(expr string) string {
+		if strings.HasPrefix(expr, "RbConfig") {
+			return hostOS
+		}
+		return platform
+	}
+
+	if match := platformMatchPattern.FindStringSubmatch(term); match != nil {
+		matched, ok := matchRubyRegexp(match[2], match[3], subject(term))
+		if !ok {
+			return false, false
+		}
+		return matched == (match[1] == "=~"), true
+	}
+
+	if match := platformCallPattern.FindStringSubmatch(term); match != nil {
+		s := subject(term)
+		switch match[1] {
+		case "match?":
+			if match[4] != "" || match[2] == "" {
+				return false, false // match? with a string pattern: leave it to Ruby
+			}
+			return matchRubyRegexp(match[2], match[3], s)
+		case "include?":
+			if match[2] != "" {
+				return false, false
+			}
+			return strings.Contains(s, match[4]), true
+		case "start_with?":
+			if match[2] != "" {
+				return false, false
+			}
+			return strings.HasPrefix(s, match[4]), true
+		}
+	}
+
+	if match := envPresencePattern.FindStringSubmatch(term); match != nil {
+		name := match[1] + match[2] + match[3]
+		_, set := os.LookupEnv(name)
+		return set, true
+	}
+
+	if match := envNilPattern.FindStringSubmatch(term); match != nil {
+		_, set := os.LookupEnv(match[1])
+		return !set, true
+	}
+
+	if match := envComparePattern.FindStringSubmatch(term); match != nil {
+		equal := os.Getenv(match[1]) == match[3]
+		if _, set := os.LookupEnv(match[1]); !set {
+			equal = false // nil == "" is false in Ruby
+		}
+		return equal == (match[2] == "=="), true
+	}
+
+	return false, false
+}
+
+// matchRubyRegexp matches a Ruby regexp literal's source against s.
+// ok is false when the pattern isn't valid in Go's RE2 syntax.
+func matchRubyRegexp(source, flags, s string) (matched, ok bool) {
+	if strings.Contains(flags, "i") {
+		source = "(?i)" + source
+	}
+	re, err := regexp.Compile(source)
+	if err != nil {
+		return false, false
+	}
+	return re.MatchString(s), true
+}
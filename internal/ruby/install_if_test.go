@@ -0,0 +1,108 @@
+package ruby
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGemfileInstallIf(t *testing.T) {
+	gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+	content := `source "https://rubygems.org"
+
+gem "rack"
+
+install_if -> { RUBY_PLATFORM =~ /darwin/ } do
+  gem "terminal-notifier" # macOS only
+  group :test do
+    gem "rb-fsevent"
+  end
+end
+
+group :development do
+  install_if -> { ENV["ORE_PROFILE"] } do
+    gem "stackprof"
+  end
+  gem "pry"
+end
+
+install_if method(:windows?) do
+  gem "wdm"
+end
+`
+	if err := os.WriteFile(gemfilePath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gems, err := ParseGemfileInstallIf(gemfilePath)
+	if err != nil {
+		t.Fatalf("ParseGemfileInstallIf returned error: %v", err)
+	}
+
+	want := map[string]string{
+		"terminal-notifier": "RUBY_PLATFORM =~ /darwin/",
+		"rb-fsevent":        "RUBY_PLATFORM =~ /darwin/",
+		"stackprof":         `ENV["ORE_PROFILE"]`,
+		"wdm":               "method(:windows?)",
+	}
+	if len(gems) != len(want) {
+		t.Fatalf("expected %d install_if gems, got %+v", len(want), gems)
+	}
+	for _, gem := range gems {
+		if len(gem.Conditions) != 1 || gem.Conditions[0] != want[gem.Name] {
+			t.Errorf("%s: expected condition %q, got %q", gem.Name, want[gem.Name], gem.Conditions)
+		}
+	}
+}
+
+func TestInstallIfGemEvaluatePlatform(t *testing.T) {
+	darwinOnly := InstallIfGem{Name: "terminal-notifier", Conditions: []string{"RUBY_PLATFORM =~ /darwin/"}}
+	notWindows := InstallIfGem{Name: "rb-inotify", Conditions: []string{"!Gem.win_platform?"}}
+	hostOS := InstallIfGem{Name: "wdm", Conditions: []string{`RbConfig::CONFIG["host_os"] =~ /mswin|mingw/i`}}
+
+	tests := []struct {
+		gem      InstallIfGem
+		platform string
+		include  bool
+	}{
+		{darwinOnly, "arm64-darwin23", true},
+		{darwinOnly, "x86_64-linux", false},
+		{notWindows, "x86_64-linux", true},
+		{notWindows, "x64-mingw-ucrt", false},
+		{hostOS, "x64-mingw-ucrt", true},
+		{hostOS, "arm64-darwin23", false},
+	}
+
+	for _, tt := range tests {
+		include, decided := tt.gem.Evaluate(tt.platform)
+		if !decided || include != tt.include {
+			t.Errorf("%s on %s: got include=%v decided=%v, want include=%v", tt.gem.Name, tt.platform, include, decided, tt.include)
+		}
+	}
+}
+
+func TestInstallIfGemEvaluateEnvAndUnknown(t *testing.T) {
+	t.Setenv("ORE_TEST_PROFILE", "1")
+
+	tests := []struct {
+		condition string
+		include   bool
+		decided   bool
+	}{
+		{`ENV["ORE_TEST_PROFILE"]`, true, true},
+		{`ENV.key?("ORE_TEST_UNSET")`, false, true},
+		{`!ENV.fetch("ORE_TEST_UNSET", nil)`, true, true},
+		{`ENV["ORE_TEST_PROFILE"] == "1" && RUBY_PLATFORM.include?("linux")`, true, true},
+		{`ENV["ORE_TEST_UNSET"] || RUBY_PLATFORM.include?("darwin")`, false, true},
+		{`File.exist?("/etc/debian_version")`, true, false},
+		{`(RUBY_PLATFORM =~ /linux/) && ENV["X"]`, true, false},
+	}
+
+	for _, tt := range tests {
+		gem := InstallIfGem{Name: "demo", Conditions: []string{tt.condition}}
+		include, decided := gem.Evaluate("x86_64-linux")
+		if include != tt.include || decided != tt.decided {
+			t.Errorf("%s: got include=%v decided=%v, want include=%v decided=%v", tt.condition, include, decided, tt.include, tt.decided)
+		}
+	}
+}
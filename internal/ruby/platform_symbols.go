@@ -0,0 +1,74 @@
+package ruby
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// platformSymbolPattern matches a Gemfile platform symbol with a Ruby version
+// suffix, e.g. mri_31 is MRI running Ruby 3.1
+var platformSymbolPattern = regexp.MustCompile(`^(.+)_(\d)(\d+)$`)
+
+// MatchesPlatformSymbols reports whether a gem scoped with `platforms:` (or a
+// `platforms ... do` block) is installed on engine, running on platform (a
+// RUBY_PLATFORM like "x86_64-linux", or "" if unknown). A gem without platform
+// symbols always matches, as does one with a symbol we don't know.
+//
+// Ruby developers: these are Bundler's platform symbols, not gem platforms:
+//
+//	gem "jruby-openssl", platforms: :jruby
+//	gem "byebug", platforms: [:mri, :windows]
+//	gem "tzinfo-data", platforms: %i[windows jruby]
+func MatchesPlatformSymbols(symbols []string, engine Engine, platform string) bool {
+	if len(symbols) == 0 {
+		return true
+	}
+	for _, symbol := range symbols {
+		if matchesPlatformSymbol(symbol, engine, platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPlatformSymbol evaluates a single symbol, following Bundler's CurrentRuby
+func matchesPlatformSymbol(symbol string, engine Engine, platform string) bool {
+	name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(symbol), ":"))
+
+	// A version suffix (mri_31, windows_33) also requires that Ruby version, if known
+	if match := platformSymbolPattern.FindStringSubmatch(name); match != nil {
+		version := match[2] + "." + match[3]
+		if engine.Version != "" && engine.Version != version && !strings.HasPrefix(engine.Version, version+".") {
+			return false
+		}
+		name = match[1]
+	}
+
+	windows := isWindowsPlatform(platform)
+	switch name {
+	case "ruby":
+		return !windows && (engine.Name == EngineMRI || engine.Name == EngineTruffleRuby)
+	case "mri":
+		return !windows && engine.Name == EngineMRI
+	case "jruby":
+		return engine.Name == EngineJRuby
+	case "truffleruby":
+		return engine.Name == EngineTruffleRuby
+	case "windows", "mswin", "mswin64", "mingw", "x64_mingw":
+		return windows
+	case "rbx", "maglev":
+		return false
+	default:
+		return true // Not a symbol we understand; keep the gem
+	}
+}
+
+// isWindowsPlatform reports whether platform is a Windows RUBY_PLATFORM, falling back
+// to the OS ore runs on when the platform is unknown
+func isWindowsPlatform(platform string) bool {
+	if platform == "" {
+		return runtime.GOOS == "windows"
+	}
+	return strings.Contains(platform, "mingw") || strings.Contains(platform, "mswin")
+}
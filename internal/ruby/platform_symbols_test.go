@@ -0,0 +1,36 @@
+package ruby
+
+import "testing"
+
+func TestMatchesPlatformSymbols(t *testing.T) {
+	mri := Engine{Name: EngineMRI, Version: "3.3.0"}
+	jruby := Engine{Name: EngineJRuby, Version: "3.1.4"}
+	truffle := Engine{Name: EngineTruffleRuby, Version: "3.2.2"}
+
+	tests := []struct {
+		symbols  []string
+		engine   Engine
+		platform string
+		want     bool
+	}{
+		{nil, mri, "x86_64-linux", true},
+		{[]string{"jruby"}, mri, "x86_64-linux", false},
+		{[]string{":jruby"}, jruby, "universal-java-17", true},
+		{[]string{"mri", "windows"}, jruby, "universal-java-17", false},
+		{[]string{"ruby"}, truffle, "x86_64-linux", true},
+		{[]string{"mri"}, truffle, "x86_64-linux", false},
+		{[]string{"mri"}, mri, "x64-mingw-ucrt", false},
+		{[]string{"windows"}, mri, "x64-mingw-ucrt", true},
+		{[]string{"x64_mingw"}, mri, "x64-mingw-ucrt", true},
+		{[]string{"mri_33"}, mri, "x86_64-linux", true},
+		{[]string{"mri_31"}, mri, "x86_64-linux", false},
+		{[]string{"mri_31"}, Engine{Name: EngineMRI}, "x86_64-linux", true},
+		{[]string{"some_new_platform"}, mri, "x86_64-linux", true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchesPlatformSymbols(tt.symbols, tt.engine, tt.platform); got != tt.want {
+			t.Errorf("MatchesPlatformSymbols(%v, %s, %q) = %v, want %v", tt.symbols, tt.engine, tt.platform, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package ruby
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Requirement represents the Gemfile `ruby` directive
+// e.g. ruby "3.3.0", engine: "jruby", engine_version: "9.4.0.0"
+type Requirement struct {
+	Version       string // Ruby language version or constraint ("3.3.0", "~> 3.3")
+	Engine        string // Normalized engine name (mri, jruby, truffleruby); empty when not specified
+	EngineVersion string // Engine version (e.g. "9.4.0.0" for JRuby)
+	Patchlevel    string // Optional patchlevel (e.g. "100")
+}
+
+var (
+	rubyDirectivePattern = regexp.MustCompile(`^ruby\s*\(?\s*["']([^"']+)["'](.*)$`)
+	rubyOptionPattern    = regexp.MustCompile(`:?(engine_version|engine|patchlevel)(?::|\s*=>)\s*["']([^"']+)["']`)
+	// engineVersionPattern is a Gem::Version, e.g. 9.4.5.0 or 24.0.0.pre
+	engineVersionPattern = regexp.MustCompile(`^[0-9]+(\.[0-9A-Za-z]+)*(-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+)
+
+// ParseGemfileRubyRequirement reads the `ruby` directive from a Gemfile.
+// Returns nil when the Gemfile has no ruby directive, and an error when its
+// engine_version isn't a version.
+//
+// Ruby developers: gemfile-go only exposes the version string, so we scan
+// for the directive ourselves to pick up engine:/engine_version:/patchlevel:
+func ParseGemfileRubyRequirement(gemfilePath string) (*Requirement, error) {
+	file, err := os.Open(gemfilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if req := ParseRubyDirective(scanner.Text()); req != nil {
+			if req.EngineVersion != "" && !engineVersionPattern.MatchString(req.EngineVersion) {
+				return nil, fmt.Errorf("invalid engine_version %q in %s: expected a version such as \"9.4.5.0\"", req.EngineVersion, gemfilePath)
+			}
+			return req, nil
+		}
+	}
+
+	return nil, scanner.Err()
+}
+
+// ParseRubyDirective parses a single Gemfile line such as
+// `ruby "3.3.0", engine: "jruby", engine_version: "9.4.0.0"`.
+// Returns nil if the line is not a ruby directive.
+func ParseRubyDirective(line string) *Requirement {
+	line = strings.TrimSpace(line)
+	if idx := strings.Index(line, "#"); idx >= 0 {
+		line = strings.TrimSpace(line[:idx])
+	}
+
+	match := rubyDirectivePattern.FindStringSubmatch(line)
+	if match == nil {
+		return nil
+	}
+
+	req := &Requirement{Version: strings.TrimSpace(match[1])}
+	for _, opt := range rubyOptionPattern.FindAllStringSubmatch(match[2], -1) {
+		switch opt[1] {
+		case "engine":
+			req.Engine = normalizeEngineName(opt[2])
+		case "engine_version":
+			req.EngineVersion = opt[2]
+		case "patchlevel":
+			req.Patchlevel = opt[2]
+		}
+	}
+
+	return req
+}
+
+// LockfileString renders the requirement for the lockfile's RUBY VERSION section,
+// matching Bundler's format: "ruby 3.3.0p100 (jruby 9.4.0.0)"
+func (r *Requirement) LockfileString() string {
+	version := NormalizeRubyVersion(r.Version, func(v string) string { return v })
+
+	var b strings.Builder
+	b.WriteString("ruby ")
+	b.WriteString(version)
+	if r.Patchlevel != "" {
+		b.WriteString("p")
+		b.WriteString(r.Patchlevel)
+	}
+
+	if r.Engine != "" && r.Engine != EngineMRI {
+		engineVersion := r.EngineVersion
+		if engineVersion == "" {
+			engineVersion = version
+		}
+		b.WriteString(" (")
+		b.WriteString(r.Engine)
+		b.WriteString(" ")
+		b.WriteString(engineVersion)
+		b.WriteString(")")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package ruby
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRubyDirective(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected *Requirement
+		lockLine string
+	}{
+		{
+			line:     `ruby "3.3.0"`,
+			expected: &Requirement{Version: "3.3.0"},
+			lockLine: "ruby 3.3.0",
+		},
+		{
+			line:     `ruby "3.1.4", engine: "jruby", engine_version: "9.4.0.0"`,
+			expected: &Requirement{Version: "3.1.4", Engine: EngineJRuby, EngineVersion: "9.4.0.0"},
+			lockLine: "ruby 3.1.4 (jruby 9.4.0.0)",
+		},
+		{
+			line:     `ruby '3.2.2', :engine => 'truffleruby', :engine_version => '23.1.0', :patchlevel => '53'`,
+			expected: &Requirement{Version: "3.2.2", Engine: EngineTruffleRuby, EngineVersion: "23.1.0", Patchlevel: "53"},
+			lockLine: "ruby 3.2.2p53 (truffleruby 23.1.0)",
+		},
+		{
+			line:     `gem "ruby-progressbar"`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got := ParseRubyDirective(tt.line)
+			if tt.expected == nil {
+				if got != nil {
+					t.Fatalf("expected nil, got %+v", got)
+				}
+				return
+			}
+			if got == nil || *got != *tt.expected {
+				t.Fatalf("ParseRubyDirective(%q) = %+v, want %+v", tt.line, got, tt.expected)
+			}
+			if lock := got.LockfileString(); lock != tt.lockLine {
+				t.Errorf("LockfileString() = %q, want %q", lock, tt.lockLine)
+			}
+		})
+	}
+}
+
+func TestParseGemfileRubyRequirementRejectsBadEngineVersion(t *testing.T) {
+	gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+	write := func(directive string) {
+		t.Helper()
+		if err := os.WriteFile(gemfilePath, []byte("source \"https://rubygems.org\"\n"+directive+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`ruby "3.1.4", engine: "jruby", engine_version: "latest"`)
+	if _, err := ParseGemfileRubyRequirement(gemfilePath); err == nil || !strings.Contains(err.Error(), `invalid engine_version "latest"`) {
+		t.Errorf("expected an invalid engine_version error, got %v", err)
+	}
+
+	write(`ruby "3.4.0", engine: "truffleruby", engine_version: "24.2.0.pre"`)
+	if req, err := ParseGemfileRubyRequirement(gemfilePath); err != nil || req.EngineVersion != "24.2.0.pre" {
+		t.Errorf("expected a prerelease engine_version to be accepted, got %+v (%v)", req, err)
+	}
+}
@@ -81,9 +81,10 @@ func DetectRubyVersionFromLockfile(lockfilePath string, toMajorMinor func(string
 			continue
 		}
 
-		// Parse "   ruby 3.4.0p0" or "   ruby 3.4.0"
+		// Parse "   ruby 3.4.0p0", "   ruby 3.4.0" or "   ruby 3.1.4p0 (jruby 9.4.5.0)"
 		if inRubySection && strings.HasPrefix(trimmed, "ruby ") {
-			versionStr := strings.TrimPrefix(trimmed, "ruby ")
+			// The Ruby version comes first; DetectEngineFromLockfile reads the engine part
+			versionStr, _, _ := strings.Cut(strings.TrimPrefix(trimmed, "ruby "), " ")
 			// Remove patchlevel suffix (p0, p194, etc)
 			if idx := strings.Index(versionStr, "p"); idx > 0 {
 				versionStr = versionStr[:idx]
@@ -100,6 +101,42 @@ func DetectRubyVersionFromLockfile(lockfilePath string, toMajorMinor func(string
 	return ""
 }
 
+// DetectEngineFromLockfile returns the engine a lockfile's RUBY VERSION names, e.g.
+// {jruby 9.4.5.0} for "ruby 3.1.4p0 (jruby 9.4.5.0)". MRI and lockfiles without the
+// section give a zero Engine.
+func DetectEngineFromLockfile(lockfilePath string) Engine {
+	data, err := os.ReadFile(lockfilePath)
+	if err != nil {
+		return Engine{}
+	}
+
+	inRubySection := false
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "RUBY VERSION" {
+			inRubySection = true
+			continue
+		}
+		if !inRubySection || trimmed == "" {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "ruby ") {
+			break
+		}
+
+		_, engine, ok := strings.Cut(trimmed, "(")
+		if !ok {
+			return Engine{}
+		}
+		fields := strings.Fields(strings.TrimSuffix(engine, ")"))
+		if len(fields) != 2 || normalizeEngineName(fields[0]) == EngineMRI {
+			return Engine{}
+		}
+		return Engine{Name: normalizeEngineName(fields[0]), Version: fields[1]}
+	}
+	return Engine{}
+}
+
 // DetectRubyVersionFromGemfile extracts Ruby version from Gemfile using tree-sitter
 func DetectRubyVersionFromGemfile(gemfilePath string, toMajorMinor func(string) string) string {
 	parser := gemfile.NewGemfileParser(gemfilePath)
@@ -310,8 +347,8 @@ func GetSystemGemDir(detectRubyVersion func() string) string {
 		return userGemDir
 	}
 
-	// 5. Last resort: try `gem environment gemdir` if Ruby is available
-	cmd := exec.Command("gem", "environment", "gemdir")
+	// 5. Last resort: ask Ruby for Gem.dir if it is available
+	cmd := exec.Command(Binary(), "-e", "print Gem.dir")
 	if output, err := cmd.Output(); err == nil {
 		gemDir := strings.TrimSpace(string(output))
 		if gemDir != "" {
@@ -355,3 +392,24 @@ func GetStandardGemPaths(rubyVersion string) []string {
 
 	return paths
 }
+
+// ActiveVersion returns RUBY_VERSION of the selected interpreter (see Binary), or ""
+// when it can't be run
+func ActiveVersion() string {
+	output, err := exec.Command(Binary(), "-e", "print RUBY_VERSION").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// LockfileRubyMismatch compares the lockfile's RUBY VERSION with the active Ruby by
+// major.minor, the ABI native extensions are built against. It returns the locked
+// version when they differ, and "" when they match or either one is unknown.
+func LockfileRubyMismatch(lockfilePath, activeVersion string) string {
+	locked := DetectRubyVersionFromLockfile(lockfilePath, func(version string) string { return version })
+	if locked == "" || activeVersion == "" || sameMajorMinor(locked, activeVersion) {
+		return ""
+	}
+	return locked
+}
@@ -431,3 +431,24 @@ BUNDLED WITH
 		}
 	})
 }
+
+func TestLockfileRubyMismatch(t *testing.T) {
+	lockfilePath := filepath.Join(t.TempDir(), "Gemfile.lock")
+	lock := "GEM\n  specs:\n\nRUBY VERSION\n   ruby 3.3.6p108\n\nBUNDLED WITH\n   2.5.23\n"
+	if err := os.WriteFile(lockfilePath, []byte(lock), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := LockfileRubyMismatch(lockfilePath, "3.3.0"); got != "" {
+		t.Errorf("expected same major.minor to match, got %q", got)
+	}
+	if got := LockfileRubyMismatch(lockfilePath, "3.4.1"); got != "3.3.6" {
+		t.Errorf("expected mismatch reporting 3.3.6, got %q", got)
+	}
+	if got := LockfileRubyMismatch(lockfilePath, ""); got != "" {
+		t.Errorf("expected unknown active Ruby to be ignored, got %q", got)
+	}
+	if got := LockfileRubyMismatch(filepath.Join(t.TempDir(), "missing.lock"), "3.4.1"); got != "" {
+		t.Errorf("expected lockfile without RUBY VERSION to be ignored, got %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/contriboss/ore-light/internal/httpclient"
+)
+
+const (
+	// breakerThreshold is how many consecutive failed downloads open a source's circuit
+	breakerThreshold = 3
+
+	// breakerWindow is how close together those failures must be
+	breakerWindow = 30 * time.Second
+
+	// breakerCooldown is how long an open circuit is skipped before a download re-probes it
+	breakerCooldown = 30 * time.Second
+)
+
+// circuitBreaker tracks download failures per source URL. Once a source fails
+// breakerThreshold times in a row within breakerWindow its circuit opens: downloads go
+// straight to its fallback or the next source for breakerCooldown, after which one
+// download tries the source again (half-open) and either closes or re-opens the circuit.
+type circuitBreaker struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	states map[string]*circuitState
+}
+
+// circuitState is one source's failure history
+type circuitState struct {
+	failures     int       // Consecutive failures in the current window
+	firstFailure time.Time // Start of the current window
+	openUntil    time.Time // Downloads skip the source until then
+	probing      bool      // A download is re-probing the source after the cooldown
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{now: time.Now, states: make(map[string]*circuitState)}
+}
+
+// allow reports whether a download may use sourceURL. After the cooldown, the first
+// caller is let through to re-probe the source and later callers wait for its result.
+func (b *circuitBreaker) allow(sourceURL string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.states[sourceURL]
+	if state == nil || state.openUntil.IsZero() {
+		return true
+	}
+	if b.now().Before(state.openUntil) || state.probing {
+		return false
+	}
+	state.probing = true
+	return true
+}
+
+// success closes sourceURL's circuit
+func (b *circuitBreaker) success(sourceURL string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.states, sourceURL)
+}
+
+// failure records a failed download from sourceURL and reports whether it opened the
+// circuit (a failed re-probe re-opens it)
+func (b *circuitBreaker) failure(sourceURL string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	state := b.states[sourceURL]
+	if state == nil {
+		state = &circuitState{}
+		b.states[sourceURL] = state
+	}
+
+	if state.probing {
+		state.probing = false
+		state.openUntil = now.Add(breakerCooldown)
+		return false // Still open; already reported
+	}
+	if !state.openUntil.IsZero() {
+		return false // A download started before the circuit opened
+	}
+
+	if state.failures == 0 || now.Sub(state.firstFailure) > breakerWindow {
+		state.failures = 0
+		state.firstFailure = now
+	}
+	state.failures++
+	if state.failures < breakerThreshold {
+		return false
+	}
+	state.failures = 0
+	state.openUntil = now.Add(breakerCooldown)
+	return true
+}
+
+// release ends a re-probe that didn't say anything about the source (e.g. a cancelled
+// download), letting the next download probe it
+func (b *circuitBreaker) release(sourceURL string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if state := b.states[sourceURL]; state != nil {
+		state.probing = false
+	}
+}
+
+// circuitOpenError is a source skipped because its circuit is open
+type circuitOpenError struct {
+	URL string
+}
+
+func (e *circuitOpenError) Error() string {
+	return fmt.Sprintf("skipped %s after repeated failures", httpclient.RedactURL(e.URL))
+}
+
+// recordResult feeds a download's outcome from sourceURL to the circuit breaker. Only
+// failures the fallback could fix count; a 404 or 429 means the source is up.
+func (m *Manager) recordResult(ctx context.Context, sourceURL string, err error) {
+	var httpErr *HTTPError
+	switch {
+	case err == nil:
+		m.breaker.success(sourceURL)
+	case ctx.Err() != nil:
+		m.breaker.release(sourceURL)
+	case errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests:
+		m.breaker.success(sourceURL)
+	case isRetryableError(err):
+		if m.breaker.failure(sourceURL) {
+			fmt.Fprintf(os.Stderr, "Warning: %s failed %d times in a row; using other sources for %s\n",
+				httpclient.RedactURL(sourceURL), breakerThreshold, breakerCooldown)
+		}
+	default:
+		m.breaker.success(sourceURL)
+	}
+}
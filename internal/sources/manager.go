@@ -2,14 +2,18 @@ package sources
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/contriboss/ore-light/internal/httpclient"
 )
 
 // Authentication holds authentication information extracted from URLs
@@ -19,19 +23,34 @@ type Authentication struct {
 	Token    string
 }
 
-// Header returns the appropriate Authorization header value
+// Header returns the appropriate Authorization header value. Like Bundler, a token is
+// sent as the basic auth user with an empty password (what gem servers such as
+// Gemfury, GitHub Packages and Sidekiq Pro expect).
 func (a *Authentication) Header() string {
 	if a.Token != "" {
-		return "Bearer " + a.Token
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(a.Token+":"))
 	}
 	// For basic auth, the client will handle it via URL
 	return ""
 }
 
+// DefaultHealthPath is probed by CheckHealth when a source doesn't set HealthPath.
+// The compact index exists on every version of a source, unlike any particular .gem.
+const DefaultHealthPath = "/versions"
+
+// healthCheckAttempts is how many times each health probe is tried before giving up
+const healthCheckAttempts = 2
+
 // Source represents a gem source with optional fallback
 type Source struct {
-	URL          string
-	FallbackURL  string
+	URL         string
+	FallbackURL string
+	HealthPath  string // Path probed by CheckHealth (default DefaultHealthPath)
+
+	// ResponseTimeout bounds how long a download from URL waits for response headers
+	// before moving on to the fallback (0 uses the HTTP client's timeout)
+	ResponseTimeout time.Duration
+
 	auth         *Authentication
 	fallbackAuth *Authentication
 }
@@ -51,23 +70,92 @@ func extractAuth(sourceURL string) (string, *Authentication) {
 	username := parsed.User.Username()
 	password, hasPassword := parsed.User.Password()
 
-	// Check if it's token auth (token:@ or token:x-oauth-basic@)
+	setCredentials(auth, username, password, hasPassword)
+
+	// Remove auth from URL
+	parsed.User = nil
+	return parsed.String(), auth
+}
+
+// setCredentials fills auth from a user/password pair, treating a user alone
+// (token:@ or token:x-oauth-basic@) as a token
+func setCredentials(auth *Authentication, username, password string, hasPassword bool) {
 	if username != "" && (!hasPassword || password == "" || password == "x-oauth-basic") {
 		auth.Token = username
 	} else {
 		auth.Username = username
 		auth.Password = password
 	}
+}
 
-	// Remove auth from URL
-	parsed.User = nil
-	return parsed.String(), auth
+// credentialLookup finds configured credentials for a source host; see SetCredentialLookup
+var (
+	credentialMu     sync.RWMutex
+	credentialLookup func(host string) string
+)
+
+// SetCredentialLookup registers where sources created afterwards get credentials when
+// their URL carries none. lookup returns "user:pass" or a token for a host, or "".
+//
+// Ruby developers: this is how `bundle config gems.example.com user:pass` keeps
+// secrets out of the Gemfile
+func SetCredentialLookup(lookup func(host string) string) {
+	credentialMu.Lock()
+	defer credentialMu.Unlock()
+	credentialLookup = lookup
+}
+
+// configuredAuth returns the credentials registered for sourceURL's host, if any
+func configuredAuth(sourceURL string) *Authentication {
+	credentialMu.RLock()
+	lookup := credentialLookup
+	credentialMu.RUnlock()
+	if lookup == nil || sourceURL == "" {
+		return nil
+	}
+
+	parsed, err := url.Parse(sourceURL)
+	if err != nil || parsed.Hostname() == "" {
+		return nil
+	}
+	credentials := lookup(parsed.Hostname())
+	if credentials == "" {
+		return nil
+	}
+
+	auth := &Authentication{}
+	username, password, hasPassword := strings.Cut(credentials, ":")
+	setCredentials(auth, username, password, hasPassword)
+	return auth
+}
+
+// AuthFor returns the credentials for requests to sourceURL: those in the URL, otherwise
+// the ones registered with SetCredentialLookup for its host. nil when there are none.
+// For clients outside a Manager, such as the compact index client.
+func AuthFor(sourceURL string) *Authentication {
+	cleanURL, auth := extractAuth(sourceURL)
+	if auth == nil {
+		auth = configuredAuth(cleanURL)
+	}
+	return auth
+}
+
+// Apply adds the credentials to req as basic auth; a nil Authentication adds nothing
+func (a *Authentication) Apply(req *http.Request) {
+	applyAuth(req, a)
 }
 
-// NewSource creates a new Source with authentication extraction
+// NewSource creates a new Source with authentication extraction. Credentials in the URL
+// win; otherwise those registered with SetCredentialLookup for its host are used.
 func NewSource(url, fallback string) *Source {
 	cleanURL, auth := extractAuth(url)
 	cleanFallback, fallbackAuth := extractAuth(fallback)
+	if auth == nil {
+		auth = configuredAuth(cleanURL)
+	}
+	if fallbackAuth == nil {
+		fallbackAuth = configuredAuth(cleanFallback)
+	}
 
 	return &Source{
 		URL:          cleanURL,
@@ -83,75 +171,133 @@ type Manager struct {
 	client       *http.Client
 	healthStatus map[string]bool
 	mu           sync.RWMutex
+
+	breaker       *circuitBreaker // Skips sources that keep failing
+	limiter       *rateLimiter    // Optional aggregate bandwidth cap (--max-rate)
+	backoffMu     sync.Mutex
+	backoffUntil  time.Time // Downloads pause until this time after a 429
+	rateLimitHits int       // Consecutive 429s, for exponential backoff
 }
 
 // NewManager creates a new source manager
 func NewManager(sourceConfigs []SourceConfig, client *http.Client) *Manager {
 	if client == nil {
-		client = &http.Client{
-			Timeout: 30 * time.Second,
-		}
+		client = httpclient.New()
 	}
 
 	sources := make([]*Source, 0, len(sourceConfigs))
 	for _, config := range sourceConfigs {
-		sources = append(sources, NewSource(config.URL, config.Fallback))
+		source := NewSource(config.URL, config.Fallback)
+		source.HealthPath = config.HealthPath
+		source.ResponseTimeout = config.ResponseTimeout
+		sources = append(sources, source)
 	}
 
 	return &Manager{
 		sources:      sources,
 		client:       client,
 		healthStatus: make(map[string]bool),
+		breaker:      newCircuitBreaker(),
 	}
 }
 
 // SourceConfig represents a source configuration
 type SourceConfig struct {
-	URL      string
-	Fallback string
+	URL        string
+	Fallback   string
+	HealthPath string // Optional CheckHealth probe path for unusual servers
+
+	// ResponseTimeout bounds the wait for response headers from URL, so a slow primary
+	// mirror gives way to Fallback quickly (0 uses the HTTP client's timeout)
+	ResponseTimeout time.Duration
 }
 
-// CheckHealth performs pre-flight health checks on all sources
+// CheckHealth performs pre-flight health checks on all sources.
+// Each source is probed at its HealthPath (the compact index /versions by default),
+// then at its root, so no particular gem or version needs to exist on the server.
 func (m *Manager) CheckHealth(ctx context.Context) {
 	var wg sync.WaitGroup
 
-	checkSource := func(url string) {
+	checkSource := func(url string, auth *Authentication, healthPath string) {
 		if url == "" {
 			return
 		}
 
 		wg.Go(func() {
-			// Try to fetch a small gem to test the source
-			// Using rake as it's commonly available
-			testURL := fmt.Sprintf("%s/downloads/rake-13.0.6.gem", url)
-			req, err := http.NewRequestWithContext(ctx, http.MethodHead, testURL, nil)
-			if err != nil {
-				m.setHealthStatus(url, false)
-				return
-			}
-
-			resp, err := m.client.Do(req)
-			if err != nil {
-				m.setHealthStatus(url, false)
-				return
-			}
-			_ = resp.Body.Close()
-
-			// Consider 200 or 404 as healthy (404 means source works, gem doesn't exist)
-			healthy := resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound
-			m.setHealthStatus(url, healthy)
+			m.setHealthStatus(url, m.probeSource(ctx, url, auth, healthPath))
 		})
 	}
 
 	// Check all sources and their fallbacks
 	for _, source := range m.sources {
-		checkSource(source.URL)
-		checkSource(source.FallbackURL)
+		checkSource(source.URL, source.auth, source.HealthPath)
+		checkSource(source.FallbackURL, source.fallbackAuth, source.HealthPath)
 	}
 
 	wg.Wait()
 }
 
+// probeSource reports whether a source answers at healthPath or, failing that, its root
+func (m *Manager) probeSource(ctx context.Context, baseURL string, auth *Authentication, healthPath string) bool {
+	if healthPath == "" {
+		healthPath = DefaultHealthPath
+	}
+
+	paths := []string{healthPath}
+	if healthPath != "/" {
+		paths = append(paths, "/")
+	}
+
+	for _, path := range paths {
+		probeURL := strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(path, "/")
+		for range healthCheckAttempts {
+			if ctx.Err() != nil {
+				return false
+			}
+			status, err := m.headStatus(ctx, probeURL, auth)
+			if err != nil {
+				continue // Network error - retry
+			}
+			// 2xx/3xx means the endpoint works; 404 means the server works without it
+			if status < http.StatusBadRequest || status == http.StatusNotFound {
+				return true
+			}
+			break // The server answered; try the next path instead of retrying
+		}
+	}
+
+	return false
+}
+
+// headStatus sends a HEAD request and returns the response status code
+func (m *Manager) headStatus(ctx context.Context, url string, auth *Authentication) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	applyAuth(req, auth)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// applyAuth adds a source's credentials to a request
+func applyAuth(req *http.Request, auth *Authentication) {
+	if auth == nil {
+		return
+	}
+	if auth.Token != "" {
+		req.SetBasicAuth(auth.Token, "")
+	} else if auth.Username != "" {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+}
+
 func (m *Manager) setHealthStatus(url string, healthy bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -170,13 +316,42 @@ func (m *Manager) DownloadGem(ctx context.Context, gemName string, writer io.Wri
 	if len(m.sources) == 0 {
 		return errors.New("no gem sources configured")
 	}
+	return m.downloadFrom(ctx, m.sources, gemName, writer)
+}
 
-	var lastErr error
+// DownloadGemFrom downloads a gem locked to a specific remote, e.g. one declared in a
+// Gemfile `source "https://gems.example.com" do ... end` block. A configured source with
+// that URL is used with its fallback; any other remote is downloaded from directly, with
+// the credentials registered for its host. An empty remote means the configured sources.
+func (m *Manager) DownloadGemFrom(ctx context.Context, remote, gemName string, writer io.Writer) error {
+	if remote == "" {
+		return m.DownloadGem(ctx, gemName, writer)
+	}
 
+	cleanRemote, _ := extractAuth(remote)
 	for _, source := range m.sources {
+		if sameSourceURL(source.URL, cleanRemote) {
+			return m.downloadFrom(ctx, []*Source{source}, gemName, writer)
+		}
+	}
+	return m.downloadFrom(ctx, []*Source{NewSource(strings.TrimSuffix(remote, "/"), "")}, gemName, writer)
+}
+
+// sameSourceURL compares source URLs ignoring case and a trailing slash
+func sameSourceURL(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "/"), strings.TrimSuffix(b, "/"))
+}
+
+// downloadFrom tries sources in order, switching to a source's fallback on retryable errors.
+// Sources whose circuit is open are skipped, except the last one tried.
+func (m *Manager) downloadFrom(ctx context.Context, sources []*Source, gemName string, writer io.Writer) error {
+	var lastErr error
+
+	for i, source := range sources {
+		lastSource := i == len(sources)-1
+
 		// Try primary source
-		downloadURL := fmt.Sprintf("%s/downloads/%s", source.URL, gemName)
-		err := m.download(ctx, downloadURL, source.auth, writer)
+		err := m.downloadFromSource(ctx, source.URL, source.auth, source.ResponseTimeout, gemName, writer, source.FallbackURL != "" || !lastSource)
 
 		if err == nil {
 			return nil // Success!
@@ -186,10 +361,19 @@ func (m *Manager) DownloadGem(ctx context.Context, gemName string, writer io.Wri
 
 		// Check if error is retryable and we have a fallback
 		if isRetryableError(err) && source.FallbackURL != "" {
-			fallbackURL := fmt.Sprintf("%s/downloads/%s", source.FallbackURL, gemName)
-			fmt.Printf("Primary source %s failed, trying fallback %s\n", source.URL, source.FallbackURL)
+			// Discard any partial primary download before switching sources
+			if resumable, ok := writer.(resumableWriter); ok {
+				if err := resetWriter(resumable); err != nil {
+					return fmt.Errorf("failed to reset download: %w", err)
+				}
+			}
+
+			var skipped *circuitOpenError
+			if !errors.As(err, &skipped) {
+				fmt.Printf("Primary source %s failed, trying fallback %s\n", httpclient.RedactURL(source.URL), httpclient.RedactURL(source.FallbackURL))
+			}
 
-			err = m.download(ctx, fallbackURL, source.fallbackAuth, writer)
+			err = m.downloadFromSource(ctx, source.FallbackURL, source.fallbackAuth, 0, gemName, writer, !lastSource)
 			if err == nil {
 				return nil // Fallback succeeded!
 			}
@@ -209,34 +393,229 @@ func (m *Manager) DownloadGem(ctx context.Context, gemName string, writer io.Wri
 	return errors.New("no sources available")
 }
 
-func (m *Manager) download(ctx context.Context, url string, auth *Authentication, writer io.Writer) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// downloadFromSource downloads gemName from one source URL. With skippable set (there's
+// somewhere else to try), the download goes through the circuit breaker: it's skipped
+// while the source's circuit is open, and its outcome is recorded.
+func (m *Manager) downloadFromSource(ctx context.Context, sourceURL string, auth *Authentication, headerTimeout time.Duration, gemName string, writer io.Writer, skippable bool) error {
+	if skippable && !m.breaker.allow(sourceURL) {
+		return &circuitOpenError{URL: sourceURL}
+	}
+	downloadURL := fmt.Sprintf("%s/downloads/%s", sourceURL, gemName)
+	err := m.download(ctx, downloadURL, auth, headerTimeout, writer)
+	if skippable {
+		m.recordResult(ctx, sourceURL, err)
+	}
+	return err
+}
+
+// maxDownloadAttempts bounds how often an interrupted transfer is resumed or restarted
+const maxDownloadAttempts = 3
+
+// resumableWriter is a download destination that can be rewound (e.g. *os.File).
+// Interrupted downloads are only retried when the writer supports this.
+type resumableWriter interface {
+	io.Writer
+	io.Seeker
+	Truncate(size int64) error
+}
+
+// download fetches url into writer, resuming interrupted transfers with HTTP range
+// requests when the server supports them (Accept-Ranges: bytes)
+//
+// Ruby developers: Like `curl -C -` - on a dropped connection we ask for
+// "Range: bytes=<written>-" instead of starting a 50MB gem over
+func (m *Manager) download(ctx context.Context, url string, auth *Authentication, headerTimeout time.Duration, writer io.Writer) error {
+	resumable, canRetry := writer.(resumableWriter)
+
+	var written int64
+	var lastErr error
+	for attempt := 1; attempt <= maxDownloadAttempts; attempt++ {
+		// Every worker pauses while a source is rate limiting us
+		if err := m.waitForBackoff(ctx); err != nil {
+			return err
+		}
+
+		result, err := m.downloadRange(ctx, url, auth, headerTimeout, writer, written)
+		written = result.written
+		if err == nil {
+			m.noteDownloadSuccess()
+			return nil
+		}
+		lastErr = err
+
+		// 429s are retried after the shared backoff
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests {
+			continue
+		}
+
+		// Only interrupted transfers are retried here; other HTTP errors go to the fallback source
+		var transferErr *transferError
+		if !errors.As(err, &transferErr) || !canRetry || ctx.Err() != nil {
+			return err
+		}
+
+		// Start over if the server can't resume
+		if !result.acceptRanges && written > 0 {
+			if err := resetWriter(resumable); err != nil {
+				return lastErr
+			}
+			written = 0
+		}
+
+		if attempt < maxDownloadAttempts {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * 500 * time.Millisecond):
+			}
+		}
+	}
+
+	return lastErr
+}
+
+// rangeResult describes the state of a (possibly partial) download
+type rangeResult struct {
+	written      int64 // Total bytes in writer after this attempt
+	acceptRanges bool  // Server supports resuming with Range requests
+}
+
+// downloadRange performs one download attempt starting at offset. With headerTimeout
+// set, the request is abandoned if response headers take longer than that.
+// The final size is validated against Content-Length/Content-Range.
+func (m *Manager) downloadRange(ctx context.Context, url string, auth *Authentication, headerTimeout time.Duration, writer io.Writer, offset int64) (rangeResult, error) {
+	result := rangeResult{written: offset}
+
+	reqCtx := ctx
+	var headerTimer *time.Timer
+	if headerTimeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		headerTimer = time.AfterFunc(headerTimeout, cancel)
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return result, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add authentication if present
-	if auth != nil {
-		if auth.Token != "" {
-			req.Header.Set("Authorization", "Bearer "+auth.Token)
-		} else if auth.Username != "" {
-			req.SetBasicAuth(auth.Username, auth.Password)
-		}
+	applyAuth(req, auth)
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
 
 	resp, err := m.client.Do(req)
+	// The headers are in; the body may take as long as it streams
+	if headerTimer != nil && !headerTimer.Stop() && ctx.Err() == nil {
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return result, fmt.Errorf("network error: no response from %s within %s (timeout)", httpclient.RedactURL(url), headerTimeout)
+	}
 	if err != nil {
-		return fmt.Errorf("network error: %w", err)
+		return result, fmt.Errorf("network error: %w", httpclient.RedactError(err))
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return &HTTPError{StatusCode: resp.StatusCode, URL: url}
+	total := int64(-1)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		result.acceptRanges = resp.Header.Get("Accept-Ranges") == "bytes"
+		if offset > 0 {
+			// Server ignored our Range header; restart from the beginning
+			resumable, ok := writer.(resumableWriter)
+			if !ok {
+				return result, fmt.Errorf("server does not support resuming %s", httpclient.RedactURL(url))
+			}
+			if err := resetWriter(resumable); err != nil {
+				return result, fmt.Errorf("failed to restart download: %w", err)
+			}
+			result.written = 0
+		}
+		total = resp.ContentLength
+	case http.StatusPartialContent:
+		result.acceptRanges = true
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return result, fmt.Errorf("unexpected Content-Range %q resuming %s at byte %d", resp.Header.Get("Content-Range"), httpclient.RedactURL(url), offset)
+		}
+		total = size
+	case http.StatusTooManyRequests:
+		m.noteRateLimited(url, resp.Header.Get("Retry-After"))
+		return result, &HTTPError{StatusCode: resp.StatusCode, URL: url}
+	default:
+		return result, &HTTPError{StatusCode: resp.StatusCode, URL: url}
+	}
+
+	n, err := io.Copy(writer, m.throttle(ctx, resp.Body))
+	result.written += n
+	if err != nil {
+		return result, &transferError{written: result.written, err: err}
+	}
+
+	if total >= 0 && result.written != total {
+		return result, &transferError{written: result.written, err: fmt.Errorf("expected %d bytes", total)}
 	}
 
-	_, err = io.Copy(writer, resp.Body)
+	return result, nil
+}
+
+// transferError is a download that failed mid-transfer and can be resumed
+type transferError struct {
+	written int64
+	err     error
+}
+
+func (e *transferError) Error() string {
+	return fmt.Sprintf("network error: download interrupted after %d bytes: %v", e.written, httpclient.RedactError(e.err))
+}
+
+func (e *transferError) Unwrap() error {
+	return e.err
+}
+
+// parseContentRange parses "bytes <start>-<end>/<size>" and returns start and size.
+// size is -1 when the server reports it as unknown ("*").
+func parseContentRange(header string) (start, size int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rangePart, sizePart, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	startPart, _, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(startPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if sizePart == "*" {
+		return start, -1, true
+	}
+	size, err = strconv.ParseInt(sizePart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, size, true
+}
+
+// resetWriter rewinds a partially written download to empty
+func resetWriter(w resumableWriter) error {
+	if err := w.Truncate(0); err != nil {
+		return err
+	}
+	_, err := w.Seek(0, io.SeekStart)
 	return err
 }
 
@@ -247,7 +626,7 @@ type HTTPError struct {
 }
 
 func (e *HTTPError) Error() string {
-	return fmt.Sprintf("HTTP %d from %s", e.StatusCode, e.URL)
+	return fmt.Sprintf("HTTP %d from %s", e.StatusCode, httpclient.RedactURL(e.URL))
 }
 
 // isRetryableError determines if an error should trigger a fallback
@@ -256,6 +635,12 @@ func isRetryableError(err error) bool {
 		return false
 	}
 
+	// A source skipped by the circuit breaker leaves the next one to try
+	var skipped *circuitOpenError
+	if errors.As(err, &skipped) {
+		return true
+	}
+
 	// Network errors are retryable
 	if strings.Contains(err.Error(), "network error") ||
 		strings.Contains(err.Error(), "connection refused") ||
@@ -0,0 +1,303 @@
+package sources
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadResumesWithRange(t *testing.T) {
+	payload := []byte(strings.Repeat("gemdata", 1000))
+	var ranges []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("Accept-Ranges", "bytes")
+
+		// First request: promise the full body but drop the connection halfway
+		if len(ranges) == 1 {
+			w.Header().Set("Content-Length", fmt.Sprint(len(payload)))
+			_, _ = w.Write(payload[:len(payload)/2])
+			return
+		}
+
+		var start int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start); err != nil {
+			t.Errorf("expected Range header on resume, got %q", r.Header.Get("Range"))
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(payload)-1, len(payload)))
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(payload[start:])
+	}))
+	defer server.Close()
+
+	file, err := os.CreateTemp(t.TempDir(), "ore-*.gem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	manager := NewManager([]SourceConfig{{URL: server.URL}}, server.Client())
+	if err := manager.DownloadGem(context.Background(), "big-1.0.0.gem", file); err != nil {
+		t.Fatalf("DownloadGem returned error: %v", err)
+	}
+
+	got, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("downloaded %d bytes, want %d identical bytes", len(got), len(payload))
+	}
+	if len(ranges) != 2 || ranges[1] != fmt.Sprintf("bytes=%d-", len(payload)/2) {
+		t.Fatalf("expected a resume request for the second half, got %q", ranges)
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		header    string
+		start     int64
+		size      int64
+		wantValid bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-99/*", 0, -1, true},
+		{"bytes */200", 0, 0, false},
+		{"items 0-1/2", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		start, size, ok := parseContentRange(tt.header)
+		if ok != tt.wantValid || (ok && (start != tt.start || size != tt.size)) {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v", tt.header, start, size, ok)
+		}
+	}
+}
+
+func TestDownloadBacksOffOn429(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("gem"))
+	}))
+	defer server.Close()
+
+	manager := NewManager([]SourceConfig{{URL: server.URL}}, server.Client())
+	manager.SetMaxRate(1024 * 1024)
+
+	var buf bytes.Buffer
+	if err := manager.DownloadGem(context.Background(), "rack-3.0.0.gem", &buf); err != nil {
+		t.Fatalf("DownloadGem returned error: %v", err)
+	}
+	if buf.String() != "gem" || requests != 2 {
+		t.Fatalf("expected retry after 429, got %q after %d requests", buf.String(), requests)
+	}
+}
+
+func TestCheckHealthWithoutProbeGem(t *testing.T) {
+	// Compact index mirror: /versions works, no rake-13.0.6.gem anywhere
+	compactIndex := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/versions" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer compactIndex.Close()
+
+	// Plain gem server without a compact index: only the root answers
+	plainServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer plainServer.Close()
+
+	// Unusual server with a custom probe path
+	customServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer customServer.Close()
+
+	brokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer brokenServer.Close()
+
+	manager := NewManager([]SourceConfig{
+		{URL: compactIndex.URL},
+		{URL: plainServer.URL},
+		{URL: customServer.URL, HealthPath: "/api/ping"},
+		{URL: brokenServer.URL},
+	}, nil)
+	manager.CheckHealth(context.Background())
+
+	want := map[string]bool{
+		compactIndex.URL: true,
+		plainServer.URL:  true,
+		customServer.URL: true,
+		brokenServer.URL: false,
+	}
+	for _, source := range manager.GetSources() {
+		if source.Healthy != want[source.URL] {
+			t.Errorf("%s healthy = %v, want %v", source.URL, source.Healthy, want[source.URL])
+		}
+	}
+}
+
+func TestConfiguredCredentials(t *testing.T) {
+	var authorization []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("gem"))
+	}))
+	defer server.Close()
+
+	SetCredentialLookup(func(host string) string {
+		if host == "127.0.0.1" {
+			return "secret-token"
+		}
+		return ""
+	})
+	t.Cleanup(func() { SetCredentialLookup(nil) })
+
+	var buf bytes.Buffer
+	manager := NewManager([]SourceConfig{{URL: server.URL}}, server.Client())
+	if err := manager.DownloadGem(context.Background(), "rack-3.0.0.gem", &buf); err != nil {
+		t.Fatalf("DownloadGem returned error: %v", err)
+	}
+
+	// Credentials in the URL still win over configured ones
+	urlWithUser := strings.Replace(server.URL, "http://", "http://deploy:pass@", 1)
+	manager = NewManager([]SourceConfig{{URL: urlWithUser}}, server.Client())
+	if err := manager.DownloadGem(context.Background(), "rack-3.0.0.gem", &buf); err != nil {
+		t.Fatalf("DownloadGem returned error: %v", err)
+	}
+
+	// A token goes out as the basic auth user, as Bundler sends it
+	token := "Basic " + base64.StdEncoding.EncodeToString([]byte("secret-token:"))
+	if len(authorization) != 2 || authorization[0] != token || !strings.HasPrefix(authorization[1], "Basic ") || authorization[1] == token {
+		t.Fatalf("unexpected Authorization headers %q", authorization)
+	}
+}
+
+func TestDownloadGemFromLockedRemote(t *testing.T) {
+	var hits []string
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits = append(hits, name+" "+r.URL.Path)
+			_, _ = w.Write([]byte("gem"))
+		})
+	}
+	public := httptest.NewServer(handler("public"))
+	defer public.Close()
+	private := httptest.NewServer(handler("private"))
+	defer private.Close()
+
+	var buf bytes.Buffer
+	manager := NewManager([]SourceConfig{{URL: public.URL}}, public.Client())
+	if err := manager.DownloadGemFrom(context.Background(), "", "rack-3.0.0.gem", &buf); err != nil {
+		t.Fatalf("DownloadGemFrom returned error: %v", err)
+	}
+	if err := manager.DownloadGemFrom(context.Background(), private.URL+"/", "acme-1.0.0.gem", &buf); err != nil {
+		t.Fatalf("DownloadGemFrom returned error: %v", err)
+	}
+
+	want := []string{"public /downloads/rack-3.0.0.gem", "private /downloads/acme-1.0.0.gem"}
+	if strings.Join(hits, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected requests %q, got %q", want, hits)
+	}
+}
+
+func TestCircuitBreakerSkipsFailingSource(t *testing.T) {
+	var primaryRequests, fallbackRequests int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryRequests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackRequests++
+		_, _ = w.Write([]byte("gem"))
+	}))
+	defer fallback.Close()
+
+	manager := NewManager([]SourceConfig{{URL: primary.URL, Fallback: fallback.URL}}, primary.Client())
+	now := time.Now()
+	manager.breaker.now = func() time.Time { return now }
+
+	download := func() {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := manager.DownloadGem(context.Background(), "rack-3.0.0.gem", &buf); err != nil {
+			t.Fatalf("DownloadGem returned error: %v", err)
+		}
+		if buf.String() != "gem" {
+			t.Fatalf("expected the fallback's gem, got %q", buf.String())
+		}
+	}
+
+	for range breakerThreshold + 2 {
+		download()
+	}
+	if primaryRequests != breakerThreshold || fallbackRequests != breakerThreshold+2 {
+		t.Fatalf("expected the primary to be skipped after %d failures, got %d primary and %d fallback requests",
+			breakerThreshold, primaryRequests, fallbackRequests)
+	}
+
+	// After the cooldown one download re-probes the primary, which re-opens the circuit
+	now = now.Add(breakerCooldown + time.Second)
+	download()
+	download()
+	if primaryRequests != breakerThreshold+1 {
+		t.Errorf("expected a single re-probe of the primary, got %d requests", primaryRequests)
+	}
+}
+
+func TestResponseTimeoutFallsBack(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("gem"))
+	}))
+	defer fallback.Close()
+
+	manager := NewManager([]SourceConfig{{URL: slow.URL, Fallback: fallback.URL, ResponseTimeout: 50 * time.Millisecond}}, slow.Client())
+	var buf bytes.Buffer
+	start := time.Now()
+	if err := manager.DownloadGem(context.Background(), "rack-3.0.0.gem", &buf); err != nil {
+		t.Fatalf("DownloadGem returned error: %v", err)
+	}
+	if buf.String() != "gem" {
+		t.Fatalf("expected the fallback's gem, got %q", buf.String())
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the slow primary to be abandoned quickly, took %s", elapsed)
+	}
+}
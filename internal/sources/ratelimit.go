@@ -0,0 +1,161 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/contriboss/ore-light/internal/httpclient"
+)
+
+// maxRateLimitBackoff caps how long downloads pause after a 429 response
+const maxRateLimitBackoff = 30 * time.Second
+
+// rateLimiter is a token bucket shared by all concurrent downloads.
+// Tokens are bytes; the bucket refills at rate bytes/sec up to one second of burst.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	return &rateLimiter{
+		rate:   float64(bytesPerSec),
+		tokens: float64(bytesPerSec),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until n bytes may be consumed.
+// Tokens are reserved up front, so concurrent callers queue behind each other.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// chunkSize is the largest read charged to the bucket at once
+func (l *rateLimiter) chunkSize() int {
+	size := int(l.rate / 4)
+	if size < 1024 {
+		size = 1024
+	}
+	if size > 32*1024 {
+		size = 32 * 1024
+	}
+	return size
+}
+
+// throttledReader charges every read against a shared rateLimiter
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if chunk := t.limiter.chunkSize(); len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if waitErr := t.limiter.wait(t.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// SetMaxRate caps aggregate download throughput across all concurrent downloads.
+// A rate of 0 or less removes the limit.
+func (m *Manager) SetMaxRate(bytesPerSec int64) {
+	if bytesPerSec <= 0 {
+		m.limiter = nil
+		return
+	}
+	m.limiter = newRateLimiter(bytesPerSec)
+}
+
+// throttle wraps body with the manager's rate limiter, if any
+func (m *Manager) throttle(ctx context.Context, body io.Reader) io.Reader {
+	if m.limiter == nil {
+		return body
+	}
+	return &throttledReader{ctx: ctx, r: body, limiter: m.limiter}
+}
+
+// noteRateLimited pauses all downloads after a source answers 429 Too Many Requests.
+// Honors Retry-After (in seconds) when present, otherwise backs off exponentially.
+func (m *Manager) noteRateLimited(url, retryAfter string) {
+	m.backoffMu.Lock()
+	defer m.backoffMu.Unlock()
+
+	m.rateLimitHits++
+	delay := time.Second << min(m.rateLimitHits-1, 5)
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	}
+	delay = min(delay, maxRateLimitBackoff)
+
+	until := time.Now().Add(delay)
+	if until.After(m.backoffUntil) {
+		m.backoffUntil = until
+		fmt.Printf("Rate limited by %s (HTTP 429), backing off for %s\n", httpclient.RedactURL(url), delay)
+	}
+}
+
+// noteDownloadSuccess resets the exponential backoff after a successful download
+func (m *Manager) noteDownloadSuccess() {
+	m.backoffMu.Lock()
+	m.rateLimitHits = 0
+	m.backoffMu.Unlock()
+}
+
+// waitForBackoff blocks while downloads are paused due to rate limiting
+func (m *Manager) waitForBackoff(ctx context.Context) error {
+	m.backoffMu.Lock()
+	delay := time.Until(m.backoffUntil)
+	m.backoffMu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}